@@ -66,6 +66,36 @@ func createGinServer(dependencies dependencies.Dependencies) *gin.Engine {
 		scanner.NewScheduler(dependencies.ScannerRepository),
 	).Handle)
 
+	ginEngine.Any("/api/v1/deleted-items", handlers.NewDeletedItemsHandler(
+		dependencies.AccountRepository,
+		dependencies.FilesManager,
+	).Handle)
+
+	ginEngine.Any("/api/v1/dedup-stats", handlers.NewDedupStatsHandler(
+		dependencies.AccountRepository,
+		dependencies.FilesManager,
+	).Handle)
+
+	ginEngine.Any("/api/v1/failed-downloads", handlers.NewFailedDownloadsHandler(
+		dependencies.AccountRepository,
+		dependencies.RetryQueue,
+	).Handle)
+
+	ginEngine.Any("/api/v1/download-status", handlers.NewDownloadStatusHandler(
+		dependencies.DiskGuard,
+	).Handle)
+
+	ginEngine.Any("/api/v1/quota", handlers.NewQuotaHandler(
+		dependencies.QuotaLedger,
+	).Handle)
+
+	ginEngine.Any("/api/v1/settings", handlers.NewSettingsHandler(
+		dependencies.SettingsRepository,
+		dependencies.SecretsCipher,
+	).Handle)
+
+	ginEngine.Any("/api/v1/path-template/preview", handlers.NewPathTemplatePreviewHandler().Handle)
+
 	ginEngine.Any("/api/v1/clients", handlers.NewClientsApiHandler(
 		dependencies.AccountRepository,
 		dependencies.GoogleClientRepository,
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google-backup/internal/dependencies"
+	"google-backup/internal/encryption"
+	"google-backup/internal/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Copies the backup to a folder with the files decrypted and under their original paths.
+// Without -source the backup is read from the storages of the accounts and the key from the settings,
+// with -source a copy of the backup on disk is restored without the database, the key is given by flags then.
+// Prints a JSON report to stdout and exits with 1 if any file failed
+func main() {
+	target := flag.String("target", "", "folder the files are restored to")
+	folder := flag.String("folder", "", "restore only this folder, e.g. the email of an account")
+	source := flag.String("source", "", "local copy of the backup, the database isn't needed then")
+	keyFile := flag.String("key-file", "", "key file the backup was encrypted with")
+	passphraseFile := flag.String("passphrase-file", "", "file with the passphrase the backup was encrypted with, the passphrase can be set in BACKUP_PASSPHRASE too")
+	flag.Parse()
+
+	// stdout is reserved for the report
+	log.SetOutput(os.Stderr)
+	log.SetLevel(log.InfoLevel)
+
+	if *target == "" {
+		log.Fatal("-target is required")
+	}
+
+	passphrase := os.Getenv("BACKUP_PASSPHRASE")
+
+	if *passphraseFile != "" {
+		content, err := os.ReadFile(*passphraseFile)
+		if err != nil {
+			log.Fatal(fmt.Errorf("read passphrase file: %w", err))
+		}
+
+		passphrase = strings.TrimRight(string(content), "\r\n")
+	}
+
+	var sourceStorage storage.Backend
+	var keyring *encryption.Keyring
+	folders := []string{*folder}
+	closeDb := func() {}
+
+	if *source != "" {
+		sourceStorage = storage.NewLocal(*source)
+	} else {
+		dependencies, err := dependencies.NewFactory().Create()
+		if err != nil {
+			log.Fatal(fmt.Errorf("create depdendencies: %w", err))
+		}
+		defer dependencies.DbConnection.Close()
+
+		closeDb = func() { dependencies.DbConnection.Close() }
+
+		sourceStorage = dependencies.RawStorage
+
+		settingsData, err := dependencies.SettingsReader.Get()
+		if err != nil {
+			log.Fatal(fmt.Errorf("get settings: %w", err))
+		}
+
+		// flags take precedence over the key of the settings
+		if passphrase == "" && *keyFile == "" && settingsData.Encryption != nil {
+			passphrase = settingsData.Encryption.Passphrase
+			*keyFile = settingsData.Encryption.KeyFile
+		}
+
+		if *folder == "" {
+			accounts, err := dependencies.AccountRepository.GetAccounts()
+			if err != nil {
+				log.Fatal(fmt.Errorf("get accounts: %w", err))
+			}
+
+			folders = folders[:0]
+			for _, account := range accounts {
+				folders = append(folders, string(account))
+			}
+		}
+	}
+
+	if passphrase != "" || *keyFile != "" {
+		var err error
+
+		keyring, err = encryption.NewKeyring(passphrase, *keyFile)
+		if err != nil {
+			log.Fatal(fmt.Errorf("new keyring: %w", err))
+		}
+	}
+
+	targetStorage := storage.NewLocal(*target)
+
+	reports := make(map[string]storage.RestoreReport, len(folders))
+	failed := 0
+
+	for _, folder := range folders {
+		log.WithField("folder", folder).Info("restore folder")
+
+		report, err := storage.Restore(sourceStorage, keyring, folder, targetStorage)
+		if err != nil {
+			log.Fatal(fmt.Errorf("restore %s: %w", folder, err))
+		}
+
+		reports[folder] = report
+		failed += len(report.Failed)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(map[string]interface{}{"folders": reports, "failed": failed})
+	if err != nil {
+		log.Fatal(fmt.Errorf("encode report: %w", err))
+	}
+
+	if failed > 0 {
+		closeDb()
+		os.Exit(1)
+	}
+}
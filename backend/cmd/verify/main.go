@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"google-backup/internal/dependencies"
+	"google-backup/internal/files"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Verifies the backup on disk against the metadata without calling Google APIs.
+// Prints a JSON report to stdout and exits with 1 if any problem was found
+func main() {
+	email := flag.String("email", "", "verify only this account")
+	skipHashes := flag.Bool("skip-hashes", false, "check presence and sizes only, without reading the files")
+	flag.Parse()
+
+	// stdout is reserved for the report
+	log.SetOutput(os.Stderr)
+	log.SetLevel(log.InfoLevel)
+
+	if os.Getenv("PRODUCTION") == "true" {
+		log.SetLevel(log.ErrorLevel)
+	}
+
+	dependencies, err := dependencies.NewFactory().Create()
+	if err != nil {
+		log.Fatal(fmt.Errorf("create depdendencies: %w", err))
+	}
+	defer dependencies.DbConnection.Close()
+
+	emails := []string{*email}
+
+	if *email == "" {
+		accounts, err := dependencies.AccountRepository.GetAccounts()
+		if err != nil {
+			log.Fatal(fmt.Errorf("get accounts: %w", err))
+		}
+
+		emails = emails[:0]
+		for _, account := range accounts {
+			emails = append(emails, string(account))
+		}
+	}
+
+	reports := make([]files.VerifyReport, 0, len(emails))
+	problems := 0
+
+	for _, email := range emails {
+		log.WithField("email", email).Info("verify account")
+
+		report, err := dependencies.FilesManager.Verify(email, !*skipHashes)
+		if err != nil {
+			log.Fatal(fmt.Errorf("verify %s: %w", email, err))
+		}
+
+		reports = append(reports, report)
+		problems += len(report.Problems)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(map[string]interface{}{"accounts": reports, "problems": problems})
+	if err != nil {
+		log.Fatal(fmt.Errorf("encode report: %w", err))
+	}
+
+	if problems > 0 {
+		dependencies.DbConnection.Close()
+		os.Exit(1)
+	}
+}
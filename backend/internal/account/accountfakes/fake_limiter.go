@@ -0,0 +1,195 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package accountfakes
+
+import (
+	"google-backup/internal/account"
+	"sync"
+)
+
+type FakeLimiter struct {
+	LimitReachedStub        func(string, string) (bool, error)
+	limitReachedMutex       sync.RWMutex
+	limitReachedArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	limitReachedReturns struct {
+		result1 bool
+		result2 error
+	}
+	limitReachedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	SetLimitReachedStub        func(string, string, bool) error
+	setLimitReachedMutex       sync.RWMutex
+	setLimitReachedArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 bool
+	}
+	setLimitReachedReturns struct {
+		result1 error
+	}
+	setLimitReachedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLimiter) LimitReached(arg1 string, arg2 string) (bool, error) {
+	fake.limitReachedMutex.Lock()
+	ret, specificReturn := fake.limitReachedReturnsOnCall[len(fake.limitReachedArgsForCall)]
+	fake.limitReachedArgsForCall = append(fake.limitReachedArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LimitReachedStub
+	fakeReturns := fake.limitReachedReturns
+	fake.recordInvocation("LimitReached", []interface{}{arg1, arg2})
+	fake.limitReachedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLimiter) LimitReachedCallCount() int {
+	fake.limitReachedMutex.RLock()
+	defer fake.limitReachedMutex.RUnlock()
+	return len(fake.limitReachedArgsForCall)
+}
+
+func (fake *FakeLimiter) LimitReachedCalls(stub func(string, string) (bool, error)) {
+	fake.limitReachedMutex.Lock()
+	defer fake.limitReachedMutex.Unlock()
+	fake.LimitReachedStub = stub
+}
+
+func (fake *FakeLimiter) LimitReachedArgsForCall(i int) (string, string) {
+	fake.limitReachedMutex.RLock()
+	defer fake.limitReachedMutex.RUnlock()
+	argsForCall := fake.limitReachedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLimiter) LimitReachedReturns(result1 bool, result2 error) {
+	fake.limitReachedMutex.Lock()
+	defer fake.limitReachedMutex.Unlock()
+	fake.LimitReachedStub = nil
+	fake.limitReachedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLimiter) LimitReachedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.limitReachedMutex.Lock()
+	defer fake.limitReachedMutex.Unlock()
+	fake.LimitReachedStub = nil
+	if fake.limitReachedReturnsOnCall == nil {
+		fake.limitReachedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.limitReachedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLimiter) SetLimitReached(arg1 string, arg2 string, arg3 bool) error {
+	fake.setLimitReachedMutex.Lock()
+	ret, specificReturn := fake.setLimitReachedReturnsOnCall[len(fake.setLimitReachedArgsForCall)]
+	fake.setLimitReachedArgsForCall = append(fake.setLimitReachedArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.SetLimitReachedStub
+	fakeReturns := fake.setLimitReachedReturns
+	fake.recordInvocation("SetLimitReached", []interface{}{arg1, arg2, arg3})
+	fake.setLimitReachedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLimiter) SetLimitReachedCallCount() int {
+	fake.setLimitReachedMutex.RLock()
+	defer fake.setLimitReachedMutex.RUnlock()
+	return len(fake.setLimitReachedArgsForCall)
+}
+
+func (fake *FakeLimiter) SetLimitReachedCalls(stub func(string, string, bool) error) {
+	fake.setLimitReachedMutex.Lock()
+	defer fake.setLimitReachedMutex.Unlock()
+	fake.SetLimitReachedStub = stub
+}
+
+func (fake *FakeLimiter) SetLimitReachedArgsForCall(i int) (string, string, bool) {
+	fake.setLimitReachedMutex.RLock()
+	defer fake.setLimitReachedMutex.RUnlock()
+	argsForCall := fake.setLimitReachedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLimiter) SetLimitReachedReturns(result1 error) {
+	fake.setLimitReachedMutex.Lock()
+	defer fake.setLimitReachedMutex.Unlock()
+	fake.SetLimitReachedStub = nil
+	fake.setLimitReachedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLimiter) SetLimitReachedReturnsOnCall(i int, result1 error) {
+	fake.setLimitReachedMutex.Lock()
+	defer fake.setLimitReachedMutex.Unlock()
+	fake.SetLimitReachedStub = nil
+	if fake.setLimitReachedReturnsOnCall == nil {
+		fake.setLimitReachedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setLimitReachedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLimiter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.limitReachedMutex.RLock()
+	defer fake.limitReachedMutex.RUnlock()
+	fake.setLimitReachedMutex.RLock()
+	defer fake.setLimitReachedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLimiter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ account.Limiter = new(FakeLimiter)
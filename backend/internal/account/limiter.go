@@ -12,6 +12,7 @@ const (
 	DownloadLimitType   = "download"
 )
 
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Limiter
 type Limiter interface {
 	LimitReached(email, limitType string) (bool, error)
 	SetLimitReached(email, limitType string, limitReached bool) error
@@ -168,7 +168,8 @@ func (r *repo) GetAccounts() ([][]byte, error) {
 		}
 
 		bucket.ForEach(func(k, v []byte) error {
-			accounts = append(accounts, v)
+			// keys are emails, they are valid only during the transaction
+			accounts = append(accounts, append([]byte{}, k...))
 
 			return nil
 		})
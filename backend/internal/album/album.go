@@ -0,0 +1,88 @@
+package album
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google-backup/internal/media"
+)
+
+type Albums interface {
+	SaveAlbum(email string, album media.Album) error
+	GetAlbums(email string) ([]media.Album, error)
+	AddMediaItem(email, albumId, mediaItemId string) (bool, error)
+	GetMediaItemAlbums(email, mediaItemId string) ([]media.Album, error)
+}
+
+type albums struct {
+	repository Repository
+}
+
+func NewAlbums(repository Repository) albums {
+	return albums{repository: repository}
+}
+
+func (a albums) SaveAlbum(email string, album media.Album) error {
+	albumJson, err := json.Marshal(album)
+	if err != nil {
+		return fmt.Errorf("marshal album: %w", err)
+	}
+
+	return a.repository.SaveAlbum(email, album.ID, albumJson)
+}
+
+func (a albums) GetAlbums(email string) ([]media.Album, error) {
+	albumsJson, err := a.repository.GetAlbums(email)
+	if err != nil {
+		return nil, fmt.Errorf("get albums: %w", err)
+	}
+
+	result := make([]media.Album, 0, len(albumsJson))
+
+	for _, albumJson := range albumsJson {
+		var album media.Album
+		err = json.Unmarshal(albumJson, &album)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal album: %w", err)
+		}
+
+		result = append(result, album)
+	}
+
+	return result, nil
+}
+
+// Returns true if the media item wasn't in the album before
+func (a albums) AddMediaItem(email, albumId, mediaItemId string) (bool, error) {
+	return a.repository.SaveMembership(email, mediaItemId, albumId)
+}
+
+func (a albums) GetMediaItemAlbums(email, mediaItemId string) ([]media.Album, error) {
+	albumIds, err := a.repository.GetMediaItemAlbumIds(email, mediaItemId)
+	if err != nil {
+		return nil, fmt.Errorf("get media item album ids: %w", err)
+	}
+
+	result := make([]media.Album, 0, len(albumIds))
+
+	for _, albumId := range albumIds {
+		albumJson, err := a.repository.GetAlbum(email, albumId)
+		if err != nil {
+			return nil, fmt.Errorf("get album: %w", err)
+		}
+
+		if albumJson == nil {
+			continue
+		}
+
+		var album media.Album
+		err = json.Unmarshal(albumJson, &album)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal album: %w", err)
+		}
+
+		result = append(result, album)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,443 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package albumfakes
+
+import (
+	"google-backup/internal/album"
+	"sync"
+)
+
+type FakeRepository struct {
+	GetAlbumStub        func(string, string) ([]byte, error)
+	getAlbumMutex       sync.RWMutex
+	getAlbumArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getAlbumReturns struct {
+		result1 []byte
+		result2 error
+	}
+	getAlbumReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	GetAlbumsStub        func(string) (map[string][]byte, error)
+	getAlbumsMutex       sync.RWMutex
+	getAlbumsArgsForCall []struct {
+		arg1 string
+	}
+	getAlbumsReturns struct {
+		result1 map[string][]byte
+		result2 error
+	}
+	getAlbumsReturnsOnCall map[int]struct {
+		result1 map[string][]byte
+		result2 error
+	}
+	GetMediaItemAlbumIdsStub        func(string, string) ([]string, error)
+	getMediaItemAlbumIdsMutex       sync.RWMutex
+	getMediaItemAlbumIdsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getMediaItemAlbumIdsReturns struct {
+		result1 []string
+		result2 error
+	}
+	getMediaItemAlbumIdsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	SaveAlbumStub        func(string, string, []byte) error
+	saveAlbumMutex       sync.RWMutex
+	saveAlbumArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}
+	saveAlbumReturns struct {
+		result1 error
+	}
+	saveAlbumReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveMembershipStub        func(string, string, string) (bool, error)
+	saveMembershipMutex       sync.RWMutex
+	saveMembershipArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	saveMembershipReturns struct {
+		result1 bool
+		result2 error
+	}
+	saveMembershipReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRepository) GetAlbum(arg1 string, arg2 string) ([]byte, error) {
+	fake.getAlbumMutex.Lock()
+	ret, specificReturn := fake.getAlbumReturnsOnCall[len(fake.getAlbumArgsForCall)]
+	fake.getAlbumArgsForCall = append(fake.getAlbumArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetAlbumStub
+	fakeReturns := fake.getAlbumReturns
+	fake.recordInvocation("GetAlbum", []interface{}{arg1, arg2})
+	fake.getAlbumMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepository) GetAlbumCallCount() int {
+	fake.getAlbumMutex.RLock()
+	defer fake.getAlbumMutex.RUnlock()
+	return len(fake.getAlbumArgsForCall)
+}
+
+func (fake *FakeRepository) GetAlbumCalls(stub func(string, string) ([]byte, error)) {
+	fake.getAlbumMutex.Lock()
+	defer fake.getAlbumMutex.Unlock()
+	fake.GetAlbumStub = stub
+}
+
+func (fake *FakeRepository) GetAlbumArgsForCall(i int) (string, string) {
+	fake.getAlbumMutex.RLock()
+	defer fake.getAlbumMutex.RUnlock()
+	argsForCall := fake.getAlbumArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRepository) GetAlbumReturns(result1 []byte, result2 error) {
+	fake.getAlbumMutex.Lock()
+	defer fake.getAlbumMutex.Unlock()
+	fake.GetAlbumStub = nil
+	fake.getAlbumReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetAlbumReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.getAlbumMutex.Lock()
+	defer fake.getAlbumMutex.Unlock()
+	fake.GetAlbumStub = nil
+	if fake.getAlbumReturnsOnCall == nil {
+		fake.getAlbumReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.getAlbumReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetAlbums(arg1 string) (map[string][]byte, error) {
+	fake.getAlbumsMutex.Lock()
+	ret, specificReturn := fake.getAlbumsReturnsOnCall[len(fake.getAlbumsArgsForCall)]
+	fake.getAlbumsArgsForCall = append(fake.getAlbumsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetAlbumsStub
+	fakeReturns := fake.getAlbumsReturns
+	fake.recordInvocation("GetAlbums", []interface{}{arg1})
+	fake.getAlbumsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepository) GetAlbumsCallCount() int {
+	fake.getAlbumsMutex.RLock()
+	defer fake.getAlbumsMutex.RUnlock()
+	return len(fake.getAlbumsArgsForCall)
+}
+
+func (fake *FakeRepository) GetAlbumsCalls(stub func(string) (map[string][]byte, error)) {
+	fake.getAlbumsMutex.Lock()
+	defer fake.getAlbumsMutex.Unlock()
+	fake.GetAlbumsStub = stub
+}
+
+func (fake *FakeRepository) GetAlbumsArgsForCall(i int) string {
+	fake.getAlbumsMutex.RLock()
+	defer fake.getAlbumsMutex.RUnlock()
+	argsForCall := fake.getAlbumsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepository) GetAlbumsReturns(result1 map[string][]byte, result2 error) {
+	fake.getAlbumsMutex.Lock()
+	defer fake.getAlbumsMutex.Unlock()
+	fake.GetAlbumsStub = nil
+	fake.getAlbumsReturns = struct {
+		result1 map[string][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetAlbumsReturnsOnCall(i int, result1 map[string][]byte, result2 error) {
+	fake.getAlbumsMutex.Lock()
+	defer fake.getAlbumsMutex.Unlock()
+	fake.GetAlbumsStub = nil
+	if fake.getAlbumsReturnsOnCall == nil {
+		fake.getAlbumsReturnsOnCall = make(map[int]struct {
+			result1 map[string][]byte
+			result2 error
+		})
+	}
+	fake.getAlbumsReturnsOnCall[i] = struct {
+		result1 map[string][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetMediaItemAlbumIds(arg1 string, arg2 string) ([]string, error) {
+	fake.getMediaItemAlbumIdsMutex.Lock()
+	ret, specificReturn := fake.getMediaItemAlbumIdsReturnsOnCall[len(fake.getMediaItemAlbumIdsArgsForCall)]
+	fake.getMediaItemAlbumIdsArgsForCall = append(fake.getMediaItemAlbumIdsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetMediaItemAlbumIdsStub
+	fakeReturns := fake.getMediaItemAlbumIdsReturns
+	fake.recordInvocation("GetMediaItemAlbumIds", []interface{}{arg1, arg2})
+	fake.getMediaItemAlbumIdsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepository) GetMediaItemAlbumIdsCallCount() int {
+	fake.getMediaItemAlbumIdsMutex.RLock()
+	defer fake.getMediaItemAlbumIdsMutex.RUnlock()
+	return len(fake.getMediaItemAlbumIdsArgsForCall)
+}
+
+func (fake *FakeRepository) GetMediaItemAlbumIdsCalls(stub func(string, string) ([]string, error)) {
+	fake.getMediaItemAlbumIdsMutex.Lock()
+	defer fake.getMediaItemAlbumIdsMutex.Unlock()
+	fake.GetMediaItemAlbumIdsStub = stub
+}
+
+func (fake *FakeRepository) GetMediaItemAlbumIdsArgsForCall(i int) (string, string) {
+	fake.getMediaItemAlbumIdsMutex.RLock()
+	defer fake.getMediaItemAlbumIdsMutex.RUnlock()
+	argsForCall := fake.getMediaItemAlbumIdsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRepository) GetMediaItemAlbumIdsReturns(result1 []string, result2 error) {
+	fake.getMediaItemAlbumIdsMutex.Lock()
+	defer fake.getMediaItemAlbumIdsMutex.Unlock()
+	fake.GetMediaItemAlbumIdsStub = nil
+	fake.getMediaItemAlbumIdsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetMediaItemAlbumIdsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.getMediaItemAlbumIdsMutex.Lock()
+	defer fake.getMediaItemAlbumIdsMutex.Unlock()
+	fake.GetMediaItemAlbumIdsStub = nil
+	if fake.getMediaItemAlbumIdsReturnsOnCall == nil {
+		fake.getMediaItemAlbumIdsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.getMediaItemAlbumIdsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) SaveAlbum(arg1 string, arg2 string, arg3 []byte) error {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.saveAlbumMutex.Lock()
+	ret, specificReturn := fake.saveAlbumReturnsOnCall[len(fake.saveAlbumArgsForCall)]
+	fake.saveAlbumArgsForCall = append(fake.saveAlbumArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.SaveAlbumStub
+	fakeReturns := fake.saveAlbumReturns
+	fake.recordInvocation("SaveAlbum", []interface{}{arg1, arg2, arg3Copy})
+	fake.saveAlbumMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepository) SaveAlbumCallCount() int {
+	fake.saveAlbumMutex.RLock()
+	defer fake.saveAlbumMutex.RUnlock()
+	return len(fake.saveAlbumArgsForCall)
+}
+
+func (fake *FakeRepository) SaveAlbumCalls(stub func(string, string, []byte) error) {
+	fake.saveAlbumMutex.Lock()
+	defer fake.saveAlbumMutex.Unlock()
+	fake.SaveAlbumStub = stub
+}
+
+func (fake *FakeRepository) SaveAlbumArgsForCall(i int) (string, string, []byte) {
+	fake.saveAlbumMutex.RLock()
+	defer fake.saveAlbumMutex.RUnlock()
+	argsForCall := fake.saveAlbumArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRepository) SaveAlbumReturns(result1 error) {
+	fake.saveAlbumMutex.Lock()
+	defer fake.saveAlbumMutex.Unlock()
+	fake.SaveAlbumStub = nil
+	fake.saveAlbumReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) SaveAlbumReturnsOnCall(i int, result1 error) {
+	fake.saveAlbumMutex.Lock()
+	defer fake.saveAlbumMutex.Unlock()
+	fake.SaveAlbumStub = nil
+	if fake.saveAlbumReturnsOnCall == nil {
+		fake.saveAlbumReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveAlbumReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) SaveMembership(arg1 string, arg2 string, arg3 string) (bool, error) {
+	fake.saveMembershipMutex.Lock()
+	ret, specificReturn := fake.saveMembershipReturnsOnCall[len(fake.saveMembershipArgsForCall)]
+	fake.saveMembershipArgsForCall = append(fake.saveMembershipArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.SaveMembershipStub
+	fakeReturns := fake.saveMembershipReturns
+	fake.recordInvocation("SaveMembership", []interface{}{arg1, arg2, arg3})
+	fake.saveMembershipMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepository) SaveMembershipCallCount() int {
+	fake.saveMembershipMutex.RLock()
+	defer fake.saveMembershipMutex.RUnlock()
+	return len(fake.saveMembershipArgsForCall)
+}
+
+func (fake *FakeRepository) SaveMembershipCalls(stub func(string, string, string) (bool, error)) {
+	fake.saveMembershipMutex.Lock()
+	defer fake.saveMembershipMutex.Unlock()
+	fake.SaveMembershipStub = stub
+}
+
+func (fake *FakeRepository) SaveMembershipArgsForCall(i int) (string, string, string) {
+	fake.saveMembershipMutex.RLock()
+	defer fake.saveMembershipMutex.RUnlock()
+	argsForCall := fake.saveMembershipArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRepository) SaveMembershipReturns(result1 bool, result2 error) {
+	fake.saveMembershipMutex.Lock()
+	defer fake.saveMembershipMutex.Unlock()
+	fake.SaveMembershipStub = nil
+	fake.saveMembershipReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) SaveMembershipReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.saveMembershipMutex.Lock()
+	defer fake.saveMembershipMutex.Unlock()
+	fake.SaveMembershipStub = nil
+	if fake.saveMembershipReturnsOnCall == nil {
+		fake.saveMembershipReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.saveMembershipReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getAlbumMutex.RLock()
+	defer fake.getAlbumMutex.RUnlock()
+	fake.getAlbumsMutex.RLock()
+	defer fake.getAlbumsMutex.RUnlock()
+	fake.getMediaItemAlbumIdsMutex.RLock()
+	defer fake.getMediaItemAlbumIdsMutex.RUnlock()
+	fake.saveAlbumMutex.RLock()
+	defer fake.saveAlbumMutex.RUnlock()
+	fake.saveMembershipMutex.RLock()
+	defer fake.saveMembershipMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ album.Repository = new(FakeRepository)
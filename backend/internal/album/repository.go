@@ -0,0 +1,149 @@
+package album
+
+import (
+	"bytes"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	albumsBucketName          = "albums"
+	albumMembershipBucketName = "album_membership"
+	membershipKeySeparator    = "/"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Repository
+type Repository interface {
+	SaveAlbum(email, albumId string, data []byte) error
+	GetAlbum(email, albumId string) ([]byte, error)
+	GetAlbums(email string) (map[string][]byte, error)
+	SaveMembership(email, mediaItemId, albumId string) (bool, error)
+	GetMediaItemAlbumIds(email, mediaItemId string) ([]string, error)
+}
+
+type repository struct {
+	db *bbolt.DB
+}
+
+func NewRepository(db *bbolt.DB) repository {
+	return repository{db: db}
+}
+
+func (r repository) SaveAlbum(email, albumId string, data []byte) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
+		if err != nil {
+			return fmt.Errorf("create bucket: %w", err)
+		}
+
+		albumsBucket, err := bucket.CreateBucketIfNotExists([]byte(albumsBucketName))
+		if err != nil {
+			return fmt.Errorf("create albums bucket: %w", err)
+		}
+
+		return albumsBucket.Put([]byte(albumId), data)
+	})
+}
+
+func (r repository) GetAlbum(email, albumId string) ([]byte, error) {
+	var data []byte
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		albumsBucket := bucket.Bucket([]byte(albumsBucketName))
+		if albumsBucket == nil {
+			return nil
+		}
+
+		data = albumsBucket.Get([]byte(albumId))
+
+		return nil
+	})
+
+	return data, err
+}
+
+func (r repository) GetAlbums(email string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		albumsBucket := bucket.Bucket([]byte(albumsBucketName))
+		if albumsBucket == nil {
+			return nil
+		}
+
+		return albumsBucket.ForEach(func(k, v []byte) error {
+			values[string(k)] = v
+
+			return nil
+		})
+	})
+
+	return values, err
+}
+
+// Membership is stored as "mediaItemId/albumId" keys, so albums of a media item are found by a prefix scan.
+// Returns true if the media item wasn't in the album before
+func (r repository) SaveMembership(email, mediaItemId, albumId string) (bool, error) {
+	added := false
+
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
+		if err != nil {
+			return fmt.Errorf("create bucket: %w", err)
+		}
+
+		membershipBucket, err := bucket.CreateBucketIfNotExists([]byte(albumMembershipBucketName))
+		if err != nil {
+			return fmt.Errorf("create album membership bucket: %w", err)
+		}
+
+		key := []byte(mediaItemId + membershipKeySeparator + albumId)
+		if membershipBucket.Get(key) != nil {
+			return nil
+		}
+
+		added = true
+
+		return membershipBucket.Put(key, []byte{})
+	})
+
+	return added, err
+}
+
+func (r repository) GetMediaItemAlbumIds(email, mediaItemId string) ([]string, error) {
+	var albumIds []string
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		membershipBucket := bucket.Bucket([]byte(albumMembershipBucketName))
+		if membershipBucket == nil {
+			return nil
+		}
+
+		prefix := []byte(mediaItemId + membershipKeySeparator)
+		c := membershipBucket.Cursor()
+
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			albumIds = append(albumIds, string(k[len(prefix):]))
+		}
+
+		return nil
+	})
+
+	return albumIds, err
+}
@@ -12,12 +12,7 @@ type Connection struct {
 }
 
 func NewConnection() (*Connection, error) {
-	dbPath := os.Getenv("DATABASE_PATH")
-	if dbPath == "" {
-		dbPath = "/Users/michael/github.com/moontechs/google-photos-backup/backend/database.db" // TODO fix
-	}
-
-	db, err := bbolt.Open(dbPath, 0600, nil)
+	db, err := bbolt.Open(Path(), 0600, nil)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
@@ -25,6 +20,15 @@ func NewConnection() (*Connection, error) {
 	return &Connection{DB: db}, nil
 }
 
+func Path() string {
+	dbPath := os.Getenv("DATABASE_PATH")
+	if dbPath == "" {
+		dbPath = "/Users/michael/github.com/moontechs/google-photos-backup/backend/database.db" // TODO fix
+	}
+
+	return dbPath
+}
+
 func (c *Connection) Close() error {
 	if c.DB == nil {
 		return nil
@@ -4,16 +4,22 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"google-backup/internal/account"
+	"google-backup/internal/album"
 	"google-backup/internal/auth"
 	"google-backup/internal/db"
 	"google-backup/internal/downloader"
+	"google-backup/internal/drive"
 	"google-backup/internal/files"
 	"google-backup/internal/google_client"
 	"google-backup/internal/media_reader"
+	"google-backup/internal/quota"
 	"google-backup/internal/scanner"
+	"google-backup/internal/secrets"
 	"google-backup/internal/settings"
+	"google-backup/internal/storage"
 )
 
 type Factory interface {
@@ -32,12 +38,24 @@ type Dependencies struct {
 	AccountLimiter         account.Limiter
 	SettingsRepository     settings.Repository
 	SettingsInitializer    settings.SettingsInitializer
+	SettingsReader         settings.SettingsReader
+	SecretsCipher          secrets.Cipher
 	DownloaderRepository   downloader.Repository
 	Downloader             downloader.Downloader
+	RetryQueue             downloader.RetryQueue
+	DiskGuard              downloader.DiskGuard
+	QuotaRepository        quota.Repository
+	QuotaLedger            quota.Ledger
+	Storage                storage.Backend
+	RawStorage             storage.Backend
 	MediaReader            media_reader.Reader
 	FilesRepository        files.Repository
 	FilesManager           files.FilesManager
 	GoogleClientRepository google_client.Repository
+	AlbumRepository        album.Repository
+	Albums                 album.Albums
+	DriveRepository        drive.Repository
+	DriveTree              drive.Tree
 }
 
 type factory struct{}
@@ -65,20 +83,51 @@ func (f factory) Create() (Dependencies, error) {
 		DownloaderRepository:   downloader.NewRepository(connection.DB),
 		FilesRepository:        files.NewRepository(connection.DB),
 		GoogleClientRepository: google_client.NewRepository(connection.DB),
+		AlbumRepository:        album.NewRepository(connection.DB),
+		DriveRepository:        drive.NewRepository(connection.DB),
+		QuotaRepository:        quota.NewRepository(connection.DB),
 	}
 
-	deps.SettingsInitializer = settings.NewSettings(deps.SettingsRepository)
+	secretsKey, err := secrets.LoadKey(secretsKeyPath())
+	if err != nil {
+		return Dependencies{}, fmt.Errorf("load secrets key: %w", err)
+	}
+
+	deps.SecretsCipher, err = secrets.NewCipher(secretsKey)
+	if err != nil {
+		return Dependencies{}, fmt.Errorf("new secrets cipher: %w", err)
+	}
+
+	deps.SettingsInitializer = settings.NewSettings(deps.SettingsRepository, deps.SecretsCipher)
+
+	deps.SettingsReader = settings.NewSettings(deps.SettingsRepository, deps.SecretsCipher)
+
+	deps.Albums = album.NewAlbums(deps.AlbumRepository)
+
+	deps.DriveTree = drive.NewTree(deps.DriveRepository)
 
 	deps.Account = account.NewAccount(deps.AccountRepository)
 
 	deps.GoogleAuth = auth.NewGoogleAuth(deps.AuthRepository, deps.GoogleClientRepository)
 
-	deps.FilesManager = files.NewFilesManager(deps.FilesRepository)
+	deps.RawStorage = storage.NewAccounts(deps.SettingsReader)
+
+	deps.Storage = storage.NewEncrypted(deps.RawStorage, deps.SettingsReader)
+
+	deps.FilesManager = files.NewFilesManager(deps.FilesRepository, deps.Albums, deps.SettingsReader, deps.Storage)
+
+	deps.QuotaLedger = quota.NewLedger(
+		deps.QuotaRepository,
+		deps.Account,
+		deps.GoogleClientRepository,
+		deps.SettingsReader,
+	)
 
 	deps.MediaReader = media_reader.NewMediaReader(
 		deps.Account,
 		deps.GoogleAuth,
 		deps.AccountLimiter,
+		deps.QuotaLedger,
 	)
 
 	deps.UpdatesScanner = scanner.NewUpdatesScanner(
@@ -86,15 +135,39 @@ func (f factory) Create() (Dependencies, error) {
 		deps.DownloadScheduler,
 		deps.AccountLimiter,
 		deps.MediaReader,
+		deps.Albums,
+		deps.DriveTree,
+		deps.SettingsReader,
+		deps.FilesManager,
 	)
 
+	deps.RetryQueue = downloader.NewRetryQueue(deps.DownloaderRepository, deps.DownloadScheduler)
+
+	deps.DiskGuard = downloader.NewDiskGuard(deps.DownloaderRepository)
+
 	deps.Downloader = downloader.NewDownloader(
 		deps.DownloaderRepository,
 		&http.Client{},
 		deps.MediaReader,
 		deps.AccountLimiter,
 		deps.FilesManager,
+		deps.DriveTree,
+		deps.RetryQueue,
+		deps.SettingsReader,
+		deps.DiskGuard,
+		deps.QuotaLedger,
+		deps.Storage,
 	)
 
 	return deps, nil
 }
+
+// The key of the credentials saved in the settings, next to the database by default
+func secretsKeyPath() string {
+	keyPath := os.Getenv("SECRETS_KEY_PATH")
+	if keyPath == "" {
+		keyPath = db.Path() + ".key"
+	}
+
+	return keyPath
+}
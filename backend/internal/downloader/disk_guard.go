@@ -0,0 +1,163 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Reasons of paused downloads
+const (
+	PauseReasonLowDiskSpace = "free disk space is below the minimum"
+	PauseReasonNoSpaceLeft  = "no space left on device"
+)
+
+var errFreeSpaceUnsupported = errors.New("free space is not supported on this platform")
+
+type Pause struct {
+	Reason string `json:"reason"`
+	// Bytes available under the root path when the downloads were paused
+	FreeSpace    uint64 `json:"free_space"`
+	MinFreeSpace int64  `json:"min_free_space,omitempty"`
+	Since        string `json:"since"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . DiskGuard
+type DiskGuard interface {
+	Check(path string, minFreeSpace int64) (bool, error)
+	PauseNoSpaceLeft(path string) error
+	GetPause() (*Pause, error)
+}
+
+// Pauses the downloads of all accounts while the volume of the root path is full.
+// The pause is saved, so it is visible to the API, and lifted by the first check which finds enough space
+type diskGuard struct {
+	repository Repository
+}
+
+func NewDiskGuard(repository Repository) diskGuard {
+	return diskGuard{repository: repository}
+}
+
+// Returns false if the downloads have to stay paused. Without a minimum the volume isn't checked,
+// only failed writes pause the downloads then
+func (g diskGuard) Check(path string, minFreeSpace int64) (bool, error) {
+	if minFreeSpace <= 0 {
+		return true, g.resume(0)
+	}
+
+	freeSpace, err := freeSpace(existingPath(path))
+	if errors.Is(err, errFreeSpaceUnsupported) {
+		return true, g.resume(0)
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("free space: %w", err)
+	}
+
+	if freeSpace < uint64(minFreeSpace) {
+		return false, g.pause(Pause{Reason: PauseReasonLowDiskSpace, FreeSpace: freeSpace, MinFreeSpace: minFreeSpace})
+	}
+
+	return true, g.resume(freeSpace)
+}
+
+// Called when a write failed, the downloads are retried by the next run
+func (g diskGuard) PauseNoSpaceLeft(path string) error {
+	freeSpace, err := freeSpace(existingPath(path))
+	if err != nil && !errors.Is(err, errFreeSpaceUnsupported) {
+		return fmt.Errorf("free space: %w", err)
+	}
+
+	return g.pause(Pause{Reason: PauseReasonNoSpaceLeft, FreeSpace: freeSpace})
+}
+
+// Returns nil if the downloads are not paused
+func (g diskGuard) GetPause() (*Pause, error) {
+	pauseJson, err := g.repository.GetPause()
+	if err != nil {
+		return nil, fmt.Errorf("get pause: %w", err)
+	}
+
+	if pauseJson == nil {
+		return nil, nil
+	}
+
+	var pause Pause
+	err = json.Unmarshal(pauseJson, &pause)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal pause: %w", err)
+	}
+
+	return &pause, nil
+}
+
+func (g diskGuard) pause(pause Pause) error {
+	previous, err := g.GetPause()
+	if err != nil {
+		return err
+	}
+
+	pause.Since = time.Now().UTC().Format(time.RFC3339)
+	if previous != nil {
+		pause.Since = previous.Since
+	} else {
+		log.WithFields(log.Fields{
+			"reason":         pause.Reason,
+			"free_space":     pause.FreeSpace,
+			"min_free_space": pause.MinFreeSpace,
+		}).Warn("downloads paused")
+	}
+
+	pauseJson, err := json.Marshal(pause)
+	if err != nil {
+		return fmt.Errorf("marshal pause: %w", err)
+	}
+
+	return g.repository.SavePause(pauseJson)
+}
+
+// The free space is logged unless it is zero, it isn't measured without a minimum
+func (g diskGuard) resume(freeSpace uint64) error {
+	previous, err := g.GetPause()
+	if err != nil {
+		return err
+	}
+
+	if previous == nil {
+		return nil
+	}
+
+	fields := log.Fields{"paused_since": previous.Since}
+	if freeSpace > 0 {
+		fields["free_space"] = freeSpace
+	}
+
+	log.WithFields(fields).Info("downloads resumed")
+
+	return g.repository.DeletePause()
+}
+
+// The root path is created by the first download, before that the volume of its nearest existing parent is used
+func existingPath(path string) string {
+	for {
+		_, err := os.Stat(path)
+		parent := filepath.Dir(path)
+
+		if err == nil || parent == path {
+			return path
+		}
+
+		path = parent
+	}
+}
+
+func isNoSpaceLeftError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
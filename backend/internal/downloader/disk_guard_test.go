@@ -0,0 +1,171 @@
+//go:build linux || darwin
+
+package downloader
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"google-backup/internal/account"
+	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/drive"
+	"google-backup/internal/media"
+	"google-backup/internal/media/mediafakes"
+	"google-backup/internal/media_reader/media_readerfakes"
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskGuard(t *testing.T) {
+	t.Run("root path which doesn't exist yet", func(t *testing.T) {
+		guard := NewDiskGuard(newTestRepository(t))
+
+		ok, err := guard.Check(filepath.Join(t.TempDir(), "photos/backup"), 1)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("free space below the minimum", func(t *testing.T) {
+		guard := NewDiskGuard(newTestRepository(t))
+
+		ok, err := guard.Check(filepath.Join(t.TempDir(), "photos/backup"), math.MaxInt64)
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		pause, err := guard.GetPause()
+		assert.NoError(t, err)
+		require.NotNil(t, pause)
+		assert.Equal(t, PauseReasonLowDiskSpace, pause.Reason)
+		assert.Equal(t, int64(math.MaxInt64), pause.MinFreeSpace)
+	})
+
+	t.Run("pause is lifted once there is space", func(t *testing.T) {
+		guard := NewDiskGuard(newTestRepository(t))
+		root := t.TempDir()
+
+		require.NoError(t, guard.PauseNoSpaceLeft(root))
+
+		ok, err := guard.Check(root, 1)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		pause, err := guard.GetPause()
+		assert.NoError(t, err)
+		assert.Nil(t, pause)
+	})
+
+	t.Run("without minimum", func(t *testing.T) {
+		guard := NewDiskGuard(newTestRepository(t))
+
+		require.NoError(t, guard.PauseNoSpaceLeft(t.TempDir()))
+
+		// the volume isn't read, the path may not exist at all
+		ok, err := guard.Check("", 0)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		pause, err := guard.GetPause()
+		assert.NoError(t, err)
+		assert.Nil(t, pause)
+	})
+}
+
+func TestDownloadAllDiskSpace(t *testing.T) {
+	newDownloader := func(t *testing.T, settingsData settings.SettingsData, emails ...string) (downloader, *accountfakes.FakeLimiter) {
+		repository := newTestRepository(t)
+
+		readers := make(map[string]media.Reader, len(emails))
+		for _, email := range emails {
+			readers[email] = new(mediafakes.FakeReader)
+		}
+
+		fakeMediaReader := new(media_readerfakes.FakeReader)
+		fakeMediaReader.CreateMediaReadersReturns(readers, nil)
+		fakeMediaReader.CreateDriveReadersReturns(map[string]drive.Reader{}, nil)
+
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(settingsData, nil)
+
+		// stops the downloads of the account before anything is claimed
+		fakeAccountLimiter := new(accountfakes.FakeLimiter)
+		fakeAccountLimiter.LimitReachedReturns(true, nil)
+
+		return downloader{
+			repository:     repository,
+			mediaReader:    fakeMediaReader,
+			accountLimiter: fakeAccountLimiter,
+			retryQueue:     NewRetryQueue(repository, NewScheduler(repository)),
+			settingsReader: fakeSettingsReader,
+			diskGuard:      NewDiskGuard(repository),
+			bandwidth:      newBandwidthLimiter(),
+			mediaItems:     newMediaItemsCache(),
+		}, fakeAccountLimiter
+	}
+
+	downloadedEmails := func(fakeAccountLimiter *accountfakes.FakeLimiter) []string {
+		var result []string
+		for i := 0; i < fakeAccountLimiter.LimitReachedCallCount(); i++ {
+			email, limitType := fakeAccountLimiter.LimitReachedArgsForCall(i)
+			if limitType == account.ApiRequestLimitType {
+				result = append(result, email)
+			}
+		}
+
+		return result
+	}
+
+	remoteStorages := map[string]settings.AccountStorage{
+		"remote@gmail.com": {Type: settings.StorageTypeWebdav, Webdav: &settings.WebdavStorage{Url: "https://cloud.example.com/dav"}},
+	}
+
+	t.Run("full volume skips only accounts on the local disk", func(t *testing.T) {
+		d, fakeAccountLimiter := newDownloader(t, settings.SettingsData{
+			RootPath:        filepath.Join(t.TempDir(), "missing"),
+			MinFreeSpace:    math.MaxInt64,
+			AccountStorages: remoteStorages,
+		}, "local@gmail.com", "remote@gmail.com")
+
+		err := d.DownloadAll(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"remote@gmail.com"}, downloadedEmails(fakeAccountLimiter))
+	})
+
+	t.Run("remote accounts only", func(t *testing.T) {
+		d, fakeAccountLimiter := newDownloader(t, settings.SettingsData{
+			RootPath:        "/path/which/does/not/exist",
+			MinFreeSpace:    math.MaxInt64,
+			AccountStorages: remoteStorages,
+		}, "remote@gmail.com")
+
+		err := d.DownloadAll(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"remote@gmail.com"}, downloadedEmails(fakeAccountLimiter))
+
+		pause, err := d.diskGuard.GetPause()
+		assert.NoError(t, err)
+		assert.Nil(t, pause)
+	})
+
+	t.Run("root path which doesn't exist yet", func(t *testing.T) {
+		d, fakeAccountLimiter := newDownloader(t, settings.SettingsData{
+			RootPath:     filepath.Join(t.TempDir(), "missing"),
+			MinFreeSpace: 1,
+		}, "local@gmail.com")
+
+		err := d.DownloadAll(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"local@gmail.com"}, downloadedEmails(fakeAccountLimiter))
+	})
+}
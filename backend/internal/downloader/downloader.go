@@ -2,22 +2,32 @@ package downloader
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
-	"os"
+	"strings"
+	"sync/atomic"
 
 	"google-backup/internal/account"
+	"google-backup/internal/drive"
+	"google-backup/internal/encryption"
 	"google-backup/internal/files"
 	"google-backup/internal/media"
 	"google-backup/internal/media_reader"
+	"google-backup/internal/quota"
+	"google-backup/internal/settings"
+	"google-backup/internal/storage"
 
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
-const downloadsBatchLimit = 50
+const partialFileSuffix = ".partial"
 
 type Downloader interface {
 	DownloadAll(ctx context.Context) error
@@ -29,18 +39,35 @@ type TooManyRequestsError struct {
 
 type NotOkRequestError struct {
 	error
+	StatusCode int
 }
 
 type ManuallyRepeatableError struct {
 	error
 }
 
+type VideoNotReadyError struct {
+	error
+}
+
+type UnexpectedContentTypeError struct {
+	error
+}
+
 type downloader struct {
 	repository     Repository
 	httpClient     *http.Client
 	mediaReader    media_reader.Reader
 	accountLimiter account.Limiter
 	filesManager   files.FilesManager
+	driveTree      drive.Tree
+	retryQueue     RetryQueue
+	settingsReader settings.SettingsReader
+	diskGuard      DiskGuard
+	quotaLedger    quota.Ledger
+	storage        storage.Backend
+	bandwidth      *bandwidthLimiter
+	mediaItems     *mediaItemsCache
 }
 
 func NewDownloader(
@@ -49,6 +76,12 @@ func NewDownloader(
 	mediaReader media_reader.Reader,
 	accountLimiter account.Limiter,
 	filesManager files.FilesManager,
+	driveTree drive.Tree,
+	retryQueue RetryQueue,
+	settingsReader settings.SettingsReader,
+	diskGuard DiskGuard,
+	quotaLedger quota.Ledger,
+	storage storage.Backend,
 ) downloader {
 	return downloader{
 		repository:     repository,
@@ -56,24 +89,72 @@ func NewDownloader(
 		mediaReader:    mediaReader,
 		accountLimiter: accountLimiter,
 		filesManager:   filesManager,
+		driveTree:      driveTree,
+		retryQueue:     retryQueue,
+		settingsReader: settingsReader,
+		diskGuard:      diskGuard,
+		quotaLedger:    quotaLedger,
+		storage:        storage,
+		bandwidth:      newBandwidthLimiter(),
+		mediaItems:     newMediaItemsCache(),
 	}
 }
 
 func (d downloader) DownloadAll(ctx context.Context) error {
+	settingsData, err := d.settingsReader.Get()
+	if err != nil {
+		return fmt.Errorf("get settings: %w", err)
+	}
+
 	readers, err := d.mediaReader.CreateMediaReaders(ctx)
 	if err != nil {
 		return fmt.Errorf("create media readers: %w", err)
 	}
 
-	errs, ctx := errgroup.WithContext(ctx)
+	driveReaders, err := d.mediaReader.CreateDriveReaders(ctx)
+	if err != nil {
+		return fmt.Errorf("create drive readers: %w", err)
+	}
+
+	// only accounts backed up to the local disk fill the volume of the root path,
+	// without them the volume isn't checked and a previous pause is lifted
+	minFreeSpace := int64(0)
+	for email := range readers {
+		if settingsData.IsLocalStorage(email) {
+			minFreeSpace = settingsData.MinFreeSpace
+		}
+	}
+
+	for email := range driveReaders {
+		if settingsData.IsLocalStorage(email) {
+			minFreeSpace = settingsData.MinFreeSpace
+		}
+	}
+
+	// checked before every batch, a full volume would fail every download
+	localSpaceOk, err := d.diskGuard.Check(settingsData.RootPath, minFreeSpace)
+	if err != nil {
+		return fmt.Errorf("check disk space: %w", err)
+	}
+
+	pool := newWorkerPool(settingsData)
+
+	d.bandwidth.SetSettings(settingsData)
+
+	// accounts don't cancel each other, a failing account would interrupt the downloads of the others
+	var errs errgroup.Group
 
 	for email, reader := range readers {
+		if !localSpaceOk && settingsData.IsLocalStorage(email) {
+			continue
+		}
+
 		r := reader
 		e := email
 
 		errs.Go(
 			func() error {
-				err = d.download(ctx, r, e)
+				err := d.download(ctx, r, e, pool)
 				if err != nil {
 					return fmt.Errorf("download: %w", err)
 				}
@@ -83,47 +164,163 @@ func (d downloader) DownloadAll(ctx context.Context) error {
 		)
 	}
 
+	for email, reader := range driveReaders {
+		if !localSpaceOk && settingsData.IsLocalStorage(email) {
+			continue
+		}
+
+		r := reader
+		e := email
+
+		errs.Go(
+			func() error {
+				err := d.downloadDrive(ctx, r, e, pool)
+				if err != nil {
+					return fmt.Errorf("download drive: %w", err)
+				}
+
+				return nil
+			},
+		)
+	}
+
 	return errs.Wait()
 }
 
-func (d downloader) download(ctx context.Context, mediaReader media.Reader, email string) error {
-	counter := 0
+// Runs the account workers until the batch size is reached or the queue is empty
+func (d downloader) download(ctx context.Context, mediaReader media.Reader, email string, pool workerPool) error {
+	err := d.retryQueue.ScheduleDue(email, DownloadTypePhotos)
+	if err != nil {
+		return fmt.Errorf("schedule due retries: %w", err)
+	}
 
-	for counter < downloadsBatchLimit {
-		fileMeta, err := d.downloadFromBaseUrl(ctx, mediaReader, email)
-		if err != nil {
-			if errors.As(err, &media.TooManyRequestsError{}) {
-				d.accountLimiter.SetLimitReached(string(email), account.ApiRequestLimitType, true)
+	err = d.repository.ReleaseDownloadRequests(email)
+	if err != nil {
+		return fmt.Errorf("release download requests: %w", err)
+	}
 
-				return fmt.Errorf("download from base url: %w", err)
-			}
+	defer d.bandwidth.LogThroughput(email)
 
-			if errors.As(err, &TooManyRequestsError{}) {
-				d.accountLimiter.SetLimitReached(string(email), account.DownloadLimitType, true)
+	var counter atomic.Int64
 
-				return fmt.Errorf("download from base url: %w", err)
-			}
+	// workers don't cancel each other, the other workers stop once the limits are reached or the queue is empty
+	var workers errgroup.Group
 
-			if fileMeta.MediaItem.ID != "" {
-				d.repository.DeleteDownloadRequest(email, fileMeta.MediaItem.ID)
+	for i := 0; i < pool.workersPerAccount; i++ {
+		workers.Go(func() error {
+			for counter.Add(1) <= int64(pool.batchSize) {
+				if !pool.acquire(ctx) {
+					return nil
+				}
+
+				done, err := d.downloadNext(ctx, mediaReader, email)
+
+				pool.release()
+
+				if err != nil {
+					return err
+				}
+
+				if done {
+					return nil
+				}
 			}
 
-			// TODO add to errors reporter and continue loop. Files package has a function
+			return nil
+		})
+	}
+
+	return workers.Wait()
+}
+
+// Returns true if there is nothing to download
+func (d downloader) downloadNext(ctx context.Context, mediaReader media.Reader, email string) (bool, error) {
+	fileMeta, err := d.downloadFromBaseUrl(ctx, mediaReader, email)
+	if err != nil {
+		// the other workers of the account see the limit before they claim the next request
+		if errors.As(err, &media.TooManyRequestsError{}) {
+			d.accountLimiter.SetLimitReached(string(email), account.ApiRequestLimitType, true)
+			log.WithFields(log.Fields{"email": email, "error": err}).Warn("api request limit reached")
+
+			return true, d.releaseDownloadRequest(email, fileMeta.MediaItem.ID)
 		}
 
-		if fileMeta.MediaItem.ID != "" {
-			err = d.repository.DeleteDownloadRequest(email, fileMeta.MediaItem.ID)
-			if err != nil {
-				return fmt.Errorf("delete download request: %w", err)
+		if errors.As(err, &TooManyRequestsError{}) {
+			d.accountLimiter.SetLimitReached(string(email), account.DownloadLimitType, true)
+			log.WithFields(log.Fields{"email": email, "error": err}).Warn("download limit reached")
+
+			return true, d.releaseDownloadRequest(email, fileMeta.MediaItem.ID)
+		}
+
+		// downloaded after the quota reset
+		if errors.Is(err, quota.ErrBudgetSpent) {
+			return true, d.releaseDownloadRequest(email, fileMeta.MediaItem.ID)
+		}
+
+		// interrupted by the shutdown, the partial file is resumed by the next run
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+			return true, d.releaseDownloadRequest(email, fileMeta.MediaItem.ID)
+		}
+
+		if fileMeta.MediaItem.ID == "" {
+			return true, fmt.Errorf("download from base url: %w", err)
+		}
+
+		// the claimed request is released and downloaded again by the next run
+		if isNoSpaceLeftError(err) {
+			pauseErr := d.pauseNoSpaceLeft()
+			if pauseErr != nil {
+				return true, fmt.Errorf("pause downloads: %w", pauseErr)
 			}
+
+			return true, fmt.Errorf("download from base url: %w", err)
 		}
 
-		err = d.filesManager.SaveFileMeta(email, fileMeta)
+		err = d.retryQueue.Fail(email, DownloadTypePhotos, fileMeta.MediaItem.ID, err)
 		if err != nil {
-			return fmt.Errorf("save file meta: %w", err)
+			return true, fmt.Errorf("fail download: %w", err)
 		}
 
-		counter++
+		err = d.repository.DeleteDownloadRequest(email, fileMeta.MediaItem.ID)
+		if err != nil {
+			return true, fmt.Errorf("delete download request: %w", err)
+		}
+
+		return false, nil
+	}
+
+	// nothing to download
+	if fileMeta.MediaItem.ID == "" {
+		return true, nil
+	}
+
+	err = d.filesManager.SaveFileMeta(email, fileMeta)
+	if err != nil {
+		return true, fmt.Errorf("save file meta: %w", err)
+	}
+
+	err = d.retryQueue.Succeed(email, DownloadTypePhotos, fileMeta.MediaItem.ID)
+	if err != nil {
+		return true, fmt.Errorf("succeed download: %w", err)
+	}
+
+	err = d.repository.DeleteDownloadRequest(email, fileMeta.MediaItem.ID)
+	if err != nil {
+		return true, fmt.Errorf("delete download request: %w", err)
+	}
+
+	return false, nil
+}
+
+// Puts the claimed request back into the queue, there is nothing to release without a claimed request
+func (d downloader) releaseDownloadRequest(email string, mediaItemId string) error {
+	if mediaItemId == "" {
+		return nil
+	}
+
+	err := d.repository.ReleaseDownloadRequest(email, mediaItemId)
+	if err != nil {
+		return fmt.Errorf("release download request: %w", err)
 	}
 
 	return nil
@@ -132,9 +329,36 @@ func (d downloader) download(ctx context.Context, mediaReader media.Reader, emai
 func (d downloader) downloadFromBaseUrl(ctx context.Context, mediaReader media.Reader, email string) (files.FileMeta, error) {
 	fileMeta := files.FileMeta{}
 
-	downloadRequestJson, err := d.repository.GetDownloadRequest(email)
+	limitReached, err := d.accountLimiter.LimitReached(email, account.ApiRequestLimitType)
+	if err != nil {
+		return fileMeta, fmt.Errorf("limit reached: %w", err)
+	}
+
+	if limitReached {
+		return fileMeta, nil
+	}
+
+	limitReached, err = d.accountLimiter.LimitReached(email, account.DownloadLimitType)
+	if err != nil {
+		return fileMeta, fmt.Errorf("download limit reached: %w", err)
+	}
+
+	if limitReached {
+		return fileMeta, nil
+	}
+
+	available, err := d.quotaLedger.Available(email, quota.DownloadQuotaType)
+	if err != nil {
+		return fileMeta, fmt.Errorf("download quota available: %w", err)
+	}
+
+	if !available {
+		return fileMeta, nil
+	}
+
+	downloadRequestJson, err := d.repository.ClaimDownloadRequest(email)
 	if err != nil {
-		return fileMeta, fmt.Errorf("get download request: %w", err)
+		return fileMeta, fmt.Errorf("claim download request: %w", err)
 	}
 
 	if downloadRequestJson == nil {
@@ -151,22 +375,23 @@ func (d downloader) downloadFromBaseUrl(ctx context.Context, mediaReader media.R
 		return fileMeta, nil
 	}
 
-	limitReached, err := d.accountLimiter.LimitReached(email, account.ApiRequestLimitType)
-	if err != nil {
-		return fileMeta, fmt.Errorf("limit reached: %w", err)
-	}
+	// lets the caller retry the request even if the media item can't be fetched
+	fileMeta.MediaItem.ID = downloadRequest.MediaItemId
 
-	if limitReached {
-		return fileMeta, nil
-	}
+	// the claimed item is downloaded only once, a retry needs a fresh base url anyway
+	defer d.mediaItems.delete(email, downloadRequest.MediaItemId)
 
-	mediaItem, err := mediaReader.GetMediaItem(downloadRequest.MediaItemId)
+	mediaItem, err := d.getMediaItem(mediaReader, email, downloadRequest.MediaItemId)
 	if err != nil {
 		return fileMeta, fmt.Errorf("get media item: %w", err)
 	}
 
 	fileMeta.MediaItem = mediaItem
 
+	if !mediaItem.IsReadyToDownload() {
+		return fileMeta, VideoNotReadyError{fmt.Errorf("video status: %s", mediaItem.MediaMetadata.Video.Status)}
+	}
+
 	filePathName, err := d.filesManager.GenerateFilePathName(email, mediaItem)
 	if err != nil {
 		return fileMeta, fmt.Errorf("create file path name: %w", err)
@@ -177,81 +402,471 @@ func (d downloader) downloadFromBaseUrl(ctx context.Context, mediaReader media.R
 		return fileMeta, fmt.Errorf("file exists: %w", err)
 	}
 
-	err = d.filesManager.CreateFolderIfDoesNotExist(filePathName)
+	previousFileMeta, _, err := d.filesManager.GetFileMeta(email, mediaItem.ID)
 	if err != nil {
-		return fileMeta, fmt.Errorf("create folder: %w", err)
+		return fileMeta, fmt.Errorf("get file meta: %w", err)
 	}
 
-	err = d.downloadFile(
-		filePathName,
-		mediaItem.BaseUrl,
-		func(reader io.Reader) (bool, error) {
-			if fileExists {
-				return d.filesManager.EqualHash(filePathName, reader)
-			} else {
-				return true, nil
-			}
-		},
-	)
+	// changed files are detected by the hash of the downloaded content
+	storedHash := previousFileMeta.ContentHash
+	if previousFileMeta.DownloadedContentHash != "" {
+		storedHash = previousFileMeta.DownloadedContentHash
+	}
+
+	shouldReplace := d.replaceIfChanged(filePathName, fileExists, storedHash)
+	replaced := false
+
+	// the markers are looked for while the image is downloaded, the image isn't read again afterwards.
+	// Only the attempt which gets the content writes to it
+	motionPhotoDetector := files.NewMotionPhotoDetector()
+
+	err = d.withFreshBaseUrl(mediaReader, &mediaItem, func(mediaItem media.MediaItem) error {
+		err := d.reserveDownload(email)
+		if err != nil {
+			return err
+		}
+
+		fileMeta.ContentHash, err = d.downloadFile(
+			ctx,
+			email,
+			mediaItem.ID,
+			filePathName,
+			mediaItem.DownloadUrl(),
+			"",
+			motionPhotoDetector,
+			func(hash string) (bool, error) {
+				replaced, err = shouldReplace(hash)
+
+				return replaced, err
+			},
+		)
+
+		return err
+	})
 	if err != nil {
 		return fileMeta, fmt.Errorf("download file: %w", err)
 	}
 
+	fileMeta.MediaItem = mediaItem
+
+	err = d.embedExif(filePathName, &fileMeta, previousFileMeta, replaced)
+	if err != nil {
+		return fileMeta, fmt.Errorf("embed exif: %w", err)
+	}
+
+	fileMeta.Size, err = d.fileSize(filePathName)
+	if err != nil {
+		return fileMeta, fmt.Errorf("file size: %w", err)
+	}
+
+	err = d.filesManager.StoreContent(email, mediaItem.ID, filePathName, fileMeta.ContentHash, false)
+	if err != nil {
+		return fileMeta, fmt.Errorf("store content: %w", err)
+	}
+
 	err = d.filesManager.UpdateCreationTime(filePathName, mediaItem.MediaMetadata.CreationTime)
 	if err != nil {
 		return fileMeta, fmt.Errorf("update creation time: %w", err)
 	}
 
+	err = d.filesManager.LinkToAlbums(email, filePathName, mediaItem.ID)
+	if err != nil {
+		return fileMeta, fmt.Errorf("link to albums: %w", err)
+	}
+
+	// the content store may have replaced the file by an object stored before encryption was enabled
+	fileMeta.Encryption, err = storage.EncryptionScheme(d.storage, filePathName)
+	if err != nil {
+		return fileMeta, fmt.Errorf("encryption scheme: %w", err)
+	}
+
 	fileMeta.FilePathName = filePathName
 
+	if mediaItem.MayBeMotionPhoto() && motionPhotoDetector.Found() {
+		motionVideoFilePathName, motionVideoContentHash, err := d.downloadMotionVideo(
+			ctx,
+			email,
+			filePathName,
+			mediaItem,
+			previousFileMeta.MotionVideoContentHash,
+		)
+		if err != nil {
+			return fileMeta, fmt.Errorf("download motion video: %w", err)
+		}
+
+		fileMeta.MotionVideoFilePathName = motionVideoFilePathName
+		fileMeta.MotionVideoContentHash = motionVideoContentHash
+
+		if motionVideoFilePathName != "" {
+			fileMeta.MotionVideoSize, err = d.fileSize(motionVideoFilePathName)
+			if err != nil {
+				return fileMeta, fmt.Errorf("motion video file size: %w", err)
+			}
+
+			err = d.filesManager.StoreContent(email, mediaItem.ID, motionVideoFilePathName, motionVideoContentHash, true)
+			if err != nil {
+				return fileMeta, fmt.Errorf("store motion video content: %w", err)
+			}
+
+			fileMeta.MotionVideoEncryption, err = storage.EncryptionScheme(d.storage, motionVideoFilePathName)
+			if err != nil {
+				return fileMeta, fmt.Errorf("motion video encryption scheme: %w", err)
+			}
+		}
+	}
+
 	return fileMeta, nil
 }
 
+// Embeds the metadata into the file on disk and records the hashes of the downloaded and of the embedded content
+func (d downloader) embedExif(filePathName string, fileMeta *files.FileMeta, previousFileMeta files.FileMeta, replaced bool) error {
+	// the file on disk is the previously embedded one
+	if !replaced && previousFileMeta.DownloadedContentHash != "" {
+		fileMeta.ContentHash = previousFileMeta.ContentHash
+		fileMeta.DownloadedContentHash = previousFileMeta.DownloadedContentHash
+	}
+
+	embedded, err := d.filesManager.EmbedExif(filePathName, fileMeta.MediaItem)
+	if err != nil {
+		return fmt.Errorf("embed exif: %w", err)
+	}
+
+	if !embedded {
+		return nil
+	}
+
+	contentHash, err := d.filesManager.HashFile(filePathName)
+	if err != nil {
+		return fmt.Errorf("hash file: %w", err)
+	}
+
+	if fileMeta.DownloadedContentHash == "" {
+		fileMeta.DownloadedContentHash = fileMeta.ContentHash
+	}
+
+	fileMeta.ContentHash = contentHash
+
+	return nil
+}
+
+// Saves the video component of a motion photo next to the still image.
+// The base url was just used for the still image, so it isn't refreshed, and the video request isn't
+// reserved in the download budget, it belongs to the download of the photo.
+// Returns the file path name and the content hash, or empty strings if the photo has no video component
+func (d downloader) downloadMotionVideo(
+	ctx context.Context,
+	email string,
+	filePathName string,
+	mediaItem media.MediaItem,
+	storedHash string,
+) (string, string, error) {
+	motionVideoFilePathName := d.filesManager.GenerateMotionVideoFilePathName(filePathName)
+
+	_, err := d.storage.Stat(motionVideoFilePathName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", "", fmt.Errorf("stat motion video file: %w", err)
+	}
+
+	fileExists := err == nil
+
+	contentHash, err := d.downloadFile(
+		ctx,
+		email,
+		mediaItem.ID,
+		motionVideoFilePathName,
+		mediaItem.MotionVideoUrl(),
+		"video/",
+		nil,
+		d.replaceIfChanged(motionVideoFilePathName, fileExists, storedHash),
+	)
+	if err != nil {
+		var notOkErr NotOkRequestError
+
+		// the image declares a video which Google doesn't return, it is backed up as a photo
+		if errors.As(err, &UnexpectedContentTypeError{}) || (errors.As(err, &notOkErr) && notOkErr.StatusCode == http.StatusNotFound) {
+			log.WithFields(log.Fields{"email": email, "media_item_id": mediaItem.ID}).Warn("motion photo without video")
+
+			return "", "", nil
+		}
+
+		return "", "", err
+	}
+
+	err = d.filesManager.UpdateCreationTime(motionVideoFilePathName, mediaItem.MediaMetadata.CreationTime)
+	if err != nil {
+		return "", "", fmt.Errorf("update creation time: %w", err)
+	}
+
+	return motionVideoFilePathName, contentHash, nil
+}
+
+// Pauses the downloads until the volume of the root path has space again
+func (d downloader) pauseNoSpaceLeft() error {
+	settingsData, err := d.settingsReader.Get()
+	if err != nil {
+		return fmt.Errorf("get settings: %w", err)
+	}
+
+	return d.diskGuard.PauseNoSpaceLeft(settingsData.RootPath)
+}
+
+// Recorded in the file meta for the backup verification
+func (d downloader) fileSize(filePathName string) (int64, error) {
+	fileInfo, err := d.storage.Stat(filePathName)
+	if err != nil {
+		return 0, fmt.Errorf("stat file: %w", err)
+	}
+
+	return fileInfo.Size, nil
+}
+
+// Retries the download once with a fresh base url if the previous one expired
+func (d downloader) withFreshBaseUrl(
+	mediaReader media.Reader,
+	mediaItem *media.MediaItem,
+	download func(mediaItem media.MediaItem) error,
+) error {
+	err := download(*mediaItem)
+
+	var notOkErr NotOkRequestError
+	if !errors.As(err, &notOkErr) || notOkErr.StatusCode != http.StatusForbidden {
+		return err
+	}
+
+	freshMediaItem, err := mediaReader.GetMediaItem(mediaItem.ID)
+	if err != nil {
+		return fmt.Errorf("refresh media item: %w", err)
+	}
+
+	*mediaItem = freshMediaItem
+
+	return download(freshMediaItem)
+}
+
+// Replaces an existing file only if the downloaded content is different.
+// Files downloaded before hashes were recorded are hashed on disk
+func (d downloader) replaceIfChanged(filePathName string, fileExists bool, storedHash string) func(hash string) (bool, error) {
+	return func(hash string) (bool, error) {
+		if !fileExists {
+			return true, nil
+		}
+
+		if storedHash == "" {
+			existingHash, err := d.filesManager.HashFile(filePathName)
+			if err != nil {
+				return false, fmt.Errorf("hash file: %w", err)
+			}
+
+			storedHash = existingHash
+		}
+
+		return storedHash != hash, nil
+	}
+}
+
+// Counts the download in the daily budget of the client of the account
+func (d downloader) reserveDownload(email string) error {
+	reserved, err := d.quotaLedger.Reserve(email, quota.DownloadQuotaType)
+	if err != nil {
+		return fmt.Errorf("reserve download: %w", err)
+	}
+
+	if !reserved {
+		return fmt.Errorf("reserve download: %w", quota.ErrBudgetSpent)
+	}
+
+	return nil
+}
+
+// Downloads into a partial file, resuming it with a Range request if it already exists,
+// and renames it into place once the whole body is written and synced.
+// The content is hashed while it is written, the sha256 of the whole file is returned.
+// The whole content is also written to the content writer unless it is nil.
+// An empty content type prefix accepts any response content type
 func (d downloader) downloadFile(
+	ctx context.Context,
+	email string,
+	mediaItemId string,
 	filePathName string,
 	url string,
-	shouldDownload func(reader io.Reader) (bool, error),
-) error {
-	resp, err := d.httpClient.Get(url + "=d")
+	contentTypePrefix string,
+	contentWriter io.Writer,
+	shouldReplace func(hash string) (bool, error),
+) (string, error) {
+	partialFilePathName := filePathName + partialFileSuffix
+
+	var offset int64
+	info, err := d.storage.Stat(partialFilePathName)
+	if err == nil {
+		offset = info.Size
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("stat partial file: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("download file: %w", err)
+		return "", fmt.Errorf("download file: %w", err)
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return TooManyRequestsError{}
+		return "", TooManyRequestsError{errors.New("too many requests")}
+	}
+
+	// the partial file is bigger than the remote one, start from scratch next time
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		err = d.storage.Delete(partialFilePathName)
+		if err != nil {
+			return "", fmt.Errorf("remove partial file: %w", err)
+		}
+
+		return "", NotOkRequestError{error: fmt.Errorf("range %d- not satisfiable", offset), StatusCode: resp.StatusCode}
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	hash := sha256.New()
+	content := io.Writer(hash)
+	if contentWriter != nil {
+		content = io.MultiWriter(hash, contentWriter)
+	}
+
+	appendData := false
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the server ignored the range header and sent the whole file
+	case http.StatusPartialContent:
+		// the body doesn't continue the partial file, start from scratch next time
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			err = d.storage.Delete(partialFilePathName)
+			if err != nil {
+				return "", fmt.Errorf("remove partial file: %w", err)
+			}
+
+			return "", NotOkRequestError{
+				error:      fmt.Errorf("unexpected content range: %s", resp.Header.Get("Content-Range")),
+				StatusCode: resp.StatusCode,
+			}
+		}
+
+		err = d.readPartialFile(partialFilePathName, content)
+		if errors.Is(err, encryption.ErrTruncated) {
+			// the write of the encrypted partial file was interrupted, start from scratch next time
+			d.storage.Delete(partialFilePathName)
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("read partial file: %w", err)
+		}
+
+		appendData = true
+	default:
 		responseBody, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return fmt.Errorf("read response body: %w", err)
+			return "", fmt.Errorf("read response body: %w", err)
 		}
 
-		return NotOkRequestError{fmt.Errorf(string(responseBody))}
+		return "", NotOkRequestError{error: errors.New(string(responseBody)), StatusCode: resp.StatusCode}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentTypePrefix != "" && !strings.HasPrefix(contentType, contentTypePrefix) {
+		return "", UnexpectedContentTypeError{fmt.Errorf("unexpected content type: %s", contentType)}
+	}
+
+	out, err := d.storage.Writer(partialFilePathName, appendData)
+	if err != nil {
+		return "", fmt.Errorf("open partial file: %w", err)
 	}
 
-	ok, err := shouldDownload(resp.Body)
+	err = writeAndSync(out, io.TeeReader(d.bandwidth.Reader(ctx, email, resp.Body), content))
 	if err != nil {
-		return fmt.Errorf("should download: %w", err)
+		return "", fmt.Errorf("write partial file: %w", err)
+	}
+
+	contentHash := hex.EncodeToString(hash.Sum(nil))
+
+	ok, err := shouldReplace(contentHash)
+	if err != nil {
+		return "", fmt.Errorf("should replace: %w", err)
 	}
 
 	if !ok {
-		return nil
+		err = d.storage.Delete(partialFilePathName)
+		if err != nil {
+			return "", fmt.Errorf("remove partial file: %w", err)
+		}
+
+		return contentHash, nil
+	}
+
+	return contentHash, d.commitPartialFile(filePathName, map[string]string{
+		storage.MetadataMediaItemId: mediaItemId,
+		storage.MetadataSha256:      contentHash,
+	})
+}
+
+// Writes the whole reader into a partial file and atomically renames it into place
+func (d downloader) saveFile(ctx context.Context, email string, filePathName string, reader io.Reader, metadata map[string]string) error {
+	out, err := d.storage.Writer(filePathName+partialFileSuffix, false)
+	if err != nil {
+		return fmt.Errorf("create partial file: %w", err)
 	}
 
-	out, err := os.Create(d.filesManager.AddRootFolderToPath(filePathName))
+	err = writeAndSync(out, d.bandwidth.Reader(ctx, email, reader))
+	if err != nil {
+		return fmt.Errorf("write partial file: %w", err)
+	}
+
+	return d.commitPartialFile(filePathName, metadata)
+}
+
+// The metadata is recorded by storages which keep metadata, e.g. object storages
+func (d downloader) commitPartialFile(filePathName string, metadata map[string]string) error {
+	err := storage.RenameWithMetadata(d.storage, filePathName+partialFileSuffix, filePathName, metadata)
 	if err != nil {
-		return fmt.Errorf("create file: %w", err)
+		return fmt.Errorf("rename partial file: %w", err)
 	}
 
-	defer out.Close()
+	return nil
+}
 
-	_, err = io.Copy(out, resp.Body)
+// The storage makes the data durable on close
+func writeAndSync(out io.WriteCloser, reader io.Reader) error {
+	_, err := io.Copy(out, reader)
 	if err != nil {
+		out.Close()
+
 		return fmt.Errorf("copy file: %w", err)
 	}
 
+	err = out.Close()
+	if err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+
 	return nil
 }
+
+// Writes the content of the partial file, so the hash and the content writer see the whole file
+func (d downloader) readPartialFile(partialFilePathName string, content io.Writer) error {
+	file, err := d.storage.Reader(partialFilePathName)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(content, file)
+
+	return err
+}
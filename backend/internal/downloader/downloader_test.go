@@ -0,0 +1,310 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google-backup/internal/account"
+	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/encryption"
+	"google-backup/internal/files/filesfakes"
+	"google-backup/internal/media"
+	"google-backup/internal/media/mediafakes"
+	"google-backup/internal/quota/quotafakes"
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+	"google-backup/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadFile(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	contentHash := sha256.Sum256(content)
+
+	newDownloader := func(t *testing.T, backend storage.Backend, handler http.HandlerFunc) (downloader, string) {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		return downloader{httpClient: server.Client(), storage: backend, bandwidth: newBandwidthLimiter()}, server.URL
+	}
+
+	writePartial := func(t *testing.T, backend storage.Backend, data []byte) {
+		writer, err := backend.Writer("user@gmail.com/2023/4/IMG_0001.JPG"+partialFileSuffix, false)
+		require.NoError(t, err)
+
+		_, err = writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+	}
+
+	read := func(t *testing.T, backend storage.Backend, pathName string) []byte {
+		reader, err := backend.Reader(pathName)
+		require.NoError(t, err)
+
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		return data
+	}
+
+	replace := func(hash string) (bool, error) {
+		return true, nil
+	}
+
+	t.Run("server ignoring the range", func(t *testing.T) {
+		backend := storage.NewLocal(t.TempDir())
+		writePartial(t, backend, content[:8])
+
+		var rangeHeader string
+		d, url := newDownloader(t, backend, func(w http.ResponseWriter, r *http.Request) {
+			rangeHeader = r.Header.Get("Range")
+
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(content)
+		})
+
+		hash, err := d.downloadFile(context.Background(), "user@gmail.com", "item-1", "user@gmail.com/2023/4/IMG_0001.JPG", url, "image/", nil, replace)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bytes=8-", rangeHeader)
+		assert.Equal(t, hex.EncodeToString(contentHash[:]), hash)
+		assert.Equal(t, content, read(t, backend, "user@gmail.com/2023/4/IMG_0001.JPG"))
+
+		_, err = backend.Stat("user@gmail.com/2023/4/IMG_0001.JPG" + partialFileSuffix)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("resumed download", func(t *testing.T) {
+		backend := storage.NewLocal(t.TempDir())
+		writePartial(t, backend, content[:8])
+
+		d, url := newDownloader(t, backend, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 8-%d/%d", len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[8:])
+		})
+
+		var written bytes.Buffer
+		hash, err := d.downloadFile(context.Background(), "user@gmail.com", "item-1", "user@gmail.com/2023/4/IMG_0001.JPG", url, "image/", &written, replace)
+
+		assert.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(contentHash[:]), hash)
+		assert.Equal(t, content, read(t, backend, "user@gmail.com/2023/4/IMG_0001.JPG"))
+		assert.Equal(t, content, written.Bytes())
+	})
+
+	t.Run("range not satisfiable", func(t *testing.T) {
+		backend := storage.NewLocal(t.TempDir())
+		writePartial(t, backend, append(content, content...))
+
+		d, url := newDownloader(t, backend, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		})
+
+		_, err := d.downloadFile(context.Background(), "user@gmail.com", "item-1", "user@gmail.com/2023/4/IMG_0001.JPG", url, "image/", nil, replace)
+
+		var notOkErr NotOkRequestError
+		assert.True(t, errors.As(err, &notOkErr))
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, notOkErr.StatusCode)
+
+		_, err = backend.Stat("user@gmail.com/2023/4/IMG_0001.JPG" + partialFileSuffix)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("content range not continuing the partial file", func(t *testing.T) {
+		backend := storage.NewLocal(t.TempDir())
+		writePartial(t, backend, content[:8])
+
+		d, url := newDownloader(t, backend, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content)
+		})
+
+		_, err := d.downloadFile(context.Background(), "user@gmail.com", "item-1", "user@gmail.com/2023/4/IMG_0001.JPG", url, "image/", nil, replace)
+
+		var notOkErr NotOkRequestError
+		assert.True(t, errors.As(err, &notOkErr))
+		assert.Equal(t, http.StatusPartialContent, notOkErr.StatusCode)
+
+		_, err = backend.Stat("user@gmail.com/2023/4/IMG_0001.JPG" + partialFileSuffix)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("truncated encrypted partial file", func(t *testing.T) {
+		salt := make([]byte, encryption.SaltSize)
+
+		keyring, err := encryption.NewKeyring("secret", "")
+		require.NoError(t, err)
+
+		key, err := keyring.Key(encryption.KdfScrypt, salt)
+		require.NoError(t, err)
+
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(settings.SettingsData{Encryption: &settings.Encryption{
+			Enabled:    true,
+			Passphrase: "secret",
+			Salt:       base64.StdEncoding.EncodeToString(salt),
+			KeyCheck:   key.Check(),
+		}}, nil)
+
+		root := t.TempDir()
+		backend := storage.NewEncrypted(storage.NewLocal(root), fakeSettingsReader)
+		writePartial(t, backend, content)
+
+		storedPath := filepath.Join(root, "user@gmail.com/2023/4/IMG_0001.JPG"+partialFileSuffix+".enc")
+		stored, err := os.ReadFile(storedPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(storedPath, stored[:len(stored)-4], 0644))
+
+		d, url := newDownloader(t, backend, func(w http.ResponseWriter, r *http.Request) {
+			var offset int
+			fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset)
+
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[offset:])
+		})
+
+		_, err = d.downloadFile(context.Background(), "user@gmail.com", "item-1", "user@gmail.com/2023/4/IMG_0001.JPG", url, "image/", nil, replace)
+
+		assert.ErrorIs(t, err, encryption.ErrTruncated)
+
+		_, err = os.Stat(storedPath)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestDownloadNext(t *testing.T) {
+	type testDownloader struct {
+		downloader
+		repository         repo
+		fakeMediaReader    *mediafakes.FakeReader
+		fakeAccountLimiter *accountfakes.FakeLimiter
+	}
+
+	newDownloader := func(t *testing.T, handler http.HandlerFunc) testDownloader {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		repository := newTestRepository(t)
+		require.NoError(t, NewScheduler(repository).ScheduleDownload(testEmail, "item-1"))
+
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.BatchGetMediaItemsReturns(map[string]media.MediaItem{
+			"item-1": {ID: "item-1", BaseUrl: server.URL + "/item-1", MimeType: "image/png"},
+		}, nil)
+
+		fakeQuotaLedger := new(quotafakes.FakeLedger)
+		fakeQuotaLedger.AvailableReturns(true, nil)
+		fakeQuotaLedger.ReserveReturns(true, nil)
+
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		fakeFilesManager.GenerateFilePathNameReturns("user@gmail.com/2023/4/IMG_0001.PNG", nil)
+
+		fakeAccountLimiter := new(accountfakes.FakeLimiter)
+
+		return testDownloader{
+			downloader: downloader{
+				repository:     repository,
+				httpClient:     server.Client(),
+				accountLimiter: fakeAccountLimiter,
+				filesManager:   fakeFilesManager,
+				retryQueue:     NewRetryQueue(repository, NewScheduler(repository)),
+				quotaLedger:    fakeQuotaLedger,
+				storage:        storage.NewLocal(t.TempDir()),
+				bandwidth:      newBandwidthLimiter(),
+				mediaItems:     newMediaItemsCache(),
+			},
+			repository:         repository,
+			fakeMediaReader:    fakeMediaReader,
+			fakeAccountLimiter: fakeAccountLimiter,
+		}
+	}
+
+	// the released request is claimed again, no retry is recorded for it
+	assertReleased := func(t *testing.T, d testDownloader) {
+		downloadRequestJson, err := d.repository.ClaimDownloadRequest(testEmail)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"media_item_id":"item-1"}`, string(downloadRequestJson))
+
+		retries, err := d.repository.GetRetries(testEmail)
+		assert.NoError(t, err)
+		assert.Empty(t, retries)
+	}
+
+	t.Run("download limit reached", func(t *testing.T) {
+		d := newDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		done, err := d.downloadNext(context.Background(), d.fakeMediaReader, testEmail)
+
+		assert.NoError(t, err)
+		assert.True(t, done)
+		assertReleased(t, d)
+
+		require.Equal(t, 1, d.fakeAccountLimiter.SetLimitReachedCallCount())
+		email, limitType, limitReached := d.fakeAccountLimiter.SetLimitReachedArgsForCall(0)
+		assert.Equal(t, testEmail, email)
+		assert.Equal(t, account.DownloadLimitType, limitType)
+		assert.True(t, limitReached)
+	})
+
+	t.Run("shutdown during the download", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		d := newDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("first part"))
+			w.(http.Flusher).Flush()
+
+			cancel()
+			<-r.Context().Done()
+		})
+
+		done, err := d.downloadNext(ctx, d.fakeMediaReader, testEmail)
+
+		assert.NoError(t, err)
+		assert.True(t, done)
+		assertReleased(t, d)
+		assert.Equal(t, 0, d.fakeAccountLimiter.SetLimitReachedCallCount())
+	})
+
+	t.Run("failed download", func(t *testing.T) {
+		d := newDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		done, err := d.downloadNext(context.Background(), d.fakeMediaReader, testEmail)
+
+		assert.NoError(t, err)
+		assert.False(t, done)
+
+		downloadRequestJson, err := d.repository.ClaimDownloadRequest(testEmail)
+		assert.NoError(t, err)
+		assert.Nil(t, downloadRequestJson)
+
+		retry, err := d.repository.GetRetry(testEmail, "photos/item-1")
+		assert.NoError(t, err)
+		assert.NotNil(t, retry)
+	})
+}
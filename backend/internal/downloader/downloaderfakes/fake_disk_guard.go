@@ -0,0 +1,261 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package downloaderfakes
+
+import (
+	"google-backup/internal/downloader"
+	"sync"
+)
+
+type FakeDiskGuard struct {
+	CheckStub        func(string, int64) (bool, error)
+	checkMutex       sync.RWMutex
+	checkArgsForCall []struct {
+		arg1 string
+		arg2 int64
+	}
+	checkReturns struct {
+		result1 bool
+		result2 error
+	}
+	checkReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	GetPauseStub        func() (*downloader.Pause, error)
+	getPauseMutex       sync.RWMutex
+	getPauseArgsForCall []struct {
+	}
+	getPauseReturns struct {
+		result1 *downloader.Pause
+		result2 error
+	}
+	getPauseReturnsOnCall map[int]struct {
+		result1 *downloader.Pause
+		result2 error
+	}
+	PauseNoSpaceLeftStub        func(string) error
+	pauseNoSpaceLeftMutex       sync.RWMutex
+	pauseNoSpaceLeftArgsForCall []struct {
+		arg1 string
+	}
+	pauseNoSpaceLeftReturns struct {
+		result1 error
+	}
+	pauseNoSpaceLeftReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDiskGuard) Check(arg1 string, arg2 int64) (bool, error) {
+	fake.checkMutex.Lock()
+	ret, specificReturn := fake.checkReturnsOnCall[len(fake.checkArgsForCall)]
+	fake.checkArgsForCall = append(fake.checkArgsForCall, struct {
+		arg1 string
+		arg2 int64
+	}{arg1, arg2})
+	stub := fake.CheckStub
+	fakeReturns := fake.checkReturns
+	fake.recordInvocation("Check", []interface{}{arg1, arg2})
+	fake.checkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDiskGuard) CheckCallCount() int {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	return len(fake.checkArgsForCall)
+}
+
+func (fake *FakeDiskGuard) CheckCalls(stub func(string, int64) (bool, error)) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = stub
+}
+
+func (fake *FakeDiskGuard) CheckArgsForCall(i int) (string, int64) {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	argsForCall := fake.checkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDiskGuard) CheckReturns(result1 bool, result2 error) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	fake.checkReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskGuard) CheckReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	if fake.checkReturnsOnCall == nil {
+		fake.checkReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.checkReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskGuard) GetPause() (*downloader.Pause, error) {
+	fake.getPauseMutex.Lock()
+	ret, specificReturn := fake.getPauseReturnsOnCall[len(fake.getPauseArgsForCall)]
+	fake.getPauseArgsForCall = append(fake.getPauseArgsForCall, struct {
+	}{})
+	stub := fake.GetPauseStub
+	fakeReturns := fake.getPauseReturns
+	fake.recordInvocation("GetPause", []interface{}{})
+	fake.getPauseMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDiskGuard) GetPauseCallCount() int {
+	fake.getPauseMutex.RLock()
+	defer fake.getPauseMutex.RUnlock()
+	return len(fake.getPauseArgsForCall)
+}
+
+func (fake *FakeDiskGuard) GetPauseCalls(stub func() (*downloader.Pause, error)) {
+	fake.getPauseMutex.Lock()
+	defer fake.getPauseMutex.Unlock()
+	fake.GetPauseStub = stub
+}
+
+func (fake *FakeDiskGuard) GetPauseReturns(result1 *downloader.Pause, result2 error) {
+	fake.getPauseMutex.Lock()
+	defer fake.getPauseMutex.Unlock()
+	fake.GetPauseStub = nil
+	fake.getPauseReturns = struct {
+		result1 *downloader.Pause
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskGuard) GetPauseReturnsOnCall(i int, result1 *downloader.Pause, result2 error) {
+	fake.getPauseMutex.Lock()
+	defer fake.getPauseMutex.Unlock()
+	fake.GetPauseStub = nil
+	if fake.getPauseReturnsOnCall == nil {
+		fake.getPauseReturnsOnCall = make(map[int]struct {
+			result1 *downloader.Pause
+			result2 error
+		})
+	}
+	fake.getPauseReturnsOnCall[i] = struct {
+		result1 *downloader.Pause
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskGuard) PauseNoSpaceLeft(arg1 string) error {
+	fake.pauseNoSpaceLeftMutex.Lock()
+	ret, specificReturn := fake.pauseNoSpaceLeftReturnsOnCall[len(fake.pauseNoSpaceLeftArgsForCall)]
+	fake.pauseNoSpaceLeftArgsForCall = append(fake.pauseNoSpaceLeftArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.PauseNoSpaceLeftStub
+	fakeReturns := fake.pauseNoSpaceLeftReturns
+	fake.recordInvocation("PauseNoSpaceLeft", []interface{}{arg1})
+	fake.pauseNoSpaceLeftMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDiskGuard) PauseNoSpaceLeftCallCount() int {
+	fake.pauseNoSpaceLeftMutex.RLock()
+	defer fake.pauseNoSpaceLeftMutex.RUnlock()
+	return len(fake.pauseNoSpaceLeftArgsForCall)
+}
+
+func (fake *FakeDiskGuard) PauseNoSpaceLeftCalls(stub func(string) error) {
+	fake.pauseNoSpaceLeftMutex.Lock()
+	defer fake.pauseNoSpaceLeftMutex.Unlock()
+	fake.PauseNoSpaceLeftStub = stub
+}
+
+func (fake *FakeDiskGuard) PauseNoSpaceLeftArgsForCall(i int) string {
+	fake.pauseNoSpaceLeftMutex.RLock()
+	defer fake.pauseNoSpaceLeftMutex.RUnlock()
+	argsForCall := fake.pauseNoSpaceLeftArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDiskGuard) PauseNoSpaceLeftReturns(result1 error) {
+	fake.pauseNoSpaceLeftMutex.Lock()
+	defer fake.pauseNoSpaceLeftMutex.Unlock()
+	fake.PauseNoSpaceLeftStub = nil
+	fake.pauseNoSpaceLeftReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDiskGuard) PauseNoSpaceLeftReturnsOnCall(i int, result1 error) {
+	fake.pauseNoSpaceLeftMutex.Lock()
+	defer fake.pauseNoSpaceLeftMutex.Unlock()
+	fake.PauseNoSpaceLeftStub = nil
+	if fake.pauseNoSpaceLeftReturnsOnCall == nil {
+		fake.pauseNoSpaceLeftReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pauseNoSpaceLeftReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDiskGuard) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	fake.getPauseMutex.RLock()
+	defer fake.getPauseMutex.RUnlock()
+	fake.pauseNoSpaceLeftMutex.RLock()
+	defer fake.pauseNoSpaceLeftMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDiskGuard) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ downloader.DiskGuard = new(FakeDiskGuard)
@@ -0,0 +1,432 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package downloaderfakes
+
+import (
+	"google-backup/internal/downloader"
+	"sync"
+)
+
+type FakeRetryQueue struct {
+	FailStub        func(string, string, string, error) error
+	failMutex       sync.RWMutex
+	failArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 error
+	}
+	failReturns struct {
+		result1 error
+	}
+	failReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetDeadLettersStub        func(string) ([]downloader.FailedDownload, error)
+	getDeadLettersMutex       sync.RWMutex
+	getDeadLettersArgsForCall []struct {
+		arg1 string
+	}
+	getDeadLettersReturns struct {
+		result1 []downloader.FailedDownload
+		result2 error
+	}
+	getDeadLettersReturnsOnCall map[int]struct {
+		result1 []downloader.FailedDownload
+		result2 error
+	}
+	RequeueStub        func(string, string, string) (bool, error)
+	requeueMutex       sync.RWMutex
+	requeueArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	requeueReturns struct {
+		result1 bool
+		result2 error
+	}
+	requeueReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ScheduleDueStub        func(string, string) error
+	scheduleDueMutex       sync.RWMutex
+	scheduleDueArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	scheduleDueReturns struct {
+		result1 error
+	}
+	scheduleDueReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SucceedStub        func(string, string, string) error
+	succeedMutex       sync.RWMutex
+	succeedArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	succeedReturns struct {
+		result1 error
+	}
+	succeedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRetryQueue) Fail(arg1 string, arg2 string, arg3 string, arg4 error) error {
+	fake.failMutex.Lock()
+	ret, specificReturn := fake.failReturnsOnCall[len(fake.failArgsForCall)]
+	fake.failArgsForCall = append(fake.failArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 error
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.FailStub
+	fakeReturns := fake.failReturns
+	fake.recordInvocation("Fail", []interface{}{arg1, arg2, arg3, arg4})
+	fake.failMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRetryQueue) FailCallCount() int {
+	fake.failMutex.RLock()
+	defer fake.failMutex.RUnlock()
+	return len(fake.failArgsForCall)
+}
+
+func (fake *FakeRetryQueue) FailCalls(stub func(string, string, string, error) error) {
+	fake.failMutex.Lock()
+	defer fake.failMutex.Unlock()
+	fake.FailStub = stub
+}
+
+func (fake *FakeRetryQueue) FailArgsForCall(i int) (string, string, string, error) {
+	fake.failMutex.RLock()
+	defer fake.failMutex.RUnlock()
+	argsForCall := fake.failArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeRetryQueue) FailReturns(result1 error) {
+	fake.failMutex.Lock()
+	defer fake.failMutex.Unlock()
+	fake.FailStub = nil
+	fake.failReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRetryQueue) FailReturnsOnCall(i int, result1 error) {
+	fake.failMutex.Lock()
+	defer fake.failMutex.Unlock()
+	fake.FailStub = nil
+	if fake.failReturnsOnCall == nil {
+		fake.failReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.failReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRetryQueue) GetDeadLetters(arg1 string) ([]downloader.FailedDownload, error) {
+	fake.getDeadLettersMutex.Lock()
+	ret, specificReturn := fake.getDeadLettersReturnsOnCall[len(fake.getDeadLettersArgsForCall)]
+	fake.getDeadLettersArgsForCall = append(fake.getDeadLettersArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetDeadLettersStub
+	fakeReturns := fake.getDeadLettersReturns
+	fake.recordInvocation("GetDeadLetters", []interface{}{arg1})
+	fake.getDeadLettersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRetryQueue) GetDeadLettersCallCount() int {
+	fake.getDeadLettersMutex.RLock()
+	defer fake.getDeadLettersMutex.RUnlock()
+	return len(fake.getDeadLettersArgsForCall)
+}
+
+func (fake *FakeRetryQueue) GetDeadLettersCalls(stub func(string) ([]downloader.FailedDownload, error)) {
+	fake.getDeadLettersMutex.Lock()
+	defer fake.getDeadLettersMutex.Unlock()
+	fake.GetDeadLettersStub = stub
+}
+
+func (fake *FakeRetryQueue) GetDeadLettersArgsForCall(i int) string {
+	fake.getDeadLettersMutex.RLock()
+	defer fake.getDeadLettersMutex.RUnlock()
+	argsForCall := fake.getDeadLettersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRetryQueue) GetDeadLettersReturns(result1 []downloader.FailedDownload, result2 error) {
+	fake.getDeadLettersMutex.Lock()
+	defer fake.getDeadLettersMutex.Unlock()
+	fake.GetDeadLettersStub = nil
+	fake.getDeadLettersReturns = struct {
+		result1 []downloader.FailedDownload
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRetryQueue) GetDeadLettersReturnsOnCall(i int, result1 []downloader.FailedDownload, result2 error) {
+	fake.getDeadLettersMutex.Lock()
+	defer fake.getDeadLettersMutex.Unlock()
+	fake.GetDeadLettersStub = nil
+	if fake.getDeadLettersReturnsOnCall == nil {
+		fake.getDeadLettersReturnsOnCall = make(map[int]struct {
+			result1 []downloader.FailedDownload
+			result2 error
+		})
+	}
+	fake.getDeadLettersReturnsOnCall[i] = struct {
+		result1 []downloader.FailedDownload
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRetryQueue) Requeue(arg1 string, arg2 string, arg3 string) (bool, error) {
+	fake.requeueMutex.Lock()
+	ret, specificReturn := fake.requeueReturnsOnCall[len(fake.requeueArgsForCall)]
+	fake.requeueArgsForCall = append(fake.requeueArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.RequeueStub
+	fakeReturns := fake.requeueReturns
+	fake.recordInvocation("Requeue", []interface{}{arg1, arg2, arg3})
+	fake.requeueMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRetryQueue) RequeueCallCount() int {
+	fake.requeueMutex.RLock()
+	defer fake.requeueMutex.RUnlock()
+	return len(fake.requeueArgsForCall)
+}
+
+func (fake *FakeRetryQueue) RequeueCalls(stub func(string, string, string) (bool, error)) {
+	fake.requeueMutex.Lock()
+	defer fake.requeueMutex.Unlock()
+	fake.RequeueStub = stub
+}
+
+func (fake *FakeRetryQueue) RequeueArgsForCall(i int) (string, string, string) {
+	fake.requeueMutex.RLock()
+	defer fake.requeueMutex.RUnlock()
+	argsForCall := fake.requeueArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRetryQueue) RequeueReturns(result1 bool, result2 error) {
+	fake.requeueMutex.Lock()
+	defer fake.requeueMutex.Unlock()
+	fake.RequeueStub = nil
+	fake.requeueReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRetryQueue) RequeueReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.requeueMutex.Lock()
+	defer fake.requeueMutex.Unlock()
+	fake.RequeueStub = nil
+	if fake.requeueReturnsOnCall == nil {
+		fake.requeueReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.requeueReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRetryQueue) ScheduleDue(arg1 string, arg2 string) error {
+	fake.scheduleDueMutex.Lock()
+	ret, specificReturn := fake.scheduleDueReturnsOnCall[len(fake.scheduleDueArgsForCall)]
+	fake.scheduleDueArgsForCall = append(fake.scheduleDueArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ScheduleDueStub
+	fakeReturns := fake.scheduleDueReturns
+	fake.recordInvocation("ScheduleDue", []interface{}{arg1, arg2})
+	fake.scheduleDueMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRetryQueue) ScheduleDueCallCount() int {
+	fake.scheduleDueMutex.RLock()
+	defer fake.scheduleDueMutex.RUnlock()
+	return len(fake.scheduleDueArgsForCall)
+}
+
+func (fake *FakeRetryQueue) ScheduleDueCalls(stub func(string, string) error) {
+	fake.scheduleDueMutex.Lock()
+	defer fake.scheduleDueMutex.Unlock()
+	fake.ScheduleDueStub = stub
+}
+
+func (fake *FakeRetryQueue) ScheduleDueArgsForCall(i int) (string, string) {
+	fake.scheduleDueMutex.RLock()
+	defer fake.scheduleDueMutex.RUnlock()
+	argsForCall := fake.scheduleDueArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRetryQueue) ScheduleDueReturns(result1 error) {
+	fake.scheduleDueMutex.Lock()
+	defer fake.scheduleDueMutex.Unlock()
+	fake.ScheduleDueStub = nil
+	fake.scheduleDueReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRetryQueue) ScheduleDueReturnsOnCall(i int, result1 error) {
+	fake.scheduleDueMutex.Lock()
+	defer fake.scheduleDueMutex.Unlock()
+	fake.ScheduleDueStub = nil
+	if fake.scheduleDueReturnsOnCall == nil {
+		fake.scheduleDueReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.scheduleDueReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRetryQueue) Succeed(arg1 string, arg2 string, arg3 string) error {
+	fake.succeedMutex.Lock()
+	ret, specificReturn := fake.succeedReturnsOnCall[len(fake.succeedArgsForCall)]
+	fake.succeedArgsForCall = append(fake.succeedArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.SucceedStub
+	fakeReturns := fake.succeedReturns
+	fake.recordInvocation("Succeed", []interface{}{arg1, arg2, arg3})
+	fake.succeedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRetryQueue) SucceedCallCount() int {
+	fake.succeedMutex.RLock()
+	defer fake.succeedMutex.RUnlock()
+	return len(fake.succeedArgsForCall)
+}
+
+func (fake *FakeRetryQueue) SucceedCalls(stub func(string, string, string) error) {
+	fake.succeedMutex.Lock()
+	defer fake.succeedMutex.Unlock()
+	fake.SucceedStub = stub
+}
+
+func (fake *FakeRetryQueue) SucceedArgsForCall(i int) (string, string, string) {
+	fake.succeedMutex.RLock()
+	defer fake.succeedMutex.RUnlock()
+	argsForCall := fake.succeedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRetryQueue) SucceedReturns(result1 error) {
+	fake.succeedMutex.Lock()
+	defer fake.succeedMutex.Unlock()
+	fake.SucceedStub = nil
+	fake.succeedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRetryQueue) SucceedReturnsOnCall(i int, result1 error) {
+	fake.succeedMutex.Lock()
+	defer fake.succeedMutex.Unlock()
+	fake.SucceedStub = nil
+	if fake.succeedReturnsOnCall == nil {
+		fake.succeedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.succeedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRetryQueue) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.failMutex.RLock()
+	defer fake.failMutex.RUnlock()
+	fake.getDeadLettersMutex.RLock()
+	defer fake.getDeadLettersMutex.RUnlock()
+	fake.requeueMutex.RLock()
+	defer fake.requeueMutex.RUnlock()
+	fake.scheduleDueMutex.RLock()
+	defer fake.scheduleDueMutex.RUnlock()
+	fake.succeedMutex.RLock()
+	defer fake.succeedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRetryQueue) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ downloader.RetryQueue = new(FakeRetryQueue)
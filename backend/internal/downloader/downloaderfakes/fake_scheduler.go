@@ -0,0 +1,188 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package downloaderfakes
+
+import (
+	"google-backup/internal/downloader"
+	"sync"
+)
+
+type FakeScheduler struct {
+	ScheduleDownloadStub        func(string, string) error
+	scheduleDownloadMutex       sync.RWMutex
+	scheduleDownloadArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	scheduleDownloadReturns struct {
+		result1 error
+	}
+	scheduleDownloadReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ScheduleDriveDownloadStub        func(string, string) error
+	scheduleDriveDownloadMutex       sync.RWMutex
+	scheduleDriveDownloadArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	scheduleDriveDownloadReturns struct {
+		result1 error
+	}
+	scheduleDriveDownloadReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeScheduler) ScheduleDownload(arg1 string, arg2 string) error {
+	fake.scheduleDownloadMutex.Lock()
+	ret, specificReturn := fake.scheduleDownloadReturnsOnCall[len(fake.scheduleDownloadArgsForCall)]
+	fake.scheduleDownloadArgsForCall = append(fake.scheduleDownloadArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ScheduleDownloadStub
+	fakeReturns := fake.scheduleDownloadReturns
+	fake.recordInvocation("ScheduleDownload", []interface{}{arg1, arg2})
+	fake.scheduleDownloadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeScheduler) ScheduleDownloadCallCount() int {
+	fake.scheduleDownloadMutex.RLock()
+	defer fake.scheduleDownloadMutex.RUnlock()
+	return len(fake.scheduleDownloadArgsForCall)
+}
+
+func (fake *FakeScheduler) ScheduleDownloadCalls(stub func(string, string) error) {
+	fake.scheduleDownloadMutex.Lock()
+	defer fake.scheduleDownloadMutex.Unlock()
+	fake.ScheduleDownloadStub = stub
+}
+
+func (fake *FakeScheduler) ScheduleDownloadArgsForCall(i int) (string, string) {
+	fake.scheduleDownloadMutex.RLock()
+	defer fake.scheduleDownloadMutex.RUnlock()
+	argsForCall := fake.scheduleDownloadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeScheduler) ScheduleDownloadReturns(result1 error) {
+	fake.scheduleDownloadMutex.Lock()
+	defer fake.scheduleDownloadMutex.Unlock()
+	fake.ScheduleDownloadStub = nil
+	fake.scheduleDownloadReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduler) ScheduleDownloadReturnsOnCall(i int, result1 error) {
+	fake.scheduleDownloadMutex.Lock()
+	defer fake.scheduleDownloadMutex.Unlock()
+	fake.ScheduleDownloadStub = nil
+	if fake.scheduleDownloadReturnsOnCall == nil {
+		fake.scheduleDownloadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.scheduleDownloadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduler) ScheduleDriveDownload(arg1 string, arg2 string) error {
+	fake.scheduleDriveDownloadMutex.Lock()
+	ret, specificReturn := fake.scheduleDriveDownloadReturnsOnCall[len(fake.scheduleDriveDownloadArgsForCall)]
+	fake.scheduleDriveDownloadArgsForCall = append(fake.scheduleDriveDownloadArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ScheduleDriveDownloadStub
+	fakeReturns := fake.scheduleDriveDownloadReturns
+	fake.recordInvocation("ScheduleDriveDownload", []interface{}{arg1, arg2})
+	fake.scheduleDriveDownloadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeScheduler) ScheduleDriveDownloadCallCount() int {
+	fake.scheduleDriveDownloadMutex.RLock()
+	defer fake.scheduleDriveDownloadMutex.RUnlock()
+	return len(fake.scheduleDriveDownloadArgsForCall)
+}
+
+func (fake *FakeScheduler) ScheduleDriveDownloadCalls(stub func(string, string) error) {
+	fake.scheduleDriveDownloadMutex.Lock()
+	defer fake.scheduleDriveDownloadMutex.Unlock()
+	fake.ScheduleDriveDownloadStub = stub
+}
+
+func (fake *FakeScheduler) ScheduleDriveDownloadArgsForCall(i int) (string, string) {
+	fake.scheduleDriveDownloadMutex.RLock()
+	defer fake.scheduleDriveDownloadMutex.RUnlock()
+	argsForCall := fake.scheduleDriveDownloadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeScheduler) ScheduleDriveDownloadReturns(result1 error) {
+	fake.scheduleDriveDownloadMutex.Lock()
+	defer fake.scheduleDriveDownloadMutex.Unlock()
+	fake.ScheduleDriveDownloadStub = nil
+	fake.scheduleDriveDownloadReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduler) ScheduleDriveDownloadReturnsOnCall(i int, result1 error) {
+	fake.scheduleDriveDownloadMutex.Lock()
+	defer fake.scheduleDriveDownloadMutex.Unlock()
+	fake.ScheduleDriveDownloadStub = nil
+	if fake.scheduleDriveDownloadReturnsOnCall == nil {
+		fake.scheduleDriveDownloadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.scheduleDriveDownloadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeScheduler) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.scheduleDownloadMutex.RLock()
+	defer fake.scheduleDownloadMutex.RUnlock()
+	fake.scheduleDriveDownloadMutex.RLock()
+	defer fake.scheduleDriveDownloadMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeScheduler) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ downloader.Scheduler = new(FakeScheduler)
@@ -0,0 +1,255 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google-backup/internal/account"
+	"google-backup/internal/drive"
+	"google-backup/internal/files"
+	"google-backup/internal/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Drive files are downloaded by a single worker because folder moves depend on the order of changes
+func (d downloader) downloadDrive(ctx context.Context, driveReader drive.Reader, email string, pool workerPool) error {
+	err := d.retryQueue.ScheduleDue(email, DownloadTypeDrive)
+	if err != nil {
+		return fmt.Errorf("schedule due retries: %w", err)
+	}
+
+	defer d.bandwidth.LogThroughput(email)
+
+	for counter := 0; counter < pool.batchSize; counter++ {
+		if !pool.acquire(ctx) {
+			return nil
+		}
+
+		fileId, err := d.downloadDriveFile(ctx, driveReader, email)
+
+		pool.release()
+
+		if err != nil {
+			if errors.As(err, &drive.TooManyRequestsError{}) {
+				d.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, true)
+				log.WithFields(log.Fields{"email": email, "error": err}).Warn("drive api request limit reached")
+
+				return nil
+			}
+
+			// interrupted by the shutdown, the request stays in the queue for the next run
+			if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+				return nil
+			}
+
+			if fileId == "" {
+				return fmt.Errorf("download drive file: %w", err)
+			}
+
+			// the request stays in the queue for the next run
+			if isNoSpaceLeftError(err) {
+				pauseErr := d.pauseNoSpaceLeft()
+				if pauseErr != nil {
+					return fmt.Errorf("pause downloads: %w", pauseErr)
+				}
+
+				return fmt.Errorf("download drive file: %w", err)
+			}
+
+			err = d.retryQueue.Fail(email, DownloadTypeDrive, fileId, err)
+			if err != nil {
+				return fmt.Errorf("fail drive download: %w", err)
+			}
+		} else if fileId != "" {
+			err = d.retryQueue.Succeed(email, DownloadTypeDrive, fileId)
+			if err != nil {
+				return fmt.Errorf("succeed drive download: %w", err)
+			}
+		}
+
+		// nothing to download
+		if fileId == "" {
+			return nil
+		}
+
+		err = d.repository.DeleteDriveDownloadRequest(email, fileId)
+		if err != nil {
+			return fmt.Errorf("delete drive download request: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Returns id of the processed file or an empty string if there is nothing to download
+func (d downloader) downloadDriveFile(ctx context.Context, driveReader drive.Reader, email string) (string, error) {
+	downloadRequestJson, err := d.repository.GetDriveDownloadRequest(email)
+	if err != nil {
+		return "", fmt.Errorf("get drive download request: %w", err)
+	}
+
+	if downloadRequestJson == nil {
+		return "", nil
+	}
+
+	var downloadRequest DriveDownloadRequest
+	err = json.Unmarshal(downloadRequestJson, &downloadRequest)
+	if err != nil {
+		return "", fmt.Errorf("unmarshal drive download request: %w", err)
+	}
+
+	if downloadRequest.FileId == "" {
+		return "", nil
+	}
+
+	limitReached, err := d.accountLimiter.LimitReached(email, account.ApiRequestLimitType)
+	if err != nil {
+		return "", fmt.Errorf("limit reached: %w", err)
+	}
+
+	if limitReached {
+		return "", nil
+	}
+
+	file, err := driveReader.GetFile(downloadRequest.FileId)
+	if err != nil {
+		// the file was deleted permanently or the account lost access to it
+		if errors.As(err, &drive.NotFoundError{}) {
+			return downloadRequest.FileId, d.filesManager.MarkDriveFileRemoved(email, downloadRequest.FileId, nil)
+		}
+
+		return downloadRequest.FileId, fmt.Errorf("get file: %w", err)
+	}
+
+	if file.Trashed {
+		return file.ID, d.filesManager.MarkDriveFileRemoved(email, file.ID, &file)
+	}
+
+	if file.IsFolder() {
+		return file.ID, d.updateDriveFolder(driveReader, email, file)
+	}
+
+	if !file.IsDownloadable() {
+		return file.ID, nil
+	}
+
+	folderPath, err := d.driveTree.GetFolderPath(email, driveReader, file)
+	if err != nil {
+		return file.ID, fmt.Errorf("get folder path: %w", err)
+	}
+
+	filePathName, err := d.filesManager.GenerateDriveFilePathName(email, folderPath, file)
+	if err != nil {
+		return file.ID, fmt.Errorf("generate drive file path name: %w", err)
+	}
+
+	fileExists, err := d.filesManager.DriveFileExists(email, filePathName, file)
+	if err != nil {
+		return file.ID, fmt.Errorf("drive file exists: %w", err)
+	}
+
+	if fileExists {
+		return file.ID, nil
+	}
+
+	fileMeta, found, err := d.filesManager.GetDriveFileMeta(email, file.ID)
+	if err != nil {
+		return file.ID, fmt.Errorf("get drive file meta: %w", err)
+	}
+
+	previousFilePathName := ""
+
+	if found && fileMeta.FilePathName != filePathName {
+		// renamed or moved without content changes
+		if d.driveFileContentEqual(fileMeta.File, file) {
+			err = d.filesManager.MoveDriveFile(email, fileMeta, file, filePathName)
+			if err != nil {
+				return file.ID, fmt.Errorf("move drive file: %w", err)
+			}
+
+			return file.ID, nil
+		}
+
+		previousFilePathName = fileMeta.FilePathName
+	}
+
+	content, err := driveReader.DownloadFile(file)
+	if err != nil {
+		return file.ID, fmt.Errorf("download file: %w", err)
+	}
+
+	defer content.Close()
+
+	err = d.saveFile(ctx, email, filePathName, content, map[string]string{storage.MetadataDriveFileId: file.ID})
+	if err != nil {
+		return file.ID, fmt.Errorf("save file: %w", err)
+	}
+
+	err = d.filesManager.UpdateCreationTime(filePathName, file.ModifiedTime)
+	if err != nil {
+		return file.ID, fmt.Errorf("update creation time: %w", err)
+	}
+
+	encryptionScheme, err := storage.EncryptionScheme(d.storage, filePathName)
+	if err != nil {
+		return file.ID, fmt.Errorf("encryption scheme: %w", err)
+	}
+
+	err = d.filesManager.SaveDriveFileMeta(email, files.DriveFileMeta{
+		FilePathName: filePathName,
+		File:         file,
+		Encryption:   encryptionScheme,
+	})
+	if err != nil {
+		return file.ID, fmt.Errorf("save drive file meta: %w", err)
+	}
+
+	if previousFilePathName != "" {
+		err = d.filesManager.RemoveFile(previousFilePathName)
+		if err != nil {
+			return file.ID, fmt.Errorf("remove previous file: %w", err)
+		}
+	}
+
+	return file.ID, nil
+}
+
+// Saves the folder and moves the local folder if the folder was renamed or moved
+func (d downloader) updateDriveFolder(driveReader drive.Reader, email string, folder drive.File) error {
+	previousFolderPath, err := d.driveTree.GetFolderPath(email, driveReader, drive.File{Parents: []string{folder.ID}})
+	if err != nil {
+		return fmt.Errorf("get previous folder path: %w", err)
+	}
+
+	err = d.driveTree.SaveFolder(email, folder)
+	if err != nil {
+		return fmt.Errorf("save folder: %w", err)
+	}
+
+	folderPath, err := d.driveTree.GetFolderPath(email, driveReader, drive.File{Parents: []string{folder.ID}})
+	if err != nil {
+		return fmt.Errorf("get folder path: %w", err)
+	}
+
+	if previousFolderPath == folderPath {
+		return nil
+	}
+
+	return d.filesManager.MoveDriveFolder(
+		email,
+		d.filesManager.GenerateDriveFolderPathName(email, previousFolderPath),
+		d.filesManager.GenerateDriveFolderPathName(email, folderPath),
+	)
+}
+
+// Google Docs files have no checksum, their modification time is compared instead
+func (d downloader) driveFileContentEqual(previous drive.File, current drive.File) bool {
+	if previous.Md5Checksum != "" || current.Md5Checksum != "" {
+		return previous.Md5Checksum == current.Md5Checksum
+	}
+
+	return previous.ModifiedTime == current.ModifiedTime
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package downloader
+
+func freeSpace(path string) (uint64, error) {
+	return 0, errFreeSpaceUnsupported
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin
+
+package downloader
+
+import "syscall"
+
+// Bytes available to unprivileged users on the volume of the path
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,159 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"google-backup/internal/media"
+)
+
+// Base urls expire after about 60 minutes, cached items are dropped a bit earlier
+const mediaItemsCacheTtl = 50 * time.Minute
+
+// Keeps media items resolved in batches until their download starts
+type mediaItemsCache struct {
+	mutex      sync.Mutex
+	items      map[string]cachedMediaItem
+	fetchLocks map[string]*sync.Mutex
+}
+
+type cachedMediaItem struct {
+	mediaItem media.MediaItem
+	fetched   time.Time
+}
+
+func newMediaItemsCache() *mediaItemsCache {
+	return &mediaItemsCache{
+		items:      make(map[string]cachedMediaItem),
+		fetchLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+func (c *mediaItemsCache) get(email string, mediaItemId string, now time.Time) (media.MediaItem, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, ok := c.items[mediaItemsCacheKey(email, mediaItemId)]
+	if !ok || now.Sub(item.fetched) >= mediaItemsCacheTtl {
+		return media.MediaItem{}, false
+	}
+
+	return item.mediaItem, true
+}
+
+func (c *mediaItemsCache) set(email string, mediaItem media.MediaItem, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// items which were never downloaded would stay forever otherwise
+	for key, item := range c.items {
+		if now.Sub(item.fetched) >= mediaItemsCacheTtl {
+			delete(c.items, key)
+		}
+	}
+
+	c.items[mediaItemsCacheKey(email, mediaItem.ID)] = cachedMediaItem{mediaItem: mediaItem, fetched: now}
+}
+
+func (c *mediaItemsCache) delete(email string, mediaItemId string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.items, mediaItemsCacheKey(email, mediaItemId))
+}
+
+// Workers of the same account wait for each other's batch instead of fetching the same items
+func (c *mediaItemsCache) fetchLock(email string) *sync.Mutex {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	lock, ok := c.fetchLocks[email]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.fetchLocks[email] = lock
+	}
+
+	return lock
+}
+
+func mediaItemsCacheKey(email string, mediaItemId string) string {
+	return email + "/" + mediaItemId
+}
+
+// Returns the cached media item or resolves it together with the next queued items in one request
+func (d downloader) getMediaItem(mediaReader media.Reader, email string, mediaItemId string) (media.MediaItem, error) {
+	mediaItem, ok := d.mediaItems.get(email, mediaItemId, time.Now())
+	if ok {
+		return mediaItem, nil
+	}
+
+	lock := d.mediaItems.fetchLock(email)
+	lock.Lock()
+	defer lock.Unlock()
+
+	mediaItem, ok = d.mediaItems.get(email, mediaItemId, time.Now())
+	if ok {
+		return mediaItem, nil
+	}
+
+	mediaItemIds, err := d.nextMediaItemIds(email, mediaItemId)
+	if err != nil {
+		return media.MediaItem{}, fmt.Errorf("next media item ids: %w", err)
+	}
+
+	mediaItems, err := mediaReader.BatchGetMediaItems(mediaItemIds)
+	if err != nil {
+		return media.MediaItem{}, fmt.Errorf("batch get media items: %w", err)
+	}
+
+	now := time.Now()
+	for _, item := range mediaItems {
+		d.mediaItems.set(email, item, now)
+	}
+
+	mediaItem, ok = mediaItems[mediaItemId]
+	if ok {
+		return mediaItem, nil
+	}
+
+	// the single get returns the reason why the item couldn't be resolved
+	return mediaReader.GetMediaItem(mediaItemId)
+}
+
+// Returns the id followed by the queued ids which aren't cached yet
+func (d downloader) nextMediaItemIds(email string, mediaItemId string) ([]string, error) {
+	mediaItemIds := []string{mediaItemId}
+
+	downloadRequestsJson, err := d.repository.PeekDownloadRequests(email, media.BatchGetMaxMediaItems*2)
+	if err != nil {
+		return nil, fmt.Errorf("peek download requests: %w", err)
+	}
+
+	now := time.Now()
+
+	for _, downloadRequestJson := range downloadRequestsJson {
+		if len(mediaItemIds) == media.BatchGetMaxMediaItems {
+			break
+		}
+
+		var downloadRequest DownloadRequest
+		err = json.Unmarshal(downloadRequestJson, &downloadRequest)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal download request: %w", err)
+		}
+
+		if downloadRequest.MediaItemId == "" || downloadRequest.MediaItemId == mediaItemId {
+			continue
+		}
+
+		if _, ok := d.mediaItems.get(email, downloadRequest.MediaItemId, now); ok {
+			continue
+		}
+
+		mediaItemIds = append(mediaItemIds, downloadRequest.MediaItemId)
+	}
+
+	return mediaItemIds, nil
+}
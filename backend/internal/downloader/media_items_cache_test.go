@@ -0,0 +1,210 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google-backup/internal/media"
+	"google-backup/internal/media/mediafakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaItemsCache(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("item before and after the expiry", func(t *testing.T) {
+		cache := newMediaItemsCache()
+		cache.set(testEmail, media.MediaItem{ID: "item-1", BaseUrl: "https://example.com/1"}, now)
+
+		mediaItem, ok := cache.get(testEmail, "item-1", now.Add(mediaItemsCacheTtl-time.Second))
+
+		assert.True(t, ok)
+		assert.Equal(t, "https://example.com/1", mediaItem.BaseUrl)
+
+		_, ok = cache.get(testEmail, "item-1", now.Add(mediaItemsCacheTtl))
+
+		assert.False(t, ok)
+	})
+
+	t.Run("items of other accounts", func(t *testing.T) {
+		cache := newMediaItemsCache()
+		cache.set(testEmail, media.MediaItem{ID: "item-1"}, now)
+
+		_, ok := cache.get("other@gmail.com", "item-1", now)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("expired items are dropped", func(t *testing.T) {
+		cache := newMediaItemsCache()
+		cache.set(testEmail, media.MediaItem{ID: "item-1"}, now)
+		cache.set(testEmail, media.MediaItem{ID: "item-2"}, now.Add(mediaItemsCacheTtl))
+
+		assert.Len(t, cache.items, 1)
+
+		_, ok := cache.get(testEmail, "item-2", now.Add(mediaItemsCacheTtl))
+
+		assert.True(t, ok)
+	})
+}
+
+func TestGetMediaItem(t *testing.T) {
+	newDownloader := func(t *testing.T, mediaItemIds ...string) downloader {
+		repository := newTestRepository(t)
+
+		for _, mediaItemId := range mediaItemIds {
+			require.NoError(t, NewScheduler(repository).ScheduleDownload(testEmail, mediaItemId))
+		}
+
+		return downloader{repository: repository, mediaItems: newMediaItemsCache()}
+	}
+
+	batchGet := func(mediaItemIds []string) (map[string]media.MediaItem, error) {
+		result := make(map[string]media.MediaItem, len(mediaItemIds))
+		for _, mediaItemId := range mediaItemIds {
+			result[mediaItemId] = media.MediaItem{ID: mediaItemId, BaseUrl: "https://example.com/" + mediaItemId}
+		}
+
+		return result, nil
+	}
+
+	t.Run("queued items are resolved in one batch", func(t *testing.T) {
+		d := newDownloader(t, "item-1", "item-2", "item-3")
+
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.BatchGetMediaItemsStub = batchGet
+
+		for _, mediaItemId := range []string{"item-1", "item-2", "item-3"} {
+			mediaItem, err := d.getMediaItem(fakeMediaReader, testEmail, mediaItemId)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.com/"+mediaItemId, mediaItem.BaseUrl)
+		}
+
+		require.Equal(t, 1, fakeMediaReader.BatchGetMediaItemsCallCount())
+		assert.Equal(t, []string{"item-1", "item-2", "item-3"}, fakeMediaReader.BatchGetMediaItemsArgsForCall(0))
+		assert.Equal(t, 0, fakeMediaReader.GetMediaItemCallCount())
+	})
+
+	t.Run("batch is limited to the api maximum", func(t *testing.T) {
+		var mediaItemIds []string
+		for i := 0; i < media.BatchGetMaxMediaItems+10; i++ {
+			mediaItemIds = append(mediaItemIds, fmt.Sprintf("item-%03d", i))
+		}
+
+		d := newDownloader(t, mediaItemIds...)
+
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.BatchGetMediaItemsStub = batchGet
+
+		_, err := d.getMediaItem(fakeMediaReader, testEmail, "item-000")
+
+		assert.NoError(t, err)
+		assert.Len(t, fakeMediaReader.BatchGetMediaItemsArgsForCall(0), media.BatchGetMaxMediaItems)
+	})
+
+	t.Run("cached items aren't fetched again", func(t *testing.T) {
+		d := newDownloader(t, "item-1", "item-2")
+		d.mediaItems.set(testEmail, media.MediaItem{ID: "item-2"}, time.Now())
+
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.BatchGetMediaItemsStub = batchGet
+
+		_, err := d.getMediaItem(fakeMediaReader, testEmail, "item-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"item-1"}, fakeMediaReader.BatchGetMediaItemsArgsForCall(0))
+	})
+
+	t.Run("expired items are fetched again", func(t *testing.T) {
+		d := newDownloader(t, "item-1")
+		d.mediaItems.set(testEmail, media.MediaItem{ID: "item-1", BaseUrl: "https://example.com/expired"}, time.Now().Add(-mediaItemsCacheTtl))
+
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.BatchGetMediaItemsStub = batchGet
+
+		mediaItem, err := d.getMediaItem(fakeMediaReader, testEmail, "item-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/item-1", mediaItem.BaseUrl)
+		assert.Equal(t, 1, fakeMediaReader.BatchGetMediaItemsCallCount())
+	})
+
+	t.Run("item missing from the batch", func(t *testing.T) {
+		d := newDownloader(t, "item-1")
+
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.BatchGetMediaItemsReturns(map[string]media.MediaItem{}, nil)
+		fakeMediaReader.GetMediaItemReturns(media.MediaItem{}, errors.New("not found"))
+
+		_, err := d.getMediaItem(fakeMediaReader, testEmail, "item-1")
+
+		assert.EqualError(t, err, "not found")
+		require.Equal(t, 1, fakeMediaReader.GetMediaItemCallCount())
+		assert.Equal(t, "item-1", fakeMediaReader.GetMediaItemArgsForCall(0))
+	})
+}
+
+func TestWithFreshBaseUrl(t *testing.T) {
+	t.Run("expired base url is refreshed", func(t *testing.T) {
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.GetMediaItemReturns(media.MediaItem{ID: "item-1", BaseUrl: "https://example.com/fresh"}, nil)
+
+		mediaItem := media.MediaItem{ID: "item-1", BaseUrl: "https://example.com/expired"}
+
+		var baseUrls []string
+		err := downloader{}.withFreshBaseUrl(fakeMediaReader, &mediaItem, func(mediaItem media.MediaItem) error {
+			baseUrls = append(baseUrls, mediaItem.BaseUrl)
+
+			if mediaItem.BaseUrl == "https://example.com/expired" {
+				return fmt.Errorf("download file: %w", NotOkRequestError{error: errors.New("forbidden"), StatusCode: http.StatusForbidden})
+			}
+
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/expired", "https://example.com/fresh"}, baseUrls)
+		assert.Equal(t, "https://example.com/fresh", mediaItem.BaseUrl)
+		assert.Equal(t, "item-1", fakeMediaReader.GetMediaItemArgsForCall(0))
+	})
+
+	t.Run("other errors aren't retried", func(t *testing.T) {
+		fakeMediaReader := new(mediafakes.FakeReader)
+
+		mediaItem := media.MediaItem{ID: "item-1"}
+
+		calls := 0
+		err := downloader{}.withFreshBaseUrl(fakeMediaReader, &mediaItem, func(mediaItem media.MediaItem) error {
+			calls++
+
+			return NotOkRequestError{error: errors.New("not found"), StatusCode: http.StatusNotFound}
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 0, fakeMediaReader.GetMediaItemCallCount())
+	})
+
+	t.Run("failed refresh", func(t *testing.T) {
+		fakeMediaReader := new(mediafakes.FakeReader)
+		fakeMediaReader.GetMediaItemReturns(media.MediaItem{}, errors.New("unavailable"))
+
+		mediaItem := media.MediaItem{ID: "item-1"}
+
+		calls := 0
+		err := downloader{}.withFreshBaseUrl(fakeMediaReader, &mediaItem, func(mediaItem media.MediaItem) error {
+			calls++
+
+			return NotOkRequestError{error: errors.New("forbidden"), StatusCode: http.StatusForbidden}
+		})
+
+		assert.EqualError(t, err, "refresh media item: unavailable")
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,61 @@
+package downloader
+
+import (
+	"context"
+
+	"google-backup/internal/settings"
+)
+
+const (
+	defaultWorkersPerAccount = 1
+	defaultBatchSize         = 50
+)
+
+// Limits concurrent downloads per account and, optionally, across all accounts
+type workerPool struct {
+	workersPerAccount int
+	batchSize         int
+	// nil if there is no global cap
+	slots chan struct{}
+}
+
+func newWorkerPool(settingsData settings.SettingsData) workerPool {
+	pool := workerPool{
+		workersPerAccount: defaultWorkersPerAccount,
+		batchSize:         defaultBatchSize,
+	}
+
+	if settingsData.DownloadWorkersPerAccount > 0 {
+		pool.workersPerAccount = settingsData.DownloadWorkersPerAccount
+	}
+
+	if settingsData.DownloadBatchSize > 0 {
+		pool.batchSize = settingsData.DownloadBatchSize
+	}
+
+	if settingsData.DownloadWorkersTotal > 0 {
+		pool.slots = make(chan struct{}, settingsData.DownloadWorkersTotal)
+	}
+
+	return pool
+}
+
+// Blocks until a global slot is free. Returns false if the context is done
+func (p workerPool) acquire(ctx context.Context) bool {
+	if p.slots == nil {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p workerPool) release() {
+	if p.slots != nil {
+		<-p.slots
+	}
+}
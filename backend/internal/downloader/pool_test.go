@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google-backup/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWorkerPool(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		pool := newWorkerPool(settings.SettingsData{})
+
+		assert.Equal(t, defaultWorkersPerAccount, pool.workersPerAccount)
+		assert.Equal(t, defaultBatchSize, pool.batchSize)
+		assert.Nil(t, pool.slots)
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		pool := newWorkerPool(settings.SettingsData{DownloadWorkersPerAccount: 4, DownloadWorkersTotal: 6, DownloadBatchSize: 200})
+
+		assert.Equal(t, 4, pool.workersPerAccount)
+		assert.Equal(t, 200, pool.batchSize)
+		assert.Equal(t, 6, cap(pool.slots))
+	})
+}
+
+func TestWorkerPool(t *testing.T) {
+	t.Run("global slot cap", func(t *testing.T) {
+		pool := newWorkerPool(settings.SettingsData{DownloadWorkersTotal: 2})
+
+		assert.True(t, pool.acquire(context.Background()))
+		assert.True(t, pool.acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		assert.False(t, pool.acquire(ctx))
+
+		pool.release()
+
+		assert.True(t, pool.acquire(context.Background()))
+	})
+
+	t.Run("waiting worker gets the released slot", func(t *testing.T) {
+		pool := newWorkerPool(settings.SettingsData{DownloadWorkersTotal: 1})
+
+		assert.True(t, pool.acquire(context.Background()))
+
+		acquired := make(chan bool)
+		go func() {
+			acquired <- pool.acquire(context.Background())
+		}()
+
+		select {
+		case <-acquired:
+			assert.Fail(t, "slot acquired before it was released")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		pool.release()
+
+		assert.True(t, <-acquired)
+	})
+
+	t.Run("shutdown while waiting for a slot", func(t *testing.T) {
+		pool := newWorkerPool(settings.SettingsData{DownloadWorkersTotal: 1})
+
+		assert.True(t, pool.acquire(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		acquired := make(chan bool)
+		go func() {
+			acquired <- pool.acquire(ctx)
+		}()
+
+		cancel()
+
+		assert.False(t, <-acquired)
+	})
+
+	t.Run("shutdown without global cap", func(t *testing.T) {
+		pool := newWorkerPool(settings.SettingsData{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		assert.True(t, pool.acquire(ctx))
+
+		cancel()
+
+		assert.False(t, pool.acquire(ctx))
+
+		// releasing without a global cap doesn't block
+		pool.release()
+	})
+}
@@ -6,18 +6,48 @@ import (
 	"go.etcd.io/bbolt"
 )
 
-const downloadRequestBucketName = "download_request"
+const (
+	downloadRequestBucketName      = "download_request"
+	claimedDownloadBucketName      = "claimed_download_request"
+	driveDownloadRequestBucketName = "drive_download_request"
+	retryBucketName                = "download_retry"
+	deadLetterBucketName           = "download_dead_letter"
+	// Top level bucket shared by all accounts
+	statusBucketName = "downloader_status"
+	pauseKey         = "pause"
+)
 
 type Repository interface {
 	UpdateDownloadRequest(email string, mediaItemId string, value []byte) error
-	GetDownloadRequest(email string) ([]byte, error)
+	ClaimDownloadRequest(email string) ([]byte, error)
+	PeekDownloadRequests(email string, limit int) ([][]byte, error)
+	ReleaseDownloadRequests(email string) error
+	ReleaseDownloadRequest(email string, mediaItemId string) error
 	DeleteDownloadRequest(email string, mediaItemId string) error
+	UpdateDriveDownloadRequest(email string, fileId string, value []byte) error
+	GetDriveDownloadRequest(email string) ([]byte, error)
+	DeleteDriveDownloadRequest(email string, fileId string) error
+	UpdateRetry(email string, key string, value []byte) error
+	GetRetry(email string, key string) ([]byte, error)
+	GetRetries(email string) (map[string][]byte, error)
+	DeleteRetry(email string, key string) error
+	UpdateDeadLetter(email string, key string, value []byte) error
+	GetDeadLetter(email string, key string) ([]byte, error)
+	GetDeadLetters(email string) (map[string][]byte, error)
+	DeleteDeadLetter(email string, key string) error
+	SavePause(value []byte) error
+	GetPause() ([]byte, error)
+	DeletePause() error
 }
 
 type DownloadRequest struct {
 	MediaItemId string `json:"media_item_id"`
 }
 
+type DriveDownloadRequest struct {
+	FileId string `json:"file_id"`
+}
+
 type repo struct {
 	DB *bbolt.DB
 }
@@ -27,31 +57,262 @@ func NewRepository(db *bbolt.DB) repo {
 }
 
 func (r repo) UpdateDownloadRequest(email string, mediaItemId string, value []byte) error {
+	return r.update(downloadRequestBucketName, email, mediaItemId, value)
+}
+
+// Moves the first download request which isn't being processed into the claimed bucket,
+// so concurrent workers never get the same request. Returns nil if there are no download requests
+func (r repo) ClaimDownloadRequest(email string) ([]byte, error) {
+	var value []byte
+
+	err := r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+		downloadRequestBucket := bucket.Bucket([]byte(downloadRequestBucketName))
+		if downloadRequestBucket == nil {
+			return nil
+		}
+		claimedBucket, err := bucket.CreateBucketIfNotExists([]byte(claimedDownloadBucketName))
+		if err != nil {
+			return fmt.Errorf("create %s bucket: %w", claimedDownloadBucketName, err)
+		}
+
+		c := downloadRequestBucket.Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			// scheduled again while being downloaded, picked up after the running download
+			if claimedBucket.Get(k) != nil {
+				continue
+			}
+
+			value = make([]byte, len(v))
+			copy(value, v)
+
+			err = claimedBucket.Put(k, value)
+			if err != nil {
+				return fmt.Errorf("put claimed download request: %w", err)
+			}
+
+			return downloadRequestBucket.Delete(k)
+		}
+
+		return nil
+	})
+
+	return value, err
+}
+
+// Returns up to limit queued download requests without claiming them
+func (r repo) PeekDownloadRequests(email string, limit int) ([][]byte, error) {
+	var values [][]byte
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+		downloadRequestBucket := bucket.Bucket([]byte(downloadRequestBucketName))
+		if downloadRequestBucket == nil {
+			return nil
+		}
+
+		c := downloadRequestBucket.Cursor()
+
+		for k, v := c.First(); k != nil && len(values) < limit; k, v = c.Next() {
+			value := make([]byte, len(v))
+			copy(value, v)
+			values = append(values, value)
+		}
+
+		return nil
+	})
+
+	return values, err
+}
+
+// Puts claimed download requests left after an interrupted run back into the queue
+func (r repo) ReleaseDownloadRequests(email string) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+		claimedBucket := bucket.Bucket([]byte(claimedDownloadBucketName))
+		if claimedBucket == nil {
+			return nil
+		}
+		downloadRequestBucket, err := bucket.CreateBucketIfNotExists([]byte(downloadRequestBucketName))
+		if err != nil {
+			return fmt.Errorf("create %s bucket: %w", downloadRequestBucketName, err)
+		}
+
+		err = claimedBucket.ForEach(func(k, v []byte) error {
+			return downloadRequestBucket.Put(k, v)
+		})
+		if err != nil {
+			return fmt.Errorf("release claimed download requests: %w", err)
+		}
+
+		return bucket.DeleteBucket([]byte(claimedDownloadBucketName))
+	})
+}
+
+// Puts a claimed download request back into the queue, e.g. after its download was interrupted.
+// A request scheduled again while being downloaded is kept
+func (r repo) ReleaseDownloadRequest(email string, mediaItemId string) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+		claimedBucket := bucket.Bucket([]byte(claimedDownloadBucketName))
+		if claimedBucket == nil {
+			return nil
+		}
+
+		value := claimedBucket.Get([]byte(mediaItemId))
+		if value == nil {
+			return nil
+		}
+
+		downloadRequestBucket, err := bucket.CreateBucketIfNotExists([]byte(downloadRequestBucketName))
+		if err != nil {
+			return fmt.Errorf("create %s bucket: %w", downloadRequestBucketName, err)
+		}
+
+		if downloadRequestBucket.Get([]byte(mediaItemId)) == nil {
+			err = downloadRequestBucket.Put([]byte(mediaItemId), append([]byte(nil), value...))
+			if err != nil {
+				return fmt.Errorf("put download request: %w", err)
+			}
+		}
+
+		return claimedBucket.Delete([]byte(mediaItemId))
+	})
+}
+
+// Deletes a claimed download request
+func (r repo) DeleteDownloadRequest(email string, mediaItemId string) error {
+	return r.deleteIfExists(claimedDownloadBucketName, email, mediaItemId)
+}
+
+func (r repo) UpdateDriveDownloadRequest(email string, fileId string, value []byte) error {
+	return r.update(driveDownloadRequestBucketName, email, fileId, value)
+}
+
+func (r repo) GetDriveDownloadRequest(email string) ([]byte, error) {
+	return r.first(driveDownloadRequestBucketName, email)
+}
+
+func (r repo) DeleteDriveDownloadRequest(email string, fileId string) error {
+	return r.delete(driveDownloadRequestBucketName, email, fileId)
+}
+
+func (r repo) UpdateRetry(email string, key string, value []byte) error {
+	return r.update(retryBucketName, email, key, value)
+}
+
+func (r repo) GetRetry(email string, key string) ([]byte, error) {
+	return r.get(retryBucketName, email, key)
+}
+
+func (r repo) GetRetries(email string) (map[string][]byte, error) {
+	return r.getAll(retryBucketName, email)
+}
+
+func (r repo) DeleteRetry(email string, key string) error {
+	return r.deleteIfExists(retryBucketName, email, key)
+}
+
+func (r repo) UpdateDeadLetter(email string, key string, value []byte) error {
+	return r.update(deadLetterBucketName, email, key, value)
+}
+
+func (r repo) GetDeadLetter(email string, key string) ([]byte, error) {
+	return r.get(deadLetterBucketName, email, key)
+}
+
+func (r repo) GetDeadLetters(email string) (map[string][]byte, error) {
+	return r.getAll(deadLetterBucketName, email)
+}
+
+func (r repo) DeleteDeadLetter(email string, key string) error {
+	return r.deleteIfExists(deadLetterBucketName, email, key)
+}
+
+func (r repo) SavePause(value []byte) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(statusBucketName))
+		if err != nil {
+			return fmt.Errorf("create %s bucket: %w", statusBucketName, err)
+		}
+
+		return bucket.Put([]byte(pauseKey), value)
+	})
+}
+
+// Returns nil if the downloads are not paused
+func (r repo) GetPause() ([]byte, error) {
+	var value []byte
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(statusBucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		v := bucket.Get([]byte(pauseKey))
+		if v != nil {
+			value = make([]byte, len(v))
+			copy(value, v)
+		}
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (r repo) DeletePause() error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(statusBucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.Delete([]byte(pauseKey))
+	})
+}
+
+func (r repo) update(bucketName string, email string, key string, value []byte) error {
 	return r.DB.Update(func(tx *bbolt.Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
 		if err != nil {
 			return fmt.Errorf("create bucket: %w", err)
 		}
-		downloadRequestBucket, err := bucket.CreateBucketIfNotExists([]byte(downloadRequestBucketName))
+		downloadRequestBucket, err := bucket.CreateBucketIfNotExists([]byte(bucketName))
 		if err != nil {
-			return fmt.Errorf("create download request bucket: %w", err)
+			return fmt.Errorf("create %s bucket: %w", bucketName, err)
 		}
 
-		return downloadRequestBucket.Put([]byte(mediaItemId), value)
+		return downloadRequestBucket.Put([]byte(key), value)
 	})
 }
 
-func (r repo) GetDownloadRequest(email string) ([]byte, error) {
+// Returns nil if there are no download requests
+func (r repo) first(bucketName string, email string) ([]byte, error) {
 	var value []byte
 
 	err := r.DB.View(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(email))
 		if bucket == nil {
-			return fmt.Errorf("account not found")
+			return nil
 		}
-		downloadRequestBucket := bucket.Bucket([]byte(downloadRequestBucketName))
+		downloadRequestBucket := bucket.Bucket([]byte(bucketName))
 		if downloadRequestBucket == nil {
-			return fmt.Errorf("download request bucket not found")
+			return nil
 		}
 
 		c := downloadRequestBucket.Cursor()
@@ -65,17 +326,83 @@ func (r repo) GetDownloadRequest(email string) ([]byte, error) {
 	return value, err
 }
 
-func (r repo) DeleteDownloadRequest(email string, mediaItemId string) error {
+func (r repo) delete(bucketName string, email string, key string) error {
 	return r.DB.Update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(email))
 		if bucket == nil {
 			return fmt.Errorf("account not found")
 		}
-		downloadRequestBucket := bucket.Bucket([]byte(downloadRequestBucketName))
+		downloadRequestBucket := bucket.Bucket([]byte(bucketName))
 		if downloadRequestBucket == nil {
-			return fmt.Errorf("download request bucket not found")
+			return fmt.Errorf("%s bucket not found", bucketName)
+		}
+
+		return downloadRequestBucket.Delete([]byte(key))
+	})
+}
+
+// Returns nil if the key does not exist
+func (r repo) get(bucketName string, email string, key string) ([]byte, error) {
+	var value []byte
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+		keyBucket := bucket.Bucket([]byte(bucketName))
+		if keyBucket == nil {
+			return nil
+		}
+
+		v := keyBucket.Get([]byte(key))
+		if v != nil {
+			value = make([]byte, len(v))
+			copy(value, v)
+		}
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (r repo) getAll(bucketName string, email string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+		keyBucket := bucket.Bucket([]byte(bucketName))
+		if keyBucket == nil {
+			return nil
+		}
+
+		return keyBucket.ForEach(func(k, v []byte) error {
+			value := make([]byte, len(v))
+			copy(value, v)
+			values[string(k)] = value
+
+			return nil
+		})
+	})
+
+	return values, err
+}
+
+func (r repo) deleteIfExists(bucketName string, email string, key string) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+		keyBucket := bucket.Bucket([]byte(bucketName))
+		if keyBucket == nil {
+			return nil
 		}
 
-		return downloadRequestBucket.Delete([]byte(mediaItemId))
+		return keyBucket.Delete([]byte(key))
 	})
 }
@@ -0,0 +1,141 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadRequestClaims(t *testing.T) {
+	schedule := func(t *testing.T, repository repo, mediaItemIds ...string) {
+		for _, mediaItemId := range mediaItemIds {
+			require.NoError(t, NewScheduler(repository).ScheduleDownload(testEmail, mediaItemId))
+		}
+	}
+
+	claim := func(t *testing.T, repository repo) string {
+		downloadRequestJson, err := repository.ClaimDownloadRequest(testEmail)
+		require.NoError(t, err)
+
+		if downloadRequestJson == nil {
+			return ""
+		}
+
+		var downloadRequest DownloadRequest
+		require.NoError(t, json.Unmarshal(downloadRequestJson, &downloadRequest))
+
+		return downloadRequest.MediaItemId
+	}
+
+	t.Run("concurrent workers claim every request once", func(t *testing.T) {
+		repository := newTestRepository(t)
+
+		for i := 0; i < 100; i++ {
+			schedule(t, repository, fmt.Sprintf("item-%03d", i))
+		}
+
+		var mutex sync.Mutex
+		claimed := make(map[string]int)
+
+		var workers sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			workers.Add(1)
+
+			go func() {
+				defer workers.Done()
+
+				for {
+					downloadRequestJson, err := repository.ClaimDownloadRequest(testEmail)
+					if !assert.NoError(t, err) || downloadRequestJson == nil {
+						return
+					}
+
+					var downloadRequest DownloadRequest
+					assert.NoError(t, json.Unmarshal(downloadRequestJson, &downloadRequest))
+
+					mutex.Lock()
+					claimed[downloadRequest.MediaItemId]++
+					mutex.Unlock()
+				}
+			}()
+		}
+
+		workers.Wait()
+
+		assert.Len(t, claimed, 100)
+		for mediaItemId, count := range claimed {
+			assert.Equal(t, 1, count, mediaItemId)
+		}
+	})
+
+	t.Run("request scheduled again while it is downloaded", func(t *testing.T) {
+		repository := newTestRepository(t)
+		schedule(t, repository, "item-1")
+
+		assert.Equal(t, "item-1", claim(t, repository))
+
+		schedule(t, repository, "item-1")
+
+		// picked up after the running download
+		assert.Equal(t, "", claim(t, repository))
+
+		require.NoError(t, repository.DeleteDownloadRequest(testEmail, "item-1"))
+
+		assert.Equal(t, "item-1", claim(t, repository))
+	})
+
+	t.Run("release of a claimed request", func(t *testing.T) {
+		repository := newTestRepository(t)
+		schedule(t, repository, "item-1", "item-2")
+
+		assert.Equal(t, "item-1", claim(t, repository))
+
+		err := repository.ReleaseDownloadRequest(testEmail, "item-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "item-1", claim(t, repository))
+		assert.Equal(t, "item-2", claim(t, repository))
+		assert.Equal(t, "", claim(t, repository))
+	})
+
+	t.Run("release of a request which isn't claimed", func(t *testing.T) {
+		repository := newTestRepository(t)
+
+		err := repository.ReleaseDownloadRequest(testEmail, "item-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "", claim(t, repository))
+	})
+
+	t.Run("release of the requests left by an interrupted run", func(t *testing.T) {
+		repository := newTestRepository(t)
+		schedule(t, repository, "item-1", "item-2", "item-3")
+
+		assert.Equal(t, "item-1", claim(t, repository))
+		assert.Equal(t, "item-2", claim(t, repository))
+
+		err := repository.ReleaseDownloadRequests(testEmail)
+
+		assert.NoError(t, err)
+
+		downloadRequests, err := repository.PeekDownloadRequests(testEmail, 10)
+		assert.NoError(t, err)
+		assert.Len(t, downloadRequests, 3)
+	})
+
+	t.Run("deleted claimed request", func(t *testing.T) {
+		repository := newTestRepository(t)
+		schedule(t, repository, "item-1")
+
+		assert.Equal(t, "item-1", claim(t, repository))
+
+		require.NoError(t, repository.DeleteDownloadRequest(testEmail, "item-1"))
+		require.NoError(t, repository.ReleaseDownloadRequests(testEmail))
+
+		assert.Equal(t, "", claim(t, repository))
+	})
+}
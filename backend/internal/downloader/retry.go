@@ -0,0 +1,270 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"google-backup/internal/drive"
+	"google-backup/internal/media"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DownloadTypePhotos = "photos"
+	DownloadTypeDrive  = "drive"
+
+	retryMaxAttempts = 8
+	retryBaseDelay   = 5 * time.Minute
+	retryMaxDelay    = 24 * time.Hour
+)
+
+type FailedDownload struct {
+	Type            string `json:"type"`
+	Id              string `json:"id"`
+	Attempts        int    `json:"attempts"`
+	LastError       string `json:"last_error"`
+	NextAttemptTime string `json:"next_attempt_time,omitempty"`
+	DeadLetterTime  string `json:"dead_letter_time,omitempty"`
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . RetryQueue
+type RetryQueue interface {
+	Fail(email string, downloadType string, id string, cause error) error
+	Succeed(email string, downloadType string, id string) error
+	ScheduleDue(email string, downloadType string) error
+	GetDeadLetters(email string) ([]FailedDownload, error)
+	Requeue(email string, downloadType string, id string) (bool, error)
+}
+
+type retryQueue struct {
+	repository Repository
+	scheduler  Scheduler
+}
+
+func NewRetryQueue(repository Repository, scheduler Scheduler) retryQueue {
+	return retryQueue{repository: repository, scheduler: scheduler}
+}
+
+// Schedules a retry with exponential backoff or moves the download into the dead-letter bucket
+// if the error is permanent or the attempts are exhausted
+func (q retryQueue) Fail(email string, downloadType string, id string, cause error) error {
+	key := retryKey(downloadType, id)
+
+	failedDownload := FailedDownload{Type: downloadType, Id: id}
+
+	failedDownloadJson, err := q.repository.GetRetry(email, key)
+	if err != nil {
+		return fmt.Errorf("get retry: %w", err)
+	}
+
+	if failedDownloadJson != nil {
+		err = json.Unmarshal(failedDownloadJson, &failedDownload)
+		if err != nil {
+			return fmt.Errorf("unmarshal retry: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+
+	failedDownload.Attempts++
+	failedDownload.LastError = cause.Error()
+
+	if IsPermanentError(cause) || failedDownload.Attempts >= retryMaxAttempts {
+		failedDownload.NextAttemptTime = ""
+		failedDownload.DeadLetterTime = now.Format(time.RFC3339)
+
+		failedDownloadJson, err = json.Marshal(failedDownload)
+		if err != nil {
+			return fmt.Errorf("marshal dead letter: %w", err)
+		}
+
+		err = q.repository.UpdateDeadLetter(email, key, failedDownloadJson)
+		if err != nil {
+			return fmt.Errorf("update dead letter: %w", err)
+		}
+
+		log.WithFields(log.Fields{
+			"email":    email,
+			"type":     downloadType,
+			"id":       id,
+			"attempts": failedDownload.Attempts,
+		}).Warn(fmt.Errorf("download moved to dead letters: %w", cause))
+
+		return q.repository.DeleteRetry(email, key)
+	}
+
+	failedDownload.NextAttemptTime = now.Add(retryDelay(failedDownload.Attempts)).Format(time.RFC3339)
+
+	failedDownloadJson, err = json.Marshal(failedDownload)
+	if err != nil {
+		return fmt.Errorf("marshal retry: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"email":             email,
+		"type":              downloadType,
+		"id":                id,
+		"attempts":          failedDownload.Attempts,
+		"next_attempt_time": failedDownload.NextAttemptTime,
+	}).Error(fmt.Errorf("download failed: %w", cause))
+
+	return q.repository.UpdateRetry(email, key, failedDownloadJson)
+}
+
+func (q retryQueue) Succeed(email string, downloadType string, id string) error {
+	return q.repository.DeleteRetry(email, retryKey(downloadType, id))
+}
+
+// Puts retries whose next attempt time has passed back into the download queue
+func (q retryQueue) ScheduleDue(email string, downloadType string) error {
+	retries, err := q.repository.GetRetries(email)
+	if err != nil {
+		return fmt.Errorf("get retries: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	for key, failedDownloadJson := range retries {
+		var failedDownload FailedDownload
+		err = json.Unmarshal(failedDownloadJson, &failedDownload)
+		if err != nil {
+			return fmt.Errorf("unmarshal retry %s: %w", key, err)
+		}
+
+		if failedDownload.Type != downloadType {
+			continue
+		}
+
+		nextAttemptTime, err := time.Parse(time.RFC3339, failedDownload.NextAttemptTime)
+		if err != nil {
+			return fmt.Errorf("parse next attempt time %s: %w", key, err)
+		}
+
+		if nextAttemptTime.After(now) {
+			continue
+		}
+
+		err = q.schedule(email, failedDownload.Type, failedDownload.Id)
+		if err != nil {
+			return fmt.Errorf("schedule %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func (q retryQueue) GetDeadLetters(email string) ([]FailedDownload, error) {
+	deadLetters, err := q.repository.GetDeadLetters(email)
+	if err != nil {
+		return nil, fmt.Errorf("get dead letters: %w", err)
+	}
+
+	result := make([]FailedDownload, 0, len(deadLetters))
+
+	for key, failedDownloadJson := range deadLetters {
+		var failedDownload FailedDownload
+		err = json.Unmarshal(failedDownloadJson, &failedDownload)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal dead letter %s: %w", key, err)
+		}
+
+		result = append(result, failedDownload)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DeadLetterTime < result[j].DeadLetterTime
+	})
+
+	return result, nil
+}
+
+// Moves a dead letter back into the download queue with reset attempts.
+// Returns false if there is no such dead letter
+func (q retryQueue) Requeue(email string, downloadType string, id string) (bool, error) {
+	key := retryKey(downloadType, id)
+
+	failedDownloadJson, err := q.repository.GetDeadLetter(email, key)
+	if err != nil {
+		return false, fmt.Errorf("get dead letter: %w", err)
+	}
+
+	if failedDownloadJson == nil {
+		return false, nil
+	}
+
+	err = q.schedule(email, downloadType, id)
+	if err != nil {
+		return false, fmt.Errorf("schedule: %w", err)
+	}
+
+	err = q.repository.DeleteDeadLetter(email, key)
+	if err != nil {
+		return false, fmt.Errorf("delete dead letter: %w", err)
+	}
+
+	return true, nil
+}
+
+func (q retryQueue) schedule(email string, downloadType string, id string) error {
+	switch downloadType {
+	case DownloadTypePhotos:
+		return q.scheduler.ScheduleDownload(email, id)
+	case DownloadTypeDrive:
+		return q.scheduler.ScheduleDriveDownload(email, id)
+	default:
+		return fmt.Errorf("unknown download type: %s", downloadType)
+	}
+}
+
+// Permanent errors won't go away by themselves, retrying them only wastes the quota
+func IsPermanentError(err error) bool {
+	if errors.As(err, &ManuallyRepeatableError{}) {
+		return true
+	}
+
+	var statusCode int
+
+	var downloadErr NotOkRequestError
+	var mediaErr media.NotOkRequestError
+	var driveErr drive.NotOkRequestError
+
+	switch {
+	case errors.As(err, &downloadErr):
+		statusCode = downloadErr.StatusCode
+	case errors.As(err, &mediaErr):
+		statusCode = mediaErr.StatusCode
+	case errors.As(err, &driveErr):
+		statusCode = driveErr.StatusCode
+	}
+
+	switch statusCode {
+	// timeouts, and partial files bigger than the remote file, which are deleted, succeed on the next attempt
+	case http.StatusRequestTimeout, http.StatusRequestedRangeNotSatisfiable:
+		return false
+	}
+
+	return statusCode >= 400 && statusCode < 500
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+
+	return delay
+}
+
+func retryKey(downloadType string, id string) string {
+	return downloadType + "/" + id
+}
@@ -0,0 +1,250 @@
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google-backup/internal/drive"
+	"google-backup/internal/media"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+const testEmail = "user@gmail.com"
+
+func newTestRepository(t *testing.T) repo {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	return NewRepository(db)
+}
+
+func TestIsPermanentError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"request timeout", NotOkRequestError{error: errors.New("timeout"), StatusCode: http.StatusRequestTimeout}, false},
+		{"range not satisfiable", NotOkRequestError{error: errors.New("range"), StatusCode: http.StatusRequestedRangeNotSatisfiable}, false},
+		{"error without status code", errors.New("connection reset"), false},
+		{"not ok request without status code", NotOkRequestError{error: errors.New("content range")}, false},
+		{"server error", NotOkRequestError{error: errors.New("unavailable"), StatusCode: http.StatusServiceUnavailable}, false},
+		{"not found", NotOkRequestError{error: errors.New("not found"), StatusCode: http.StatusNotFound}, true},
+		{"wrapped media error", fmt.Errorf("get media item: %w", media.NotOkRequestError{StatusCode: http.StatusForbidden}), true},
+		{"wrapped drive error", fmt.Errorf("get file: %w", drive.NotOkRequestError{StatusCode: http.StatusBadRequest}), true},
+		{"manually repeatable error", ManuallyRepeatableError{errors.New("unsupported video")}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, IsPermanentError(test.err))
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{7, 320 * time.Minute},
+		{9, 1280 * time.Minute},
+		{10, 24 * time.Hour},
+		{30, 24 * time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d attempts", test.attempts), func(t *testing.T) {
+			assert.Equal(t, test.expected, retryDelay(test.attempts))
+		})
+	}
+}
+
+func TestRetryQueue(t *testing.T) {
+	transientErr := NotOkRequestError{error: errors.New("unavailable"), StatusCode: http.StatusServiceUnavailable}
+
+	getRetry := func(t *testing.T, repository repo, key string) *FailedDownload {
+		failedDownloadJson, err := repository.GetRetry(testEmail, key)
+		require.NoError(t, err)
+
+		if failedDownloadJson == nil {
+			return nil
+		}
+
+		var failedDownload FailedDownload
+		require.NoError(t, json.Unmarshal(failedDownloadJson, &failedDownload))
+
+		return &failedDownload
+	}
+
+	getDeadLetter := func(t *testing.T, repository repo, key string) *FailedDownload {
+		failedDownloadJson, err := repository.GetDeadLetter(testEmail, key)
+		require.NoError(t, err)
+
+		if failedDownloadJson == nil {
+			return nil
+		}
+
+		var failedDownload FailedDownload
+		require.NoError(t, json.Unmarshal(failedDownloadJson, &failedDownload))
+
+		return &failedDownload
+	}
+
+	saveRetry := func(t *testing.T, repository repo, failedDownload FailedDownload) {
+		failedDownloadJson, err := json.Marshal(failedDownload)
+		require.NoError(t, err)
+
+		require.NoError(t, repository.UpdateRetry(testEmail, retryKey(failedDownload.Type, failedDownload.Id), failedDownloadJson))
+	}
+
+	t.Run("first failure schedules a retry", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		err := queue.Fail(testEmail, DownloadTypePhotos, "item-1", transientErr)
+
+		assert.NoError(t, err)
+
+		failedDownload := getRetry(t, repository, "photos/item-1")
+		require.NotNil(t, failedDownload)
+		assert.Equal(t, 1, failedDownload.Attempts)
+		assert.Equal(t, "unavailable", failedDownload.LastError)
+
+		nextAttemptTime, err := time.Parse(time.RFC3339, failedDownload.NextAttemptTime)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(retryBaseDelay), nextAttemptTime, time.Minute)
+	})
+
+	t.Run("repeated failures back off", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		for i := 0; i < retryMaxAttempts-1; i++ {
+			require.NoError(t, queue.Fail(testEmail, DownloadTypePhotos, "item-1", transientErr))
+		}
+
+		failedDownload := getRetry(t, repository, "photos/item-1")
+		require.NotNil(t, failedDownload)
+		assert.Equal(t, retryMaxAttempts-1, failedDownload.Attempts)
+
+		nextAttemptTime, err := time.Parse(time.RFC3339, failedDownload.NextAttemptTime)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(retryDelay(retryMaxAttempts-1)), nextAttemptTime, time.Minute)
+		assert.Nil(t, getDeadLetter(t, repository, "photos/item-1"))
+	})
+
+	t.Run("exhausted attempts move the download to dead letters", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		for i := 0; i < retryMaxAttempts; i++ {
+			require.NoError(t, queue.Fail(testEmail, DownloadTypePhotos, "item-1", transientErr))
+		}
+
+		assert.Nil(t, getRetry(t, repository, "photos/item-1"))
+
+		deadLetter := getDeadLetter(t, repository, "photos/item-1")
+		require.NotNil(t, deadLetter)
+		assert.Equal(t, retryMaxAttempts, deadLetter.Attempts)
+		assert.Empty(t, deadLetter.NextAttemptTime)
+		assert.NotEmpty(t, deadLetter.DeadLetterTime)
+	})
+
+	t.Run("permanent error moves the download to dead letters", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		err := queue.Fail(testEmail, DownloadTypeDrive, "file-1", NotOkRequestError{error: errors.New("not found"), StatusCode: http.StatusNotFound})
+
+		assert.NoError(t, err)
+		assert.Nil(t, getRetry(t, repository, "drive/file-1"))
+
+		deadLetter := getDeadLetter(t, repository, "drive/file-1")
+		require.NotNil(t, deadLetter)
+		assert.Equal(t, 1, deadLetter.Attempts)
+		assert.Equal(t, DownloadTypeDrive, deadLetter.Type)
+	})
+
+	t.Run("success deletes the retry", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		require.NoError(t, queue.Fail(testEmail, DownloadTypePhotos, "item-1", transientErr))
+
+		err := queue.Succeed(testEmail, DownloadTypePhotos, "item-1")
+
+		assert.NoError(t, err)
+		assert.Nil(t, getRetry(t, repository, "photos/item-1"))
+	})
+
+	t.Run("due retries are scheduled", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+		saveRetry(t, repository, FailedDownload{Type: DownloadTypePhotos, Id: "item-due", Attempts: 2, NextAttemptTime: past})
+		saveRetry(t, repository, FailedDownload{Type: DownloadTypePhotos, Id: "item-later", Attempts: 2, NextAttemptTime: future})
+		saveRetry(t, repository, FailedDownload{Type: DownloadTypeDrive, Id: "file-due", Attempts: 2, NextAttemptTime: past})
+
+		err := queue.ScheduleDue(testEmail, DownloadTypePhotos)
+
+		assert.NoError(t, err)
+
+		downloadRequests, err := repository.PeekDownloadRequests(testEmail, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte(`{"media_item_id":"item-due"}`)}, downloadRequests)
+
+		driveDownloadRequest, err := repository.GetDriveDownloadRequest(testEmail)
+		assert.NoError(t, err)
+		assert.Nil(t, driveDownloadRequest)
+
+		// the retry keeps its attempts until the download succeeds or fails again
+		assert.NotNil(t, getRetry(t, repository, "photos/item-due"))
+	})
+
+	t.Run("requeue of a dead letter", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		require.NoError(t, queue.Fail(testEmail, DownloadTypeDrive, "file-1", ManuallyRepeatableError{errors.New("unsupported")}))
+
+		requeued, err := queue.Requeue(testEmail, DownloadTypeDrive, "file-1")
+
+		assert.NoError(t, err)
+		assert.True(t, requeued)
+		assert.Nil(t, getDeadLetter(t, repository, "drive/file-1"))
+
+		driveDownloadRequest, err := repository.GetDriveDownloadRequest(testEmail)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"file_id":"file-1"}`, string(driveDownloadRequest))
+	})
+
+	t.Run("requeue of an unknown dead letter", func(t *testing.T) {
+		repository := newTestRepository(t)
+		queue := NewRetryQueue(repository, NewScheduler(repository))
+
+		requeued, err := queue.Requeue(testEmail, DownloadTypePhotos, "item-1")
+
+		assert.NoError(t, err)
+		assert.False(t, requeued)
+
+		downloadRequests, err := repository.PeekDownloadRequests(testEmail, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, downloadRequests)
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 )
 
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Scheduler
 type Scheduler interface {
 	ScheduleDownload(email string, mediaItemId string) error
+	ScheduleDriveDownload(email string, fileId string) error
 }
 
 type scheduler struct {
@@ -29,3 +31,16 @@ func (s scheduler) ScheduleDownload(email string, mediaItemId string) error {
 
 	return s.repository.UpdateDownloadRequest(email, mediaItemId, downloadRequestJson)
 }
+
+func (s scheduler) ScheduleDriveDownload(email string, fileId string) error {
+	downloadRequest := DriveDownloadRequest{
+		FileId: fileId,
+	}
+
+	downloadRequestJson, err := json.Marshal(downloadRequest)
+	if err != nil {
+		return fmt.Errorf("marshal drive download data: %w", err)
+	}
+
+	return s.repository.UpdateDriveDownloadRequest(email, fileId, downloadRequestJson)
+}
@@ -0,0 +1,189 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google-backup/internal/settings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// Largest chunk read at once when a limit is set, keeps the throttling smooth
+const maxThrottledChunkSize = 32 * 1024
+
+// Shares the global bandwidth limit between accounts and applies the per account limits.
+// The limits are re-evaluated on every read, so schedules take effect in the middle of long downloads
+type bandwidthLimiter struct {
+	global *rate.Limiter
+
+	mutex        sync.Mutex
+	settingsData settings.SettingsData
+	accounts     map[string]*accountBandwidth
+}
+
+type accountBandwidth struct {
+	limiter *rate.Limiter
+	bytes   atomic.Int64
+	started time.Time
+}
+
+func newBandwidthLimiter() *bandwidthLimiter {
+	return &bandwidthLimiter{
+		global:   rate.NewLimiter(rate.Inf, maxThrottledChunkSize),
+		accounts: make(map[string]*accountBandwidth),
+	}
+}
+
+// Settings are re-read on every downloader run
+func (b *bandwidthLimiter) SetSettings(settingsData settings.SettingsData) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.settingsData = settingsData
+}
+
+func (b *bandwidthLimiter) Reader(ctx context.Context, email string, reader io.Reader) io.Reader {
+	return &throttledReader{
+		ctx:     ctx,
+		reader:  reader,
+		limiter: b,
+		account: b.account(email),
+	}
+}
+
+// Logs the throughput of the account since the previous call and resets the counters
+func (b *bandwidthLimiter) LogThroughput(email string) {
+	account := b.account(email)
+
+	bytes := account.bytes.Swap(0)
+
+	b.mutex.Lock()
+	started := account.started
+	account.started = time.Now()
+	b.mutex.Unlock()
+
+	if bytes == 0 {
+		return
+	}
+
+	seconds := time.Since(started).Seconds()
+
+	log.WithFields(log.Fields{
+		"email":            email,
+		"bytes":            bytes,
+		"seconds":          int64(seconds),
+		"bytes_per_second": int64(float64(bytes) / seconds),
+	}).Info("download throughput")
+}
+
+func (b *bandwidthLimiter) account(email string) *accountBandwidth {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	account, ok := b.accounts[email]
+	if !ok {
+		account = &accountBandwidth{
+			limiter: rate.NewLimiter(rate.Inf, maxThrottledChunkSize),
+			started: time.Now(),
+		}
+		b.accounts[email] = account
+	}
+
+	return account
+}
+
+// Readers of all accounts apply the limits, the mutex keeps their changes of the shared global limiter apart
+func (b *bandwidthLimiter) apply(account *accountBandwidth, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	globalLimit, accountLimit := b.settingsData.BandwidthLimitsAt(now)
+
+	setLimit(b.global, globalLimit)
+	setLimit(account.limiter, accountLimit)
+}
+
+// The burst is set before the limit, readers never see a limit without its burst.
+// The burst of an unlimited limiter is kept, it is ignored until a limit is set
+func setLimit(limiter *rate.Limiter, bytesPerSecond int64) {
+	if bytesPerSecond <= 0 {
+		if limiter.Limit() != rate.Inf {
+			limiter.SetLimit(rate.Inf)
+		}
+
+		return
+	}
+
+	if limiter.Limit() != rate.Limit(bytesPerSecond) {
+		limiter.SetBurst(int(min(bytesPerSecond, maxThrottledChunkSize)))
+		limiter.SetLimit(rate.Limit(bytesPerSecond))
+	}
+}
+
+type throttledReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *bandwidthLimiter
+	account *accountBandwidth
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	r.limiter.apply(r.account, time.Now())
+
+	chunkSize := len(p)
+	for _, limiter := range []*rate.Limiter{r.limiter.global, r.account.limiter} {
+		chunkSize = stepSize(limiter, chunkSize)
+	}
+
+	n, err := r.reader.Read(p[:chunkSize])
+	if n <= 0 {
+		return n, err
+	}
+
+	r.account.bytes.Add(int64(n))
+
+	for _, limiter := range []*rate.Limiter{r.limiter.global, r.account.limiter} {
+		waitErr := waitN(r.ctx, limiter, n)
+		if waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+// Waits in burst sized steps, the burst may shrink when a schedule starts
+func waitN(ctx context.Context, limiter *rate.Limiter, n int) error {
+	for n > 0 {
+		step := stepSize(limiter, n)
+
+		err := limiter.WaitN(ctx, step)
+		if err != nil {
+			// the burst shrank after the step was taken, the next step is smaller
+			if ctx.Err() == nil && limiter.Limit() != rate.Inf && step > limiter.Burst() && limiter.Burst() > 0 {
+				continue
+			}
+
+			return err
+		}
+
+		n -= step
+	}
+
+	return nil
+}
+
+// Up to n bytes and at most the burst of a limited limiter, but never zero bytes for a positive n,
+// so readers and waits always make progress
+func stepSize(limiter *rate.Limiter, n int) int {
+	if limiter.Limit() == rate.Inf || n <= 1 {
+		return n
+	}
+
+	return max(1, min(n, limiter.Burst()))
+}
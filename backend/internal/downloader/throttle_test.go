@@ -0,0 +1,163 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"google-backup/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	at := func(clock string) time.Time {
+		result, _ := time.Parse(settings.BandwidthScheduleTimeLayout, clock)
+
+		return result
+	}
+
+	t.Run("schedule starts and ends", func(t *testing.T) {
+		limiter := newBandwidthLimiter()
+		limiter.SetSettings(settings.SettingsData{
+			DownloadBandwidthLimit: 1000000,
+			DownloadBandwidthSchedules: []settings.BandwidthSchedule{
+				{Start: "23:00", End: "07:00", AccountBandwidthLimit: 10000},
+			},
+		})
+		account := limiter.account(testEmail)
+
+		limiter.apply(account, at("22:59"))
+
+		assert.Equal(t, rate.Limit(1000000), limiter.global.Limit())
+		assert.Equal(t, maxThrottledChunkSize, limiter.global.Burst())
+		assert.Equal(t, rate.Inf, account.limiter.Limit())
+
+		limiter.apply(account, at("23:00"))
+
+		assert.Equal(t, rate.Inf, limiter.global.Limit())
+		assert.Equal(t, rate.Limit(10000), account.limiter.Limit())
+		assert.Equal(t, 10000, account.limiter.Burst())
+
+		limiter.apply(account, at("07:00"))
+
+		assert.Equal(t, rate.Limit(1000000), limiter.global.Limit())
+		assert.Equal(t, rate.Inf, account.limiter.Limit())
+	})
+
+	t.Run("limits of new settings", func(t *testing.T) {
+		limiter := newBandwidthLimiter()
+		account := limiter.account(testEmail)
+
+		limiter.apply(account, at("12:00"))
+
+		assert.Equal(t, rate.Inf, limiter.global.Limit())
+
+		limiter.SetSettings(settings.SettingsData{DownloadBandwidthLimit: 500})
+		limiter.apply(account, at("12:00"))
+
+		assert.Equal(t, rate.Limit(500), limiter.global.Limit())
+		assert.Equal(t, 500, limiter.global.Burst())
+	})
+
+	t.Run("reads while schedules switch", func(t *testing.T) {
+		limiter := newBandwidthLimiter()
+		content := bytes.Repeat([]byte("0123456789"), 10000)
+
+		var readers sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			readers.Add(1)
+
+			email := fmt.Sprintf("user%d@gmail.com", i)
+
+			go func() {
+				defer readers.Done()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+
+				read, err := io.ReadAll(limiter.Reader(ctx, email, bytes.NewReader(content)))
+
+				assert.NoError(t, err)
+				assert.Equal(t, len(content), len(read))
+			}()
+		}
+
+		// the limits and bursts change under the readers, the last settings don't limit them
+		for i := 0; i < 200; i++ {
+			switch i % 3 {
+			case 0:
+				limiter.SetSettings(settings.SettingsData{DownloadBandwidthLimit: 50000000, DownloadAccountBandwidthLimit: 20000000})
+			case 1:
+				limiter.SetSettings(settings.SettingsData{DownloadBandwidthLimit: 5000000, DownloadAccountBandwidthLimit: 10000})
+			case 2:
+				limiter.SetSettings(settings.SettingsData{})
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		limiter.SetSettings(settings.SettingsData{})
+
+		readers.Wait()
+	})
+}
+
+func TestWaitN(t *testing.T) {
+	t.Run("steps of the burst", func(t *testing.T) {
+		limiter := rate.NewLimiter(rate.Limit(1000000000), 100)
+
+		err := waitN(context.Background(), limiter, 1000)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("limiter without burst", func(t *testing.T) {
+		limiter := rate.NewLimiter(rate.Limit(1000), 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		err := waitN(ctx, limiter, 1000)
+
+		assert.Error(t, err)
+		assert.NoError(t, ctx.Err())
+	})
+
+	t.Run("shutdown while waiting", func(t *testing.T) {
+		limiter := rate.NewLimiter(rate.Limit(1), 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := waitN(ctx, limiter, 10)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestStepSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		limiter  *rate.Limiter
+		n        int
+		expected int
+	}{
+		{"unlimited", rate.NewLimiter(rate.Inf, 0), 100000, 100000},
+		{"below the burst", rate.NewLimiter(1000, 500), 100, 100},
+		{"above the burst", rate.NewLimiter(1000, 500), 1000, 500},
+		{"zero burst", rate.NewLimiter(1000, 0), 1000, 1},
+		{"nothing to wait for", rate.NewLimiter(1000, 0), 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, stepSize(test.limiter, test.n))
+		})
+	}
+}
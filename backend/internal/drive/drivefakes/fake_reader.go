@@ -0,0 +1,423 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package drivefakes
+
+import (
+	"google-backup/internal/drive"
+	"io"
+	"sync"
+)
+
+type FakeReader struct {
+	DownloadFileStub        func(drive.File) (io.ReadCloser, error)
+	downloadFileMutex       sync.RWMutex
+	downloadFileArgsForCall []struct {
+		arg1 drive.File
+	}
+	downloadFileReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	downloadFileReturnsOnCall map[int]struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	GetChangesStub        func(string) (drive.Changes, error)
+	getChangesMutex       sync.RWMutex
+	getChangesArgsForCall []struct {
+		arg1 string
+	}
+	getChangesReturns struct {
+		result1 drive.Changes
+		result2 error
+	}
+	getChangesReturnsOnCall map[int]struct {
+		result1 drive.Changes
+		result2 error
+	}
+	GetFileStub        func(string) (drive.File, error)
+	getFileMutex       sync.RWMutex
+	getFileArgsForCall []struct {
+		arg1 string
+	}
+	getFileReturns struct {
+		result1 drive.File
+		result2 error
+	}
+	getFileReturnsOnCall map[int]struct {
+		result1 drive.File
+		result2 error
+	}
+	GetFilesStub        func(string) (drive.Files, error)
+	getFilesMutex       sync.RWMutex
+	getFilesArgsForCall []struct {
+		arg1 string
+	}
+	getFilesReturns struct {
+		result1 drive.Files
+		result2 error
+	}
+	getFilesReturnsOnCall map[int]struct {
+		result1 drive.Files
+		result2 error
+	}
+	GetStartPageTokenStub        func() (string, error)
+	getStartPageTokenMutex       sync.RWMutex
+	getStartPageTokenArgsForCall []struct {
+	}
+	getStartPageTokenReturns struct {
+		result1 string
+		result2 error
+	}
+	getStartPageTokenReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReader) DownloadFile(arg1 drive.File) (io.ReadCloser, error) {
+	fake.downloadFileMutex.Lock()
+	ret, specificReturn := fake.downloadFileReturnsOnCall[len(fake.downloadFileArgsForCall)]
+	fake.downloadFileArgsForCall = append(fake.downloadFileArgsForCall, struct {
+		arg1 drive.File
+	}{arg1})
+	stub := fake.DownloadFileStub
+	fakeReturns := fake.downloadFileReturns
+	fake.recordInvocation("DownloadFile", []interface{}{arg1})
+	fake.downloadFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) DownloadFileCallCount() int {
+	fake.downloadFileMutex.RLock()
+	defer fake.downloadFileMutex.RUnlock()
+	return len(fake.downloadFileArgsForCall)
+}
+
+func (fake *FakeReader) DownloadFileCalls(stub func(drive.File) (io.ReadCloser, error)) {
+	fake.downloadFileMutex.Lock()
+	defer fake.downloadFileMutex.Unlock()
+	fake.DownloadFileStub = stub
+}
+
+func (fake *FakeReader) DownloadFileArgsForCall(i int) drive.File {
+	fake.downloadFileMutex.RLock()
+	defer fake.downloadFileMutex.RUnlock()
+	argsForCall := fake.downloadFileArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) DownloadFileReturns(result1 io.ReadCloser, result2 error) {
+	fake.downloadFileMutex.Lock()
+	defer fake.downloadFileMutex.Unlock()
+	fake.DownloadFileStub = nil
+	fake.downloadFileReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) DownloadFileReturnsOnCall(i int, result1 io.ReadCloser, result2 error) {
+	fake.downloadFileMutex.Lock()
+	defer fake.downloadFileMutex.Unlock()
+	fake.DownloadFileStub = nil
+	if fake.downloadFileReturnsOnCall == nil {
+		fake.downloadFileReturnsOnCall = make(map[int]struct {
+			result1 io.ReadCloser
+			result2 error
+		})
+	}
+	fake.downloadFileReturnsOnCall[i] = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetChanges(arg1 string) (drive.Changes, error) {
+	fake.getChangesMutex.Lock()
+	ret, specificReturn := fake.getChangesReturnsOnCall[len(fake.getChangesArgsForCall)]
+	fake.getChangesArgsForCall = append(fake.getChangesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetChangesStub
+	fakeReturns := fake.getChangesReturns
+	fake.recordInvocation("GetChanges", []interface{}{arg1})
+	fake.getChangesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetChangesCallCount() int {
+	fake.getChangesMutex.RLock()
+	defer fake.getChangesMutex.RUnlock()
+	return len(fake.getChangesArgsForCall)
+}
+
+func (fake *FakeReader) GetChangesCalls(stub func(string) (drive.Changes, error)) {
+	fake.getChangesMutex.Lock()
+	defer fake.getChangesMutex.Unlock()
+	fake.GetChangesStub = stub
+}
+
+func (fake *FakeReader) GetChangesArgsForCall(i int) string {
+	fake.getChangesMutex.RLock()
+	defer fake.getChangesMutex.RUnlock()
+	argsForCall := fake.getChangesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) GetChangesReturns(result1 drive.Changes, result2 error) {
+	fake.getChangesMutex.Lock()
+	defer fake.getChangesMutex.Unlock()
+	fake.GetChangesStub = nil
+	fake.getChangesReturns = struct {
+		result1 drive.Changes
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetChangesReturnsOnCall(i int, result1 drive.Changes, result2 error) {
+	fake.getChangesMutex.Lock()
+	defer fake.getChangesMutex.Unlock()
+	fake.GetChangesStub = nil
+	if fake.getChangesReturnsOnCall == nil {
+		fake.getChangesReturnsOnCall = make(map[int]struct {
+			result1 drive.Changes
+			result2 error
+		})
+	}
+	fake.getChangesReturnsOnCall[i] = struct {
+		result1 drive.Changes
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetFile(arg1 string) (drive.File, error) {
+	fake.getFileMutex.Lock()
+	ret, specificReturn := fake.getFileReturnsOnCall[len(fake.getFileArgsForCall)]
+	fake.getFileArgsForCall = append(fake.getFileArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetFileStub
+	fakeReturns := fake.getFileReturns
+	fake.recordInvocation("GetFile", []interface{}{arg1})
+	fake.getFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetFileCallCount() int {
+	fake.getFileMutex.RLock()
+	defer fake.getFileMutex.RUnlock()
+	return len(fake.getFileArgsForCall)
+}
+
+func (fake *FakeReader) GetFileCalls(stub func(string) (drive.File, error)) {
+	fake.getFileMutex.Lock()
+	defer fake.getFileMutex.Unlock()
+	fake.GetFileStub = stub
+}
+
+func (fake *FakeReader) GetFileArgsForCall(i int) string {
+	fake.getFileMutex.RLock()
+	defer fake.getFileMutex.RUnlock()
+	argsForCall := fake.getFileArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) GetFileReturns(result1 drive.File, result2 error) {
+	fake.getFileMutex.Lock()
+	defer fake.getFileMutex.Unlock()
+	fake.GetFileStub = nil
+	fake.getFileReturns = struct {
+		result1 drive.File
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetFileReturnsOnCall(i int, result1 drive.File, result2 error) {
+	fake.getFileMutex.Lock()
+	defer fake.getFileMutex.Unlock()
+	fake.GetFileStub = nil
+	if fake.getFileReturnsOnCall == nil {
+		fake.getFileReturnsOnCall = make(map[int]struct {
+			result1 drive.File
+			result2 error
+		})
+	}
+	fake.getFileReturnsOnCall[i] = struct {
+		result1 drive.File
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetFiles(arg1 string) (drive.Files, error) {
+	fake.getFilesMutex.Lock()
+	ret, specificReturn := fake.getFilesReturnsOnCall[len(fake.getFilesArgsForCall)]
+	fake.getFilesArgsForCall = append(fake.getFilesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetFilesStub
+	fakeReturns := fake.getFilesReturns
+	fake.recordInvocation("GetFiles", []interface{}{arg1})
+	fake.getFilesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetFilesCallCount() int {
+	fake.getFilesMutex.RLock()
+	defer fake.getFilesMutex.RUnlock()
+	return len(fake.getFilesArgsForCall)
+}
+
+func (fake *FakeReader) GetFilesCalls(stub func(string) (drive.Files, error)) {
+	fake.getFilesMutex.Lock()
+	defer fake.getFilesMutex.Unlock()
+	fake.GetFilesStub = stub
+}
+
+func (fake *FakeReader) GetFilesArgsForCall(i int) string {
+	fake.getFilesMutex.RLock()
+	defer fake.getFilesMutex.RUnlock()
+	argsForCall := fake.getFilesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) GetFilesReturns(result1 drive.Files, result2 error) {
+	fake.getFilesMutex.Lock()
+	defer fake.getFilesMutex.Unlock()
+	fake.GetFilesStub = nil
+	fake.getFilesReturns = struct {
+		result1 drive.Files
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetFilesReturnsOnCall(i int, result1 drive.Files, result2 error) {
+	fake.getFilesMutex.Lock()
+	defer fake.getFilesMutex.Unlock()
+	fake.GetFilesStub = nil
+	if fake.getFilesReturnsOnCall == nil {
+		fake.getFilesReturnsOnCall = make(map[int]struct {
+			result1 drive.Files
+			result2 error
+		})
+	}
+	fake.getFilesReturnsOnCall[i] = struct {
+		result1 drive.Files
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetStartPageToken() (string, error) {
+	fake.getStartPageTokenMutex.Lock()
+	ret, specificReturn := fake.getStartPageTokenReturnsOnCall[len(fake.getStartPageTokenArgsForCall)]
+	fake.getStartPageTokenArgsForCall = append(fake.getStartPageTokenArgsForCall, struct {
+	}{})
+	stub := fake.GetStartPageTokenStub
+	fakeReturns := fake.getStartPageTokenReturns
+	fake.recordInvocation("GetStartPageToken", []interface{}{})
+	fake.getStartPageTokenMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetStartPageTokenCallCount() int {
+	fake.getStartPageTokenMutex.RLock()
+	defer fake.getStartPageTokenMutex.RUnlock()
+	return len(fake.getStartPageTokenArgsForCall)
+}
+
+func (fake *FakeReader) GetStartPageTokenCalls(stub func() (string, error)) {
+	fake.getStartPageTokenMutex.Lock()
+	defer fake.getStartPageTokenMutex.Unlock()
+	fake.GetStartPageTokenStub = stub
+}
+
+func (fake *FakeReader) GetStartPageTokenReturns(result1 string, result2 error) {
+	fake.getStartPageTokenMutex.Lock()
+	defer fake.getStartPageTokenMutex.Unlock()
+	fake.GetStartPageTokenStub = nil
+	fake.getStartPageTokenReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetStartPageTokenReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getStartPageTokenMutex.Lock()
+	defer fake.getStartPageTokenMutex.Unlock()
+	fake.GetStartPageTokenStub = nil
+	if fake.getStartPageTokenReturnsOnCall == nil {
+		fake.getStartPageTokenReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getStartPageTokenReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.downloadFileMutex.RLock()
+	defer fake.downloadFileMutex.RUnlock()
+	fake.getChangesMutex.RLock()
+	defer fake.getChangesMutex.RUnlock()
+	fake.getFileMutex.RLock()
+	defer fake.getFileMutex.RUnlock()
+	fake.getFilesMutex.RLock()
+	defer fake.getFilesMutex.RUnlock()
+	fake.getStartPageTokenMutex.RLock()
+	defer fake.getStartPageTokenMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ drive.Reader = new(FakeReader)
@@ -0,0 +1,200 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package drivefakes
+
+import (
+	"google-backup/internal/drive"
+	"sync"
+)
+
+type FakeRepository struct {
+	GetFolderStub        func(string, string) ([]byte, error)
+	getFolderMutex       sync.RWMutex
+	getFolderArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getFolderReturns struct {
+		result1 []byte
+		result2 error
+	}
+	getFolderReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	SaveFolderStub        func(string, string, []byte) error
+	saveFolderMutex       sync.RWMutex
+	saveFolderArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}
+	saveFolderReturns struct {
+		result1 error
+	}
+	saveFolderReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRepository) GetFolder(arg1 string, arg2 string) ([]byte, error) {
+	fake.getFolderMutex.Lock()
+	ret, specificReturn := fake.getFolderReturnsOnCall[len(fake.getFolderArgsForCall)]
+	fake.getFolderArgsForCall = append(fake.getFolderArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetFolderStub
+	fakeReturns := fake.getFolderReturns
+	fake.recordInvocation("GetFolder", []interface{}{arg1, arg2})
+	fake.getFolderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepository) GetFolderCallCount() int {
+	fake.getFolderMutex.RLock()
+	defer fake.getFolderMutex.RUnlock()
+	return len(fake.getFolderArgsForCall)
+}
+
+func (fake *FakeRepository) GetFolderCalls(stub func(string, string) ([]byte, error)) {
+	fake.getFolderMutex.Lock()
+	defer fake.getFolderMutex.Unlock()
+	fake.GetFolderStub = stub
+}
+
+func (fake *FakeRepository) GetFolderArgsForCall(i int) (string, string) {
+	fake.getFolderMutex.RLock()
+	defer fake.getFolderMutex.RUnlock()
+	argsForCall := fake.getFolderArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRepository) GetFolderReturns(result1 []byte, result2 error) {
+	fake.getFolderMutex.Lock()
+	defer fake.getFolderMutex.Unlock()
+	fake.GetFolderStub = nil
+	fake.getFolderReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetFolderReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.getFolderMutex.Lock()
+	defer fake.getFolderMutex.Unlock()
+	fake.GetFolderStub = nil
+	if fake.getFolderReturnsOnCall == nil {
+		fake.getFolderReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.getFolderReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) SaveFolder(arg1 string, arg2 string, arg3 []byte) error {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.saveFolderMutex.Lock()
+	ret, specificReturn := fake.saveFolderReturnsOnCall[len(fake.saveFolderArgsForCall)]
+	fake.saveFolderArgsForCall = append(fake.saveFolderArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.SaveFolderStub
+	fakeReturns := fake.saveFolderReturns
+	fake.recordInvocation("SaveFolder", []interface{}{arg1, arg2, arg3Copy})
+	fake.saveFolderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepository) SaveFolderCallCount() int {
+	fake.saveFolderMutex.RLock()
+	defer fake.saveFolderMutex.RUnlock()
+	return len(fake.saveFolderArgsForCall)
+}
+
+func (fake *FakeRepository) SaveFolderCalls(stub func(string, string, []byte) error) {
+	fake.saveFolderMutex.Lock()
+	defer fake.saveFolderMutex.Unlock()
+	fake.SaveFolderStub = stub
+}
+
+func (fake *FakeRepository) SaveFolderArgsForCall(i int) (string, string, []byte) {
+	fake.saveFolderMutex.RLock()
+	defer fake.saveFolderMutex.RUnlock()
+	argsForCall := fake.saveFolderArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRepository) SaveFolderReturns(result1 error) {
+	fake.saveFolderMutex.Lock()
+	defer fake.saveFolderMutex.Unlock()
+	fake.SaveFolderStub = nil
+	fake.saveFolderReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) SaveFolderReturnsOnCall(i int, result1 error) {
+	fake.saveFolderMutex.Lock()
+	defer fake.saveFolderMutex.Unlock()
+	fake.SaveFolderStub = nil
+	if fake.saveFolderReturnsOnCall == nil {
+		fake.saveFolderReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveFolderReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getFolderMutex.RLock()
+	defer fake.getFolderMutex.RUnlock()
+	fake.saveFolderMutex.RLock()
+	defer fake.saveFolderMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ drive.Repository = new(FakeRepository)
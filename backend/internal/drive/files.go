@@ -0,0 +1,114 @@
+package drive
+
+import "strings"
+
+const (
+	FolderMimeType = "application/vnd.google-apps.folder"
+
+	googleAppsMimeTypePrefix = "application/vnd.google-apps."
+)
+
+type filesListResponseBody struct {
+	Files         []File `json:"files"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+type File struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	MimeType     string   `json:"mimeType"`
+	Parents      []string `json:"parents"`
+	Md5Checksum  string   `json:"md5Checksum"`
+	Size         string   `json:"size"`
+	CreatedTime  string   `json:"createdTime"`
+	ModifiedTime string   `json:"modifiedTime"`
+	Trashed      bool     `json:"trashed"`
+}
+
+type Files struct {
+	Items         []File
+	NextPageToken string
+}
+
+type changesListResponseBody struct {
+	Changes           []Change `json:"changes"`
+	NextPageToken     string   `json:"nextPageToken"`
+	NewStartPageToken string   `json:"newStartPageToken"`
+}
+
+type startPageTokenResponseBody struct {
+	StartPageToken string `json:"startPageToken"`
+}
+
+// Removed means the file was deleted permanently or the account lost access to it,
+// the file is not set in this case
+type Change struct {
+	FileId  string `json:"fileId"`
+	Removed bool   `json:"removed"`
+	File    *File  `json:"file"`
+}
+
+// NewStartPageToken is set on the last page only and is used to request the next changes
+type Changes struct {
+	Items             []Change
+	NextPageToken     string
+	NewStartPageToken string
+}
+
+type exportFormat struct {
+	MimeType  string
+	Extension string
+}
+
+// Google Docs files can't be downloaded as is, they are exported to these formats.
+// Other Google Apps files (forms, sites, shortcuts, etc.) are not backed up
+var exportFormats = map[string]exportFormat{
+	googleAppsMimeTypePrefix + "document": {
+		MimeType:  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		Extension: ".docx",
+	},
+	googleAppsMimeTypePrefix + "spreadsheet": {
+		MimeType:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		Extension: ".xlsx",
+	},
+	googleAppsMimeTypePrefix + "presentation": {
+		MimeType:  "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		Extension: ".pptx",
+	},
+	googleAppsMimeTypePrefix + "drawing": {
+		MimeType:  "application/pdf",
+		Extension: ".pdf",
+	},
+	googleAppsMimeTypePrefix + "script": {
+		MimeType:  "application/vnd.google-apps.script+json",
+		Extension: ".json",
+	},
+}
+
+func (f File) IsFolder() bool {
+	return f.MimeType == FolderMimeType
+}
+
+func (f File) IsExportable() bool {
+	_, ok := exportFormats[f.MimeType]
+
+	return ok
+}
+
+func (f File) IsDownloadable() bool {
+	if f.IsExportable() {
+		return true
+	}
+
+	return !strings.HasPrefix(f.MimeType, googleAppsMimeTypePrefix)
+}
+
+// File name on disk, exported files get the extension of the export format
+func (f File) FileName() string {
+	format, ok := exportFormats[f.MimeType]
+	if !ok {
+		return f.Name
+	}
+
+	return f.Name + format.Extension
+}
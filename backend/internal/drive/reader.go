@@ -0,0 +1,216 @@
+package drive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	apiUrl     = "https://www.googleapis.com/drive/v3"
+	fileFields = "id,name,mimeType,parents,md5Checksum,size,createdTime,modifiedTime,trashed"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Reader
+type Reader interface {
+	GetFiles(nextPageToken string) (Files, error)
+	GetFile(fileId string) (File, error)
+	GetStartPageToken() (string, error)
+	GetChanges(pageToken string) (Changes, error)
+	// The caller must close the returned reader
+	DownloadFile(file File) (io.ReadCloser, error)
+}
+
+type TooManyRequestsError struct {
+	error
+}
+
+type NotFoundError struct {
+	error
+}
+
+type NotOkRequestError struct {
+	error
+	StatusCode int
+}
+
+type reader struct {
+	httpClient *http.Client
+}
+
+func NewReader(httpClient *http.Client) (*reader, error) {
+	return &reader{
+		httpClient: httpClient,
+	}, nil
+}
+
+func (r *reader) GetFiles(nextPageToken string) (Files, error) {
+	query := url.Values{}
+	query.Set("pageSize", "1000")
+	query.Set("q", "trashed = false")
+	query.Set("fields", "nextPageToken,files("+fileFields+")")
+
+	if nextPageToken != "" {
+		query.Set("pageToken", nextPageToken)
+	}
+
+	var responseBody filesListResponseBody
+
+	err := r.get(apiUrl+"/files?"+query.Encode(), &responseBody)
+	if err != nil {
+		return Files{}, fmt.Errorf("files list request: %w", err)
+	}
+
+	return Files{
+		Items:         responseBody.Files,
+		NextPageToken: responseBody.NextPageToken,
+	}, nil
+}
+
+func (r *reader) GetFile(fileId string) (File, error) {
+	var file File
+
+	err := r.get(apiUrl+"/files/"+url.PathEscape(fileId)+"?fields="+url.QueryEscape(fileFields), &file)
+	if err != nil {
+		return File{}, fmt.Errorf("file get request: %w", err)
+	}
+
+	return file, nil
+}
+
+func (r *reader) GetStartPageToken() (string, error) {
+	var responseBody startPageTokenResponseBody
+
+	err := r.get(apiUrl+"/changes/startPageToken", &responseBody)
+	if err != nil {
+		return "", fmt.Errorf("start page token request: %w", err)
+	}
+
+	return responseBody.StartPageToken, nil
+}
+
+func (r *reader) GetChanges(pageToken string) (Changes, error) {
+	query := url.Values{}
+	query.Set("pageToken", pageToken)
+	query.Set("pageSize", "1000")
+	query.Set("includeRemoved", "true")
+	query.Set("fields", "nextPageToken,newStartPageToken,changes(fileId,removed,file("+fileFields+"))")
+
+	var responseBody changesListResponseBody
+
+	err := r.get(apiUrl+"/changes?"+query.Encode(), &responseBody)
+	if err != nil {
+		return Changes{}, fmt.Errorf("changes list request: %w", err)
+	}
+
+	return Changes{
+		Items:             responseBody.Changes,
+		NextPageToken:     responseBody.NextPageToken,
+		NewStartPageToken: responseBody.NewStartPageToken,
+	}, nil
+}
+
+func (r *reader) DownloadFile(file File) (io.ReadCloser, error) {
+	downloadUrl := apiUrl + "/files/" + url.PathEscape(file.ID) + "?alt=media"
+
+	if format, ok := exportFormats[file.MimeType]; ok {
+		downloadUrl = apiUrl + "/files/" + url.PathEscape(file.ID) + "/export?mimeType=" + url.QueryEscape(format.MimeType)
+	}
+
+	resp, err := r.httpClient.Get(downloadUrl)
+	if err != nil {
+		return nil, fmt.Errorf("download file request: %w", err)
+	}
+
+	err = r.checkResponse(resp)
+	if err != nil {
+		resp.Body.Close()
+
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (r *reader) get(url string, responseBody any) error {
+	resp, err := r.httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	err = r.checkResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(responseBody)
+	if err != nil {
+		return fmt.Errorf("decode response body: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reader) checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return TooManyRequestsError{errors.New("too many requests")}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return NotFoundError{fmt.Errorf(string(body))}
+	}
+
+	// drive reports most of its rate limits as forbidden
+	if resp.StatusCode == http.StatusForbidden && rateLimited(body) {
+		return TooManyRequestsError{fmt.Errorf(string(body))}
+	}
+
+	return NotOkRequestError{error: fmt.Errorf(string(body)), StatusCode: resp.StatusCode}
+}
+
+// Reasons of forbidden responses which go away once the quota is refilled
+var rateLimitReasons = map[string]bool{
+	"userRateLimitExceeded":    true,
+	"rateLimitExceeded":        true,
+	"dailyLimitExceeded":       true,
+	"sharingRateLimitExceeded": true,
+	"downloadQuotaExceeded":    true,
+}
+
+// Errors are reported as {"error": {"errors": [{"reason": "userRateLimitExceeded", ...}], ...}}
+func rateLimited(body []byte) bool {
+	var response struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return false
+	}
+
+	for _, e := range response.Error.Errors {
+		if rateLimitReasons[e.Reason] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+package drive
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResponse(t *testing.T) {
+	response := func(statusCode int, body string) *http.Response {
+		return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	r := &reader{}
+
+	t.Run("rate limit reported as forbidden", func(t *testing.T) {
+		for _, reason := range []string{"userRateLimitExceeded", "rateLimitExceeded", "downloadQuotaExceeded"} {
+			err := r.checkResponse(response(http.StatusForbidden, `{"error": {"errors": [{"domain": "usageLimits", "reason": "`+reason+`", "message": "Rate Limit Exceeded"}], "code": 403, "message": "Rate Limit Exceeded"}}`))
+
+			assert.True(t, errors.As(err, &TooManyRequestsError{}), reason)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		err := r.checkResponse(response(http.StatusForbidden, `{"error": {"errors": [{"domain": "global", "reason": "insufficientFilePermissions"}], "code": 403}}`))
+
+		var notOkErr NotOkRequestError
+		assert.True(t, errors.As(err, &notOkErr))
+		assert.Equal(t, http.StatusForbidden, notOkErr.StatusCode)
+	})
+
+	t.Run("forbidden without json", func(t *testing.T) {
+		err := r.checkResponse(response(http.StatusForbidden, `forbidden`))
+
+		assert.False(t, errors.As(err, &TooManyRequestsError{}))
+	})
+
+	t.Run("too many requests", func(t *testing.T) {
+		err := r.checkResponse(response(http.StatusTooManyRequests, ``))
+
+		assert.True(t, errors.As(err, &TooManyRequestsError{}))
+	})
+}
@@ -0,0 +1,61 @@
+package drive
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+const foldersBucketName = "drive_folders"
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Repository
+type Repository interface {
+	SaveFolder(email, folderId string, data []byte) error
+	GetFolder(email, folderId string) ([]byte, error)
+}
+
+type repository struct {
+	db *bbolt.DB
+}
+
+func NewRepository(db *bbolt.DB) repository {
+	return repository{db: db}
+}
+
+func (r repository) SaveFolder(email, folderId string, data []byte) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
+		if err != nil {
+			return fmt.Errorf("create bucket: %w", err)
+		}
+
+		foldersBucket, err := bucket.CreateBucketIfNotExists([]byte(foldersBucketName))
+		if err != nil {
+			return fmt.Errorf("create drive folders bucket: %w", err)
+		}
+
+		return foldersBucket.Put([]byte(folderId), data)
+	})
+}
+
+func (r repository) GetFolder(email, folderId string) ([]byte, error) {
+	var data []byte
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		foldersBucket := bucket.Bucket([]byte(foldersBucketName))
+		if foldersBucket == nil {
+			return nil
+		}
+
+		data = foldersBucket.Get([]byte(folderId))
+
+		return nil
+	})
+
+	return data, err
+}
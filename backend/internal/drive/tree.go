@@ -0,0 +1,110 @@
+package drive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Limits walking up the parents chain in case of broken or cyclic parent references
+const maxFolderDepth = 100
+
+// Keeps folders of every account to build folder paths of files without extra API requests
+type Tree interface {
+	SaveFolder(email string, folder File) error
+	GetFolderPath(email string, reader Reader, file File) (string, error)
+}
+
+type tree struct {
+	repository Repository
+}
+
+func NewTree(repository Repository) tree {
+	return tree{repository: repository}
+}
+
+func (t tree) SaveFolder(email string, folder File) error {
+	folderJson, err := json.Marshal(folder)
+	if err != nil {
+		return fmt.Errorf("marshal folder: %w", err)
+	}
+
+	return t.repository.SaveFolder(email, folder.ID, folderJson)
+}
+
+// Returns slash separated names of the file parent folders starting from the top folder ("My Drive").
+// Folders that were not saved during a scan are requested from the API and saved.
+// Files shared with the account have no accessible parents and get an empty path
+func (t tree) GetFolderPath(email string, reader Reader, file File) (string, error) {
+	var names []string
+
+	parents := file.Parents
+
+	for len(parents) > 0 && len(names) < maxFolderDepth {
+		folder, found, err := t.getFolder(email, reader, parents[0])
+		if err != nil {
+			return "", fmt.Errorf("get folder: %w", err)
+		}
+
+		if !found {
+			break
+		}
+
+		names = append([]string{SanitizeName(folder.Name)}, names...)
+		parents = folder.Parents
+	}
+
+	return path.Join(names...), nil
+}
+
+func (t tree) getFolder(email string, reader Reader, folderId string) (File, bool, error) {
+	folderJson, err := t.repository.GetFolder(email, folderId)
+	if err != nil {
+		return File{}, false, fmt.Errorf("get folder from repository: %w", err)
+	}
+
+	if folderJson != nil {
+		var folder File
+		err = json.Unmarshal(folderJson, &folder)
+		if err != nil {
+			return File{}, false, fmt.Errorf("unmarshal folder: %w", err)
+		}
+
+		return folder, true, nil
+	}
+
+	folder, err := reader.GetFile(folderId)
+	if err != nil {
+		if errors.As(err, &NotFoundError{}) {
+			return File{}, false, nil
+		}
+
+		return File{}, false, fmt.Errorf("get folder from api: %w", err)
+	}
+
+	err = t.SaveFolder(email, folder)
+	if err != nil {
+		return File{}, false, fmt.Errorf("save folder: %w", err)
+	}
+
+	return folder, true, nil
+}
+
+// Drive names may contain path separators
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0 {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+
+	return name
+}
@@ -0,0 +1,159 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Key derivation functions, recorded in the header of every file
+const (
+	KdfScrypt  byte = 1
+	KdfKeyFile byte = 2
+)
+
+const (
+	keySize  = 32
+	SaltSize = 16
+
+	// Key files are random bytes, shorter files are rejected
+	minKeyFileSize = 32
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Master key of the files encrypted with the same passphrase or key file and salt
+type Key struct {
+	kdf    byte
+	salt   []byte
+	master []byte
+}
+
+// Derives keys from the passphrase or the key file for the salts of the files, derived keys are cached
+type Keyring struct {
+	kdf    byte
+	secret []byte
+	mutex  *sync.Mutex
+	keys   map[string]Key
+}
+
+// The key file takes precedence over the passphrase
+func NewKeyring(passphrase string, keyFile string) (*Keyring, error) {
+	keyring := &Keyring{kdf: KdfScrypt, secret: []byte(passphrase), mutex: &sync.Mutex{}, keys: map[string]Key{}}
+
+	if keyFile != "" {
+		secret, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
+		}
+
+		if len(secret) < minKeyFileSize {
+			return nil, fmt.Errorf("key file has %d bytes, at least %d are needed", len(secret), minKeyFileSize)
+		}
+
+		keyring.kdf = KdfKeyFile
+		keyring.secret = secret
+	}
+
+	if len(keyring.secret) == 0 {
+		return nil, fmt.Errorf("passphrase or key file is required")
+	}
+
+	return keyring, nil
+}
+
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+
+	_, err := io.ReadFull(rand.Reader, salt)
+	if err != nil {
+		return nil, fmt.Errorf("read random salt: %w", err)
+	}
+
+	return salt, nil
+}
+
+// Key derivation function of the keys of new files
+func (k *Keyring) Kdf() byte {
+	return k.kdf
+}
+
+// Fails if the file was encrypted with the other kind of secret
+func (k *Keyring) Key(kdf byte, salt []byte) (Key, error) {
+	if kdf != k.kdf {
+		return Key{}, fmt.Errorf("%w: file was encrypted with a %s", ErrWrongKey, kdfName(kdf))
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	key, ok := k.keys[string(salt)]
+	if ok {
+		return key, nil
+	}
+
+	key = Key{kdf: kdf, salt: append([]byte(nil), salt...)}
+
+	switch kdf {
+	case KdfScrypt:
+		master, err := scrypt.Key(k.secret, salt, scryptN, scryptR, scryptP, keySize)
+		if err != nil {
+			return Key{}, fmt.Errorf("derive key: %w", err)
+		}
+
+		key.master = master
+	case KdfKeyFile:
+		key.master = expand(k.secret, salt, "master key")
+	}
+
+	k.keys[string(salt)] = key
+
+	return key, nil
+}
+
+// Keyed hash of the path, files are named by it when their names are hidden
+func (k Key) NameHash(pathName string) string {
+	mac := hmac.New(sha256.New, expand(k.master, nil, "names"))
+	mac.Write([]byte(pathName))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Value saved with the settings to detect a changed passphrase or key file, it doesn't reveal the key
+func (k Key) Check() string {
+	return hex.EncodeToString(expand(k.master, nil, "check")[:8])
+}
+
+func (k Key) fileKey(fileSalt []byte) []byte {
+	return expand(k.master, fileSalt, "file key")
+}
+
+func expand(secret []byte, salt []byte, info string) []byte {
+	key := make([]byte, keySize)
+
+	// reading less than 255 hashes of hkdf output never fails
+	io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key)
+
+	return key
+}
+
+func kdfName(kdf byte) string {
+	switch kdf {
+	case KdfScrypt:
+		return "passphrase"
+	case KdfKeyFile:
+		return "key file"
+	}
+
+	return fmt.Sprintf("unknown key derivation %d", kdf)
+}
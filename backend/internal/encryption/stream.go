@@ -0,0 +1,331 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Recorded in the file meta of encrypted files
+const Scheme = "aes-256-gcm-stream-v1"
+
+// Files start with a header of the magic, the key derivation function, the salt of the key and the random salt of the file.
+// The key of the file is derived from the key and the salt of the file, so nonces are counters of the chunks.
+// The path of the file follows in a padded block, then the content in chunks, the last chunk is marked in its nonce.
+// The header is authenticated with every block, so it can't be changed either
+const (
+	chunkSize     = 64 << 10
+	tagSize       = 16
+	nameBlockSize = 1024
+	headerSize    = len(magic) + 1 + SaltSize + SaltSize
+
+	// Last byte of the nonce
+	flagChunk      = 0
+	flagFinalChunk = 1
+	flagName       = 2
+)
+
+const magic = "GBAKENC1"
+
+var (
+	// The file doesn't start with the header of encrypted files
+	ErrNotEncrypted = errors.New("file is not encrypted")
+	ErrWrongKey     = errors.New("wrong encryption key")
+	ErrDamaged      = errors.New("encrypted file is damaged")
+	// The file ends before its last chunk, e.g. the write was interrupted
+	ErrTruncated = fmt.Errorf("%w: file is cut off", ErrDamaged)
+)
+
+// Encrypts the written data in chunks, the last chunk is written on close
+type writer struct {
+	out     io.WriteCloser
+	aead    cipher.AEAD
+	header  []byte
+	counter uint64
+	chunk   []byte
+	sealed  []byte
+	err     error
+}
+
+// Decrypts the content of a file chunk by chunk, every chunk is authenticated before it is returned
+type Reader struct {
+	in       *bufio.Reader
+	aead     cipher.AEAD
+	header   []byte
+	pathName string
+	counter  uint64
+	sealed   []byte
+	chunk    []byte
+	// Rest of the chunk which wasn't read yet
+	plain []byte
+	done  bool
+	err   error
+}
+
+// Writes the header and the path, the path is read back by restores of files with hidden names
+func NewWriter(out io.WriteCloser, key Key, pathName string) (io.WriteCloser, error) {
+	if len(pathName) > nameBlockSize-2 {
+		return nil, fmt.Errorf("path has %d bytes, at most %d can be encrypted", len(pathName), nameBlockSize-2)
+	}
+
+	if len(key.salt) != SaltSize {
+		return nil, fmt.Errorf("key salt has %d bytes, expected %d", len(key.salt), SaltSize)
+	}
+
+	fileSalt, err := NewSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, key.kdf)
+	header = append(header, key.salt...)
+	header = append(header, fileSalt...)
+
+	aead, err := newAead(key.fileKey(fileSalt))
+	if err != nil {
+		return nil, err
+	}
+
+	nameBlock := make([]byte, nameBlockSize)
+	binary.BigEndian.PutUint16(nameBlock, uint16(len(pathName)))
+	copy(nameBlock[2:], pathName)
+
+	_, err = out.Write(aead.Seal(append([]byte(nil), header...), nonce(0, flagName), nameBlock, header))
+	if err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+
+	return &writer{
+		out:     out,
+		aead:    aead,
+		header:  header,
+		counter: 1,
+		chunk:   make([]byte, 0, chunkSize),
+		sealed:  make([]byte, 0, chunkSize+tagSize),
+	}, nil
+}
+
+// Reads the header and the path, the key is looked up by the key derivation function and the salt of the header
+func NewReader(in io.Reader, keyFor func(kdf byte, salt []byte) (Key, error)) (*Reader, error) {
+	header := make([]byte, headerSize)
+
+	_, err := io.ReadFull(in, header)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrNotEncrypted
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	if !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		return nil, ErrNotEncrypted
+	}
+
+	salt := header[len(magic)+1 : len(magic)+1+SaltSize]
+	fileSalt := header[len(magic)+1+SaltSize:]
+
+	key, err := keyFor(header[len(magic)], salt)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAead(key.fileKey(fileSalt))
+	if err != nil {
+		return nil, err
+	}
+
+	sealedName := make([]byte, nameBlockSize+tagSize)
+
+	_, err = io.ReadFull(in, sealedName)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrTruncated
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read path: %w", err)
+	}
+
+	nameBlock, err := aead.Open(nil, nonce(0, flagName), sealedName, header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: can't decrypt the path of the file", ErrWrongKey)
+	}
+
+	nameLength := int(binary.BigEndian.Uint16(nameBlock))
+	if nameLength > nameBlockSize-2 {
+		return nil, fmt.Errorf("%w: path length %d", ErrDamaged, nameLength)
+	}
+
+	return &Reader{
+		in:       bufio.NewReaderSize(in, chunkSize+tagSize),
+		aead:     aead,
+		header:   header,
+		pathName: string(nameBlock[2 : 2+nameLength]),
+		counter:  1,
+		sealed:   make([]byte, chunkSize+tagSize),
+		chunk:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// Size of the content of an encrypted file of the given size
+func PlainSize(size int64) int64 {
+	size -= int64(headerSize + nameBlockSize + tagSize)
+	if size <= 0 {
+		return 0
+	}
+
+	chunks := size / (chunkSize + tagSize)
+	rest := size % (chunkSize + tagSize)
+
+	return chunks*chunkSize + max(rest-tagSize, 0)
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	written := 0
+
+	for len(p) > 0 {
+		// the last chunk is sealed on close, so a full chunk is only sealed once more data follows
+		if len(w.chunk) == chunkSize {
+			w.err = w.seal(flagChunk)
+			if w.err != nil {
+				return written, w.err
+			}
+		}
+
+		n := min(chunkSize-len(w.chunk), len(p))
+		w.chunk = append(w.chunk, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Writes the last chunk and closes the underlying writer, the written data is a complete file even after a failed copy
+func (w *writer) Close() error {
+	if w.err == nil {
+		w.err = w.seal(flagFinalChunk)
+	}
+
+	err := w.out.Close()
+	if w.err != nil {
+		return w.err
+	}
+
+	return err
+}
+
+func (w *writer) seal(flag byte) error {
+	w.sealed = w.aead.Seal(w.sealed[:0], nonce(w.counter, flag), w.chunk, w.header)
+	w.counter++
+	w.chunk = w.chunk[:0]
+
+	_, err := w.out.Write(w.sealed)
+	if err != nil {
+		return fmt.Errorf("write chunk: %w", err)
+	}
+
+	return nil
+}
+
+// Path the file was written to
+func (r *Reader) PathName() string {
+	return r.pathName
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.err = r.next()
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+
+	return n, nil
+}
+
+func (r *Reader) next() error {
+	n, err := io.ReadFull(r.in, r.sealed)
+	if errors.Is(err, io.EOF) {
+		return ErrTruncated
+	}
+
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("read chunk: %w", err)
+	}
+
+	last := err != nil
+	if !last {
+		_, err = r.in.Peek(1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("read chunk: %w", err)
+		}
+
+		last = err != nil
+	}
+
+	sealed := r.sealed[:n]
+
+	if !last {
+		r.plain, err = r.aead.Open(r.chunk[:0], nonce(r.counter, flagChunk), sealed, r.header)
+		if err != nil {
+			return fmt.Errorf("%w: chunk %d", ErrDamaged, r.counter)
+		}
+
+		r.counter++
+
+		return nil
+	}
+
+	r.plain, err = r.aead.Open(r.chunk[:0], nonce(r.counter, flagFinalChunk), sealed, r.header)
+	if err == nil {
+		r.done = true
+
+		return nil
+	}
+
+	// the last piece of an interrupted write is short or not marked as the last chunk
+	return ErrTruncated
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new aes cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("new gcm: %w", err)
+	}
+
+	return aead, nil
+}
+
+// Counter of the chunk followed by the flag
+func nonce(counter uint64, flag byte) []byte {
+	result := make([]byte, 12)
+	binary.BigEndian.PutUint64(result[3:11], counter)
+	result[11] = flag
+
+	return result
+}
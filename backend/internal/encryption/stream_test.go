@@ -0,0 +1,221 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bufferCloser struct {
+	*bytes.Buffer
+}
+
+func (b bufferCloser) Close() error {
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	keyring, err := NewKeyring("secret", "")
+	require.NoError(t, err)
+
+	salt, err := NewSalt()
+	require.NoError(t, err)
+
+	key, err := keyring.Key(keyring.Kdf(), salt)
+	require.NoError(t, err)
+
+	encrypt := func(t *testing.T, plain []byte, pathName string) []byte {
+		out := bufferCloser{Buffer: &bytes.Buffer{}}
+
+		writer, err := NewWriter(out, key, pathName)
+		require.NoError(t, err)
+
+		_, err = writer.Write(plain)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		return out.Bytes()
+	}
+
+	decrypt := func(encrypted []byte, keyring *Keyring) ([]byte, string, error) {
+		reader, err := NewReader(bytes.NewReader(encrypted), keyring.Key)
+		if err != nil {
+			return nil, "", err
+		}
+
+		plain, err := io.ReadAll(reader)
+
+		return plain, reader.PathName(), err
+	}
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 5} {
+			plain := make([]byte, size)
+			_, err := rand.Read(plain)
+			require.NoError(t, err)
+
+			encrypted := encrypt(t, plain, "user@gmail.com/photos/2023/01/IMG_0001.JPG")
+
+			decrypted, pathName, err := decrypt(encrypted, keyring)
+
+			assert.NoError(t, err, "size %d", size)
+			assert.Equal(t, plain, decrypted, "size %d", size)
+			assert.Equal(t, "user@gmail.com/photos/2023/01/IMG_0001.JPG", pathName)
+			assert.Equal(t, int64(size), PlainSize(int64(len(encrypted))), "size %d", size)
+		}
+	})
+
+	t.Run("decrypt with writes of any size", func(t *testing.T) {
+		plain := bytes.Repeat([]byte("0123456789"), chunkSize/4)
+		out := bufferCloser{Buffer: &bytes.Buffer{}}
+
+		writer, err := NewWriter(out, key, "file")
+		require.NoError(t, err)
+
+		for rest := plain; len(rest) > 0; {
+			n := min(len(rest), 7777)
+			_, err = writer.Write(rest[:n])
+			require.NoError(t, err)
+			rest = rest[n:]
+		}
+
+		require.NoError(t, writer.Close())
+
+		decrypted, _, err := decrypt(out.Bytes(), keyring)
+
+		assert.NoError(t, err)
+		assert.Equal(t, plain, decrypted)
+	})
+
+	t.Run("decrypt with wrong passphrase", func(t *testing.T) {
+		otherKeyring, err := NewKeyring("other", "")
+		require.NoError(t, err)
+
+		_, _, err = decrypt(encrypt(t, []byte("content"), "file"), otherKeyring)
+
+		assert.ErrorIs(t, err, ErrWrongKey)
+	})
+
+	t.Run("decrypt with key file", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "key")
+		require.NoError(t, os.WriteFile(keyFile, bytes.Repeat([]byte{1}, minKeyFileSize), 0600))
+
+		keyFileKeyring, err := NewKeyring("secret", keyFile)
+		require.NoError(t, err)
+
+		_, _, err = decrypt(encrypt(t, []byte("content"), "file"), keyFileKeyring)
+
+		assert.ErrorIs(t, err, ErrWrongKey)
+	})
+
+	t.Run("decrypt plain file", func(t *testing.T) {
+		_, _, err := decrypt(bytes.Repeat([]byte("plain"), 100), keyring)
+
+		assert.ErrorIs(t, err, ErrNotEncrypted)
+	})
+
+	t.Run("decrypt truncated file", func(t *testing.T) {
+		plain := make([]byte, 2*chunkSize+100)
+		encrypted := encrypt(t, plain, "file")
+
+		for _, size := range []int{
+			headerSize + nameBlockSize + tagSize,
+			headerSize + nameBlockSize + tagSize + chunkSize + tagSize,
+			headerSize + nameBlockSize + tagSize + chunkSize + tagSize + 100,
+			len(encrypted) - 1,
+		} {
+			_, _, err := decrypt(encrypted[:size], keyring)
+
+			assert.ErrorIs(t, err, ErrTruncated, "size %d", size)
+			assert.ErrorIs(t, err, ErrDamaged, "size %d", size)
+		}
+	})
+
+	t.Run("decrypt changed file", func(t *testing.T) {
+		encrypted := encrypt(t, make([]byte, 2*chunkSize), "file")
+		encrypted[headerSize+nameBlockSize+tagSize+10] ^= 1
+
+		_, _, err := decrypt(encrypted, keyring)
+
+		assert.ErrorIs(t, err, ErrDamaged)
+	})
+
+	t.Run("decrypt file with changed header", func(t *testing.T) {
+		encrypted := encrypt(t, []byte("content"), "file")
+		encrypted[headerSize-1] ^= 1
+
+		_, _, err := decrypt(encrypted, keyring)
+
+		assert.ErrorIs(t, err, ErrWrongKey)
+	})
+
+	t.Run("encrypt too long path", func(t *testing.T) {
+		_, err := NewWriter(bufferCloser{Buffer: &bytes.Buffer{}}, key, string(make([]byte, nameBlockSize)))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestKeys(t *testing.T) {
+	salt := make([]byte, SaltSize)
+
+	keyring, err := NewKeyring("secret", "")
+	require.NoError(t, err)
+
+	key, err := keyring.Key(KdfScrypt, salt)
+	require.NoError(t, err)
+
+	t.Run("hash names", func(t *testing.T) {
+		assert.Equal(t, key.NameHash("user@gmail.com/photos/a.jpg"), key.NameHash("user@gmail.com/photos/a.jpg"))
+		assert.NotEqual(t, key.NameHash("user@gmail.com/photos/a.jpg"), key.NameHash("user@gmail.com/photos/b.jpg"))
+		assert.Len(t, key.NameHash("user@gmail.com/photos/a.jpg"), 64)
+	})
+
+	t.Run("hash names with other key", func(t *testing.T) {
+		otherKeyring, err := NewKeyring("other", "")
+		require.NoError(t, err)
+
+		otherKey, err := otherKeyring.Key(KdfScrypt, salt)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, key.NameHash("user@gmail.com/photos/a.jpg"), otherKey.NameHash("user@gmail.com/photos/a.jpg"))
+		assert.NotEqual(t, key.Check(), otherKey.Check())
+	})
+
+	t.Run("derive same key", func(t *testing.T) {
+		sameKeyring, err := NewKeyring("secret", "")
+		require.NoError(t, err)
+
+		sameKey, err := sameKeyring.Key(KdfScrypt, salt)
+		require.NoError(t, err)
+
+		assert.Equal(t, key.Check(), sameKey.Check())
+	})
+
+	t.Run("key of other derivation", func(t *testing.T) {
+		_, err := keyring.Key(KdfKeyFile, salt)
+
+		assert.ErrorIs(t, err, ErrWrongKey)
+	})
+
+	t.Run("short key file", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "key")
+		require.NoError(t, os.WriteFile(keyFile, []byte("short"), 0600))
+
+		_, err := NewKeyring("", keyFile)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("no passphrase", func(t *testing.T) {
+		_, err := NewKeyring("", "")
+
+		assert.Error(t, err)
+	})
+}
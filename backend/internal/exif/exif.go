@@ -0,0 +1,357 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	tagImageDescription   = 0x010e
+	tagMake               = 0x010f
+	tagModel              = 0x0110
+	tagExifIfdPointer     = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+
+	typeAscii = 2
+	typeLong  = 4
+
+	markerSoi  = 0xd8
+	markerEoi  = 0xd9
+	markerSos  = 0xda
+	markerApp0 = 0xe0
+	markerApp1 = 0xe1
+
+	dateTimeLayout = "2006:01:02 15:04:05"
+	// Segment length includes the two length bytes
+	maxSegmentLength = 0xffff
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+var ErrNotJpeg = errors.New("not a jpeg file")
+
+// Values written into the image, empty values are skipped
+type Tags struct {
+	DateTimeOriginal time.Time
+	ImageDescription string
+	Make             string
+	Model            string
+}
+
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+type ifdEntry struct {
+	tag   uint16
+	raw   []byte
+	value []byte
+}
+
+// Writes the tags into the EXIF segment of the jpeg, the segment is created if the image has none.
+// The description is replaced, the original date and the camera are written only if the image has none.
+// Existing data is never moved, new directories are appended to the EXIF data,
+// so offsets inside maker notes stay valid. Returns false if nothing had to be changed
+func EmbedJpeg(jpeg []byte, tags Tags) ([]byte, bool, error) {
+	if len(jpeg) < 4 || jpeg[0] != 0xff || jpeg[1] != markerSoi {
+		return nil, false, ErrNotJpeg
+	}
+
+	insertAt := 2
+	exifStart, exifEnd := -1, -1
+
+	for position := 2; position+4 <= len(jpeg); {
+		if jpeg[position] != 0xff {
+			return nil, false, fmt.Errorf("invalid marker at %d", position)
+		}
+
+		marker := jpeg[position+1]
+
+		// padding before a marker
+		if marker == 0xff {
+			position++
+
+			continue
+		}
+
+		if marker == markerSos || marker == markerEoi {
+			break
+		}
+
+		// markers without a payload
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			position += 2
+
+			continue
+		}
+
+		segmentEnd := position + 2 + int(binary.BigEndian.Uint16(jpeg[position+2:]))
+		if segmentEnd > len(jpeg) {
+			return nil, false, fmt.Errorf("segment at %d is truncated", position)
+		}
+
+		if marker == markerApp0 && insertAt == position {
+			insertAt = segmentEnd
+		}
+
+		if marker == markerApp1 && exifStart < 0 && bytes.HasPrefix(jpeg[position+4:segmentEnd], exifHeader) {
+			exifStart, exifEnd = position, segmentEnd
+		}
+
+		position = segmentEnd
+	}
+
+	var tiff []byte
+	var changed bool
+	var err error
+
+	if exifStart >= 0 {
+		tiff, changed, err = updateTiff(jpeg[exifStart+4+len(exifHeader):exifEnd], tags)
+		if err != nil {
+			return nil, false, fmt.Errorf("update exif: %w", err)
+		}
+	} else {
+		tiff, changed, err = updateTiff(emptyTiff(), tags)
+		if err != nil {
+			return nil, false, fmt.Errorf("create exif: %w", err)
+		}
+	}
+
+	if !changed {
+		return jpeg, false, nil
+	}
+
+	segmentLength := 2 + len(exifHeader) + len(tiff)
+	if segmentLength > maxSegmentLength {
+		return nil, false, fmt.Errorf("exif segment is too large: %d bytes", segmentLength)
+	}
+
+	segment := make([]byte, 0, 2+segmentLength)
+	segment = append(segment, 0xff, markerApp1)
+	segment = binary.BigEndian.AppendUint16(segment, uint16(segmentLength))
+	segment = append(segment, exifHeader...)
+	segment = append(segment, tiff...)
+
+	if exifStart < 0 {
+		exifStart, exifEnd = insertAt, insertAt
+	}
+
+	result := make([]byte, 0, len(jpeg)-(exifEnd-exifStart)+len(segment))
+	result = append(result, jpeg[:exifStart]...)
+	result = append(result, segment...)
+	result = append(result, jpeg[exifEnd:]...)
+
+	return result, true, nil
+}
+
+// Big endian tiff header followed by an empty first directory
+func emptyTiff() []byte {
+	return []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 0, 0, 0, 0, 0}
+}
+
+func updateTiff(original []byte, tags Tags) ([]byte, bool, error) {
+	if len(original) < 8 {
+		return nil, false, fmt.Errorf("tiff header is truncated")
+	}
+
+	var order byteOrder
+
+	switch string(original[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, false, fmt.Errorf("unknown byte order")
+	}
+
+	if order.Uint16(original[2:]) != 42 {
+		return nil, false, fmt.Errorf("invalid tiff header")
+	}
+
+	ifd0, nextIfdOffset, err := readIfd(original, order, order.Uint32(original[4:]))
+	if err != nil {
+		return nil, false, fmt.Errorf("read ifd0: %w", err)
+	}
+
+	var exifIfd []ifdEntry
+	exifIfdIndex := slices.IndexFunc(ifd0, func(entry ifdEntry) bool { return entry.tag == tagExifIfdPointer })
+
+	if exifIfdIndex >= 0 {
+		exifIfd, _, err = readIfd(original, order, order.Uint32(ifd0[exifIfdIndex].raw[8:]))
+		if err != nil {
+			return nil, false, fmt.Errorf("read exif ifd: %w", err)
+		}
+	}
+
+	ifd0Changed, exifIfdChanged := false, false
+
+	description := strings.TrimSpace(tags.ImageDescription)
+	if description != "" && asciiValue(original, order, ifd0, tagImageDescription) != description {
+		ifd0 = setAscii(ifd0, tagImageDescription, description)
+		ifd0Changed = true
+	}
+
+	if tags.Make != "" && asciiValue(original, order, ifd0, tagMake) == "" {
+		ifd0 = setAscii(ifd0, tagMake, tags.Make)
+		ifd0Changed = true
+	}
+
+	if tags.Model != "" && asciiValue(original, order, ifd0, tagModel) == "" {
+		ifd0 = setAscii(ifd0, tagModel, tags.Model)
+		ifd0Changed = true
+	}
+
+	// camera dates are in the local time of the camera, only missing dates are filled
+	if !tags.DateTimeOriginal.IsZero() && asciiValue(original, order, exifIfd, tagDateTimeOriginal) == "" {
+		exifIfd = setAscii(exifIfd, tagDateTimeOriginal, tags.DateTimeOriginal.UTC().Format(dateTimeLayout))
+		exifIfd = setAscii(exifIfd, tagOffsetTimeOriginal, "+00:00")
+		exifIfdChanged = true
+	}
+
+	if !ifd0Changed && !exifIfdChanged {
+		return original, false, nil
+	}
+
+	tiff := slices.Clone(original)
+
+	if exifIfdChanged {
+		var exifIfdOffset uint32
+		tiff, exifIfdOffset = appendIfd(tiff, order, exifIfd, 0)
+
+		pointer := order.AppendUint32(nil, exifIfdOffset)
+		ifd0 = setEntry(ifd0, ifdEntry{tag: tagExifIfdPointer, raw: ifdEntryRaw(order, tagExifIfdPointer, typeLong, 1, pointer)})
+	}
+
+	var ifd0Offset uint32
+	tiff, ifd0Offset = appendIfd(tiff, order, ifd0, nextIfdOffset)
+
+	order.PutUint32(tiff[4:], ifd0Offset)
+
+	return tiff, true, nil
+}
+
+func readIfd(tiff []byte, order byteOrder, offset uint32) ([]ifdEntry, uint32, error) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return nil, 0, fmt.Errorf("ifd offset %d is out of range", offset)
+	}
+
+	count := int(order.Uint16(tiff[offset:]))
+	end := int(offset) + 2 + count*12
+
+	if end+4 > len(tiff) {
+		return nil, 0, fmt.Errorf("ifd at %d is truncated", offset)
+	}
+
+	entries := make([]ifdEntry, 0, count)
+	for i := 0; i < count; i++ {
+		raw := tiff[int(offset)+2+i*12 : int(offset)+2+(i+1)*12]
+		entries = append(entries, ifdEntry{tag: order.Uint16(raw), raw: slices.Clone(raw)})
+	}
+
+	return entries, order.Uint32(tiff[end:]), nil
+}
+
+// Returns the trimmed ascii value of the tag or an empty string if the tag is missing
+func asciiValue(tiff []byte, order byteOrder, entries []ifdEntry, tag uint16) string {
+	index := slices.IndexFunc(entries, func(entry ifdEntry) bool { return entry.tag == tag })
+	if index < 0 {
+		return ""
+	}
+
+	entry := entries[index]
+	if entry.value != nil {
+		return strings.Trim(string(entry.value), "\x00 ")
+	}
+
+	if order.Uint16(entry.raw[2:]) != typeAscii {
+		return ""
+	}
+
+	count := order.Uint32(entry.raw[4:])
+	if count <= 4 {
+		return strings.Trim(string(entry.raw[8:8+count]), "\x00 ")
+	}
+
+	offset := order.Uint32(entry.raw[8:])
+	if uint64(offset)+uint64(count) > uint64(len(tiff)) {
+		return ""
+	}
+
+	return strings.Trim(string(tiff[offset:offset+count]), "\x00 ")
+}
+
+// New values are written after the directory when it is appended
+func setAscii(entries []ifdEntry, tag uint16, value string) []ifdEntry {
+	return setEntry(entries, ifdEntry{tag: tag, value: append([]byte(value), 0)})
+}
+
+func setEntry(entries []ifdEntry, entry ifdEntry) []ifdEntry {
+	entries = slices.DeleteFunc(slices.Clone(entries), func(existing ifdEntry) bool { return existing.tag == entry.tag })
+	entries = append(entries, entry)
+
+	// readers expect directory entries sorted by tag
+	slices.SortFunc(entries, func(a, b ifdEntry) int { return int(a.tag) - int(b.tag) })
+
+	return entries
+}
+
+func ifdEntryRaw(order byteOrder, tag uint16, valueType uint16, count uint32, value []byte) []byte {
+	raw := make([]byte, 0, 12)
+	raw = order.AppendUint16(raw, tag)
+	raw = order.AppendUint16(raw, valueType)
+	raw = order.AppendUint32(raw, count)
+	raw = append(raw, value...)
+
+	return append(raw, make([]byte, 12-len(raw))...)
+}
+
+// Appends the directory followed by the values which don't fit into the entries, returns its offset
+func appendIfd(tiff []byte, order byteOrder, entries []ifdEntry, nextIfdOffset uint32) ([]byte, uint32) {
+	// directories start on a word boundary
+	if len(tiff)%2 != 0 {
+		tiff = append(tiff, 0)
+	}
+
+	offset := uint32(len(tiff))
+	valuesOffset := offset + 2 + uint32(len(entries))*12 + 4
+
+	var values []byte
+
+	tiff = order.AppendUint16(tiff, uint16(len(entries)))
+
+	for _, entry := range entries {
+		if entry.value == nil {
+			tiff = append(tiff, entry.raw...)
+
+			continue
+		}
+
+		count := uint32(len(entry.value))
+
+		if count <= 4 {
+			tiff = append(tiff, ifdEntryRaw(order, entry.tag, typeAscii, count, entry.value)...)
+
+			continue
+		}
+
+		valueOffset := order.AppendUint32(nil, valuesOffset+uint32(len(values)))
+		tiff = append(tiff, ifdEntryRaw(order, entry.tag, typeAscii, count, valueOffset)...)
+
+		values = append(values, entry.value...)
+		if len(values)%2 != 0 {
+			values = append(values, 0)
+		}
+	}
+
+	tiff = order.AppendUint32(tiff, nextIfdOffset)
+
+	return append(tiff, values...), offset
+}
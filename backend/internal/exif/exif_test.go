@@ -0,0 +1,284 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Date(2023, 4, 5, 10, 11, 12, 0, time.UTC)
+
+// Tiff data with the entries in the first directory and in the exif directory if there are any
+func testTiff(order byteOrder, ifd0 []ifdEntry, exifIfd []ifdEntry) []byte {
+	tiff := []byte{'I', 'I', 42, 0, 0, 0, 0, 0}
+	if order == binary.BigEndian {
+		tiff = []byte{'M', 'M', 0, 42, 0, 0, 0, 0}
+	}
+
+	if exifIfd != nil {
+		var exifIfdOffset uint32
+		tiff, exifIfdOffset = appendIfd(tiff, order, exifIfd, 0)
+
+		ifd0 = setEntry(ifd0, ifdEntry{tag: tagExifIfdPointer, raw: ifdEntryRaw(order, tagExifIfdPointer, typeLong, 1, order.AppendUint32(nil, exifIfdOffset))})
+	}
+
+	tiff, ifd0Offset := appendIfd(tiff, order, ifd0, 0)
+	order.PutUint32(tiff[4:], ifd0Offset)
+
+	return tiff
+}
+
+func testJpeg(segments ...[]byte) []byte {
+	jpeg := []byte{0xff, markerSoi}
+
+	for _, segment := range segments {
+		jpeg = append(jpeg, segment...)
+	}
+
+	// scan header and image data
+	jpeg = append(jpeg, 0xff, markerSos, 0, 4, 1, 2)
+	jpeg = append(jpeg, bytes.Repeat([]byte{0x55}, 100)...)
+
+	return append(jpeg, 0xff, markerEoi)
+}
+
+func testSegment(marker byte, payload []byte) []byte {
+	segment := binary.BigEndian.AppendUint16([]byte{0xff, marker}, uint16(2+len(payload)))
+
+	return append(segment, payload...)
+}
+
+func testApp0() []byte {
+	return testSegment(markerApp0, []byte("JFIF\x00\x01\x02\x00\x00\x01\x00\x01\x00\x00"))
+}
+
+func testApp1(tiff []byte) []byte {
+	return testSegment(markerApp1, append(bytes.Clone(exifHeader), tiff...))
+}
+
+// Returns the tiff data of the first exif segment
+func jpegTiff(t *testing.T, jpeg []byte) []byte {
+	for position := 2; position+4 <= len(jpeg); {
+		marker := jpeg[position+1]
+		if marker == markerSos {
+			break
+		}
+
+		end := position + 2 + int(binary.BigEndian.Uint16(jpeg[position+2:]))
+
+		if marker == markerApp1 && bytes.HasPrefix(jpeg[position+4:end], exifHeader) {
+			return jpeg[position+4+len(exifHeader) : end]
+		}
+
+		position = end
+	}
+
+	require.Fail(t, "no exif segment")
+
+	return nil
+}
+
+// Values of the written tags by tag
+func tiffTags(t *testing.T, tiff []byte) map[uint16]string {
+	order := byteOrder(binary.LittleEndian)
+	if string(tiff[0:2]) == "MM" {
+		order = binary.BigEndian
+	}
+
+	ifd0, _, err := readIfd(tiff, order, order.Uint32(tiff[4:]))
+	require.NoError(t, err)
+
+	tags := map[uint16]string{}
+	for _, tag := range []uint16{tagImageDescription, tagMake, tagModel} {
+		if value := asciiValue(tiff, order, ifd0, tag); value != "" {
+			tags[tag] = value
+		}
+	}
+
+	index := -1
+	for i, entry := range ifd0 {
+		if entry.tag == tagExifIfdPointer {
+			index = i
+		}
+	}
+
+	if index < 0 {
+		return tags
+	}
+
+	exifIfd, _, err := readIfd(tiff, order, order.Uint32(ifd0[index].raw[8:]))
+	require.NoError(t, err)
+
+	for _, tag := range []uint16{tagDateTimeOriginal, tagOffsetTimeOriginal} {
+		if value := asciiValue(tiff, order, exifIfd, tag); value != "" {
+			tags[tag] = value
+		}
+	}
+
+	return tags
+}
+
+func TestEmbedJpeg(t *testing.T) {
+	allTags := Tags{DateTimeOriginal: testTime, ImageDescription: "Holidays", Make: "Google", Model: "Pixel 7"}
+
+	writtenTags := map[uint16]string{
+		tagImageDescription:   "Holidays",
+		tagMake:               "Google",
+		tagModel:              "Pixel 7",
+		tagDateTimeOriginal:   "2023:04:05 10:11:12",
+		tagOffsetTimeOriginal: "+00:00",
+	}
+
+	cameraTiff := func(order byteOrder) []byte {
+		return testTiff(order, []ifdEntry{
+			{tag: tagMake, value: []byte("Canon\x00")},
+			{tag: tagModel, value: []byte("EOS R5\x00")},
+		}, nil)
+	}
+
+	tests := []struct {
+		name     string
+		jpeg     []byte
+		tags     Tags
+		expected map[uint16]string
+	}{
+		{
+			name:     "no app1 segment",
+			jpeg:     testJpeg(testApp0()),
+			tags:     allTags,
+			expected: writtenTags,
+		},
+		{
+			name: "little endian tiff",
+			jpeg: testJpeg(testApp0(), testApp1(cameraTiff(binary.LittleEndian))),
+			tags: allTags,
+			expected: map[uint16]string{
+				tagImageDescription:   "Holidays",
+				tagMake:               "Canon",
+				tagModel:              "EOS R5",
+				tagDateTimeOriginal:   "2023:04:05 10:11:12",
+				tagOffsetTimeOriginal: "+00:00",
+			},
+		},
+		{
+			name: "big endian tiff",
+			jpeg: testJpeg(testApp1(cameraTiff(binary.BigEndian))),
+			tags: allTags,
+			expected: map[uint16]string{
+				tagImageDescription:   "Holidays",
+				tagMake:               "Canon",
+				tagModel:              "EOS R5",
+				tagDateTimeOriginal:   "2023:04:05 10:11:12",
+				tagOffsetTimeOriginal: "+00:00",
+			},
+		},
+		{
+			name: "existing exif ifd",
+			jpeg: testJpeg(testApp1(testTiff(binary.LittleEndian, nil, []ifdEntry{
+				{tag: tagDateTimeOriginal, value: []byte("2020:01:02 03:04:05\x00")},
+			}))),
+			tags: allTags,
+			expected: map[uint16]string{
+				tagImageDescription: "Holidays",
+				tagMake:             "Google",
+				tagModel:            "Pixel 7",
+				tagDateTimeOriginal: "2020:01:02 03:04:05",
+			},
+		},
+		{
+			name: "changed description",
+			jpeg: testJpeg(testApp1(testTiff(binary.BigEndian, []ifdEntry{
+				{tag: tagImageDescription, value: []byte("Old description\x00")},
+			}, nil))),
+			tags:     Tags{ImageDescription: "New description"},
+			expected: map[uint16]string{tagImageDescription: "New description"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			embedded, changed, err := EmbedJpeg(test.jpeg, test.tags)
+
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.Equal(t, test.expected, tiffTags(t, jpegTiff(t, embedded)))
+
+			// the image data after the segments is unchanged
+			assert.True(t, bytes.HasSuffix(embedded, test.jpeg[bytes.Index(test.jpeg, []byte{0xff, markerSos}):]))
+		})
+	}
+
+	t.Run("new segment after app0", func(t *testing.T) {
+		jpeg := testJpeg(testApp0())
+
+		embedded, _, err := EmbedJpeg(jpeg, allTags)
+
+		require.NoError(t, err)
+		assert.Equal(t, jpeg[:2+len(testApp0())], embedded[:2+len(testApp0())])
+		assert.Equal(t, []byte{0xff, markerApp1}, embedded[2+len(testApp0()):4+len(testApp0())])
+	})
+
+	t.Run("existing data keeps its offsets", func(t *testing.T) {
+		// values which don't fit into the entries are stored at offsets, like the data of maker notes
+		tiff := testTiff(binary.LittleEndian, []ifdEntry{
+			{tag: tagMake, value: []byte("Camera maker with a long name\x00")},
+		}, []ifdEntry{
+			{tag: 0x927c, raw: ifdEntryRaw(binary.LittleEndian, 0x927c, 7, 4, []byte("NOTE"))},
+		})
+
+		embedded, changed, err := EmbedJpeg(testJpeg(testApp1(tiff)), allTags)
+		require.NoError(t, err)
+		require.True(t, changed)
+
+		embeddedTiff := jpegTiff(t, embedded)
+
+		// only the offset of the first directory is changed
+		assert.Equal(t, tiff[:4], embeddedTiff[:4])
+		assert.Equal(t, tiff[8:], embeddedTiff[8:len(tiff)])
+		assert.Equal(t, "Camera maker with a long name", tiffTags(t, embeddedTiff)[tagMake])
+	})
+
+	t.Run("nothing to change", func(t *testing.T) {
+		jpeg := testJpeg(testApp1(testTiff(binary.LittleEndian, []ifdEntry{
+			{tag: tagImageDescription, value: []byte("Holidays\x00")},
+			{tag: tagMake, value: []byte("Canon\x00")},
+			{tag: tagModel, value: []byte("EOS R5\x00")},
+		}, []ifdEntry{
+			{tag: tagDateTimeOriginal, value: []byte("2020:01:02 03:04:05\x00")},
+		})))
+
+		embedded, changed, err := EmbedJpeg(jpeg, allTags)
+
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, jpeg, embedded)
+	})
+
+	t.Run("exif segment too large", func(t *testing.T) {
+		tiff := testTiff(binary.BigEndian, []ifdEntry{
+			{tag: tagMake, value: append(bytes.Repeat([]byte{'a'}, maxSegmentLength-100), 0)},
+		}, nil)
+
+		_, _, err := EmbedJpeg(testJpeg(testApp1(tiff)), allTags)
+
+		assert.ErrorContains(t, err, "too large")
+	})
+
+	t.Run("not a jpeg", func(t *testing.T) {
+		_, _, err := EmbedJpeg([]byte("\x89PNG\r\n\x1a\n"), allTags)
+
+		assert.ErrorIs(t, err, ErrNotJpeg)
+	})
+
+	t.Run("truncated segment", func(t *testing.T) {
+		jpeg := testJpeg(testApp0())
+
+		_, _, err := EmbedJpeg(jpeg[:10], allTags)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,690 @@
+package exif
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	constructionMethodFile = 0
+	constructionMethodIdat = 1
+)
+
+var ErrNotHeif = errors.New("not a heif file")
+
+var heifBrands = []string{"heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1", "avif"}
+
+// Box in a byte slice, the payload starts at start+headerSize
+type isoBox struct {
+	boxType    string
+	start      int
+	headerSize int
+	end        int
+}
+
+func (b isoBox) payload(data []byte) []byte {
+	return data[b.start+b.headerSize : b.end]
+}
+
+type ilocExtent struct {
+	index  uint64
+	offset uint64
+	length uint64
+}
+
+type ilocItem struct {
+	id                 uint32
+	constructionMethod uint16
+	dataReferenceIndex uint16
+	baseOffset         uint64
+	extents            []ilocExtent
+}
+
+type ilocBox struct {
+	version        byte
+	flags          []byte
+	offsetSize     int
+	lengthSize     int
+	baseOffsetSize int
+	indexSize      int
+	items          []ilocItem
+}
+
+type infeEntry struct {
+	id       uint32
+	itemType string
+}
+
+// Writes the tags into the exif item of the heif image like EmbedJpeg does, the item is created if the image has none.
+// The new exif data is appended in a new mdat box and the item location is changed to it,
+// the image data is not moved apart from the shift by the changed size of the meta box.
+// Returns false if nothing had to be changed
+func EmbedHeif(heif []byte, tags Tags) ([]byte, bool, error) {
+	boxes, err := readIsoBoxes(heif, 0, len(heif))
+	if err != nil {
+		return nil, false, fmt.Errorf("read boxes: %w", err)
+	}
+
+	if len(boxes) == 0 || boxes[0].boxType != "ftyp" || !hasHeifBrand(boxes[0].payload(heif)) {
+		return nil, false, ErrNotHeif
+	}
+
+	metaIndex := slices.IndexFunc(boxes, func(b isoBox) bool { return b.boxType == "meta" })
+	if metaIndex < 0 {
+		return nil, false, fmt.Errorf("meta box is missing")
+	}
+
+	// chunk offsets of image sequences would have to be shifted too
+	if slices.ContainsFunc(boxes, func(b isoBox) bool { return b.boxType == "moov" }) {
+		return nil, false, fmt.Errorf("image sequences are not supported")
+	}
+
+	meta := boxes[metaIndex]
+	if meta.end-meta.start-meta.headerSize < 4 {
+		return nil, false, fmt.Errorf("meta box is truncated")
+	}
+
+	children, err := readIsoBoxes(heif, meta.start+meta.headerSize+4, meta.end)
+	if err != nil {
+		return nil, false, fmt.Errorf("read meta boxes: %w", err)
+	}
+
+	child := func(boxType string) (isoBox, bool) {
+		index := slices.IndexFunc(children, func(b isoBox) bool { return b.boxType == boxType })
+		if index < 0 {
+			return isoBox{}, false
+		}
+
+		return children[index], true
+	}
+
+	ilocIsoBox, foundIloc := child("iloc")
+	iinfIsoBox, foundIinf := child("iinf")
+	pitmIsoBox, foundPitm := child("pitm")
+
+	if !foundIloc || !foundIinf || !foundPitm {
+		return nil, false, fmt.Errorf("iloc, iinf or pitm box is missing")
+	}
+
+	iloc, err := readIloc(ilocIsoBox.payload(heif))
+	if err != nil {
+		return nil, false, fmt.Errorf("read iloc: %w", err)
+	}
+
+	iinfVersion, infeEntries, err := readIinf(heif, iinfIsoBox)
+	if err != nil {
+		return nil, false, fmt.Errorf("read iinf: %w", err)
+	}
+
+	primaryItemId, err := readPitm(pitmIsoBox.payload(heif))
+	if err != nil {
+		return nil, false, fmt.Errorf("read pitm: %w", err)
+	}
+
+	var prefix, tiff []byte
+	exifItemId := uint32(0)
+
+	exifEntryIndex := slices.IndexFunc(infeEntries, func(entry infeEntry) bool { return entry.itemType == "Exif" })
+	if exifEntryIndex >= 0 {
+		exifItemId = infeEntries[exifEntryIndex].id
+
+		idat, _ := child("idat")
+
+		exifData, err := itemData(heif, iloc, exifItemId, idat)
+		if err != nil {
+			return nil, false, fmt.Errorf("read exif item: %w", err)
+		}
+
+		if len(exifData) < 4 || uint64(binary.BigEndian.Uint32(exifData))+4 > uint64(len(exifData)) {
+			return nil, false, fmt.Errorf("exif item is truncated")
+		}
+
+		tiffStart := 4 + int(binary.BigEndian.Uint32(exifData))
+		prefix, tiff = exifData[4:tiffStart], exifData[tiffStart:]
+	} else {
+		prefix, tiff = exifHeader, emptyTiff()
+	}
+
+	updatedTiff, changed, err := updateTiff(tiff, tags)
+	if err != nil {
+		return nil, false, fmt.Errorf("update exif: %w", err)
+	}
+
+	if !changed {
+		return heif, false, nil
+	}
+
+	exifData := binary.BigEndian.AppendUint32(nil, uint32(len(prefix)))
+	exifData = append(exifData, prefix...)
+	exifData = append(exifData, updatedTiff...)
+
+	var newInfe, newCdsc []byte
+
+	iref, foundIref := child("iref")
+	irefVersion := byte(0)
+
+	if foundIref && iref.end > iref.start+iref.headerSize {
+		irefVersion = heif[iref.start+iref.headerSize]
+	}
+
+	if exifItemId == 0 {
+		for _, entry := range infeEntries {
+			exifItemId = max(exifItemId, entry.id)
+		}
+
+		for _, item := range iloc.items {
+			exifItemId = max(exifItemId, item.id)
+		}
+
+		exifItemId++
+
+		largeIds := exifItemId > math.MaxUint16 || primaryItemId > math.MaxUint16
+		if !foundIref && largeIds {
+			irefVersion = 1
+		}
+
+		if largeIds && (iloc.version < 2 || iinfVersion == 0 || irefVersion == 0) {
+			return nil, false, fmt.Errorf("item id %d doesn't fit into the boxes", exifItemId)
+		}
+
+		iloc.items = append(iloc.items, ilocItem{id: exifItemId})
+		newInfe = infeBox(exifItemId)
+		newCdsc = cdscBox(exifItemId, primaryItemId, irefVersion > 0)
+	}
+
+	// the exif item gets one extent in the file, its offset is known once the size of the new meta box is
+	exifItemIndex := slices.IndexFunc(iloc.items, func(item ilocItem) bool { return item.id == exifItemId })
+	if exifItemIndex < 0 {
+		return nil, false, fmt.Errorf("exif item has no location")
+	}
+
+	iloc.items[exifItemIndex] = ilocItem{
+		id:                 exifItemId,
+		constructionMethod: constructionMethodFile,
+		extents:            []ilocExtent{{length: uint64(len(exifData))}},
+	}
+
+	iloc.offsetSize, iloc.lengthSize = max(iloc.offsetSize, 4), max(iloc.lengthSize, 4)
+	if iloc.version == 0 {
+		iloc.version = 1
+	}
+
+	// the sizes of the fields don't depend on the values
+	delta := int64(len(buildMeta(heif, meta, children, iloc, newInfe, newCdsc, irefVersion))) - int64(meta.end-meta.start)
+
+	iloc.items[exifItemIndex].extents[0].offset = uint64(int64(len(heif))+delta) + 8
+
+	for i := range iloc.items {
+		item := &iloc.items[i]
+
+		if i == exifItemIndex || item.constructionMethod != constructionMethodFile || item.dataReferenceIndex != 0 {
+			continue
+		}
+
+		// data behind the meta box moves with the changed size
+		baseShifted := item.baseOffset >= uint64(meta.end)
+		if baseShifted {
+			item.baseOffset = uint64(int64(item.baseOffset) + delta)
+		}
+
+		for j := range item.extents {
+			extent := &item.extents[j]
+
+			if !baseShifted && item.baseOffset+extent.offset >= uint64(meta.end) {
+				extent.offset = uint64(int64(extent.offset) + delta)
+			}
+		}
+	}
+
+	newMeta := buildMeta(heif, meta, children, iloc, newInfe, newCdsc, irefVersion)
+
+	err = checkIlocSizes(iloc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	last := boxes[len(boxes)-1]
+	result := make([]byte, 0, len(heif)+int(delta)+8+len(exifData))
+	result = append(result, heif[:meta.start]...)
+	result = append(result, newMeta...)
+	result = append(result, heif[meta.end:]...)
+
+	// a last box which extends to the end of the file gets its size, so the new mdat box is not inside it,
+	// the new meta box always has one
+	if last.start != meta.start && binary.BigEndian.Uint32(heif[last.start:]) == 0 {
+		if last.end-last.start > math.MaxUint32 {
+			return nil, false, fmt.Errorf("last box is too large")
+		}
+
+		binary.BigEndian.PutUint32(result[int64(last.start)+delta:], uint32(last.end-last.start))
+	}
+
+	result = binary.BigEndian.AppendUint32(result, uint32(8+len(exifData)))
+	result = append(result, "mdat"...)
+	result = append(result, exifData...)
+
+	return result, true, nil
+}
+
+func hasHeifBrand(ftyp []byte) bool {
+	for i := 0; i+4 <= len(ftyp); i += 4 {
+		// minor version after the major brand
+		if i == 4 {
+			continue
+		}
+
+		if slices.Contains(heifBrands, string(ftyp[i:i+4])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readIsoBoxes(data []byte, start int, end int) ([]isoBox, error) {
+	var boxes []isoBox
+
+	for position := start; position < end; {
+		if position+8 > end {
+			return nil, fmt.Errorf("box at %d is truncated", position)
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data[position:]))
+		headerSize := 8
+
+		switch size {
+		case 0:
+			size = uint64(end - position)
+		case 1:
+			if position+16 > end {
+				return nil, fmt.Errorf("box at %d is truncated", position)
+			}
+
+			size = binary.BigEndian.Uint64(data[position+8:])
+			headerSize = 16
+		}
+
+		if size < uint64(headerSize) || size > uint64(end-position) {
+			return nil, fmt.Errorf("box at %d has invalid size %d", position, size)
+		}
+
+		boxes = append(boxes, isoBox{
+			boxType:    string(data[position+4 : position+8]),
+			start:      position,
+			headerSize: headerSize,
+			end:        position + int(size),
+		})
+
+		position += int(size)
+	}
+
+	return boxes, nil
+}
+
+// Reads big endian unsigned values of 0, 2, 4 or 8 bytes
+type boxReader struct {
+	data     []byte
+	position int
+	err      error
+}
+
+func (r *boxReader) uint(size int) uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	if r.position+size > len(r.data) {
+		r.err = fmt.Errorf("box is truncated")
+
+		return 0
+	}
+
+	var value uint64
+
+	switch size {
+	case 0:
+	case 1:
+		value = uint64(r.data[r.position])
+	case 2:
+		value = uint64(binary.BigEndian.Uint16(r.data[r.position:]))
+	case 4:
+		value = uint64(binary.BigEndian.Uint32(r.data[r.position:]))
+	case 8:
+		value = binary.BigEndian.Uint64(r.data[r.position:])
+	default:
+		r.err = fmt.Errorf("invalid field size %d", size)
+	}
+
+	r.position += size
+
+	return value
+}
+
+func (r *boxReader) bytes(size int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if r.position+size > len(r.data) {
+		r.err = fmt.Errorf("box is truncated")
+
+		return nil
+	}
+
+	r.position += size
+
+	return slices.Clone(r.data[r.position-size : r.position])
+}
+
+func appendUint(data []byte, size int, value uint64) []byte {
+	switch size {
+	case 2:
+		return binary.BigEndian.AppendUint16(data, uint16(value))
+	case 4:
+		return binary.BigEndian.AppendUint32(data, uint32(value))
+	case 8:
+		return binary.BigEndian.AppendUint64(data, value)
+	}
+
+	return data
+}
+
+func readIloc(payload []byte) (ilocBox, error) {
+	r := &boxReader{data: payload}
+
+	iloc := ilocBox{version: byte(r.uint(1)), flags: r.bytes(3)}
+
+	sizes := r.uint(1)
+	iloc.offsetSize, iloc.lengthSize = int(sizes>>4), int(sizes&0x0f)
+
+	sizes = r.uint(1)
+	iloc.baseOffsetSize = int(sizes >> 4)
+
+	if iloc.version == 1 || iloc.version == 2 {
+		iloc.indexSize = int(sizes & 0x0f)
+	}
+
+	if iloc.version > 2 {
+		return ilocBox{}, fmt.Errorf("unknown version %d", iloc.version)
+	}
+
+	idSize, countSize := 2, 2
+	if iloc.version == 2 {
+		idSize, countSize = 4, 4
+	}
+
+	itemCount := r.uint(countSize)
+
+	for i := uint64(0); i < itemCount && r.err == nil; i++ {
+		item := ilocItem{id: uint32(r.uint(idSize))}
+
+		if iloc.version == 1 || iloc.version == 2 {
+			item.constructionMethod = uint16(r.uint(2) & 0x0f)
+		}
+
+		item.dataReferenceIndex = uint16(r.uint(2))
+		item.baseOffset = r.uint(iloc.baseOffsetSize)
+
+		extentCount := r.uint(2)
+
+		for j := uint64(0); j < extentCount && r.err == nil; j++ {
+			var extent ilocExtent
+
+			if iloc.indexSize > 0 {
+				extent.index = r.uint(iloc.indexSize)
+			}
+
+			extent.offset = r.uint(iloc.offsetSize)
+			extent.length = r.uint(iloc.lengthSize)
+
+			item.extents = append(item.extents, extent)
+		}
+
+		iloc.items = append(iloc.items, item)
+	}
+
+	return iloc, r.err
+}
+
+func writeIloc(iloc ilocBox) []byte {
+	payload := append([]byte{iloc.version}, iloc.flags...)
+	payload = append(payload, byte(iloc.offsetSize<<4|iloc.lengthSize), byte(iloc.baseOffsetSize<<4|iloc.indexSize))
+
+	idSize := 2
+	if iloc.version == 2 {
+		idSize = 4
+	}
+
+	payload = appendUint(payload, idSize, uint64(len(iloc.items)))
+
+	for _, item := range iloc.items {
+		payload = appendUint(payload, idSize, uint64(item.id))
+
+		if iloc.version == 1 || iloc.version == 2 {
+			payload = binary.BigEndian.AppendUint16(payload, item.constructionMethod)
+		}
+
+		payload = binary.BigEndian.AppendUint16(payload, item.dataReferenceIndex)
+		payload = appendUint(payload, iloc.baseOffsetSize, item.baseOffset)
+		payload = binary.BigEndian.AppendUint16(payload, uint16(len(item.extents)))
+
+		for _, extent := range item.extents {
+			payload = appendUint(payload, iloc.indexSize, extent.index)
+			payload = appendUint(payload, iloc.offsetSize, extent.offset)
+			payload = appendUint(payload, iloc.lengthSize, extent.length)
+		}
+	}
+
+	return isoBoxBytes("iloc", payload)
+}
+
+// Values which don't fit into the fields of the iloc box
+func checkIlocSizes(iloc ilocBox) error {
+	fits := func(value uint64, size int) bool {
+		return size == 8 || (size == 4 && value <= math.MaxUint32) || value == 0
+	}
+
+	for _, item := range iloc.items {
+		if !fits(item.baseOffset, iloc.baseOffsetSize) {
+			return fmt.Errorf("base offset of item %d is too large", item.id)
+		}
+
+		for _, extent := range item.extents {
+			if !fits(extent.offset, iloc.offsetSize) || !fits(extent.length, iloc.lengthSize) {
+				return fmt.Errorf("extent of item %d is too large", item.id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func readIinf(data []byte, iinf isoBox) (byte, []infeEntry, error) {
+	payload := iinf.payload(data)
+	r := &boxReader{data: payload}
+
+	version := byte(r.uint(1))
+	r.position = 4
+
+	countSize := 4
+	if version == 0 {
+		countSize = 2
+	}
+
+	r.uint(countSize)
+	if r.err != nil {
+		return 0, nil, r.err
+	}
+
+	start := iinf.start + iinf.headerSize + r.position
+
+	boxes, err := readIsoBoxes(data, start, iinf.end)
+	if err != nil {
+		return 0, nil, fmt.Errorf("read infe boxes: %w", err)
+	}
+
+	var entries []infeEntry
+
+	for _, box := range boxes {
+		if box.boxType != "infe" {
+			continue
+		}
+
+		infe := &boxReader{data: box.payload(data)}
+		infeVersion := infe.uint(1)
+		infe.position = 4
+
+		var entry infeEntry
+
+		if infeVersion >= 3 {
+			entry.id = uint32(infe.uint(4))
+		} else {
+			entry.id = uint32(infe.uint(2))
+		}
+
+		// item protection index
+		infe.uint(2)
+
+		if infeVersion >= 2 {
+			entry.itemType = string(infe.bytes(4))
+		}
+
+		if infe.err != nil {
+			return 0, nil, fmt.Errorf("read infe: %w", infe.err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return version, entries, nil
+}
+
+func readPitm(payload []byte) (uint32, error) {
+	r := &boxReader{data: payload}
+
+	version := r.uint(1)
+	r.position = 4
+
+	size := 2
+	if version > 0 {
+		size = 4
+	}
+
+	id := r.uint(size)
+
+	return uint32(id), r.err
+}
+
+// Data of the item from the file or from the idat box
+func itemData(data []byte, iloc ilocBox, itemId uint32, idat isoBox) ([]byte, error) {
+	index := slices.IndexFunc(iloc.items, func(item ilocItem) bool { return item.id == itemId })
+	if index < 0 {
+		return nil, fmt.Errorf("item %d has no location", itemId)
+	}
+
+	item := iloc.items[index]
+
+	var source []byte
+
+	switch {
+	case item.dataReferenceIndex != 0:
+		return nil, fmt.Errorf("item %d is in another file", itemId)
+	case item.constructionMethod == constructionMethodFile:
+		source = data
+	case item.constructionMethod == constructionMethodIdat && idat.boxType == "idat":
+		source = idat.payload(data)
+	default:
+		return nil, fmt.Errorf("item %d has unsupported construction method %d", itemId, item.constructionMethod)
+	}
+
+	var result []byte
+
+	for _, extent := range item.extents {
+		start := item.baseOffset + extent.offset
+		end := start + extent.length
+
+		// zero length is the rest of the data
+		if extent.length == 0 {
+			end = uint64(len(source))
+		}
+
+		if start > end || end > uint64(len(source)) {
+			return nil, fmt.Errorf("extent of item %d is out of range", itemId)
+		}
+
+		result = append(result, source[start:end]...)
+	}
+
+	return result, nil
+}
+
+// Meta box with the changed iloc box and with the new exif item if there is one
+func buildMeta(data []byte, meta isoBox, children []isoBox, iloc ilocBox, newInfe []byte, newCdsc []byte, irefVersion byte) []byte {
+	payload := slices.Clone(data[meta.start+meta.headerSize : meta.start+meta.headerSize+4])
+	hasIref := false
+
+	for _, child := range children {
+		switch {
+		case child.boxType == "iloc":
+			payload = append(payload, writeIloc(iloc)...)
+		case child.boxType == "iinf" && newInfe != nil:
+			iinfPayload := slices.Clone(child.payload(data))
+
+			if iinfPayload[0] == 0 {
+				binary.BigEndian.PutUint16(iinfPayload[4:], binary.BigEndian.Uint16(iinfPayload[4:])+1)
+			} else {
+				binary.BigEndian.PutUint32(iinfPayload[4:], binary.BigEndian.Uint32(iinfPayload[4:])+1)
+			}
+
+			payload = append(payload, isoBoxBytes("iinf", append(iinfPayload, newInfe...))...)
+		case child.boxType == "iref" && newCdsc != nil:
+			hasIref = true
+			payload = append(payload, isoBoxBytes("iref", append(slices.Clone(child.payload(data)), newCdsc...))...)
+		default:
+			payload = append(payload, data[child.start:child.end]...)
+		}
+	}
+
+	if newCdsc != nil && !hasIref {
+		payload = append(payload, isoBoxBytes("iref", append([]byte{irefVersion, 0, 0, 0}, newCdsc...))...)
+	}
+
+	return isoBoxBytes("meta", payload)
+}
+
+// Item info entry of an exif item
+func infeBox(itemId uint32) []byte {
+	if itemId > math.MaxUint16 {
+		payload := binary.BigEndian.AppendUint32([]byte{3, 0, 0, 0}, itemId)
+
+		return isoBoxBytes("infe", append(payload, 0, 0, 'E', 'x', 'i', 'f', 0))
+	}
+
+	payload := binary.BigEndian.AppendUint16([]byte{2, 0, 0, 0}, uint16(itemId))
+
+	return isoBoxBytes("infe", append(payload, 0, 0, 'E', 'x', 'i', 'f', 0))
+}
+
+// Reference from the exif item to the image it describes
+func cdscBox(fromItemId uint32, toItemId uint32, largeIds bool) []byte {
+	size := 2
+	if largeIds {
+		size = 4
+	}
+
+	payload := appendUint(nil, size, uint64(fromItemId))
+	payload = binary.BigEndian.AppendUint16(payload, 1)
+	payload = appendUint(payload, size, uint64(toItemId))
+
+	return isoBoxBytes("cdsc", payload)
+}
+
+func isoBoxBytes(boxType string, payload []byte) []byte {
+	box := binary.BigEndian.AppendUint32(make([]byte, 0, 8+len(payload)), uint32(8+len(payload)))
+	box = append(box, boxType...)
+
+	return append(box, payload...)
+}
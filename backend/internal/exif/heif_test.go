@@ -0,0 +1,226 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testImageData = bytes.Repeat([]byte("IMAGE"), 200)
+
+type testHeifOptions struct {
+	exifTiff    []byte
+	ilocVersion byte
+	// the mdat box extends to the end of the file
+	openMdat bool
+}
+
+// Heif file with an image item and an exif item if exif data is given, both stored in one mdat box after the meta box
+func testHeif(options testHeifOptions) []byte {
+	ftyp := isoBoxBytes("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+
+	var exifData []byte
+	if options.exifTiff != nil {
+		exifData = binary.BigEndian.AppendUint32(nil, uint32(len(exifHeader)))
+		exifData = append(exifData, exifHeader...)
+		exifData = append(exifData, options.exifTiff...)
+	}
+
+	buildMeta := func(mdatDataOffset uint64) []byte {
+		iloc := ilocBox{version: options.ilocVersion, flags: []byte{0, 0, 0}, offsetSize: 4, lengthSize: 4}
+		iloc.items = append(iloc.items, ilocItem{id: 1, extents: []ilocExtent{{offset: mdatDataOffset, length: uint64(len(testImageData))}}})
+
+		infes := isoBoxBytes("infe", []byte("\x02\x00\x00\x00\x00\x01\x00\x00hvc1\x00"))
+		entryCount := uint16(1)
+
+		if exifData != nil {
+			iloc.items = append(iloc.items, ilocItem{id: 2, extents: []ilocExtent{{offset: mdatDataOffset + uint64(len(testImageData)), length: uint64(len(exifData))}}})
+			infes = append(infes, isoBoxBytes("infe", []byte("\x02\x00\x00\x00\x00\x02\x00\x00Exif\x00"))...)
+			entryCount++
+		}
+
+		payload := []byte{0, 0, 0, 0}
+		payload = append(payload, isoBoxBytes("hdlr", []byte("\x00\x00\x00\x00\x00\x00\x00\x00pict\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"))...)
+		payload = append(payload, isoBoxBytes("pitm", []byte{0, 0, 0, 0, 0, 1})...)
+		payload = append(payload, writeIloc(iloc)...)
+		payload = append(payload, isoBoxBytes("iinf", append(binary.BigEndian.AppendUint16([]byte{0, 0, 0, 0}, entryCount), infes...))...)
+
+		if exifData != nil {
+			payload = append(payload, isoBoxBytes("iref", append([]byte{0, 0, 0, 0}, cdscBox(2, 1, false)...))...)
+		}
+
+		return isoBoxBytes("meta", payload)
+	}
+
+	meta := buildMeta(0)
+	meta = buildMeta(uint64(len(ftyp) + len(meta) + 8))
+
+	mdat := isoBoxBytes("mdat", append(slices.Clone(testImageData), exifData...))
+	if options.openMdat {
+		binary.BigEndian.PutUint32(mdat, 0)
+	}
+
+	return bytes.Join([][]byte{ftyp, meta, mdat}, nil)
+}
+
+// Data of the first item of the type
+func testHeifItem(t *testing.T, heif []byte, itemType string) []byte {
+	boxes, err := readIsoBoxes(heif, 0, len(heif))
+	require.NoError(t, err)
+
+	meta := boxes[slices.IndexFunc(boxes, func(b isoBox) bool { return b.boxType == "meta" })]
+
+	children, err := readIsoBoxes(heif, meta.start+meta.headerSize+4, meta.end)
+	require.NoError(t, err)
+
+	var iloc ilocBox
+	var entries []infeEntry
+
+	for _, child := range children {
+		switch child.boxType {
+		case "iloc":
+			iloc, err = readIloc(child.payload(heif))
+			require.NoError(t, err)
+		case "iinf":
+			_, entries, err = readIinf(heif, child)
+			require.NoError(t, err)
+		}
+	}
+
+	index := slices.IndexFunc(entries, func(entry infeEntry) bool { return entry.itemType == itemType })
+	require.GreaterOrEqual(t, index, 0, "no %s item", itemType)
+
+	data, err := itemData(heif, iloc, entries[index].id, isoBox{})
+	require.NoError(t, err)
+
+	return data
+}
+
+// Tiff data of the exif item
+func testHeifTiff(t *testing.T, heif []byte) []byte {
+	exifData := testHeifItem(t, heif, "Exif")
+
+	require.Equal(t, exifHeader, exifData[4:4+binary.BigEndian.Uint32(exifData)])
+
+	return exifData[4+binary.BigEndian.Uint32(exifData):]
+}
+
+func TestEmbedHeif(t *testing.T) {
+	allTags := Tags{DateTimeOriginal: testTime, ImageDescription: "Holidays", Make: "Apple", Model: "iPhone 15"}
+
+	tests := []struct {
+		name     string
+		options  testHeifOptions
+		expected map[uint16]string
+	}{
+		{
+			name:    "existing exif item",
+			options: testHeifOptions{exifTiff: testTiff(binary.BigEndian, []ifdEntry{{tag: tagMake, value: []byte("Samsung\x00")}}, nil)},
+			expected: map[uint16]string{
+				tagImageDescription:   "Holidays",
+				tagMake:               "Samsung",
+				tagModel:              "iPhone 15",
+				tagDateTimeOriginal:   "2023:04:05 10:11:12",
+				tagOffsetTimeOriginal: "+00:00",
+			},
+		},
+		{
+			name:    "no exif item",
+			options: testHeifOptions{},
+			expected: map[uint16]string{
+				tagImageDescription:   "Holidays",
+				tagMake:               "Apple",
+				tagModel:              "iPhone 15",
+				tagDateTimeOriginal:   "2023:04:05 10:11:12",
+				tagOffsetTimeOriginal: "+00:00",
+			},
+		},
+		{
+			name:    "iloc version 1",
+			options: testHeifOptions{exifTiff: testTiff(binary.LittleEndian, nil, nil), ilocVersion: 1},
+			expected: map[uint16]string{
+				tagImageDescription:   "Holidays",
+				tagMake:               "Apple",
+				tagModel:              "iPhone 15",
+				tagDateTimeOriginal:   "2023:04:05 10:11:12",
+				tagOffsetTimeOriginal: "+00:00",
+			},
+		},
+		{
+			name:    "mdat box until the end of the file",
+			options: testHeifOptions{openMdat: true},
+			expected: map[uint16]string{
+				tagImageDescription:   "Holidays",
+				tagMake:               "Apple",
+				tagModel:              "iPhone 15",
+				tagDateTimeOriginal:   "2023:04:05 10:11:12",
+				tagOffsetTimeOriginal: "+00:00",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			heif := testHeif(test.options)
+
+			embedded, changed, err := EmbedHeif(heif, allTags)
+
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.Equal(t, test.expected, tiffTags(t, testHeifTiff(t, embedded)))
+
+			// the image is found at its shifted location
+			assert.Equal(t, testImageData, testHeifItem(t, embedded, "hvc1"))
+
+			// embedding again finds the written values
+			_, changed, err = EmbedHeif(embedded, allTags)
+			assert.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+
+	t.Run("reference to the image", func(t *testing.T) {
+		embedded, _, err := EmbedHeif(testHeif(testHeifOptions{}), allTags)
+		require.NoError(t, err)
+
+		assert.True(t, bytes.Contains(embedded, isoBoxBytes("iref", append([]byte{0, 0, 0, 0}, cdscBox(2, 1, false)...))))
+	})
+
+	t.Run("nothing to change", func(t *testing.T) {
+		heif := testHeif(testHeifOptions{exifTiff: testTiff(binary.BigEndian, []ifdEntry{
+			{tag: tagImageDescription, value: []byte("Holidays\x00")},
+			{tag: tagMake, value: []byte("Apple\x00")},
+			{tag: tagModel, value: []byte("iPhone 15\x00")},
+		}, []ifdEntry{
+			{tag: tagDateTimeOriginal, value: []byte("2020:01:02 03:04:05\x00")},
+		})})
+
+		embedded, changed, err := EmbedHeif(heif, allTags)
+
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, heif, embedded)
+	})
+
+	t.Run("not a heif file", func(t *testing.T) {
+		_, _, err := EmbedHeif(testJpeg(testApp0()), allTags)
+
+		assert.Error(t, err)
+
+		_, _, err = EmbedHeif(isoBoxBytes("ftyp", []byte("isom\x00\x00\x00\x00isommp41")), allTags)
+
+		assert.ErrorIs(t, err, ErrNotHeif)
+	})
+
+	t.Run("truncated file", func(t *testing.T) {
+		heif := testHeif(testHeifOptions{})
+
+		_, _, err := EmbedHeif(heif[:len(heif)-10], allTags)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,84 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"google-backup/internal/exif"
+	"google-backup/internal/media"
+	"google-backup/internal/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Writes the creation time, the description and missing camera details into the EXIF of jpeg and heic files
+// if embedding is enabled. Other formats are left unchanged.
+// Returns true if the file was changed
+func (f files) EmbedExif(filePathName string, mediaItem media.MediaItem) (bool, error) {
+	settingsData, err := f.settingsReader.Get()
+	if err != nil {
+		return false, fmt.Errorf("get settings: %w", err)
+	}
+
+	var embed func(content []byte, tags exif.Tags) ([]byte, bool, error)
+
+	switch strings.ToLower(mediaItem.MimeType) {
+	case "image/jpeg":
+		embed = exif.EmbedJpeg
+	case "image/heic", "image/heif":
+		embed = exif.EmbedHeif
+	}
+
+	if !settingsData.ExifEmbeddingEnabled || embed == nil {
+		return false, nil
+	}
+
+	tags := exif.Tags{ImageDescription: mediaItem.Description}
+
+	creationTime, err := time.Parse(time.RFC3339, mediaItem.MediaMetadata.CreationTime)
+	if err == nil {
+		tags.DateTimeOriginal = creationTime
+	}
+
+	if mediaItem.MediaMetadata.Photo != nil {
+		tags.Make = mediaItem.MediaMetadata.Photo.CameraMake
+		tags.Model = mediaItem.MediaMetadata.Photo.CameraModel
+	}
+
+	content, err := f.readFile(filePathName)
+	if err != nil {
+		return false, fmt.Errorf("read file: %w", err)
+	}
+
+	if content == nil {
+		return false, fmt.Errorf("read file: %s does not exist", filePathName)
+	}
+
+	embedded, changed, err := embed(content, tags)
+	if err != nil {
+		// the downloaded file is kept as it is
+		log.WithFields(log.Fields{"file": filePathName, "error": err}).Warn("embed exif")
+
+		return false, nil
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	embeddedHash := sha256.Sum256(embedded)
+
+	// written next to the file and renamed, a content store link is replaced instead of changed
+	err = f.replaceFile(filePathName, embedded, map[string]string{
+		storage.MetadataMediaItemId: mediaItem.ID,
+		storage.MetadataSha256:      hex.EncodeToString(embeddedHash[:]),
+	})
+	if err != nil {
+		return false, fmt.Errorf("replace file: %w", err)
+	}
+
+	return true, nil
+}
@@ -1,40 +1,106 @@
 package files
 
 import (
-	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
+	"google-backup/internal/album"
+	"google-backup/internal/drive"
 	"google-backup/internal/media"
+	"google-backup/internal/settings"
+	"google-backup/internal/storage"
 )
 
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . FilesManager
 type FilesManager interface {
 	SaveDownloadError(email string, mediaItemId, message string) error
 	SaveFileMeta(email string, fileMeta FileMeta) error
 	FileExists(email string, mediaItem media.MediaItem) (bool, error)
 	GenerateFilePathName(email string, mediaItem media.MediaItem) (string, error)
-	EqualHash(filePathName string, reader io.Reader) (bool, error)
-	AddRootFolderToPath(path string) string
-	CreateFolderIfDoesNotExist(filePathName string) error
+	GenerateMotionVideoFilePathName(filePathName string) string
+	GetFileMeta(email string, mediaItemId string) (FileMeta, bool, error)
+	HashFile(filePathName string) (string, error)
+	EmbedExif(filePathName string, mediaItem media.MediaItem) (bool, error)
 	UpdateCreationTime(filePathName string, creationTime string) error
+	GetMediaItemAlbums(email string, mediaItemId string) ([]media.Album, error)
+	LinkToAlbums(email string, filePathName string, mediaItemId string) error
+	UpdateXmpSidecar(email string, mediaItemId string) error
+	SaveDriveFileMeta(email string, fileMeta DriveFileMeta) error
+	GetDriveFileMeta(email string, fileId string) (DriveFileMeta, bool, error)
+	GenerateDriveFilePathName(email string, folderPath string, file drive.File) (string, error)
+	DriveFileExists(email string, filePathName string, file drive.File) (bool, error)
+	GenerateDriveFolderPathName(email string, folderPath string) string
+	MoveDriveFile(email string, fileMeta DriveFileMeta, file drive.File, filePathName string) error
+	MoveDriveFolder(email string, oldFolderPathName string, newFolderPathName string) error
+	MarkDriveFileRemoved(email string, fileId string, file *drive.File) error
+	RemoveFile(filePathName string) error
+	ReconcileDeleted(email string, seenMediaItemIds map[string]bool) error
+	GetRemotelyDeleted(email string) ([]FileMeta, error)
+	StoreContent(email string, mediaItemId string, filePathName string, hash string, motionVideo bool) error
+	ReleaseContent(email string, mediaItemId string, hash string, motionVideo bool) error
+	GetDedupStats(email string) (DedupStats, error)
+	Verify(email string, checkHashes bool) (VerifyReport, error)
 }
 
 type files struct {
-	repository Repository
+	repository     Repository
+	albums         album.Albums
+	settingsReader settings.SettingsReader
+	storage        storage.Backend
 }
 
+const (
+	albumsFolderName        = "albums"
+	driveFolderName         = "drive"
+	albumIdSuffixLength     = 8
+	mediaItemIdSuffixLength = 8
+	motionVideoSuffix       = "_motion.mp4"
+)
+
 type FileMeta struct {
-	FilePathName string          `json:"file_path_name"`
-	MediaItem    media.MediaItem `json:"media_item"`
+	FilePathName      string          `json:"file_path_name"`
+	MediaItem         media.MediaItem `json:"media_item"`
+	RemoteDeletedTime string          `json:"remote_deleted_time,omitempty"`
+	// Video component of a motion photo saved next to the still image
+	MotionVideoFilePathName string `json:"motion_video_file_path_name,omitempty"`
+	// Sha256 of the content, calculated while downloading
+	ContentHash            string `json:"content_hash,omitempty"`
+	MotionVideoContentHash string `json:"motion_video_content_hash,omitempty"`
+	// Sizes of the files on disk in bytes
+	Size            int64 `json:"size,omitempty"`
+	MotionVideoSize int64 `json:"motion_video_size,omitempty"`
+	// Sha256 of the content as it was downloaded, set when the file was changed afterwards, e.g. by EXIF embedding
+	DownloadedContentHash string `json:"downloaded_content_hash,omitempty"`
+	// Scheme the files are encrypted with on the storage, empty for plain files
+	Encryption            string `json:"encryption,omitempty"`
+	MotionVideoEncryption string `json:"motion_video_encryption,omitempty"`
+}
+
+type DriveFileMeta struct {
+	FilePathName string     `json:"file_path_name"`
+	File         drive.File `json:"file"`
+	// Set when the file was trashed or removed from Drive, the local copy is kept
+	RemovedTime string `json:"removed_time,omitempty"`
+	// Scheme the file is encrypted with on the storage, empty for plain files
+	Encryption string `json:"encryption,omitempty"`
 }
 
-func NewFilesManager(repository Repository) files {
-	return files{repository: repository}
+func NewFilesManager(
+	repository Repository,
+	albums album.Albums,
+	settingsReader settings.SettingsReader,
+	storage storage.Backend,
+) files {
+	return files{repository: repository, albums: albums, settingsReader: settingsReader, storage: storage}
 }
 
 func (f files) SaveDownloadError(email string, mediaItemId string, message string) error {
@@ -47,7 +113,215 @@ func (f files) SaveFileMeta(email string, fileMeta FileMeta) error {
 		return fmt.Errorf("marshal media item: %w", err)
 	}
 
-	return f.repository.SaveFileMeta(email, []byte(fileMeta.MediaItem.ID), fileMetaJson)
+	err = f.repository.SaveFileMeta(email, []byte(fileMeta.MediaItem.ID), fileMetaJson)
+	if err != nil {
+		return fmt.Errorf("save file meta: %w", err)
+	}
+
+	// the meta is saved after every download and reconciliation, so the sidecar follows every change
+	err = f.writeXmpSidecar(email, fileMeta)
+	if err != nil {
+		return fmt.Errorf("write xmp sidecar: %w", err)
+	}
+
+	return nil
+}
+
+func (f files) GetFileMeta(email string, mediaItemId string) (FileMeta, bool, error) {
+	fileMetaJson, err := f.repository.GetFileMeta(email, []byte(mediaItemId))
+	if err != nil {
+		return FileMeta{}, false, fmt.Errorf("get file meta: %w", err)
+	}
+
+	if fileMetaJson == nil {
+		return FileMeta{}, false, nil
+	}
+
+	var fileMeta FileMeta
+	err = json.Unmarshal(fileMetaJson, &fileMeta)
+	if err != nil {
+		return FileMeta{}, false, fmt.Errorf("unmarshal file meta: %w", err)
+	}
+
+	return fileMeta, true, nil
+}
+
+// Keeps the path claimed by the file, the previous path is released when the file moved
+func (f files) SaveDriveFileMeta(email string, fileMeta DriveFileMeta) error {
+	previousFileMeta, found, err := f.GetDriveFileMeta(email, fileMeta.File.ID)
+	if err != nil {
+		return fmt.Errorf("get drive file meta: %w", err)
+	}
+
+	fileMetaJson, err := json.Marshal(fileMeta)
+	if err != nil {
+		return fmt.Errorf("marshal drive file: %w", err)
+	}
+
+	err = f.repository.SaveDriveFileMeta(email, []byte(fileMeta.File.ID), fileMetaJson)
+	if err != nil {
+		return fmt.Errorf("save drive file meta: %w", err)
+	}
+
+	// files in a moved folder get their new path claimed here
+	if fileMeta.FilePathName != "" {
+		_, err = f.repository.ClaimFilePath(email, fileMeta.FilePathName, fileMeta.File.ID)
+		if err != nil {
+			return fmt.Errorf("claim file path: %w", err)
+		}
+	}
+
+	if found && previousFileMeta.FilePathName != "" && previousFileMeta.FilePathName != fileMeta.FilePathName {
+		err = f.repository.ReleaseFilePath(email, previousFileMeta.FilePathName, fileMeta.File.ID)
+		if err != nil {
+			return fmt.Errorf("release file path: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (f files) GetDriveFileMeta(email string, fileId string) (DriveFileMeta, bool, error) {
+	fileMetaJson, err := f.repository.GetDriveFileMeta(email, []byte(fileId))
+	if err != nil {
+		return DriveFileMeta{}, false, fmt.Errorf("get drive file meta: %w", err)
+	}
+
+	if fileMetaJson == nil {
+		return DriveFileMeta{}, false, nil
+	}
+
+	var fileMeta DriveFileMeta
+	err = json.Unmarshal(fileMetaJson, &fileMeta)
+	if err != nil {
+		return DriveFileMeta{}, false, fmt.Errorf("unmarshal drive file meta: %w", err)
+	}
+
+	return fileMeta, true, nil
+}
+
+// Drive files keep their folder hierarchy under the "email/drive" folder and reserve their path.
+// Files with the same name in a folder get a suffix from their id like media items
+func (f files) GenerateDriveFilePathName(email string, folderPath string, file drive.File) (string, error) {
+	fileMeta, _, err := f.GetDriveFileMeta(email, file.ID)
+	if err != nil {
+		return "", fmt.Errorf("get drive file meta: %w", err)
+	}
+
+	return f.claimPath(email, path.Join(email, driveFolderName, folderPath, drive.SanitizeName(file.FileName())), file.ID, fileMeta.FilePathName)
+}
+
+func (f files) GenerateDriveFolderPathName(email string, folderPath string) string {
+	return path.Join(email, driveFolderName, folderPath)
+}
+
+// Moves the local copy of a renamed or moved file which content didn't change
+func (f files) MoveDriveFile(email string, fileMeta DriveFileMeta, file drive.File, filePathName string) error {
+	err := f.storage.Rename(fileMeta.FilePathName, filePathName)
+	if err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+
+	fileMeta.FilePathName = filePathName
+	fileMeta.File = file
+	fileMeta.RemovedTime = ""
+
+	return f.SaveDriveFileMeta(email, fileMeta)
+}
+
+// Moves the local folder of a renamed or moved Drive folder and updates paths of the files inside
+func (f files) MoveDriveFolder(email string, oldFolderPathName string, newFolderPathName string) error {
+	// folders of files with hidden names exist only by the files in them, so the folder isn't checked before
+	err := f.storage.Rename(oldFolderPathName, newFolderPathName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("rename folder: %w", err)
+	}
+
+	fileMetaJsons, err := f.repository.GetAllDriveFileMeta(email)
+	if err != nil {
+		return fmt.Errorf("get all drive file meta: %w", err)
+	}
+
+	for _, fileMetaJson := range fileMetaJsons {
+		var fileMeta DriveFileMeta
+		err = json.Unmarshal(fileMetaJson, &fileMeta)
+		if err != nil {
+			return fmt.Errorf("unmarshal drive file meta: %w", err)
+		}
+
+		if !strings.HasPrefix(fileMeta.FilePathName, oldFolderPathName+"/") {
+			continue
+		}
+
+		fileMeta.FilePathName = newFolderPathName + strings.TrimPrefix(fileMeta.FilePathName, oldFolderPathName)
+
+		err = f.SaveDriveFileMeta(email, fileMeta)
+		if err != nil {
+			return fmt.Errorf("save drive file meta: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// File is nil when it was removed from Drive permanently
+func (f files) MarkDriveFileRemoved(email string, fileId string, file *drive.File) error {
+	fileMeta, found, err := f.GetDriveFileMeta(email, fileId)
+	if err != nil {
+		return fmt.Errorf("get drive file meta: %w", err)
+	}
+
+	if !found {
+		return nil
+	}
+
+	if file != nil {
+		fileMeta.File = *file
+	}
+
+	if fileMeta.RemovedTime == "" {
+		fileMeta.RemovedTime = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	return f.SaveDriveFileMeta(email, fileMeta)
+}
+
+func (f files) RemoveFile(filePathName string) error {
+	err := f.storage.Delete(filePathName)
+	if err != nil {
+		return fmt.Errorf("remove file: %w", err)
+	}
+
+	return nil
+}
+
+// Drive file exists if it was downloaded to the same path and wasn't modified since
+func (f files) DriveFileExists(email string, filePathName string, file drive.File) (bool, error) {
+	fileExists, err := f.fileExists(filePathName)
+	if err != nil {
+		return false, fmt.Errorf("file exists: %w", err)
+	}
+
+	if !fileExists {
+		return false, nil
+	}
+
+	fileMeta, found, err := f.GetDriveFileMeta(email, file.ID)
+	if err != nil {
+		return false, fmt.Errorf("get drive file meta: %w", err)
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	return fileMeta.FilePathName == filePathName &&
+		fileMeta.File.ModifiedTime == file.ModifiedTime &&
+		fileMeta.File.Md5Checksum == file.Md5Checksum, nil
 }
 
 func (f files) FileExists(email string, mediaItem media.MediaItem) (bool, error) {
@@ -56,9 +330,9 @@ func (f files) FileExists(email string, mediaItem media.MediaItem) (bool, error)
 		return false, fmt.Errorf("generate file path name: %w", err)
 	}
 
-	fileExists, err := f.fileExistsOnDisk(f.AddRootFolderToPath(filePathName))
+	fileExists, err := f.fileExists(filePathName)
 	if err != nil {
-		return false, fmt.Errorf("file exists on disk: %w", err)
+		return false, fmt.Errorf("file exists: %w", err)
 	}
 
 	if !fileExists {
@@ -83,48 +357,175 @@ func (f files) FileExists(email string, mediaItem media.MediaItem) (bool, error)
 	return fileMeta.FilePathName == filePathName, nil
 }
 
+// Returns the path from the path template setting, "email/year/month/filename" if it is not set,
+// and reserves it for the media item.
+// Items with the same filename get a suffix from their id, e.g. "IMG_0001_a1b2c3d4.JPG".
+// Downloaded items keep their path, so a changed template, album or metadata doesn't download them again to another path
 func (f files) GenerateFilePathName(email string, mediaItem media.MediaItem) (string, error) {
-	creationTime, err := time.Parse(time.RFC3339, mediaItem.MediaMetadata.CreationTime)
+	savedFilePathName, err := f.savedFilePathName(email, mediaItem.ID)
 	if err != nil {
-		return "", fmt.Errorf("parse creation time: %w", err)
+		return "", fmt.Errorf("saved file path name: %w", err)
+	}
+
+	if savedFilePathName != "" {
+		return savedFilePathName, nil
 	}
 
-	return email + "/" + strconv.Itoa(creationTime.Year()) + "/" + strconv.Itoa(int(creationTime.Month())) + "/" + mediaItem.Filename, nil
+	filePathName, err := f.renderFilePathName(email, mediaItem)
+	if err != nil {
+		return "", fmt.Errorf("render file path name: %w", err)
+	}
+
+	return f.claimPath(email, filePathName, mediaItem.ID, "")
 }
 
-func (f files) EqualHash(filePathName string, reader io.Reader) (bool, error) {
-	hash := md5.New()
+// Returns the path in the file meta of the media item if the item owns it, an empty string otherwise.
+// Files moved into the deleted items folder are claimed by their original path
+func (f files) savedFilePathName(email string, mediaItemId string) (string, error) {
+	fileMeta, found, err := f.GetFileMeta(email, mediaItemId)
+	if err != nil {
+		return "", fmt.Errorf("get file meta: %w", err)
+	}
 
-	file, err := os.Open(f.AddRootFolderToPath(filePathName))
+	if !found || fileMeta.FilePathName == "" {
+		return "", nil
+	}
+
+	filePathName := fileMeta.FilePathName
+	if f.isInDeletedFolder(email, filePathName) {
+		filePathName = email + "/" + strings.TrimPrefix(filePathName, email+"/"+deletedFolderName+"/")
+	}
+
+	// paths of files downloaded before paths were claimed have no owner yet
+	owner, err := f.repository.ClaimFilePath(email, filePathName, mediaItemId)
 	if err != nil {
-		return false, fmt.Errorf("open file: %w", err)
+		return "", fmt.Errorf("claim file path: %w", err)
+	}
+
+	if owner != mediaItemId {
+		return "", nil
+	}
+
+	return filePathName, nil
+}
+
+func (f files) renderFilePathName(email string, mediaItem media.MediaItem) (string, error) {
+	settingsData, err := f.settingsReader.Get()
+	if err != nil {
+		return "", fmt.Errorf("get settings: %w", err)
+	}
+
+	if settingsData.PathTemplate == "" {
+		creationTime, err := time.Parse(time.RFC3339, mediaItem.MediaMetadata.CreationTime)
+		if err != nil {
+			return "", fmt.Errorf("parse creation time: %w", err)
+		}
+
+		return email + "/" + strconv.Itoa(creationTime.Year()) + "/" + strconv.Itoa(int(creationTime.Month())) + "/" + mediaItem.Filename, nil
+	}
+
+	album := ""
+
+	if strings.Contains(settingsData.PathTemplate, PathPlaceholderAlbum) {
+		albums, err := f.albums.GetMediaItemAlbums(email, mediaItem.ID)
+		if err != nil {
+			return "", fmt.Errorf("get media item albums: %w", err)
+		}
+
+		// the first title in alphabetical order, so the path doesn't depend on the scan order
+		for _, itemAlbum := range albums {
+			title := f.albumTitle(itemAlbum)
+			if title != "" && (album == "" || title < album) {
+				album = title
+			}
+		}
+	}
+
+	return RenderPathTemplate(settingsData.PathTemplate, email, mediaItem, album)
+}
+
+// Claims the first free candidate of the path for the owner, a media item or a Drive file.
+// The saved path of the owner is free even if it was downloaded before paths were claimed
+func (f files) claimPath(email string, pathName string, ownerId string, savedPathName string) (string, error) {
+	for _, candidate := range f.pathCandidates(pathName, ownerId) {
+		free, err := f.filePathFree(email, candidate, ownerId, savedPathName)
+		if err != nil {
+			return "", fmt.Errorf("file path free: %w", err)
+		}
+
+		if !free {
+			continue
+		}
+
+		owner, err := f.repository.ClaimFilePath(email, candidate, ownerId)
+		if err != nil {
+			return "", fmt.Errorf("claim file path: %w", err)
+		}
+
+		if owner == ownerId {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free file path for %s", pathName)
+}
+
+// Files downloaded before paths were claimed are on disk without an owner
+func (f files) filePathFree(email string, filePathName string, ownerId string, savedPathName string) (bool, error) {
+	owner, err := f.repository.GetFilePathOwner(email, filePathName)
+	if err != nil {
+		return false, fmt.Errorf("get file path owner: %w", err)
 	}
-	defer file.Close()
 
-	if _, err := io.Copy(hash, file); err != nil {
-		return false, fmt.Errorf("copy file: %w", err)
+	if owner != "" {
+		return owner == ownerId, nil
 	}
 
-	existingFileHash := string(hash.Sum(nil))
+	exists, err := f.fileExists(filePathName)
+	if err != nil {
+		return false, fmt.Errorf("file exists: %w", err)
+	}
+
+	return !exists || filePathName == savedPathName, nil
+}
 
-	if _, err := io.Copy(hash, reader); err != nil {
-		return false, fmt.Errorf("copy file: %w", err)
+// The path and the path with a short and a full id suffix
+func (f files) pathCandidates(pathName string, id string) []string {
+	return []string{
+		pathName,
+		f.withIdSuffix(pathName, id[max(0, len(id)-mediaItemIdSuffixLength):]),
+		f.withIdSuffix(pathName, id),
 	}
+}
 
-	newFileHash := string(hash.Sum(nil))
+func (f files) withIdSuffix(filePathName string, suffix string) string {
+	extension := path.Ext(filePathName)
 
-	return existingFileHash == newFileHash, nil
+	return strings.TrimSuffix(filePathName, extension) + "_" + suffix + extension
 }
 
-func (f files) AddRootFolderToPath(path string) string {
-	// TODO get from config
-	return "/Users/michael/github.com/moontechs/photos-backup/downloads" + "/" + path
+// Motion photo videos share the name of the still image, e.g. "PXL_0001.jpg" and "PXL_0001_motion.mp4"
+func (f files) GenerateMotionVideoFilePathName(filePathName string) string {
+	return strings.TrimSuffix(filePathName, path.Ext(filePathName)) + motionVideoSuffix
 }
 
-func (f files) CreateFolderIfDoesNotExist(filePathName string) error {
-	directory := filepath.Dir(filePathName)
+// Sha256 of the file content, the same hash is recorded in the file meta
+func (f files) HashFile(filePathName string) (string, error) {
+	file, err := f.storage.Reader(filePathName)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
 
-	return os.MkdirAll(f.AddRootFolderToPath(directory), os.ModePerm)
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", fmt.Errorf("hash file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
 func (f files) UpdateCreationTime(filePathName string, creationTime string) error {
@@ -133,13 +534,124 @@ func (f files) UpdateCreationTime(filePathName string, creationTime string) erro
 		return fmt.Errorf("parse creation time: %w", err)
 	}
 
-	return os.Chtimes(f.AddRootFolderToPath(filePathName), creationTimeParsed, creationTimeParsed)
+	return f.storage.SetTimes(filePathName, creationTimeParsed)
+}
+
+func (f files) GetMediaItemAlbums(email string, mediaItemId string) ([]media.Album, error) {
+	return f.albums.GetMediaItemAlbums(email, mediaItemId)
+}
+
+// Creates links in the "email/albums/album title" folders pointing to the file in the date tree
+func (f files) LinkToAlbums(email string, filePathName string, mediaItemId string) error {
+	settingsData, err := f.settingsReader.Get()
+	if err != nil {
+		return fmt.Errorf("get settings: %w", err)
+	}
+
+	// album folders are made of links, storages without links have none
+	if settingsData.AlbumsLayout == "" || !storage.CanLink(f.storage, filePathName, email+"/"+albumsFolderName) {
+		return nil
+	}
+
+	albums, err := f.albums.GetMediaItemAlbums(email, mediaItemId)
+	if err != nil {
+		return fmt.Errorf("get media item albums: %w", err)
+	}
+
+	if len(albums) == 0 {
+		return nil
+	}
+
+	allAlbums, err := f.albums.GetAlbums(email)
+	if err != nil {
+		return fmt.Errorf("get albums: %w", err)
+	}
+
+	titlesCount := make(map[string]int, len(allAlbums))
+	for _, album := range allAlbums {
+		titlesCount[f.albumTitle(album)]++
+	}
+
+	// album titles are not unique, so folders of albums with the same title get an album id suffix
+	for _, album := range albums {
+		folderName := f.albumTitle(album)
+		if folderName == "" || titlesCount[folderName] > 1 {
+			folderName = strings.TrimSpace(folderName + " " + album.ID[max(0, len(album.ID)-albumIdSuffixLength):])
+		}
+
+		linkPathName, err := f.claimLinkPathName(email, email+"/"+albumsFolderName+"/"+folderName+"/"+path.Base(filePathName), mediaItemId)
+		if err != nil {
+			return fmt.Errorf("claim link path name: %w", err)
+		}
+
+		err = f.createLink(settingsData.AlbumsLayout, filePathName, linkPathName)
+		if err != nil {
+			return fmt.Errorf("create link: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Items with the same filename in an album folder get a suffix from their id like in the date tree,
+// so their links don't replace each other
+func (f files) claimLinkPathName(email string, linkPathName string, mediaItemId string) (string, error) {
+	for _, candidate := range f.pathCandidates(linkPathName, mediaItemId) {
+		owner, err := f.repository.ClaimFilePath(email, candidate, mediaItemId)
+		if err != nil {
+			return "", fmt.Errorf("claim file path: %w", err)
+		}
+
+		if owner == mediaItemId {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free link path for %s", linkPathName)
+}
+
+func (f files) createLink(albumsLayout string, filePathName string, linkPathName string) error {
+	linker, ok := f.storage.(storage.Linker)
+	if !ok {
+		return storage.ErrLinksUnsupported
+	}
+
+	err := f.storage.Delete(linkPathName)
+	if err != nil {
+		return fmt.Errorf("remove existing link: %w", err)
+	}
+
+	switch albumsLayout {
+	case settings.AlbumsLayoutSymlink:
+		return linker.Symlink(filePathName, linkPathName)
+	case settings.AlbumsLayoutHardlink:
+		return linker.Link(filePathName, linkPathName)
+	}
+
+	return fmt.Errorf("unknown albums layout: %s", albumsLayout)
+}
+
+// Album title which is safe to use as a folder name
+func (f files) albumTitle(album media.Album) string {
+	title := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0 {
+			return '_'
+		}
+
+		return r
+	}, strings.TrimSpace(album.Title))
+
+	if title == "." || title == ".." {
+		return ""
+	}
+
+	return title
 }
 
-func (f files) fileExistsOnDisk(filePathName string) (bool, error) {
-	_, err := os.Stat(filePathName)
+func (f files) fileExists(filePathName string) (bool, error) {
+	_, err := f.storage.Stat(filePathName)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return false, nil
 		}
 
@@ -148,3 +660,54 @@ func (f files) fileExistsOnDisk(filePathName string) (bool, error) {
 
 	return true, nil
 }
+
+// Writes the content next to the file and renames it into place, so readers never see a half written file.
+// The metadata is recorded by storages which keep metadata
+func (f files) replaceFile(filePathName string, content []byte, metadata map[string]string) error {
+	partialPathName := filePathName + ".partial"
+
+	out, err := f.storage.Writer(partialPathName, false)
+	if err != nil {
+		return fmt.Errorf("create partial file: %w", err)
+	}
+
+	_, err = out.Write(content)
+	if err != nil {
+		out.Close()
+		f.storage.Delete(partialPathName)
+
+		return fmt.Errorf("write partial file: %w", err)
+	}
+
+	err = out.Close()
+	if err != nil {
+		f.storage.Delete(partialPathName)
+
+		return fmt.Errorf("close partial file: %w", err)
+	}
+
+	err = storage.RenameWithMetadata(f.storage, partialPathName, filePathName, metadata)
+	if err != nil {
+		f.storage.Delete(partialPathName)
+
+		return fmt.Errorf("rename partial file: %w", err)
+	}
+
+	return nil
+}
+
+// Returns nil if the file doesn't exist
+func (f files) readFile(filePathName string) ([]byte, error) {
+	file, err := f.storage.Reader(filePathName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
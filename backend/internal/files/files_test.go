@@ -0,0 +1,245 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google-backup/internal/album"
+	"google-backup/internal/drive"
+	"google-backup/internal/media"
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+	"google-backup/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+const testEmail = "user@gmail.com"
+
+type testFiles struct {
+	files
+	albums             album.Albums
+	fakeSettingsReader *settingsfakes.FakeSettingsReader
+	root               string
+}
+
+// Files manager on a database and a folder of the test
+func newTestFiles(t *testing.T, settingsData settings.SettingsData) testFiles {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	root := t.TempDir()
+	albums := album.NewAlbums(album.NewRepository(db))
+
+	fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+	fakeSettingsReader.GetReturns(settingsData, nil)
+
+	return testFiles{
+		files:              NewFilesManager(NewRepository(db), albums, fakeSettingsReader, storage.NewLocal(root)),
+		albums:             albums,
+		fakeSettingsReader: fakeSettingsReader,
+		root:               root,
+	}
+}
+
+func testMediaItem(id string, filename string) media.MediaItem {
+	return media.MediaItem{
+		ID:            id,
+		Filename:      filename,
+		MimeType:      "image/jpeg",
+		MediaMetadata: media.MediaMetadata{CreationTime: "2023-04-05T10:00:00Z", Photo: &media.Photo{}},
+	}
+}
+
+func addMediaItem(albums album.Albums, email string, albumId string, mediaItemId string) error {
+	_, err := albums.AddMediaItem(email, albumId, mediaItemId)
+
+	return err
+}
+
+func TestGenerateFilePathName(t *testing.T) {
+	t.Run("default path", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+
+		filePathName, err := f.GenerateFilePathName(testEmail, testMediaItem("item-00000001", "IMG_0001.JPG"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/2023/4/IMG_0001.JPG", filePathName)
+	})
+
+	t.Run("same file name", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+
+		filePathName, err := f.GenerateFilePathName(testEmail, testMediaItem("item-00000001", "IMG_0001.JPG"))
+		require.NoError(t, err)
+
+		otherFilePathName, err := f.GenerateFilePathName(testEmail, testMediaItem("item-00000002", "IMG_0001.JPG"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/2023/4/IMG_0001.JPG", filePathName)
+		assert.Equal(t, "user@gmail.com/2023/4/IMG_0001_00000002.JPG", otherFilePathName)
+	})
+
+	t.Run("downloaded item keeps its path", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{PathTemplate: "{account}/{album}/{filename}"})
+		mediaItem := testMediaItem("item-00000001", "IMG_0001.JPG")
+
+		filePathName, err := f.GenerateFilePathName(testEmail, mediaItem)
+		require.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/no_album/IMG_0001.JPG", filePathName)
+
+		require.NoError(t, f.SaveFileMeta(testEmail, FileMeta{FilePathName: filePathName, MediaItem: mediaItem}))
+
+		// a new album and a new template would render another path
+		require.NoError(t, f.albums.SaveAlbum(testEmail, media.Album{ID: "album-1", Title: "Holidays"}))
+		require.NoError(t, addMediaItem(f.albums, testEmail, "album-1", mediaItem.ID))
+		f.fakeSettingsReader.GetReturns(settings.SettingsData{PathTemplate: "{account}/{year}/{filename}"}, nil)
+
+		filePathName, err = f.GenerateFilePathName(testEmail, mediaItem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/no_album/IMG_0001.JPG", filePathName)
+	})
+
+	t.Run("album titled like a reserved folder", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{PathTemplate: "{account}/{album}/{filename}"})
+		mediaItem := testMediaItem("item-00000001", "IMG_0001.JPG")
+
+		require.NoError(t, f.albums.SaveAlbum(testEmail, media.Album{ID: "album-1", Title: "albums"}))
+		require.NoError(t, addMediaItem(f.albums, testEmail, "album-1", mediaItem.ID))
+
+		filePathName, err := f.GenerateFilePathName(testEmail, mediaItem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/_albums/IMG_0001.JPG", filePathName)
+	})
+
+	t.Run("item in deleted items folder keeps its original path", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+		mediaItem := testMediaItem("item-00000001", "IMG_0001.JPG")
+
+		require.NoError(t, f.SaveFileMeta(testEmail, FileMeta{FilePathName: "user@gmail.com/_deleted/2020/1/IMG_0001.JPG", MediaItem: mediaItem}))
+
+		filePathName, err := f.GenerateFilePathName(testEmail, mediaItem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/2020/1/IMG_0001.JPG", filePathName)
+	})
+
+	t.Run("saved path of another item", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+		mediaItem := testMediaItem("item-00000001", "IMG_0001.JPG")
+
+		owner, err := f.repository.ClaimFilePath(testEmail, "user@gmail.com/2020/1/IMG_0001.JPG", "item-00000002")
+		require.NoError(t, err)
+		require.Equal(t, "item-00000002", owner)
+
+		require.NoError(t, f.SaveFileMeta(testEmail, FileMeta{FilePathName: "user@gmail.com/2020/1/IMG_0001.JPG", MediaItem: mediaItem}))
+
+		filePathName, err := f.GenerateFilePathName(testEmail, mediaItem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/2023/4/IMG_0001.JPG", filePathName)
+	})
+}
+
+func TestLinkToAlbums(t *testing.T) {
+	t.Run("items with same file name in album", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{AlbumsLayout: settings.AlbumsLayoutHardlink})
+
+		require.NoError(t, f.albums.SaveAlbum(testEmail, media.Album{ID: "album-1", Title: "Holidays"}))
+
+		for _, filePathName := range []string{"user@gmail.com/2022/1/IMG_0001.JPG", "user@gmail.com/2023/4/IMG_0001.JPG"} {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(f.root, filePathName)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(f.root, filePathName), []byte(filePathName), 0644))
+		}
+
+		require.NoError(t, addMediaItem(f.albums, testEmail, "album-1", "item-00000001"))
+		require.NoError(t, addMediaItem(f.albums, testEmail, "album-1", "item-00000002"))
+
+		assert.NoError(t, f.LinkToAlbums(testEmail, "user@gmail.com/2022/1/IMG_0001.JPG", "item-00000001"))
+		assert.NoError(t, f.LinkToAlbums(testEmail, "user@gmail.com/2023/4/IMG_0001.JPG", "item-00000002"))
+
+		// linking again keeps the names
+		assert.NoError(t, f.LinkToAlbums(testEmail, "user@gmail.com/2022/1/IMG_0001.JPG", "item-00000001"))
+
+		content, err := os.ReadFile(filepath.Join(f.root, "user@gmail.com/albums/Holidays/IMG_0001.JPG"))
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/2022/1/IMG_0001.JPG", string(content))
+
+		content, err = os.ReadFile(filepath.Join(f.root, "user@gmail.com/albums/Holidays/IMG_0001_00000002.JPG"))
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/2023/4/IMG_0001.JPG", string(content))
+	})
+}
+
+func TestGenerateDriveFilePathName(t *testing.T) {
+	t.Run("same file name in folder", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+
+		filePathName, err := f.GenerateDriveFilePathName(testEmail, "Documents", drive.File{ID: "file-00000001", Name: "notes.txt"})
+		require.NoError(t, err)
+
+		otherFilePathName, err := f.GenerateDriveFilePathName(testEmail, "Documents", drive.File{ID: "file-00000002", Name: "notes.txt"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/drive/Documents/notes.txt", filePathName)
+		assert.Equal(t, "user@gmail.com/drive/Documents/notes_00000002.txt", otherFilePathName)
+	})
+
+	t.Run("downloaded file keeps its path", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+		file := drive.File{ID: "file-00000002", Name: "notes.txt"}
+
+		filePathName, err := f.GenerateDriveFilePathName(testEmail, "Documents", drive.File{ID: "file-00000001", Name: "notes.txt"})
+		require.NoError(t, err)
+		require.NoError(t, f.SaveDriveFileMeta(testEmail, DriveFileMeta{FilePathName: filePathName, File: drive.File{ID: "file-00000001", Name: "notes.txt"}}))
+
+		filePathName, err = f.GenerateDriveFilePathName(testEmail, "Documents", file)
+		require.NoError(t, err)
+		require.NoError(t, f.SaveDriveFileMeta(testEmail, DriveFileMeta{FilePathName: filePathName, File: file}))
+
+		filePathName, err = f.GenerateDriveFilePathName(testEmail, "Documents", file)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/drive/Documents/notes_00000002.txt", filePathName)
+	})
+
+	t.Run("file downloaded before paths were claimed", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+		file := drive.File{ID: "file-00000002", Name: "notes.txt"}
+
+		require.NoError(t, os.MkdirAll(filepath.Join(f.root, "user@gmail.com/drive/Documents"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(f.root, "user@gmail.com/drive/Documents/notes.txt"), []byte("notes"), 0644))
+
+		// the existing file belongs to another file
+		filePathName, err := f.GenerateDriveFilePathName(testEmail, "Documents", file)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/drive/Documents/notes_00000002.txt", filePathName)
+	})
+
+	t.Run("moved file releases its previous path", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{})
+		file := drive.File{ID: "file-00000001", Name: "notes.txt"}
+
+		filePathName, err := f.GenerateDriveFilePathName(testEmail, "Documents", file)
+		require.NoError(t, err)
+		require.NoError(t, f.SaveDriveFileMeta(testEmail, DriveFileMeta{FilePathName: filePathName, File: file}))
+
+		filePathName, err = f.GenerateDriveFilePathName(testEmail, "Archive", file)
+		require.NoError(t, err)
+		require.NoError(t, f.SaveDriveFileMeta(testEmail, DriveFileMeta{FilePathName: filePathName, File: file}))
+
+		otherFilePathName, err := f.GenerateDriveFilePathName(testEmail, "Documents", drive.File{ID: "file-00000002", Name: "notes.txt"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user@gmail.com/drive/Archive/notes.txt", filePathName)
+		assert.Equal(t, "user@gmail.com/drive/Documents/notes.txt", otherFilePathName)
+	})
+}
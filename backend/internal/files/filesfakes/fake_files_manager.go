@@ -0,0 +1,2176 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package filesfakes
+
+import (
+	"google-backup/internal/drive"
+	"google-backup/internal/files"
+	"google-backup/internal/media"
+	"sync"
+)
+
+type FakeFilesManager struct {
+	DriveFileExistsStub        func(string, string, drive.File) (bool, error)
+	driveFileExistsMutex       sync.RWMutex
+	driveFileExistsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 drive.File
+	}
+	driveFileExistsReturns struct {
+		result1 bool
+		result2 error
+	}
+	driveFileExistsReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	EmbedExifStub        func(string, media.MediaItem) (bool, error)
+	embedExifMutex       sync.RWMutex
+	embedExifArgsForCall []struct {
+		arg1 string
+		arg2 media.MediaItem
+	}
+	embedExifReturns struct {
+		result1 bool
+		result2 error
+	}
+	embedExifReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	FileExistsStub        func(string, media.MediaItem) (bool, error)
+	fileExistsMutex       sync.RWMutex
+	fileExistsArgsForCall []struct {
+		arg1 string
+		arg2 media.MediaItem
+	}
+	fileExistsReturns struct {
+		result1 bool
+		result2 error
+	}
+	fileExistsReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	GenerateDriveFilePathNameStub        func(string, string, drive.File) (string, error)
+	generateDriveFilePathNameMutex       sync.RWMutex
+	generateDriveFilePathNameArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 drive.File
+	}
+	generateDriveFilePathNameReturns struct {
+		result1 string
+		result2 error
+	}
+	generateDriveFilePathNameReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	GenerateDriveFolderPathNameStub        func(string, string) string
+	generateDriveFolderPathNameMutex       sync.RWMutex
+	generateDriveFolderPathNameArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	generateDriveFolderPathNameReturns struct {
+		result1 string
+	}
+	generateDriveFolderPathNameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	GenerateFilePathNameStub        func(string, media.MediaItem) (string, error)
+	generateFilePathNameMutex       sync.RWMutex
+	generateFilePathNameArgsForCall []struct {
+		arg1 string
+		arg2 media.MediaItem
+	}
+	generateFilePathNameReturns struct {
+		result1 string
+		result2 error
+	}
+	generateFilePathNameReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	GenerateMotionVideoFilePathNameStub        func(string) string
+	generateMotionVideoFilePathNameMutex       sync.RWMutex
+	generateMotionVideoFilePathNameArgsForCall []struct {
+		arg1 string
+	}
+	generateMotionVideoFilePathNameReturns struct {
+		result1 string
+	}
+	generateMotionVideoFilePathNameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	GetDedupStatsStub        func(string) (files.DedupStats, error)
+	getDedupStatsMutex       sync.RWMutex
+	getDedupStatsArgsForCall []struct {
+		arg1 string
+	}
+	getDedupStatsReturns struct {
+		result1 files.DedupStats
+		result2 error
+	}
+	getDedupStatsReturnsOnCall map[int]struct {
+		result1 files.DedupStats
+		result2 error
+	}
+	GetDriveFileMetaStub        func(string, string) (files.DriveFileMeta, bool, error)
+	getDriveFileMetaMutex       sync.RWMutex
+	getDriveFileMetaArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getDriveFileMetaReturns struct {
+		result1 files.DriveFileMeta
+		result2 bool
+		result3 error
+	}
+	getDriveFileMetaReturnsOnCall map[int]struct {
+		result1 files.DriveFileMeta
+		result2 bool
+		result3 error
+	}
+	GetFileMetaStub        func(string, string) (files.FileMeta, bool, error)
+	getFileMetaMutex       sync.RWMutex
+	getFileMetaArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getFileMetaReturns struct {
+		result1 files.FileMeta
+		result2 bool
+		result3 error
+	}
+	getFileMetaReturnsOnCall map[int]struct {
+		result1 files.FileMeta
+		result2 bool
+		result3 error
+	}
+	GetMediaItemAlbumsStub        func(string, string) ([]media.Album, error)
+	getMediaItemAlbumsMutex       sync.RWMutex
+	getMediaItemAlbumsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getMediaItemAlbumsReturns struct {
+		result1 []media.Album
+		result2 error
+	}
+	getMediaItemAlbumsReturnsOnCall map[int]struct {
+		result1 []media.Album
+		result2 error
+	}
+	GetRemotelyDeletedStub        func(string) ([]files.FileMeta, error)
+	getRemotelyDeletedMutex       sync.RWMutex
+	getRemotelyDeletedArgsForCall []struct {
+		arg1 string
+	}
+	getRemotelyDeletedReturns struct {
+		result1 []files.FileMeta
+		result2 error
+	}
+	getRemotelyDeletedReturnsOnCall map[int]struct {
+		result1 []files.FileMeta
+		result2 error
+	}
+	HashFileStub        func(string) (string, error)
+	hashFileMutex       sync.RWMutex
+	hashFileArgsForCall []struct {
+		arg1 string
+	}
+	hashFileReturns struct {
+		result1 string
+		result2 error
+	}
+	hashFileReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	LinkToAlbumsStub        func(string, string, string) error
+	linkToAlbumsMutex       sync.RWMutex
+	linkToAlbumsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	linkToAlbumsReturns struct {
+		result1 error
+	}
+	linkToAlbumsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	MarkDriveFileRemovedStub        func(string, string, *drive.File) error
+	markDriveFileRemovedMutex       sync.RWMutex
+	markDriveFileRemovedArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 *drive.File
+	}
+	markDriveFileRemovedReturns struct {
+		result1 error
+	}
+	markDriveFileRemovedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	MoveDriveFileStub        func(string, files.DriveFileMeta, drive.File, string) error
+	moveDriveFileMutex       sync.RWMutex
+	moveDriveFileArgsForCall []struct {
+		arg1 string
+		arg2 files.DriveFileMeta
+		arg3 drive.File
+		arg4 string
+	}
+	moveDriveFileReturns struct {
+		result1 error
+	}
+	moveDriveFileReturnsOnCall map[int]struct {
+		result1 error
+	}
+	MoveDriveFolderStub        func(string, string, string) error
+	moveDriveFolderMutex       sync.RWMutex
+	moveDriveFolderArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	moveDriveFolderReturns struct {
+		result1 error
+	}
+	moveDriveFolderReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReconcileDeletedStub        func(string, map[string]bool) error
+	reconcileDeletedMutex       sync.RWMutex
+	reconcileDeletedArgsForCall []struct {
+		arg1 string
+		arg2 map[string]bool
+	}
+	reconcileDeletedReturns struct {
+		result1 error
+	}
+	reconcileDeletedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReleaseContentStub        func(string, string, string, bool) error
+	releaseContentMutex       sync.RWMutex
+	releaseContentArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 bool
+	}
+	releaseContentReturns struct {
+		result1 error
+	}
+	releaseContentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemoveFileStub        func(string) error
+	removeFileMutex       sync.RWMutex
+	removeFileArgsForCall []struct {
+		arg1 string
+	}
+	removeFileReturns struct {
+		result1 error
+	}
+	removeFileReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveDownloadErrorStub        func(string, string, string) error
+	saveDownloadErrorMutex       sync.RWMutex
+	saveDownloadErrorArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	saveDownloadErrorReturns struct {
+		result1 error
+	}
+	saveDownloadErrorReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveDriveFileMetaStub        func(string, files.DriveFileMeta) error
+	saveDriveFileMetaMutex       sync.RWMutex
+	saveDriveFileMetaArgsForCall []struct {
+		arg1 string
+		arg2 files.DriveFileMeta
+	}
+	saveDriveFileMetaReturns struct {
+		result1 error
+	}
+	saveDriveFileMetaReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveFileMetaStub        func(string, files.FileMeta) error
+	saveFileMetaMutex       sync.RWMutex
+	saveFileMetaArgsForCall []struct {
+		arg1 string
+		arg2 files.FileMeta
+	}
+	saveFileMetaReturns struct {
+		result1 error
+	}
+	saveFileMetaReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StoreContentStub        func(string, string, string, string, bool) error
+	storeContentMutex       sync.RWMutex
+	storeContentArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}
+	storeContentReturns struct {
+		result1 error
+	}
+	storeContentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateCreationTimeStub        func(string, string) error
+	updateCreationTimeMutex       sync.RWMutex
+	updateCreationTimeArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	updateCreationTimeReturns struct {
+		result1 error
+	}
+	updateCreationTimeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateXmpSidecarStub        func(string, string) error
+	updateXmpSidecarMutex       sync.RWMutex
+	updateXmpSidecarArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	updateXmpSidecarReturns struct {
+		result1 error
+	}
+	updateXmpSidecarReturnsOnCall map[int]struct {
+		result1 error
+	}
+	VerifyStub        func(string, bool) (files.VerifyReport, error)
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct {
+		arg1 string
+		arg2 bool
+	}
+	verifyReturns struct {
+		result1 files.VerifyReport
+		result2 error
+	}
+	verifyReturnsOnCall map[int]struct {
+		result1 files.VerifyReport
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeFilesManager) DriveFileExists(arg1 string, arg2 string, arg3 drive.File) (bool, error) {
+	fake.driveFileExistsMutex.Lock()
+	ret, specificReturn := fake.driveFileExistsReturnsOnCall[len(fake.driveFileExistsArgsForCall)]
+	fake.driveFileExistsArgsForCall = append(fake.driveFileExistsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 drive.File
+	}{arg1, arg2, arg3})
+	stub := fake.DriveFileExistsStub
+	fakeReturns := fake.driveFileExistsReturns
+	fake.recordInvocation("DriveFileExists", []interface{}{arg1, arg2, arg3})
+	fake.driveFileExistsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) DriveFileExistsCallCount() int {
+	fake.driveFileExistsMutex.RLock()
+	defer fake.driveFileExistsMutex.RUnlock()
+	return len(fake.driveFileExistsArgsForCall)
+}
+
+func (fake *FakeFilesManager) DriveFileExistsCalls(stub func(string, string, drive.File) (bool, error)) {
+	fake.driveFileExistsMutex.Lock()
+	defer fake.driveFileExistsMutex.Unlock()
+	fake.DriveFileExistsStub = stub
+}
+
+func (fake *FakeFilesManager) DriveFileExistsArgsForCall(i int) (string, string, drive.File) {
+	fake.driveFileExistsMutex.RLock()
+	defer fake.driveFileExistsMutex.RUnlock()
+	argsForCall := fake.driveFileExistsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeFilesManager) DriveFileExistsReturns(result1 bool, result2 error) {
+	fake.driveFileExistsMutex.Lock()
+	defer fake.driveFileExistsMutex.Unlock()
+	fake.DriveFileExistsStub = nil
+	fake.driveFileExistsReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) DriveFileExistsReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.driveFileExistsMutex.Lock()
+	defer fake.driveFileExistsMutex.Unlock()
+	fake.DriveFileExistsStub = nil
+	if fake.driveFileExistsReturnsOnCall == nil {
+		fake.driveFileExistsReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.driveFileExistsReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) EmbedExif(arg1 string, arg2 media.MediaItem) (bool, error) {
+	fake.embedExifMutex.Lock()
+	ret, specificReturn := fake.embedExifReturnsOnCall[len(fake.embedExifArgsForCall)]
+	fake.embedExifArgsForCall = append(fake.embedExifArgsForCall, struct {
+		arg1 string
+		arg2 media.MediaItem
+	}{arg1, arg2})
+	stub := fake.EmbedExifStub
+	fakeReturns := fake.embedExifReturns
+	fake.recordInvocation("EmbedExif", []interface{}{arg1, arg2})
+	fake.embedExifMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) EmbedExifCallCount() int {
+	fake.embedExifMutex.RLock()
+	defer fake.embedExifMutex.RUnlock()
+	return len(fake.embedExifArgsForCall)
+}
+
+func (fake *FakeFilesManager) EmbedExifCalls(stub func(string, media.MediaItem) (bool, error)) {
+	fake.embedExifMutex.Lock()
+	defer fake.embedExifMutex.Unlock()
+	fake.EmbedExifStub = stub
+}
+
+func (fake *FakeFilesManager) EmbedExifArgsForCall(i int) (string, media.MediaItem) {
+	fake.embedExifMutex.RLock()
+	defer fake.embedExifMutex.RUnlock()
+	argsForCall := fake.embedExifArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) EmbedExifReturns(result1 bool, result2 error) {
+	fake.embedExifMutex.Lock()
+	defer fake.embedExifMutex.Unlock()
+	fake.EmbedExifStub = nil
+	fake.embedExifReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) EmbedExifReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.embedExifMutex.Lock()
+	defer fake.embedExifMutex.Unlock()
+	fake.EmbedExifStub = nil
+	if fake.embedExifReturnsOnCall == nil {
+		fake.embedExifReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.embedExifReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) FileExists(arg1 string, arg2 media.MediaItem) (bool, error) {
+	fake.fileExistsMutex.Lock()
+	ret, specificReturn := fake.fileExistsReturnsOnCall[len(fake.fileExistsArgsForCall)]
+	fake.fileExistsArgsForCall = append(fake.fileExistsArgsForCall, struct {
+		arg1 string
+		arg2 media.MediaItem
+	}{arg1, arg2})
+	stub := fake.FileExistsStub
+	fakeReturns := fake.fileExistsReturns
+	fake.recordInvocation("FileExists", []interface{}{arg1, arg2})
+	fake.fileExistsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) FileExistsCallCount() int {
+	fake.fileExistsMutex.RLock()
+	defer fake.fileExistsMutex.RUnlock()
+	return len(fake.fileExistsArgsForCall)
+}
+
+func (fake *FakeFilesManager) FileExistsCalls(stub func(string, media.MediaItem) (bool, error)) {
+	fake.fileExistsMutex.Lock()
+	defer fake.fileExistsMutex.Unlock()
+	fake.FileExistsStub = stub
+}
+
+func (fake *FakeFilesManager) FileExistsArgsForCall(i int) (string, media.MediaItem) {
+	fake.fileExistsMutex.RLock()
+	defer fake.fileExistsMutex.RUnlock()
+	argsForCall := fake.fileExistsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) FileExistsReturns(result1 bool, result2 error) {
+	fake.fileExistsMutex.Lock()
+	defer fake.fileExistsMutex.Unlock()
+	fake.FileExistsStub = nil
+	fake.fileExistsReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) FileExistsReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.fileExistsMutex.Lock()
+	defer fake.fileExistsMutex.Unlock()
+	fake.FileExistsStub = nil
+	if fake.fileExistsReturnsOnCall == nil {
+		fake.fileExistsReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.fileExistsReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GenerateDriveFilePathName(arg1 string, arg2 string, arg3 drive.File) (string, error) {
+	fake.generateDriveFilePathNameMutex.Lock()
+	ret, specificReturn := fake.generateDriveFilePathNameReturnsOnCall[len(fake.generateDriveFilePathNameArgsForCall)]
+	fake.generateDriveFilePathNameArgsForCall = append(fake.generateDriveFilePathNameArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 drive.File
+	}{arg1, arg2, arg3})
+	stub := fake.GenerateDriveFilePathNameStub
+	fakeReturns := fake.generateDriveFilePathNameReturns
+	fake.recordInvocation("GenerateDriveFilePathName", []interface{}{arg1, arg2, arg3})
+	fake.generateDriveFilePathNameMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) GenerateDriveFilePathNameCallCount() int {
+	fake.generateDriveFilePathNameMutex.RLock()
+	defer fake.generateDriveFilePathNameMutex.RUnlock()
+	return len(fake.generateDriveFilePathNameArgsForCall)
+}
+
+func (fake *FakeFilesManager) GenerateDriveFilePathNameCalls(stub func(string, string, drive.File) (string, error)) {
+	fake.generateDriveFilePathNameMutex.Lock()
+	defer fake.generateDriveFilePathNameMutex.Unlock()
+	fake.GenerateDriveFilePathNameStub = stub
+}
+
+func (fake *FakeFilesManager) GenerateDriveFilePathNameArgsForCall(i int) (string, string, drive.File) {
+	fake.generateDriveFilePathNameMutex.RLock()
+	defer fake.generateDriveFilePathNameMutex.RUnlock()
+	argsForCall := fake.generateDriveFilePathNameArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeFilesManager) GenerateDriveFilePathNameReturns(result1 string, result2 error) {
+	fake.generateDriveFilePathNameMutex.Lock()
+	defer fake.generateDriveFilePathNameMutex.Unlock()
+	fake.GenerateDriveFilePathNameStub = nil
+	fake.generateDriveFilePathNameReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GenerateDriveFilePathNameReturnsOnCall(i int, result1 string, result2 error) {
+	fake.generateDriveFilePathNameMutex.Lock()
+	defer fake.generateDriveFilePathNameMutex.Unlock()
+	fake.GenerateDriveFilePathNameStub = nil
+	if fake.generateDriveFilePathNameReturnsOnCall == nil {
+		fake.generateDriveFilePathNameReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.generateDriveFilePathNameReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GenerateDriveFolderPathName(arg1 string, arg2 string) string {
+	fake.generateDriveFolderPathNameMutex.Lock()
+	ret, specificReturn := fake.generateDriveFolderPathNameReturnsOnCall[len(fake.generateDriveFolderPathNameArgsForCall)]
+	fake.generateDriveFolderPathNameArgsForCall = append(fake.generateDriveFolderPathNameArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GenerateDriveFolderPathNameStub
+	fakeReturns := fake.generateDriveFolderPathNameReturns
+	fake.recordInvocation("GenerateDriveFolderPathName", []interface{}{arg1, arg2})
+	fake.generateDriveFolderPathNameMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) GenerateDriveFolderPathNameCallCount() int {
+	fake.generateDriveFolderPathNameMutex.RLock()
+	defer fake.generateDriveFolderPathNameMutex.RUnlock()
+	return len(fake.generateDriveFolderPathNameArgsForCall)
+}
+
+func (fake *FakeFilesManager) GenerateDriveFolderPathNameCalls(stub func(string, string) string) {
+	fake.generateDriveFolderPathNameMutex.Lock()
+	defer fake.generateDriveFolderPathNameMutex.Unlock()
+	fake.GenerateDriveFolderPathNameStub = stub
+}
+
+func (fake *FakeFilesManager) GenerateDriveFolderPathNameArgsForCall(i int) (string, string) {
+	fake.generateDriveFolderPathNameMutex.RLock()
+	defer fake.generateDriveFolderPathNameMutex.RUnlock()
+	argsForCall := fake.generateDriveFolderPathNameArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) GenerateDriveFolderPathNameReturns(result1 string) {
+	fake.generateDriveFolderPathNameMutex.Lock()
+	defer fake.generateDriveFolderPathNameMutex.Unlock()
+	fake.GenerateDriveFolderPathNameStub = nil
+	fake.generateDriveFolderPathNameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeFilesManager) GenerateDriveFolderPathNameReturnsOnCall(i int, result1 string) {
+	fake.generateDriveFolderPathNameMutex.Lock()
+	defer fake.generateDriveFolderPathNameMutex.Unlock()
+	fake.GenerateDriveFolderPathNameStub = nil
+	if fake.generateDriveFolderPathNameReturnsOnCall == nil {
+		fake.generateDriveFolderPathNameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.generateDriveFolderPathNameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeFilesManager) GenerateFilePathName(arg1 string, arg2 media.MediaItem) (string, error) {
+	fake.generateFilePathNameMutex.Lock()
+	ret, specificReturn := fake.generateFilePathNameReturnsOnCall[len(fake.generateFilePathNameArgsForCall)]
+	fake.generateFilePathNameArgsForCall = append(fake.generateFilePathNameArgsForCall, struct {
+		arg1 string
+		arg2 media.MediaItem
+	}{arg1, arg2})
+	stub := fake.GenerateFilePathNameStub
+	fakeReturns := fake.generateFilePathNameReturns
+	fake.recordInvocation("GenerateFilePathName", []interface{}{arg1, arg2})
+	fake.generateFilePathNameMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) GenerateFilePathNameCallCount() int {
+	fake.generateFilePathNameMutex.RLock()
+	defer fake.generateFilePathNameMutex.RUnlock()
+	return len(fake.generateFilePathNameArgsForCall)
+}
+
+func (fake *FakeFilesManager) GenerateFilePathNameCalls(stub func(string, media.MediaItem) (string, error)) {
+	fake.generateFilePathNameMutex.Lock()
+	defer fake.generateFilePathNameMutex.Unlock()
+	fake.GenerateFilePathNameStub = stub
+}
+
+func (fake *FakeFilesManager) GenerateFilePathNameArgsForCall(i int) (string, media.MediaItem) {
+	fake.generateFilePathNameMutex.RLock()
+	defer fake.generateFilePathNameMutex.RUnlock()
+	argsForCall := fake.generateFilePathNameArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) GenerateFilePathNameReturns(result1 string, result2 error) {
+	fake.generateFilePathNameMutex.Lock()
+	defer fake.generateFilePathNameMutex.Unlock()
+	fake.GenerateFilePathNameStub = nil
+	fake.generateFilePathNameReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GenerateFilePathNameReturnsOnCall(i int, result1 string, result2 error) {
+	fake.generateFilePathNameMutex.Lock()
+	defer fake.generateFilePathNameMutex.Unlock()
+	fake.GenerateFilePathNameStub = nil
+	if fake.generateFilePathNameReturnsOnCall == nil {
+		fake.generateFilePathNameReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.generateFilePathNameReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GenerateMotionVideoFilePathName(arg1 string) string {
+	fake.generateMotionVideoFilePathNameMutex.Lock()
+	ret, specificReturn := fake.generateMotionVideoFilePathNameReturnsOnCall[len(fake.generateMotionVideoFilePathNameArgsForCall)]
+	fake.generateMotionVideoFilePathNameArgsForCall = append(fake.generateMotionVideoFilePathNameArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GenerateMotionVideoFilePathNameStub
+	fakeReturns := fake.generateMotionVideoFilePathNameReturns
+	fake.recordInvocation("GenerateMotionVideoFilePathName", []interface{}{arg1})
+	fake.generateMotionVideoFilePathNameMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) GenerateMotionVideoFilePathNameCallCount() int {
+	fake.generateMotionVideoFilePathNameMutex.RLock()
+	defer fake.generateMotionVideoFilePathNameMutex.RUnlock()
+	return len(fake.generateMotionVideoFilePathNameArgsForCall)
+}
+
+func (fake *FakeFilesManager) GenerateMotionVideoFilePathNameCalls(stub func(string) string) {
+	fake.generateMotionVideoFilePathNameMutex.Lock()
+	defer fake.generateMotionVideoFilePathNameMutex.Unlock()
+	fake.GenerateMotionVideoFilePathNameStub = stub
+}
+
+func (fake *FakeFilesManager) GenerateMotionVideoFilePathNameArgsForCall(i int) string {
+	fake.generateMotionVideoFilePathNameMutex.RLock()
+	defer fake.generateMotionVideoFilePathNameMutex.RUnlock()
+	argsForCall := fake.generateMotionVideoFilePathNameArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeFilesManager) GenerateMotionVideoFilePathNameReturns(result1 string) {
+	fake.generateMotionVideoFilePathNameMutex.Lock()
+	defer fake.generateMotionVideoFilePathNameMutex.Unlock()
+	fake.GenerateMotionVideoFilePathNameStub = nil
+	fake.generateMotionVideoFilePathNameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeFilesManager) GenerateMotionVideoFilePathNameReturnsOnCall(i int, result1 string) {
+	fake.generateMotionVideoFilePathNameMutex.Lock()
+	defer fake.generateMotionVideoFilePathNameMutex.Unlock()
+	fake.GenerateMotionVideoFilePathNameStub = nil
+	if fake.generateMotionVideoFilePathNameReturnsOnCall == nil {
+		fake.generateMotionVideoFilePathNameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.generateMotionVideoFilePathNameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeFilesManager) GetDedupStats(arg1 string) (files.DedupStats, error) {
+	fake.getDedupStatsMutex.Lock()
+	ret, specificReturn := fake.getDedupStatsReturnsOnCall[len(fake.getDedupStatsArgsForCall)]
+	fake.getDedupStatsArgsForCall = append(fake.getDedupStatsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetDedupStatsStub
+	fakeReturns := fake.getDedupStatsReturns
+	fake.recordInvocation("GetDedupStats", []interface{}{arg1})
+	fake.getDedupStatsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) GetDedupStatsCallCount() int {
+	fake.getDedupStatsMutex.RLock()
+	defer fake.getDedupStatsMutex.RUnlock()
+	return len(fake.getDedupStatsArgsForCall)
+}
+
+func (fake *FakeFilesManager) GetDedupStatsCalls(stub func(string) (files.DedupStats, error)) {
+	fake.getDedupStatsMutex.Lock()
+	defer fake.getDedupStatsMutex.Unlock()
+	fake.GetDedupStatsStub = stub
+}
+
+func (fake *FakeFilesManager) GetDedupStatsArgsForCall(i int) string {
+	fake.getDedupStatsMutex.RLock()
+	defer fake.getDedupStatsMutex.RUnlock()
+	argsForCall := fake.getDedupStatsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeFilesManager) GetDedupStatsReturns(result1 files.DedupStats, result2 error) {
+	fake.getDedupStatsMutex.Lock()
+	defer fake.getDedupStatsMutex.Unlock()
+	fake.GetDedupStatsStub = nil
+	fake.getDedupStatsReturns = struct {
+		result1 files.DedupStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GetDedupStatsReturnsOnCall(i int, result1 files.DedupStats, result2 error) {
+	fake.getDedupStatsMutex.Lock()
+	defer fake.getDedupStatsMutex.Unlock()
+	fake.GetDedupStatsStub = nil
+	if fake.getDedupStatsReturnsOnCall == nil {
+		fake.getDedupStatsReturnsOnCall = make(map[int]struct {
+			result1 files.DedupStats
+			result2 error
+		})
+	}
+	fake.getDedupStatsReturnsOnCall[i] = struct {
+		result1 files.DedupStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GetDriveFileMeta(arg1 string, arg2 string) (files.DriveFileMeta, bool, error) {
+	fake.getDriveFileMetaMutex.Lock()
+	ret, specificReturn := fake.getDriveFileMetaReturnsOnCall[len(fake.getDriveFileMetaArgsForCall)]
+	fake.getDriveFileMetaArgsForCall = append(fake.getDriveFileMetaArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetDriveFileMetaStub
+	fakeReturns := fake.getDriveFileMetaReturns
+	fake.recordInvocation("GetDriveFileMeta", []interface{}{arg1, arg2})
+	fake.getDriveFileMetaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeFilesManager) GetDriveFileMetaCallCount() int {
+	fake.getDriveFileMetaMutex.RLock()
+	defer fake.getDriveFileMetaMutex.RUnlock()
+	return len(fake.getDriveFileMetaArgsForCall)
+}
+
+func (fake *FakeFilesManager) GetDriveFileMetaCalls(stub func(string, string) (files.DriveFileMeta, bool, error)) {
+	fake.getDriveFileMetaMutex.Lock()
+	defer fake.getDriveFileMetaMutex.Unlock()
+	fake.GetDriveFileMetaStub = stub
+}
+
+func (fake *FakeFilesManager) GetDriveFileMetaArgsForCall(i int) (string, string) {
+	fake.getDriveFileMetaMutex.RLock()
+	defer fake.getDriveFileMetaMutex.RUnlock()
+	argsForCall := fake.getDriveFileMetaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) GetDriveFileMetaReturns(result1 files.DriveFileMeta, result2 bool, result3 error) {
+	fake.getDriveFileMetaMutex.Lock()
+	defer fake.getDriveFileMetaMutex.Unlock()
+	fake.GetDriveFileMetaStub = nil
+	fake.getDriveFileMetaReturns = struct {
+		result1 files.DriveFileMeta
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeFilesManager) GetDriveFileMetaReturnsOnCall(i int, result1 files.DriveFileMeta, result2 bool, result3 error) {
+	fake.getDriveFileMetaMutex.Lock()
+	defer fake.getDriveFileMetaMutex.Unlock()
+	fake.GetDriveFileMetaStub = nil
+	if fake.getDriveFileMetaReturnsOnCall == nil {
+		fake.getDriveFileMetaReturnsOnCall = make(map[int]struct {
+			result1 files.DriveFileMeta
+			result2 bool
+			result3 error
+		})
+	}
+	fake.getDriveFileMetaReturnsOnCall[i] = struct {
+		result1 files.DriveFileMeta
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeFilesManager) GetFileMeta(arg1 string, arg2 string) (files.FileMeta, bool, error) {
+	fake.getFileMetaMutex.Lock()
+	ret, specificReturn := fake.getFileMetaReturnsOnCall[len(fake.getFileMetaArgsForCall)]
+	fake.getFileMetaArgsForCall = append(fake.getFileMetaArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetFileMetaStub
+	fakeReturns := fake.getFileMetaReturns
+	fake.recordInvocation("GetFileMeta", []interface{}{arg1, arg2})
+	fake.getFileMetaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeFilesManager) GetFileMetaCallCount() int {
+	fake.getFileMetaMutex.RLock()
+	defer fake.getFileMetaMutex.RUnlock()
+	return len(fake.getFileMetaArgsForCall)
+}
+
+func (fake *FakeFilesManager) GetFileMetaCalls(stub func(string, string) (files.FileMeta, bool, error)) {
+	fake.getFileMetaMutex.Lock()
+	defer fake.getFileMetaMutex.Unlock()
+	fake.GetFileMetaStub = stub
+}
+
+func (fake *FakeFilesManager) GetFileMetaArgsForCall(i int) (string, string) {
+	fake.getFileMetaMutex.RLock()
+	defer fake.getFileMetaMutex.RUnlock()
+	argsForCall := fake.getFileMetaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) GetFileMetaReturns(result1 files.FileMeta, result2 bool, result3 error) {
+	fake.getFileMetaMutex.Lock()
+	defer fake.getFileMetaMutex.Unlock()
+	fake.GetFileMetaStub = nil
+	fake.getFileMetaReturns = struct {
+		result1 files.FileMeta
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeFilesManager) GetFileMetaReturnsOnCall(i int, result1 files.FileMeta, result2 bool, result3 error) {
+	fake.getFileMetaMutex.Lock()
+	defer fake.getFileMetaMutex.Unlock()
+	fake.GetFileMetaStub = nil
+	if fake.getFileMetaReturnsOnCall == nil {
+		fake.getFileMetaReturnsOnCall = make(map[int]struct {
+			result1 files.FileMeta
+			result2 bool
+			result3 error
+		})
+	}
+	fake.getFileMetaReturnsOnCall[i] = struct {
+		result1 files.FileMeta
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeFilesManager) GetMediaItemAlbums(arg1 string, arg2 string) ([]media.Album, error) {
+	fake.getMediaItemAlbumsMutex.Lock()
+	ret, specificReturn := fake.getMediaItemAlbumsReturnsOnCall[len(fake.getMediaItemAlbumsArgsForCall)]
+	fake.getMediaItemAlbumsArgsForCall = append(fake.getMediaItemAlbumsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetMediaItemAlbumsStub
+	fakeReturns := fake.getMediaItemAlbumsReturns
+	fake.recordInvocation("GetMediaItemAlbums", []interface{}{arg1, arg2})
+	fake.getMediaItemAlbumsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) GetMediaItemAlbumsCallCount() int {
+	fake.getMediaItemAlbumsMutex.RLock()
+	defer fake.getMediaItemAlbumsMutex.RUnlock()
+	return len(fake.getMediaItemAlbumsArgsForCall)
+}
+
+func (fake *FakeFilesManager) GetMediaItemAlbumsCalls(stub func(string, string) ([]media.Album, error)) {
+	fake.getMediaItemAlbumsMutex.Lock()
+	defer fake.getMediaItemAlbumsMutex.Unlock()
+	fake.GetMediaItemAlbumsStub = stub
+}
+
+func (fake *FakeFilesManager) GetMediaItemAlbumsArgsForCall(i int) (string, string) {
+	fake.getMediaItemAlbumsMutex.RLock()
+	defer fake.getMediaItemAlbumsMutex.RUnlock()
+	argsForCall := fake.getMediaItemAlbumsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) GetMediaItemAlbumsReturns(result1 []media.Album, result2 error) {
+	fake.getMediaItemAlbumsMutex.Lock()
+	defer fake.getMediaItemAlbumsMutex.Unlock()
+	fake.GetMediaItemAlbumsStub = nil
+	fake.getMediaItemAlbumsReturns = struct {
+		result1 []media.Album
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GetMediaItemAlbumsReturnsOnCall(i int, result1 []media.Album, result2 error) {
+	fake.getMediaItemAlbumsMutex.Lock()
+	defer fake.getMediaItemAlbumsMutex.Unlock()
+	fake.GetMediaItemAlbumsStub = nil
+	if fake.getMediaItemAlbumsReturnsOnCall == nil {
+		fake.getMediaItemAlbumsReturnsOnCall = make(map[int]struct {
+			result1 []media.Album
+			result2 error
+		})
+	}
+	fake.getMediaItemAlbumsReturnsOnCall[i] = struct {
+		result1 []media.Album
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GetRemotelyDeleted(arg1 string) ([]files.FileMeta, error) {
+	fake.getRemotelyDeletedMutex.Lock()
+	ret, specificReturn := fake.getRemotelyDeletedReturnsOnCall[len(fake.getRemotelyDeletedArgsForCall)]
+	fake.getRemotelyDeletedArgsForCall = append(fake.getRemotelyDeletedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetRemotelyDeletedStub
+	fakeReturns := fake.getRemotelyDeletedReturns
+	fake.recordInvocation("GetRemotelyDeleted", []interface{}{arg1})
+	fake.getRemotelyDeletedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) GetRemotelyDeletedCallCount() int {
+	fake.getRemotelyDeletedMutex.RLock()
+	defer fake.getRemotelyDeletedMutex.RUnlock()
+	return len(fake.getRemotelyDeletedArgsForCall)
+}
+
+func (fake *FakeFilesManager) GetRemotelyDeletedCalls(stub func(string) ([]files.FileMeta, error)) {
+	fake.getRemotelyDeletedMutex.Lock()
+	defer fake.getRemotelyDeletedMutex.Unlock()
+	fake.GetRemotelyDeletedStub = stub
+}
+
+func (fake *FakeFilesManager) GetRemotelyDeletedArgsForCall(i int) string {
+	fake.getRemotelyDeletedMutex.RLock()
+	defer fake.getRemotelyDeletedMutex.RUnlock()
+	argsForCall := fake.getRemotelyDeletedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeFilesManager) GetRemotelyDeletedReturns(result1 []files.FileMeta, result2 error) {
+	fake.getRemotelyDeletedMutex.Lock()
+	defer fake.getRemotelyDeletedMutex.Unlock()
+	fake.GetRemotelyDeletedStub = nil
+	fake.getRemotelyDeletedReturns = struct {
+		result1 []files.FileMeta
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) GetRemotelyDeletedReturnsOnCall(i int, result1 []files.FileMeta, result2 error) {
+	fake.getRemotelyDeletedMutex.Lock()
+	defer fake.getRemotelyDeletedMutex.Unlock()
+	fake.GetRemotelyDeletedStub = nil
+	if fake.getRemotelyDeletedReturnsOnCall == nil {
+		fake.getRemotelyDeletedReturnsOnCall = make(map[int]struct {
+			result1 []files.FileMeta
+			result2 error
+		})
+	}
+	fake.getRemotelyDeletedReturnsOnCall[i] = struct {
+		result1 []files.FileMeta
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) HashFile(arg1 string) (string, error) {
+	fake.hashFileMutex.Lock()
+	ret, specificReturn := fake.hashFileReturnsOnCall[len(fake.hashFileArgsForCall)]
+	fake.hashFileArgsForCall = append(fake.hashFileArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.HashFileStub
+	fakeReturns := fake.hashFileReturns
+	fake.recordInvocation("HashFile", []interface{}{arg1})
+	fake.hashFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) HashFileCallCount() int {
+	fake.hashFileMutex.RLock()
+	defer fake.hashFileMutex.RUnlock()
+	return len(fake.hashFileArgsForCall)
+}
+
+func (fake *FakeFilesManager) HashFileCalls(stub func(string) (string, error)) {
+	fake.hashFileMutex.Lock()
+	defer fake.hashFileMutex.Unlock()
+	fake.HashFileStub = stub
+}
+
+func (fake *FakeFilesManager) HashFileArgsForCall(i int) string {
+	fake.hashFileMutex.RLock()
+	defer fake.hashFileMutex.RUnlock()
+	argsForCall := fake.hashFileArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeFilesManager) HashFileReturns(result1 string, result2 error) {
+	fake.hashFileMutex.Lock()
+	defer fake.hashFileMutex.Unlock()
+	fake.HashFileStub = nil
+	fake.hashFileReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) HashFileReturnsOnCall(i int, result1 string, result2 error) {
+	fake.hashFileMutex.Lock()
+	defer fake.hashFileMutex.Unlock()
+	fake.HashFileStub = nil
+	if fake.hashFileReturnsOnCall == nil {
+		fake.hashFileReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.hashFileReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) LinkToAlbums(arg1 string, arg2 string, arg3 string) error {
+	fake.linkToAlbumsMutex.Lock()
+	ret, specificReturn := fake.linkToAlbumsReturnsOnCall[len(fake.linkToAlbumsArgsForCall)]
+	fake.linkToAlbumsArgsForCall = append(fake.linkToAlbumsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.LinkToAlbumsStub
+	fakeReturns := fake.linkToAlbumsReturns
+	fake.recordInvocation("LinkToAlbums", []interface{}{arg1, arg2, arg3})
+	fake.linkToAlbumsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) LinkToAlbumsCallCount() int {
+	fake.linkToAlbumsMutex.RLock()
+	defer fake.linkToAlbumsMutex.RUnlock()
+	return len(fake.linkToAlbumsArgsForCall)
+}
+
+func (fake *FakeFilesManager) LinkToAlbumsCalls(stub func(string, string, string) error) {
+	fake.linkToAlbumsMutex.Lock()
+	defer fake.linkToAlbumsMutex.Unlock()
+	fake.LinkToAlbumsStub = stub
+}
+
+func (fake *FakeFilesManager) LinkToAlbumsArgsForCall(i int) (string, string, string) {
+	fake.linkToAlbumsMutex.RLock()
+	defer fake.linkToAlbumsMutex.RUnlock()
+	argsForCall := fake.linkToAlbumsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeFilesManager) LinkToAlbumsReturns(result1 error) {
+	fake.linkToAlbumsMutex.Lock()
+	defer fake.linkToAlbumsMutex.Unlock()
+	fake.LinkToAlbumsStub = nil
+	fake.linkToAlbumsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) LinkToAlbumsReturnsOnCall(i int, result1 error) {
+	fake.linkToAlbumsMutex.Lock()
+	defer fake.linkToAlbumsMutex.Unlock()
+	fake.LinkToAlbumsStub = nil
+	if fake.linkToAlbumsReturnsOnCall == nil {
+		fake.linkToAlbumsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.linkToAlbumsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) MarkDriveFileRemoved(arg1 string, arg2 string, arg3 *drive.File) error {
+	fake.markDriveFileRemovedMutex.Lock()
+	ret, specificReturn := fake.markDriveFileRemovedReturnsOnCall[len(fake.markDriveFileRemovedArgsForCall)]
+	fake.markDriveFileRemovedArgsForCall = append(fake.markDriveFileRemovedArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 *drive.File
+	}{arg1, arg2, arg3})
+	stub := fake.MarkDriveFileRemovedStub
+	fakeReturns := fake.markDriveFileRemovedReturns
+	fake.recordInvocation("MarkDriveFileRemoved", []interface{}{arg1, arg2, arg3})
+	fake.markDriveFileRemovedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) MarkDriveFileRemovedCallCount() int {
+	fake.markDriveFileRemovedMutex.RLock()
+	defer fake.markDriveFileRemovedMutex.RUnlock()
+	return len(fake.markDriveFileRemovedArgsForCall)
+}
+
+func (fake *FakeFilesManager) MarkDriveFileRemovedCalls(stub func(string, string, *drive.File) error) {
+	fake.markDriveFileRemovedMutex.Lock()
+	defer fake.markDriveFileRemovedMutex.Unlock()
+	fake.MarkDriveFileRemovedStub = stub
+}
+
+func (fake *FakeFilesManager) MarkDriveFileRemovedArgsForCall(i int) (string, string, *drive.File) {
+	fake.markDriveFileRemovedMutex.RLock()
+	defer fake.markDriveFileRemovedMutex.RUnlock()
+	argsForCall := fake.markDriveFileRemovedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeFilesManager) MarkDriveFileRemovedReturns(result1 error) {
+	fake.markDriveFileRemovedMutex.Lock()
+	defer fake.markDriveFileRemovedMutex.Unlock()
+	fake.MarkDriveFileRemovedStub = nil
+	fake.markDriveFileRemovedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) MarkDriveFileRemovedReturnsOnCall(i int, result1 error) {
+	fake.markDriveFileRemovedMutex.Lock()
+	defer fake.markDriveFileRemovedMutex.Unlock()
+	fake.MarkDriveFileRemovedStub = nil
+	if fake.markDriveFileRemovedReturnsOnCall == nil {
+		fake.markDriveFileRemovedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.markDriveFileRemovedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) MoveDriveFile(arg1 string, arg2 files.DriveFileMeta, arg3 drive.File, arg4 string) error {
+	fake.moveDriveFileMutex.Lock()
+	ret, specificReturn := fake.moveDriveFileReturnsOnCall[len(fake.moveDriveFileArgsForCall)]
+	fake.moveDriveFileArgsForCall = append(fake.moveDriveFileArgsForCall, struct {
+		arg1 string
+		arg2 files.DriveFileMeta
+		arg3 drive.File
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.MoveDriveFileStub
+	fakeReturns := fake.moveDriveFileReturns
+	fake.recordInvocation("MoveDriveFile", []interface{}{arg1, arg2, arg3, arg4})
+	fake.moveDriveFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) MoveDriveFileCallCount() int {
+	fake.moveDriveFileMutex.RLock()
+	defer fake.moveDriveFileMutex.RUnlock()
+	return len(fake.moveDriveFileArgsForCall)
+}
+
+func (fake *FakeFilesManager) MoveDriveFileCalls(stub func(string, files.DriveFileMeta, drive.File, string) error) {
+	fake.moveDriveFileMutex.Lock()
+	defer fake.moveDriveFileMutex.Unlock()
+	fake.MoveDriveFileStub = stub
+}
+
+func (fake *FakeFilesManager) MoveDriveFileArgsForCall(i int) (string, files.DriveFileMeta, drive.File, string) {
+	fake.moveDriveFileMutex.RLock()
+	defer fake.moveDriveFileMutex.RUnlock()
+	argsForCall := fake.moveDriveFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeFilesManager) MoveDriveFileReturns(result1 error) {
+	fake.moveDriveFileMutex.Lock()
+	defer fake.moveDriveFileMutex.Unlock()
+	fake.MoveDriveFileStub = nil
+	fake.moveDriveFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) MoveDriveFileReturnsOnCall(i int, result1 error) {
+	fake.moveDriveFileMutex.Lock()
+	defer fake.moveDriveFileMutex.Unlock()
+	fake.MoveDriveFileStub = nil
+	if fake.moveDriveFileReturnsOnCall == nil {
+		fake.moveDriveFileReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.moveDriveFileReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) MoveDriveFolder(arg1 string, arg2 string, arg3 string) error {
+	fake.moveDriveFolderMutex.Lock()
+	ret, specificReturn := fake.moveDriveFolderReturnsOnCall[len(fake.moveDriveFolderArgsForCall)]
+	fake.moveDriveFolderArgsForCall = append(fake.moveDriveFolderArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.MoveDriveFolderStub
+	fakeReturns := fake.moveDriveFolderReturns
+	fake.recordInvocation("MoveDriveFolder", []interface{}{arg1, arg2, arg3})
+	fake.moveDriveFolderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) MoveDriveFolderCallCount() int {
+	fake.moveDriveFolderMutex.RLock()
+	defer fake.moveDriveFolderMutex.RUnlock()
+	return len(fake.moveDriveFolderArgsForCall)
+}
+
+func (fake *FakeFilesManager) MoveDriveFolderCalls(stub func(string, string, string) error) {
+	fake.moveDriveFolderMutex.Lock()
+	defer fake.moveDriveFolderMutex.Unlock()
+	fake.MoveDriveFolderStub = stub
+}
+
+func (fake *FakeFilesManager) MoveDriveFolderArgsForCall(i int) (string, string, string) {
+	fake.moveDriveFolderMutex.RLock()
+	defer fake.moveDriveFolderMutex.RUnlock()
+	argsForCall := fake.moveDriveFolderArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeFilesManager) MoveDriveFolderReturns(result1 error) {
+	fake.moveDriveFolderMutex.Lock()
+	defer fake.moveDriveFolderMutex.Unlock()
+	fake.MoveDriveFolderStub = nil
+	fake.moveDriveFolderReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) MoveDriveFolderReturnsOnCall(i int, result1 error) {
+	fake.moveDriveFolderMutex.Lock()
+	defer fake.moveDriveFolderMutex.Unlock()
+	fake.MoveDriveFolderStub = nil
+	if fake.moveDriveFolderReturnsOnCall == nil {
+		fake.moveDriveFolderReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.moveDriveFolderReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) ReconcileDeleted(arg1 string, arg2 map[string]bool) error {
+	fake.reconcileDeletedMutex.Lock()
+	ret, specificReturn := fake.reconcileDeletedReturnsOnCall[len(fake.reconcileDeletedArgsForCall)]
+	fake.reconcileDeletedArgsForCall = append(fake.reconcileDeletedArgsForCall, struct {
+		arg1 string
+		arg2 map[string]bool
+	}{arg1, arg2})
+	stub := fake.ReconcileDeletedStub
+	fakeReturns := fake.reconcileDeletedReturns
+	fake.recordInvocation("ReconcileDeleted", []interface{}{arg1, arg2})
+	fake.reconcileDeletedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) ReconcileDeletedCallCount() int {
+	fake.reconcileDeletedMutex.RLock()
+	defer fake.reconcileDeletedMutex.RUnlock()
+	return len(fake.reconcileDeletedArgsForCall)
+}
+
+func (fake *FakeFilesManager) ReconcileDeletedCalls(stub func(string, map[string]bool) error) {
+	fake.reconcileDeletedMutex.Lock()
+	defer fake.reconcileDeletedMutex.Unlock()
+	fake.ReconcileDeletedStub = stub
+}
+
+func (fake *FakeFilesManager) ReconcileDeletedArgsForCall(i int) (string, map[string]bool) {
+	fake.reconcileDeletedMutex.RLock()
+	defer fake.reconcileDeletedMutex.RUnlock()
+	argsForCall := fake.reconcileDeletedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) ReconcileDeletedReturns(result1 error) {
+	fake.reconcileDeletedMutex.Lock()
+	defer fake.reconcileDeletedMutex.Unlock()
+	fake.ReconcileDeletedStub = nil
+	fake.reconcileDeletedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) ReconcileDeletedReturnsOnCall(i int, result1 error) {
+	fake.reconcileDeletedMutex.Lock()
+	defer fake.reconcileDeletedMutex.Unlock()
+	fake.ReconcileDeletedStub = nil
+	if fake.reconcileDeletedReturnsOnCall == nil {
+		fake.reconcileDeletedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.reconcileDeletedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) ReleaseContent(arg1 string, arg2 string, arg3 string, arg4 bool) error {
+	fake.releaseContentMutex.Lock()
+	ret, specificReturn := fake.releaseContentReturnsOnCall[len(fake.releaseContentArgsForCall)]
+	fake.releaseContentArgsForCall = append(fake.releaseContentArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.ReleaseContentStub
+	fakeReturns := fake.releaseContentReturns
+	fake.recordInvocation("ReleaseContent", []interface{}{arg1, arg2, arg3, arg4})
+	fake.releaseContentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) ReleaseContentCallCount() int {
+	fake.releaseContentMutex.RLock()
+	defer fake.releaseContentMutex.RUnlock()
+	return len(fake.releaseContentArgsForCall)
+}
+
+func (fake *FakeFilesManager) ReleaseContentCalls(stub func(string, string, string, bool) error) {
+	fake.releaseContentMutex.Lock()
+	defer fake.releaseContentMutex.Unlock()
+	fake.ReleaseContentStub = stub
+}
+
+func (fake *FakeFilesManager) ReleaseContentArgsForCall(i int) (string, string, string, bool) {
+	fake.releaseContentMutex.RLock()
+	defer fake.releaseContentMutex.RUnlock()
+	argsForCall := fake.releaseContentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeFilesManager) ReleaseContentReturns(result1 error) {
+	fake.releaseContentMutex.Lock()
+	defer fake.releaseContentMutex.Unlock()
+	fake.ReleaseContentStub = nil
+	fake.releaseContentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) ReleaseContentReturnsOnCall(i int, result1 error) {
+	fake.releaseContentMutex.Lock()
+	defer fake.releaseContentMutex.Unlock()
+	fake.ReleaseContentStub = nil
+	if fake.releaseContentReturnsOnCall == nil {
+		fake.releaseContentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.releaseContentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) RemoveFile(arg1 string) error {
+	fake.removeFileMutex.Lock()
+	ret, specificReturn := fake.removeFileReturnsOnCall[len(fake.removeFileArgsForCall)]
+	fake.removeFileArgsForCall = append(fake.removeFileArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RemoveFileStub
+	fakeReturns := fake.removeFileReturns
+	fake.recordInvocation("RemoveFile", []interface{}{arg1})
+	fake.removeFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) RemoveFileCallCount() int {
+	fake.removeFileMutex.RLock()
+	defer fake.removeFileMutex.RUnlock()
+	return len(fake.removeFileArgsForCall)
+}
+
+func (fake *FakeFilesManager) RemoveFileCalls(stub func(string) error) {
+	fake.removeFileMutex.Lock()
+	defer fake.removeFileMutex.Unlock()
+	fake.RemoveFileStub = stub
+}
+
+func (fake *FakeFilesManager) RemoveFileArgsForCall(i int) string {
+	fake.removeFileMutex.RLock()
+	defer fake.removeFileMutex.RUnlock()
+	argsForCall := fake.removeFileArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeFilesManager) RemoveFileReturns(result1 error) {
+	fake.removeFileMutex.Lock()
+	defer fake.removeFileMutex.Unlock()
+	fake.RemoveFileStub = nil
+	fake.removeFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) RemoveFileReturnsOnCall(i int, result1 error) {
+	fake.removeFileMutex.Lock()
+	defer fake.removeFileMutex.Unlock()
+	fake.RemoveFileStub = nil
+	if fake.removeFileReturnsOnCall == nil {
+		fake.removeFileReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeFileReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) SaveDownloadError(arg1 string, arg2 string, arg3 string) error {
+	fake.saveDownloadErrorMutex.Lock()
+	ret, specificReturn := fake.saveDownloadErrorReturnsOnCall[len(fake.saveDownloadErrorArgsForCall)]
+	fake.saveDownloadErrorArgsForCall = append(fake.saveDownloadErrorArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.SaveDownloadErrorStub
+	fakeReturns := fake.saveDownloadErrorReturns
+	fake.recordInvocation("SaveDownloadError", []interface{}{arg1, arg2, arg3})
+	fake.saveDownloadErrorMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) SaveDownloadErrorCallCount() int {
+	fake.saveDownloadErrorMutex.RLock()
+	defer fake.saveDownloadErrorMutex.RUnlock()
+	return len(fake.saveDownloadErrorArgsForCall)
+}
+
+func (fake *FakeFilesManager) SaveDownloadErrorCalls(stub func(string, string, string) error) {
+	fake.saveDownloadErrorMutex.Lock()
+	defer fake.saveDownloadErrorMutex.Unlock()
+	fake.SaveDownloadErrorStub = stub
+}
+
+func (fake *FakeFilesManager) SaveDownloadErrorArgsForCall(i int) (string, string, string) {
+	fake.saveDownloadErrorMutex.RLock()
+	defer fake.saveDownloadErrorMutex.RUnlock()
+	argsForCall := fake.saveDownloadErrorArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeFilesManager) SaveDownloadErrorReturns(result1 error) {
+	fake.saveDownloadErrorMutex.Lock()
+	defer fake.saveDownloadErrorMutex.Unlock()
+	fake.SaveDownloadErrorStub = nil
+	fake.saveDownloadErrorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) SaveDownloadErrorReturnsOnCall(i int, result1 error) {
+	fake.saveDownloadErrorMutex.Lock()
+	defer fake.saveDownloadErrorMutex.Unlock()
+	fake.SaveDownloadErrorStub = nil
+	if fake.saveDownloadErrorReturnsOnCall == nil {
+		fake.saveDownloadErrorReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveDownloadErrorReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) SaveDriveFileMeta(arg1 string, arg2 files.DriveFileMeta) error {
+	fake.saveDriveFileMetaMutex.Lock()
+	ret, specificReturn := fake.saveDriveFileMetaReturnsOnCall[len(fake.saveDriveFileMetaArgsForCall)]
+	fake.saveDriveFileMetaArgsForCall = append(fake.saveDriveFileMetaArgsForCall, struct {
+		arg1 string
+		arg2 files.DriveFileMeta
+	}{arg1, arg2})
+	stub := fake.SaveDriveFileMetaStub
+	fakeReturns := fake.saveDriveFileMetaReturns
+	fake.recordInvocation("SaveDriveFileMeta", []interface{}{arg1, arg2})
+	fake.saveDriveFileMetaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) SaveDriveFileMetaCallCount() int {
+	fake.saveDriveFileMetaMutex.RLock()
+	defer fake.saveDriveFileMetaMutex.RUnlock()
+	return len(fake.saveDriveFileMetaArgsForCall)
+}
+
+func (fake *FakeFilesManager) SaveDriveFileMetaCalls(stub func(string, files.DriveFileMeta) error) {
+	fake.saveDriveFileMetaMutex.Lock()
+	defer fake.saveDriveFileMetaMutex.Unlock()
+	fake.SaveDriveFileMetaStub = stub
+}
+
+func (fake *FakeFilesManager) SaveDriveFileMetaArgsForCall(i int) (string, files.DriveFileMeta) {
+	fake.saveDriveFileMetaMutex.RLock()
+	defer fake.saveDriveFileMetaMutex.RUnlock()
+	argsForCall := fake.saveDriveFileMetaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) SaveDriveFileMetaReturns(result1 error) {
+	fake.saveDriveFileMetaMutex.Lock()
+	defer fake.saveDriveFileMetaMutex.Unlock()
+	fake.SaveDriveFileMetaStub = nil
+	fake.saveDriveFileMetaReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) SaveDriveFileMetaReturnsOnCall(i int, result1 error) {
+	fake.saveDriveFileMetaMutex.Lock()
+	defer fake.saveDriveFileMetaMutex.Unlock()
+	fake.SaveDriveFileMetaStub = nil
+	if fake.saveDriveFileMetaReturnsOnCall == nil {
+		fake.saveDriveFileMetaReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveDriveFileMetaReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) SaveFileMeta(arg1 string, arg2 files.FileMeta) error {
+	fake.saveFileMetaMutex.Lock()
+	ret, specificReturn := fake.saveFileMetaReturnsOnCall[len(fake.saveFileMetaArgsForCall)]
+	fake.saveFileMetaArgsForCall = append(fake.saveFileMetaArgsForCall, struct {
+		arg1 string
+		arg2 files.FileMeta
+	}{arg1, arg2})
+	stub := fake.SaveFileMetaStub
+	fakeReturns := fake.saveFileMetaReturns
+	fake.recordInvocation("SaveFileMeta", []interface{}{arg1, arg2})
+	fake.saveFileMetaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) SaveFileMetaCallCount() int {
+	fake.saveFileMetaMutex.RLock()
+	defer fake.saveFileMetaMutex.RUnlock()
+	return len(fake.saveFileMetaArgsForCall)
+}
+
+func (fake *FakeFilesManager) SaveFileMetaCalls(stub func(string, files.FileMeta) error) {
+	fake.saveFileMetaMutex.Lock()
+	defer fake.saveFileMetaMutex.Unlock()
+	fake.SaveFileMetaStub = stub
+}
+
+func (fake *FakeFilesManager) SaveFileMetaArgsForCall(i int) (string, files.FileMeta) {
+	fake.saveFileMetaMutex.RLock()
+	defer fake.saveFileMetaMutex.RUnlock()
+	argsForCall := fake.saveFileMetaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) SaveFileMetaReturns(result1 error) {
+	fake.saveFileMetaMutex.Lock()
+	defer fake.saveFileMetaMutex.Unlock()
+	fake.SaveFileMetaStub = nil
+	fake.saveFileMetaReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) SaveFileMetaReturnsOnCall(i int, result1 error) {
+	fake.saveFileMetaMutex.Lock()
+	defer fake.saveFileMetaMutex.Unlock()
+	fake.SaveFileMetaStub = nil
+	if fake.saveFileMetaReturnsOnCall == nil {
+		fake.saveFileMetaReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveFileMetaReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) StoreContent(arg1 string, arg2 string, arg3 string, arg4 string, arg5 bool) error {
+	fake.storeContentMutex.Lock()
+	ret, specificReturn := fake.storeContentReturnsOnCall[len(fake.storeContentArgsForCall)]
+	fake.storeContentArgsForCall = append(fake.storeContentArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.StoreContentStub
+	fakeReturns := fake.storeContentReturns
+	fake.recordInvocation("StoreContent", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.storeContentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) StoreContentCallCount() int {
+	fake.storeContentMutex.RLock()
+	defer fake.storeContentMutex.RUnlock()
+	return len(fake.storeContentArgsForCall)
+}
+
+func (fake *FakeFilesManager) StoreContentCalls(stub func(string, string, string, string, bool) error) {
+	fake.storeContentMutex.Lock()
+	defer fake.storeContentMutex.Unlock()
+	fake.StoreContentStub = stub
+}
+
+func (fake *FakeFilesManager) StoreContentArgsForCall(i int) (string, string, string, string, bool) {
+	fake.storeContentMutex.RLock()
+	defer fake.storeContentMutex.RUnlock()
+	argsForCall := fake.storeContentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeFilesManager) StoreContentReturns(result1 error) {
+	fake.storeContentMutex.Lock()
+	defer fake.storeContentMutex.Unlock()
+	fake.StoreContentStub = nil
+	fake.storeContentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) StoreContentReturnsOnCall(i int, result1 error) {
+	fake.storeContentMutex.Lock()
+	defer fake.storeContentMutex.Unlock()
+	fake.StoreContentStub = nil
+	if fake.storeContentReturnsOnCall == nil {
+		fake.storeContentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeContentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) UpdateCreationTime(arg1 string, arg2 string) error {
+	fake.updateCreationTimeMutex.Lock()
+	ret, specificReturn := fake.updateCreationTimeReturnsOnCall[len(fake.updateCreationTimeArgsForCall)]
+	fake.updateCreationTimeArgsForCall = append(fake.updateCreationTimeArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UpdateCreationTimeStub
+	fakeReturns := fake.updateCreationTimeReturns
+	fake.recordInvocation("UpdateCreationTime", []interface{}{arg1, arg2})
+	fake.updateCreationTimeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) UpdateCreationTimeCallCount() int {
+	fake.updateCreationTimeMutex.RLock()
+	defer fake.updateCreationTimeMutex.RUnlock()
+	return len(fake.updateCreationTimeArgsForCall)
+}
+
+func (fake *FakeFilesManager) UpdateCreationTimeCalls(stub func(string, string) error) {
+	fake.updateCreationTimeMutex.Lock()
+	defer fake.updateCreationTimeMutex.Unlock()
+	fake.UpdateCreationTimeStub = stub
+}
+
+func (fake *FakeFilesManager) UpdateCreationTimeArgsForCall(i int) (string, string) {
+	fake.updateCreationTimeMutex.RLock()
+	defer fake.updateCreationTimeMutex.RUnlock()
+	argsForCall := fake.updateCreationTimeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) UpdateCreationTimeReturns(result1 error) {
+	fake.updateCreationTimeMutex.Lock()
+	defer fake.updateCreationTimeMutex.Unlock()
+	fake.UpdateCreationTimeStub = nil
+	fake.updateCreationTimeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) UpdateCreationTimeReturnsOnCall(i int, result1 error) {
+	fake.updateCreationTimeMutex.Lock()
+	defer fake.updateCreationTimeMutex.Unlock()
+	fake.UpdateCreationTimeStub = nil
+	if fake.updateCreationTimeReturnsOnCall == nil {
+		fake.updateCreationTimeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateCreationTimeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) UpdateXmpSidecar(arg1 string, arg2 string) error {
+	fake.updateXmpSidecarMutex.Lock()
+	ret, specificReturn := fake.updateXmpSidecarReturnsOnCall[len(fake.updateXmpSidecarArgsForCall)]
+	fake.updateXmpSidecarArgsForCall = append(fake.updateXmpSidecarArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UpdateXmpSidecarStub
+	fakeReturns := fake.updateXmpSidecarReturns
+	fake.recordInvocation("UpdateXmpSidecar", []interface{}{arg1, arg2})
+	fake.updateXmpSidecarMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeFilesManager) UpdateXmpSidecarCallCount() int {
+	fake.updateXmpSidecarMutex.RLock()
+	defer fake.updateXmpSidecarMutex.RUnlock()
+	return len(fake.updateXmpSidecarArgsForCall)
+}
+
+func (fake *FakeFilesManager) UpdateXmpSidecarCalls(stub func(string, string) error) {
+	fake.updateXmpSidecarMutex.Lock()
+	defer fake.updateXmpSidecarMutex.Unlock()
+	fake.UpdateXmpSidecarStub = stub
+}
+
+func (fake *FakeFilesManager) UpdateXmpSidecarArgsForCall(i int) (string, string) {
+	fake.updateXmpSidecarMutex.RLock()
+	defer fake.updateXmpSidecarMutex.RUnlock()
+	argsForCall := fake.updateXmpSidecarArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) UpdateXmpSidecarReturns(result1 error) {
+	fake.updateXmpSidecarMutex.Lock()
+	defer fake.updateXmpSidecarMutex.Unlock()
+	fake.UpdateXmpSidecarStub = nil
+	fake.updateXmpSidecarReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) UpdateXmpSidecarReturnsOnCall(i int, result1 error) {
+	fake.updateXmpSidecarMutex.Lock()
+	defer fake.updateXmpSidecarMutex.Unlock()
+	fake.UpdateXmpSidecarStub = nil
+	if fake.updateXmpSidecarReturnsOnCall == nil {
+		fake.updateXmpSidecarReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateXmpSidecarReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilesManager) Verify(arg1 string, arg2 bool) (files.VerifyReport, error) {
+	fake.verifyMutex.Lock()
+	ret, specificReturn := fake.verifyReturnsOnCall[len(fake.verifyArgsForCall)]
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct {
+		arg1 string
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.VerifyStub
+	fakeReturns := fake.verifyReturns
+	fake.recordInvocation("Verify", []interface{}{arg1, arg2})
+	fake.verifyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFilesManager) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *FakeFilesManager) VerifyCalls(stub func(string, bool) (files.VerifyReport, error)) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = stub
+}
+
+func (fake *FakeFilesManager) VerifyArgsForCall(i int) (string, bool) {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	argsForCall := fake.verifyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFilesManager) VerifyReturns(result1 files.VerifyReport, result2 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 files.VerifyReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) VerifyReturnsOnCall(i int, result1 files.VerifyReport, result2 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	if fake.verifyReturnsOnCall == nil {
+		fake.verifyReturnsOnCall = make(map[int]struct {
+			result1 files.VerifyReport
+			result2 error
+		})
+	}
+	fake.verifyReturnsOnCall[i] = struct {
+		result1 files.VerifyReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFilesManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.driveFileExistsMutex.RLock()
+	defer fake.driveFileExistsMutex.RUnlock()
+	fake.embedExifMutex.RLock()
+	defer fake.embedExifMutex.RUnlock()
+	fake.fileExistsMutex.RLock()
+	defer fake.fileExistsMutex.RUnlock()
+	fake.generateDriveFilePathNameMutex.RLock()
+	defer fake.generateDriveFilePathNameMutex.RUnlock()
+	fake.generateDriveFolderPathNameMutex.RLock()
+	defer fake.generateDriveFolderPathNameMutex.RUnlock()
+	fake.generateFilePathNameMutex.RLock()
+	defer fake.generateFilePathNameMutex.RUnlock()
+	fake.generateMotionVideoFilePathNameMutex.RLock()
+	defer fake.generateMotionVideoFilePathNameMutex.RUnlock()
+	fake.getDedupStatsMutex.RLock()
+	defer fake.getDedupStatsMutex.RUnlock()
+	fake.getDriveFileMetaMutex.RLock()
+	defer fake.getDriveFileMetaMutex.RUnlock()
+	fake.getFileMetaMutex.RLock()
+	defer fake.getFileMetaMutex.RUnlock()
+	fake.getMediaItemAlbumsMutex.RLock()
+	defer fake.getMediaItemAlbumsMutex.RUnlock()
+	fake.getRemotelyDeletedMutex.RLock()
+	defer fake.getRemotelyDeletedMutex.RUnlock()
+	fake.hashFileMutex.RLock()
+	defer fake.hashFileMutex.RUnlock()
+	fake.linkToAlbumsMutex.RLock()
+	defer fake.linkToAlbumsMutex.RUnlock()
+	fake.markDriveFileRemovedMutex.RLock()
+	defer fake.markDriveFileRemovedMutex.RUnlock()
+	fake.moveDriveFileMutex.RLock()
+	defer fake.moveDriveFileMutex.RUnlock()
+	fake.moveDriveFolderMutex.RLock()
+	defer fake.moveDriveFolderMutex.RUnlock()
+	fake.reconcileDeletedMutex.RLock()
+	defer fake.reconcileDeletedMutex.RUnlock()
+	fake.releaseContentMutex.RLock()
+	defer fake.releaseContentMutex.RUnlock()
+	fake.removeFileMutex.RLock()
+	defer fake.removeFileMutex.RUnlock()
+	fake.saveDownloadErrorMutex.RLock()
+	defer fake.saveDownloadErrorMutex.RUnlock()
+	fake.saveDriveFileMetaMutex.RLock()
+	defer fake.saveDriveFileMetaMutex.RUnlock()
+	fake.saveFileMetaMutex.RLock()
+	defer fake.saveFileMetaMutex.RUnlock()
+	fake.storeContentMutex.RLock()
+	defer fake.storeContentMutex.RUnlock()
+	fake.updateCreationTimeMutex.RLock()
+	defer fake.updateCreationTimeMutex.RUnlock()
+	fake.updateXmpSidecarMutex.RLock()
+	defer fake.updateXmpSidecarMutex.RUnlock()
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeFilesManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ files.FilesManager = new(FakeFilesManager)
@@ -0,0 +1,58 @@
+package files
+
+import (
+	"bytes"
+)
+
+// XMP properties of Google motion photos and the trailer of Samsung motion photos
+var motionPhotoMarkers = [][]byte{
+	[]byte("Camera:MotionPhoto"),
+	[]byte("GCamera:MicroVideo"),
+	[]byte("MotionPhoto_Data"),
+}
+
+// Finds the markers of an embedded video in the content written to it, e.g. while the image is downloaded,
+// so the image isn't read again. Only images with an embedded video have a video component to download
+type motionPhotoDetector struct {
+	found bool
+	// end of the previous write, markers split between two writes are found in it
+	tail    []byte
+	overlap int
+}
+
+func NewMotionPhotoDetector() *motionPhotoDetector {
+	overlap := 0
+	for _, marker := range motionPhotoMarkers {
+		overlap = max(overlap, len(marker)-1)
+	}
+
+	return &motionPhotoDetector{overlap: overlap}
+}
+
+func (d *motionPhotoDetector) Write(p []byte) (int, error) {
+	if d.found {
+		return len(p), nil
+	}
+
+	seam := append(d.tail, p[:min(len(p), d.overlap)]...)
+
+	for _, marker := range motionPhotoMarkers {
+		if bytes.Contains(seam, marker) || bytes.Contains(p, marker) {
+			d.found = true
+
+			return len(p), nil
+		}
+	}
+
+	if len(p) >= d.overlap {
+		d.tail = append(seam[:0], p[len(p)-d.overlap:]...)
+	} else {
+		d.tail = append(seam[:0], seam[max(0, len(seam)-d.overlap):]...)
+	}
+
+	return len(p), nil
+}
+
+func (d *motionPhotoDetector) Found() bool {
+	return d.found
+}
@@ -0,0 +1,45 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMotionPhotoDetector(t *testing.T) {
+	image := func(parts ...[]byte) []byte {
+		return bytes.Join(append([][]byte{{0xff, 0xd8}}, parts...), nil)
+	}
+
+	tests := []struct {
+		name     string
+		content  []byte
+		expected bool
+	}{
+		{"photo", image(bytes.Repeat([]byte{1}, 3*32*1024)), false},
+		{"google motion photo", image([]byte(`<rdf:Description GCamera:MotionPhoto="1" GCamera:MotionPhotoVersion="1">`)), true},
+		{"google micro video", image([]byte(`<rdf:Description GCamera:MicroVideo="1">`)), true},
+		{"samsung trailer", image(bytes.Repeat([]byte{1}, 2*32*1024), []byte("MotionPhoto_Data"), []byte{2, 3}), true},
+		{"marker across writes", image(bytes.Repeat([]byte{1}, 32*1024-10), []byte("MotionPhoto_Data")), true},
+	}
+
+	for _, test := range tests {
+		// downloads are written in chunks of the throttled reader, short writes split the markers
+		for _, chunkSize := range []int{32 * 1024, 5} {
+			t.Run(fmt.Sprintf("%s in chunks of %d bytes", test.name, chunkSize), func(t *testing.T) {
+				detector := NewMotionPhotoDetector()
+
+				for start := 0; start < len(test.content); start += chunkSize {
+					n, err := detector.Write(test.content[start:min(len(test.content), start+chunkSize)])
+
+					assert.NoError(t, err)
+					assert.Equal(t, min(len(test.content)-start, chunkSize), n)
+				}
+
+				assert.Equal(t, test.expected, detector.Found())
+			})
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package files
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google-backup/internal/media"
+)
+
+// Placeholders available in the path template setting
+const (
+	PathPlaceholderAccount     = "{account}"
+	PathPlaceholderYear        = "{year}"
+	PathPlaceholderMonth       = "{month}"
+	PathPlaceholderDay         = "{day}"
+	PathPlaceholderCameraMake  = "{camera_make}"
+	PathPlaceholderCameraModel = "{camera_model}"
+	PathPlaceholderMimeType    = "{mime_type}"
+	PathPlaceholderAlbum       = "{album}"
+	PathPlaceholderFilename    = "{filename}"
+	PathPlaceholderId          = "{id}"
+)
+
+const (
+	// Value of camera placeholders when the item has no camera info
+	pathTemplateUnknown = "unknown"
+	// Value of the album placeholder when the item is not in any album
+	pathTemplateNoAlbum = "no_album"
+)
+
+// Folders next to the photos in the account folder
+var reservedFolderNames = []string{albumsFolderName, driveFolderName, deletedFolderName}
+
+var pathPlaceholderRegexp = regexp.MustCompile(`\{[^{}]*\}`)
+
+var pathPlaceholders = []string{
+	PathPlaceholderAccount,
+	PathPlaceholderYear,
+	PathPlaceholderMonth,
+	PathPlaceholderDay,
+	PathPlaceholderCameraMake,
+	PathPlaceholderCameraModel,
+	PathPlaceholderMimeType,
+	PathPlaceholderAlbum,
+	PathPlaceholderFilename,
+	PathPlaceholderId,
+}
+
+// Checks that the template has known placeholders only and always places files inside the account folder.
+// The first folder must be "{account}", the file name must contain "{filename}"
+func ValidatePathTemplate(pathTemplate string) error {
+	if pathTemplate == "" {
+		return fmt.Errorf("path template is empty")
+	}
+
+	for _, placeholder := range pathPlaceholderRegexp.FindAllString(pathTemplate, -1) {
+		if !slices.Contains(pathPlaceholders, placeholder) {
+			return fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+	}
+
+	if strings.ContainsAny(pathPlaceholderRegexp.ReplaceAllString(pathTemplate, ""), "{}") {
+		return fmt.Errorf("unbalanced braces")
+	}
+
+	if strings.Contains(pathTemplate, "\\") {
+		return fmt.Errorf("backslashes are not allowed, use / as a separator")
+	}
+
+	segments := strings.Split(pathTemplate, "/")
+	if len(segments) < 2 || segments[0] != PathPlaceholderAccount {
+		return fmt.Errorf("path must start with the %s folder", PathPlaceholderAccount)
+	}
+
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("empty, . and .. folders are not allowed")
+		}
+	}
+
+	// album, drive and deleted items folders are created next to the photos
+	if slices.Contains(reservedFolderNames, segments[1]) {
+		return fmt.Errorf("%s folder is reserved", segments[1])
+	}
+
+	if !strings.Contains(segments[len(segments)-1], PathPlaceholderFilename) {
+		return fmt.Errorf("file name must contain %s", PathPlaceholderFilename)
+	}
+
+	return nil
+}
+
+// Replaces the placeholders with the media item values, album is the title of one of the item albums or empty
+func RenderPathTemplate(pathTemplate string, email string, mediaItem media.MediaItem, album string) (string, error) {
+	err := ValidatePathTemplate(pathTemplate)
+	if err != nil {
+		return "", fmt.Errorf("validate path template: %w", err)
+	}
+
+	creationTime, err := time.Parse(time.RFC3339, mediaItem.MediaMetadata.CreationTime)
+	if err != nil {
+		return "", fmt.Errorf("parse creation time: %w", err)
+	}
+
+	cameraMake, cameraModel := "", ""
+	if mediaItem.MediaMetadata.Photo != nil {
+		cameraMake, cameraModel = mediaItem.MediaMetadata.Photo.CameraMake, mediaItem.MediaMetadata.Photo.CameraModel
+	}
+
+	if mediaItem.MediaMetadata.Video != nil {
+		cameraMake, cameraModel = mediaItem.MediaMetadata.Video.CameraMake, mediaItem.MediaMetadata.Video.CameraModel
+	}
+
+	replacer := strings.NewReplacer(
+		PathPlaceholderAccount, pathTemplateValue(email, pathTemplateUnknown),
+		PathPlaceholderYear, strconv.Itoa(creationTime.Year()),
+		PathPlaceholderMonth, fmt.Sprintf("%02d", creationTime.Month()),
+		PathPlaceholderDay, fmt.Sprintf("%02d", creationTime.Day()),
+		PathPlaceholderCameraMake, pathTemplateValue(cameraMake, pathTemplateUnknown),
+		PathPlaceholderCameraModel, pathTemplateValue(cameraModel, pathTemplateUnknown),
+		PathPlaceholderMimeType, pathTemplateValue(mediaItem.MimeType, pathTemplateUnknown),
+		PathPlaceholderAlbum, pathTemplateValue(album, pathTemplateNoAlbum),
+		PathPlaceholderFilename, pathTemplateValue(mediaItem.Filename, mediaItem.ID),
+		PathPlaceholderId, pathTemplateValue(mediaItem.ID, pathTemplateUnknown),
+	)
+
+	segments := strings.Split(replacer.Replace(pathTemplate), "/")
+
+	// values can't place files into the album, drive and deleted items folders, e.g. an album titled "albums"
+	if slices.Contains(reservedFolderNames, segments[1]) {
+		segments[1] = "_" + segments[1]
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// Values can't add folders, e.g. the "image/jpeg" mime type becomes "image_jpeg"
+func pathTemplateValue(value string, fallback string) string {
+	value = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0 {
+			return '_'
+		}
+
+		return r
+	}, strings.TrimSpace(value))
+
+	if value == "" || value == "." || value == ".." {
+		return fallback
+	}
+
+	return value
+}
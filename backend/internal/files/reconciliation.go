@@ -0,0 +1,257 @@
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"google-backup/internal/settings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const deletedFolderName = "_deleted"
+
+// Marks files of media items which were not seen during a full scan as remotely deleted
+// and applies the deleted items policy. Items seen again are restored.
+// Items of albums shared by other accounts are not in the library, so they are skipped
+func (f files) ReconcileDeleted(email string, seenMediaItemIds map[string]bool) error {
+	// an empty library is more likely an API problem, nothing is marked to not lose files
+	if len(seenMediaItemIds) == 0 {
+		return nil
+	}
+
+	settingsData, err := f.settingsReader.Get()
+	if err != nil {
+		return fmt.Errorf("get settings: %w", err)
+	}
+
+	fileMetaJsons, err := f.repository.GetAllFileMeta(email)
+	if err != nil {
+		return fmt.Errorf("get all file meta: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	for mediaItemId, fileMetaJson := range fileMetaJsons {
+		var fileMeta FileMeta
+		err = json.Unmarshal(fileMetaJson, &fileMeta)
+		if err != nil {
+			return fmt.Errorf("unmarshal file meta: %w", err)
+		}
+
+		if seenMediaItemIds[mediaItemId] {
+			if fileMeta.RemoteDeletedTime != "" {
+				err = f.restoreDeleted(email, fileMeta)
+				if err != nil {
+					return fmt.Errorf("restore deleted: %w", err)
+				}
+			}
+
+			continue
+		}
+
+		sharedWithAccount, err := f.inAlbumSharedWithAccount(email, mediaItemId)
+		if err != nil {
+			return fmt.Errorf("in album shared with account: %w", err)
+		}
+
+		if sharedWithAccount {
+			continue
+		}
+
+		if fileMeta.RemoteDeletedTime == "" {
+			fileMeta.RemoteDeletedTime = now.Format(time.RFC3339)
+
+			log.WithFields(log.Fields{
+				"email":          email,
+				"media_item_id":  mediaItemId,
+				"file_path_name": fileMeta.FilePathName,
+			}).Info("media item was deleted from Google Photos")
+		}
+
+		err = f.applyDeletedItemsPolicy(email, settingsData, fileMeta, now)
+		if err != nil {
+			return fmt.Errorf("apply deleted items policy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (f files) GetRemotelyDeleted(email string) ([]FileMeta, error) {
+	fileMetaJsons, err := f.repository.GetAllFileMeta(email)
+	if err != nil {
+		return nil, fmt.Errorf("get all file meta: %w", err)
+	}
+
+	result := make([]FileMeta, 0)
+
+	for _, fileMetaJson := range fileMetaJsons {
+		var fileMeta FileMeta
+		err = json.Unmarshal(fileMetaJson, &fileMeta)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal file meta: %w", err)
+		}
+
+		if fileMeta.RemoteDeletedTime != "" {
+			result = append(result, fileMeta)
+		}
+	}
+
+	return result, nil
+}
+
+func (f files) applyDeletedItemsPolicy(
+	email string,
+	settingsData settings.SettingsData,
+	fileMeta FileMeta,
+	now time.Time,
+) error {
+	switch settingsData.DeletedItemsPolicy {
+	case settings.DeletedItemsPolicyMove:
+		if !f.isInDeletedFolder(email, fileMeta.FilePathName) {
+			deletedFilePathName := email + "/" + deletedFolderName + "/" + strings.TrimPrefix(fileMeta.FilePathName, email+"/")
+
+			err := f.moveFile(fileMeta.FilePathName, deletedFilePathName)
+			if err != nil {
+				return fmt.Errorf("move file: %w", err)
+			}
+
+			err = f.moveFile(f.xmpSidecarFilePathName(fileMeta.FilePathName), f.xmpSidecarFilePathName(deletedFilePathName))
+			if err != nil {
+				return fmt.Errorf("move xmp sidecar: %w", err)
+			}
+
+			fileMeta.FilePathName = deletedFilePathName
+
+			if fileMeta.MotionVideoFilePathName != "" {
+				deletedMotionVideoFilePathName := f.GenerateMotionVideoFilePathName(deletedFilePathName)
+
+				err = f.moveFile(fileMeta.MotionVideoFilePathName, deletedMotionVideoFilePathName)
+				if err != nil {
+					return fmt.Errorf("move motion video file: %w", err)
+				}
+
+				fileMeta.MotionVideoFilePathName = deletedMotionVideoFilePathName
+			}
+		}
+	case settings.DeletedItemsPolicyPrune:
+		deletedTime, err := time.Parse(time.RFC3339, fileMeta.RemoteDeletedTime)
+		if err != nil {
+			return fmt.Errorf("parse remote deleted time: %w", err)
+		}
+
+		if now.Sub(deletedTime) >= time.Duration(settingsData.DeletedItemsPruneDays)*24*time.Hour {
+			err = f.RemoveFile(fileMeta.FilePathName)
+			if err != nil {
+				return fmt.Errorf("remove file: %w", err)
+			}
+
+			err = f.RemoveFile(f.xmpSidecarFilePathName(fileMeta.FilePathName))
+			if err != nil {
+				return fmt.Errorf("remove xmp sidecar: %w", err)
+			}
+
+			if fileMeta.MotionVideoFilePathName != "" {
+				err = f.RemoveFile(fileMeta.MotionVideoFilePathName)
+				if err != nil {
+					return fmt.Errorf("remove motion video file: %w", err)
+				}
+			}
+
+			// the original path is claimed even if the file was moved into the deleted folder
+			originalFilePathName := email + "/" + strings.TrimPrefix(fileMeta.FilePathName, email+"/"+deletedFolderName+"/")
+
+			err = f.repository.ReleaseFilePath(email, originalFilePathName, fileMeta.MediaItem.ID)
+			if err != nil {
+				return fmt.Errorf("release file path: %w", err)
+			}
+
+			// the stored content is removed only when no other file links to it
+			if fileMeta.ContentHash != "" {
+				err = f.ReleaseContent(email, fileMeta.MediaItem.ID, fileMeta.ContentHash, false)
+				if err != nil {
+					return fmt.Errorf("release content: %w", err)
+				}
+			}
+
+			if fileMeta.MotionVideoContentHash != "" {
+				err = f.ReleaseContent(email, fileMeta.MediaItem.ID, fileMeta.MotionVideoContentHash, true)
+				if err != nil {
+					return fmt.Errorf("release motion video content: %w", err)
+				}
+			}
+
+			return f.repository.DeleteFileMeta(email, []byte(fileMeta.MediaItem.ID))
+		}
+	}
+
+	return f.SaveFileMeta(email, fileMeta)
+}
+
+func (f files) restoreDeleted(email string, fileMeta FileMeta) error {
+	if f.isInDeletedFolder(email, fileMeta.FilePathName) {
+		restoredFilePathName := email + "/" + strings.TrimPrefix(fileMeta.FilePathName, email+"/"+deletedFolderName+"/")
+
+		err := f.moveFile(fileMeta.FilePathName, restoredFilePathName)
+		if err != nil {
+			return fmt.Errorf("move file: %w", err)
+		}
+
+		err = f.moveFile(f.xmpSidecarFilePathName(fileMeta.FilePathName), f.xmpSidecarFilePathName(restoredFilePathName))
+		if err != nil {
+			return fmt.Errorf("move xmp sidecar: %w", err)
+		}
+
+		fileMeta.FilePathName = restoredFilePathName
+
+		if fileMeta.MotionVideoFilePathName != "" {
+			restoredMotionVideoFilePathName := f.GenerateMotionVideoFilePathName(restoredFilePathName)
+
+			err = f.moveFile(fileMeta.MotionVideoFilePathName, restoredMotionVideoFilePathName)
+			if err != nil {
+				return fmt.Errorf("move motion video file: %w", err)
+			}
+
+			fileMeta.MotionVideoFilePathName = restoredMotionVideoFilePathName
+		}
+	}
+
+	fileMeta.RemoteDeletedTime = ""
+
+	return f.SaveFileMeta(email, fileMeta)
+}
+
+func (f files) inAlbumSharedWithAccount(email string, mediaItemId string) (bool, error) {
+	albums, err := f.albums.GetMediaItemAlbums(email, mediaItemId)
+	if err != nil {
+		return false, fmt.Errorf("get media item albums: %w", err)
+	}
+
+	for _, album := range albums {
+		if album.ShareInfo != nil && !album.ShareInfo.IsOwned {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (f files) isInDeletedFolder(email string, filePathName string) bool {
+	return strings.HasPrefix(filePathName, email+"/"+deletedFolderName+"/")
+}
+
+func (f files) moveFile(filePathName string, newFilePathName string) error {
+	exists, err := f.fileExists(filePathName)
+	if err != nil {
+		return fmt.Errorf("file exists: %w", err)
+	}
+
+	if !exists {
+		return nil
+	}
+
+	return f.storage.Rename(filePathName, newFilePathName)
+}
@@ -9,12 +9,31 @@ import (
 const (
 	downloadErrorsBucketName = "download_errors"
 	filesMetaDataBucketName  = "files_meta_data"
+	driveFilesMetaBucketName = "drive_files_meta_data"
+	filePathsBucketName      = "file_paths"
+	// Content store buckets are shared between accounts
+	contentObjectsBucketName    = "content_objects"
+	contentReferencesBucketName = "content_references"
 )
 
 type Repository interface {
 	SaveDownloadError(email string, mediaItemId, message string) error
 	SaveFileMeta(email string, key, data []byte) error
 	GetFileMeta(email string, key []byte) ([]byte, error)
+	GetAllFileMeta(email string) (map[string][]byte, error)
+	DeleteFileMeta(email string, key []byte) error
+	SaveDriveFileMeta(email string, key, data []byte) error
+	GetDriveFileMeta(email string, key []byte) ([]byte, error)
+	GetAllDriveFileMeta(email string) (map[string][]byte, error)
+	ClaimFilePath(email string, filePathName string, mediaItemId string) (string, error)
+	ReleaseFilePath(email string, filePathName string, mediaItemId string) error
+	GetFilePathOwner(email string, filePathName string) (string, error)
+	SaveContentObject(hash string, data []byte) error
+	GetContentObject(hash string) ([]byte, error)
+	DeleteContentObject(hash string) error
+	SaveContentReference(reference string, hash string) error
+	GetContentReference(reference string) (string, error)
+	DeleteContentReference(reference string) error
 }
 
 type repository struct {
@@ -42,22 +61,62 @@ func (r repository) SaveDownloadError(email string, mediaItemId string, message
 }
 
 func (r repository) SaveFileMeta(email string, key, data []byte) error {
+	return r.save(filesMetaDataBucketName, email, key, data)
+}
+
+func (r repository) GetFileMeta(email string, key []byte) ([]byte, error) {
+	return r.get(filesMetaDataBucketName, email, key)
+}
+
+func (r repository) GetAllFileMeta(email string) (map[string][]byte, error) {
+	return r.getAll(filesMetaDataBucketName, email)
+}
+
+func (r repository) DeleteFileMeta(email string, key []byte) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		filesMetaDataBucket := bucket.Bucket([]byte(filesMetaDataBucketName))
+		if filesMetaDataBucket == nil {
+			return nil
+		}
+
+		return filesMetaDataBucket.Delete(key)
+	})
+}
+
+func (r repository) SaveDriveFileMeta(email string, key, data []byte) error {
+	return r.save(driveFilesMetaBucketName, email, key, data)
+}
+
+func (r repository) GetDriveFileMeta(email string, key []byte) ([]byte, error) {
+	return r.get(driveFilesMetaBucketName, email, key)
+}
+
+func (r repository) GetAllDriveFileMeta(email string) (map[string][]byte, error) {
+	return r.getAll(driveFilesMetaBucketName, email)
+}
+
+func (r repository) save(bucketName string, email string, key, data []byte) error {
 	return r.db.Update(func(tx *bbolt.Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
 		if err != nil {
 			return fmt.Errorf("create bucket: %w", err)
 		}
 
-		filesMetaDataBucket, err := bucket.CreateBucketIfNotExists([]byte(filesMetaDataBucketName))
+		metaDataBucket, err := bucket.CreateBucketIfNotExists([]byte(bucketName))
 		if err != nil {
-			return fmt.Errorf("create files meta data bucket: %w", err)
+			return fmt.Errorf("create %s bucket: %w", bucketName, err)
 		}
 
-		return filesMetaDataBucket.Put(key, data)
+		return metaDataBucket.Put(key, data)
 	})
 }
 
-func (r repository) GetFileMeta(email string, key []byte) ([]byte, error) {
+func (r repository) get(bucketName string, email string, key []byte) ([]byte, error) {
 	var data []byte
 	data = nil
 
@@ -67,15 +126,164 @@ func (r repository) GetFileMeta(email string, key []byte) ([]byte, error) {
 			return nil
 		}
 
-		filesMetaDataBucket := bucket.Bucket([]byte(filesMetaDataBucketName))
-		if filesMetaDataBucket == nil {
+		metaDataBucket := bucket.Bucket([]byte(bucketName))
+		if metaDataBucket == nil {
 			return nil
 		}
 
-		data = filesMetaDataBucket.Get(key)
+		data = metaDataBucket.Get(key)
 
 		return nil
 	})
 
 	return data, err
 }
+
+func (r repository) getAll(bucketName string, email string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		metaDataBucket := bucket.Bucket([]byte(bucketName))
+		if metaDataBucket == nil {
+			return nil
+		}
+
+		return metaDataBucket.ForEach(func(k, v []byte) error {
+			values[string(k)] = append([]byte{}, v...)
+
+			return nil
+		})
+	})
+
+	return values, err
+}
+
+// Saves the media item as the owner of the path if the path is free. Returns the owner of the path
+func (r repository) ClaimFilePath(email string, filePathName string, mediaItemId string) (string, error) {
+	var owner string
+
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
+		if err != nil {
+			return fmt.Errorf("create bucket: %w", err)
+		}
+
+		filePathsBucket, err := bucket.CreateBucketIfNotExists([]byte(filePathsBucketName))
+		if err != nil {
+			return fmt.Errorf("create %s bucket: %w", filePathsBucketName, err)
+		}
+
+		value := filePathsBucket.Get([]byte(filePathName))
+		if value != nil {
+			owner = string(value)
+
+			return nil
+		}
+
+		owner = mediaItemId
+
+		return filePathsBucket.Put([]byte(filePathName), []byte(mediaItemId))
+	})
+
+	return owner, err
+}
+
+func (r repository) GetFilePathOwner(email string, filePathName string) (string, error) {
+	owner, err := r.get(filePathsBucketName, email, []byte(filePathName))
+
+	return string(owner), err
+}
+
+// Frees the path only if it is owned by the media item
+func (r repository) ReleaseFilePath(email string, filePathName string, mediaItemId string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		filePathsBucket := bucket.Bucket([]byte(filePathsBucketName))
+		if filePathsBucket == nil {
+			return nil
+		}
+
+		if string(filePathsBucket.Get([]byte(filePathName))) != mediaItemId {
+			return nil
+		}
+
+		return filePathsBucket.Delete([]byte(filePathName))
+	})
+}
+
+func (r repository) SaveContentObject(hash string, data []byte) error {
+	return r.saveShared(contentObjectsBucketName, []byte(hash), data)
+}
+
+func (r repository) GetContentObject(hash string) ([]byte, error) {
+	return r.getShared(contentObjectsBucketName, []byte(hash))
+}
+
+func (r repository) DeleteContentObject(hash string) error {
+	return r.deleteShared(contentObjectsBucketName, []byte(hash))
+}
+
+func (r repository) SaveContentReference(reference string, hash string) error {
+	return r.saveShared(contentReferencesBucketName, []byte(reference), []byte(hash))
+}
+
+func (r repository) GetContentReference(reference string) (string, error) {
+	hash, err := r.getShared(contentReferencesBucketName, []byte(reference))
+
+	return string(hash), err
+}
+
+func (r repository) DeleteContentReference(reference string) error {
+	return r.deleteShared(contentReferencesBucketName, []byte(reference))
+}
+
+func (r repository) saveShared(bucketName string, key, data []byte) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(bucketName))
+		if err != nil {
+			return fmt.Errorf("create %s bucket: %w", bucketName, err)
+		}
+
+		return bucket.Put(key, data)
+	})
+}
+
+func (r repository) getShared(bucketName string, key []byte) ([]byte, error) {
+	var data []byte
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		value := bucket.Get(key)
+		if value != nil {
+			data = append([]byte{}, value...)
+		}
+
+		return nil
+	})
+
+	return data, err
+}
+
+func (r repository) deleteShared(bucketName string, key []byte) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.Delete(key)
+	})
+}
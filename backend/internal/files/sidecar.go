@@ -0,0 +1,182 @@
+package files
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sidecars are named after the whole file name, e.g. "IMG_0001.JPG.xmp", as digiKam and darktable expect
+const xmpSidecarSuffix = ".xmp"
+
+func (f files) xmpSidecarFilePathName(filePathName string) string {
+	return filePathName + xmpSidecarSuffix
+}
+
+// Rewrites the sidecar of a downloaded item whose albums changed, items which aren't downloaded get it with the download
+func (f files) UpdateXmpSidecar(email string, mediaItemId string) error {
+	fileMeta, found, err := f.GetFileMeta(email, mediaItemId)
+	if err != nil {
+		return fmt.Errorf("get file meta: %w", err)
+	}
+
+	if !found {
+		return nil
+	}
+
+	return f.writeXmpSidecar(email, fileMeta)
+}
+
+// Writes the Google Photos metadata of the item into a XMP sidecar next to the file if sidecars are enabled.
+// The file is rewritten only when the metadata changed
+func (f files) writeXmpSidecar(email string, fileMeta FileMeta) error {
+	settingsData, err := f.settingsReader.Get()
+	if err != nil {
+		return fmt.Errorf("get settings: %w", err)
+	}
+
+	if !settingsData.XmpSidecarsEnabled || fileMeta.FilePathName == "" {
+		return nil
+	}
+
+	albums, err := f.albums.GetMediaItemAlbums(email, fileMeta.MediaItem.ID)
+	if err != nil {
+		return fmt.Errorf("get media item albums: %w", err)
+	}
+
+	albumTitles := make([]string, 0, len(albums))
+	for _, album := range albums {
+		title := strings.TrimSpace(album.Title)
+		if title != "" && !slices.Contains(albumTitles, title) {
+			albumTitles = append(albumTitles, title)
+		}
+	}
+
+	slices.Sort(albumTitles)
+
+	sidecar := f.xmpSidecar(fileMeta, albumTitles)
+	sidecarPathName := f.xmpSidecarFilePathName(fileMeta.FilePathName)
+
+	existing, err := f.readFile(sidecarPathName)
+	if err != nil {
+		return fmt.Errorf("read sidecar: %w", err)
+	}
+
+	if bytes.Equal(existing, sidecar) {
+		return nil
+	}
+
+	err = f.replaceFile(sidecarPathName, sidecar, nil)
+	if err != nil {
+		return fmt.Errorf("write sidecar: %w", err)
+	}
+
+	return nil
+}
+
+// Maps the media item fields to the Dublin Core, XMP, Photoshop, EXIF and TIFF namespaces
+func (f files) xmpSidecar(fileMeta FileMeta, albumTitles []string) []byte {
+	mediaItem := fileMeta.MediaItem
+	metadata := mediaItem.MediaMetadata
+
+	var properties strings.Builder
+
+	if mediaItem.Description != "" {
+		properties.WriteString("   <dc:description>\n    <rdf:Alt>\n     <rdf:li xml:lang=\"x-default\">" + xmpEscape(mediaItem.Description) + "</rdf:li>\n    </rdf:Alt>\n   </dc:description>\n")
+	}
+
+	if len(albumTitles) > 0 {
+		properties.WriteString("   <dc:subject>\n    <rdf:Bag>\n")
+		for _, title := range albumTitles {
+			properties.WriteString("     <rdf:li>" + xmpEscape(title) + "</rdf:li>\n")
+		}
+		properties.WriteString("    </rdf:Bag>\n   </dc:subject>\n")
+	}
+
+	if mediaItem.ContributorInfo.DisplayName != "" {
+		properties.WriteString("   <dc:contributor>\n    <rdf:Bag>\n     <rdf:li>" + xmpEscape(mediaItem.ContributorInfo.DisplayName) + "</rdf:li>\n    </rdf:Bag>\n   </dc:contributor>\n")
+	}
+
+	xmpProperty(&properties, "dc:identifier", mediaItem.ID)
+	xmpProperty(&properties, "dc:source", mediaItem.ProductUrl)
+	xmpProperty(&properties, "dc:format", mediaItem.MimeType)
+	xmpProperty(&properties, "xmp:CreateDate", metadata.CreationTime)
+	xmpProperty(&properties, "photoshop:DateCreated", metadata.CreationTime)
+	xmpProperty(&properties, "exif:DateTimeOriginal", metadata.CreationTime)
+	xmpProperty(&properties, "exif:PixelXDimension", metadata.Width)
+	xmpProperty(&properties, "exif:PixelYDimension", metadata.Height)
+
+	if metadata.Photo != nil {
+		xmpProperty(&properties, "tiff:Make", metadata.Photo.CameraMake)
+		xmpProperty(&properties, "tiff:Model", metadata.Photo.CameraModel)
+		xmpProperty(&properties, "exif:FocalLength", xmpRational(metadata.Photo.FocalLength))
+		xmpProperty(&properties, "exif:FNumber", xmpRational(metadata.Photo.ApertureFNumber))
+		xmpProperty(&properties, "exif:ExposureTime", xmpExposureTime(metadata.Photo.ExposureTime))
+
+		if metadata.Photo.IsoEquivalent > 0 {
+			properties.WriteString("   <exif:ISOSpeedRatings>\n    <rdf:Seq>\n     <rdf:li>" + strconv.Itoa(metadata.Photo.IsoEquivalent) + "</rdf:li>\n    </rdf:Seq>\n   </exif:ISOSpeedRatings>\n")
+		}
+	}
+
+	if metadata.Video != nil {
+		xmpProperty(&properties, "tiff:Make", metadata.Video.CameraMake)
+		xmpProperty(&properties, "tiff:Model", metadata.Video.CameraModel)
+	}
+
+	return []byte("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n" +
+		"<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n" +
+		" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n" +
+		"  <rdf:Description rdf:about=\"\"\n" +
+		"    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n" +
+		"    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n" +
+		"    xmlns:photoshop=\"http://ns.adobe.com/photoshop/1.0/\"\n" +
+		"    xmlns:exif=\"http://ns.adobe.com/exif/1.0/\"\n" +
+		"    xmlns:tiff=\"http://ns.adobe.com/tiff/1.0/\">\n" +
+		properties.String() +
+		"  </rdf:Description>\n" +
+		" </rdf:RDF>\n" +
+		"</x:xmpmeta>\n" +
+		"<?xpacket end=\"w\"?>\n")
+}
+
+func xmpProperty(properties *strings.Builder, name string, value string) {
+	if value == "" {
+		return
+	}
+
+	properties.WriteString("   <" + name + ">" + xmpEscape(value) + "</" + name + ">\n")
+}
+
+func xmpEscape(value string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(value))
+
+	return escaped.String()
+}
+
+// XMP stores EXIF rationals as "numerator/denominator"
+func xmpRational(value float64) string {
+	if value <= 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(int64(math.Round(value*100)), 10) + "/100"
+}
+
+// Google Photos returns durations like "0.008s", short exposures are written as "1/125"
+func xmpExposureTime(exposureTime string) string {
+	seconds, err := strconv.ParseFloat(strings.TrimSuffix(exposureTime, "s"), 64)
+	if err != nil || seconds <= 0 {
+		return ""
+	}
+
+	if seconds < 1 {
+		return "1/" + strconv.FormatInt(int64(math.Round(1/seconds)), 10)
+	}
+
+	return xmpRational(seconds)
+}
@@ -0,0 +1,49 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google-backup/internal/media"
+	"google-backup/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateXmpSidecar(t *testing.T) {
+	t.Run("item added to an album", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{XmpSidecarsEnabled: true})
+		mediaItem := testMediaItem("item-00000001", "IMG_0001.JPG")
+
+		require.NoError(t, f.SaveFileMeta(testEmail, FileMeta{FilePathName: "user@gmail.com/2023/4/IMG_0001.JPG", MediaItem: mediaItem}))
+
+		sidecar, err := os.ReadFile(filepath.Join(f.root, "user@gmail.com/2023/4/IMG_0001.JPG.xmp"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(sidecar), "Holidays")
+
+		require.NoError(t, f.albums.SaveAlbum(testEmail, media.Album{ID: "album-1", Title: "Holidays"}))
+		require.NoError(t, addMediaItem(f.albums, testEmail, "album-1", mediaItem.ID))
+
+		err = f.UpdateXmpSidecar(testEmail, mediaItem.ID)
+
+		assert.NoError(t, err)
+
+		sidecar, err = os.ReadFile(filepath.Join(f.root, "user@gmail.com/2023/4/IMG_0001.JPG.xmp"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(sidecar), "<rdf:li>Holidays</rdf:li>")
+	})
+
+	t.Run("item which isn't downloaded", func(t *testing.T) {
+		f := newTestFiles(t, settings.SettingsData{XmpSidecarsEnabled: true})
+
+		err := f.UpdateXmpSidecar(testEmail, "item-00000001")
+
+		assert.NoError(t, err)
+
+		entries, err := os.ReadDir(f.root)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
@@ -0,0 +1,289 @@
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+
+	"google-backup/internal/storage"
+)
+
+const (
+	contentStoreFolderName     = "_store"
+	motionVideoReferenceSuffix = "/motion"
+)
+
+// Serializes reference counting and linking, the same content may be stored by several accounts at once
+var contentStoreMutex sync.Mutex
+
+type ContentObject struct {
+	Size int64 `json:"size"`
+	// Files linked to the object, "email/mediaItemId" or "email/mediaItemId/motion" for motion videos
+	References []string `json:"references"`
+}
+
+type DedupStats struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+	// Files which content is also referenced by other files of any account
+	SharedFiles int   `json:"shared_files"`
+	SharedBytes int64 `json:"shared_bytes"`
+}
+
+// Content addressed objects are stored under "_store/ab/cd/abcd..." in the root folder
+func (f files) contentObjectPathName(hash string) string {
+	return path.Join(contentStoreFolderName, hash[0:2], hash[2:4], hash)
+}
+
+func contentReference(email string, mediaItemId string, motionVideo bool) string {
+	if motionVideo {
+		return email + "/" + mediaItemId + motionVideoReferenceSuffix
+	}
+
+	return email + "/" + mediaItemId
+}
+
+// Moves the downloaded file into the content store if it is enabled and replaces the file with a hardlink
+func (f files) StoreContent(email string, mediaItemId string, filePathName string, hash string, motionVideo bool) error {
+	settingsData, err := f.settingsReader.Get()
+	if err != nil {
+		return fmt.Errorf("get settings: %w", err)
+	}
+
+	if !settingsData.ContentStoreEnabled {
+		return nil
+	}
+
+	// the store is on the local disk, files of accounts on other storages stay where they are
+	if !storage.CanLink(f.storage, filePathName, f.contentObjectPathName(hash)) {
+		return nil
+	}
+
+	fileInfo, err := f.storage.Stat(filePathName)
+	if err != nil {
+		return fmt.Errorf("stat file: %w", err)
+	}
+
+	reference := contentReference(email, mediaItemId, motionVideo)
+
+	contentStoreMutex.Lock()
+	defer contentStoreMutex.Unlock()
+
+	previousHash, err := f.repository.GetContentReference(reference)
+	if err != nil {
+		return fmt.Errorf("get content reference: %w", err)
+	}
+
+	// the item was downloaded again with a different content
+	if previousHash != "" && previousHash != hash {
+		err = f.releaseContent(reference, previousHash)
+		if err != nil {
+			return fmt.Errorf("release previous content: %w", err)
+		}
+	}
+
+	object := ContentObject{Size: fileInfo.Size}
+
+	objectJson, err := f.repository.GetContentObject(hash)
+	if err != nil {
+		return fmt.Errorf("get content object: %w", err)
+	}
+
+	if objectJson != nil {
+		err = json.Unmarshal(objectJson, &object)
+		if err != nil {
+			return fmt.Errorf("unmarshal content object: %w", err)
+		}
+	}
+
+	err = f.linkContentObject(hash, filePathName)
+	if err != nil {
+		return fmt.Errorf("link content object: %w", err)
+	}
+
+	if !slices.Contains(object.References, reference) {
+		object.References = append(object.References, reference)
+	}
+
+	objectJson, err = json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("marshal content object: %w", err)
+	}
+
+	err = f.repository.SaveContentObject(hash, objectJson)
+	if err != nil {
+		return fmt.Errorf("save content object: %w", err)
+	}
+
+	err = f.repository.SaveContentReference(reference, hash)
+	if err != nil {
+		return fmt.Errorf("save content reference: %w", err)
+	}
+
+	return nil
+}
+
+// Drops the reference of a removed file and removes the object when nothing references it anymore
+func (f files) ReleaseContent(email string, mediaItemId string, hash string, motionVideo bool) error {
+	contentStoreMutex.Lock()
+	defer contentStoreMutex.Unlock()
+
+	return f.releaseContent(contentReference(email, mediaItemId, motionVideo), hash)
+}
+
+func (f files) GetDedupStats(email string) (DedupStats, error) {
+	stats := DedupStats{}
+
+	fileMetas, err := f.repository.GetAllFileMeta(email)
+	if err != nil {
+		return stats, fmt.Errorf("get all file meta: %w", err)
+	}
+
+	for key, fileMetaJson := range fileMetas {
+		var fileMeta FileMeta
+		err = json.Unmarshal(fileMetaJson, &fileMeta)
+		if err != nil {
+			return stats, fmt.Errorf("unmarshal file meta %s: %w", key, err)
+		}
+
+		for _, hash := range []string{fileMeta.ContentHash, fileMeta.MotionVideoContentHash} {
+			if hash == "" {
+				continue
+			}
+
+			object, found, err := f.getContentObject(hash)
+			if err != nil {
+				return stats, fmt.Errorf("get content object: %w", err)
+			}
+
+			if !found {
+				continue
+			}
+
+			stats.Files++
+			stats.Bytes += object.Size
+
+			if len(object.References) > 1 {
+				stats.SharedFiles++
+				stats.SharedBytes += object.Size
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+func (f files) releaseContent(reference string, hash string) error {
+	object, found, err := f.getContentObject(hash)
+	if err != nil {
+		return fmt.Errorf("get content object: %w", err)
+	}
+
+	err = f.repository.DeleteContentReference(reference)
+	if err != nil {
+		return fmt.Errorf("delete content reference: %w", err)
+	}
+
+	if !found {
+		return nil
+	}
+
+	references := make([]string, 0, len(object.References))
+	for _, objectReference := range object.References {
+		if objectReference != reference {
+			references = append(references, objectReference)
+		}
+	}
+
+	if len(references) > 0 {
+		object.References = references
+
+		objectJson, err := json.Marshal(object)
+		if err != nil {
+			return fmt.Errorf("marshal content object: %w", err)
+		}
+
+		return f.repository.SaveContentObject(hash, objectJson)
+	}
+
+	err = f.RemoveFile(f.contentObjectPathName(hash))
+	if err != nil {
+		return fmt.Errorf("remove content object file: %w", err)
+	}
+
+	return f.repository.DeleteContentObject(hash)
+}
+
+func (f files) getContentObject(hash string) (ContentObject, bool, error) {
+	objectJson, err := f.repository.GetContentObject(hash)
+	if err != nil {
+		return ContentObject{}, false, fmt.Errorf("get content object: %w", err)
+	}
+
+	if objectJson == nil {
+		return ContentObject{}, false, nil
+	}
+
+	var object ContentObject
+	err = json.Unmarshal(objectJson, &object)
+	if err != nil {
+		return ContentObject{}, false, fmt.Errorf("unmarshal content object: %w", err)
+	}
+
+	return object, true, nil
+}
+
+// Links a new file into the store, or replaces the file with a hardlink to the already stored content
+func (f files) linkContentObject(hash string, filePathName string) error {
+	objectPathName := f.contentObjectPathName(hash)
+
+	linker, ok := f.storage.(storage.Linker)
+	if !ok {
+		return storage.ErrLinksUnsupported
+	}
+
+	_, err := f.storage.Stat(objectPathName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return linker.Link(filePathName, objectPathName)
+	}
+
+	if err != nil {
+		return fmt.Errorf("stat content object: %w", err)
+	}
+
+	sameFile, err := linker.SameFile(objectPathName, filePathName)
+	if err != nil {
+		return fmt.Errorf("same file: %w", err)
+	}
+
+	if sameFile {
+		return nil
+	}
+
+	// link next to the file and rename over it, so the file is never missing
+	linkPathName := filePathName + ".link"
+
+	// left by an interrupted previous attempt
+	err = f.storage.Delete(linkPathName)
+	if err != nil {
+		return fmt.Errorf("remove link: %w", err)
+	}
+
+	err = linker.Link(objectPathName, linkPathName)
+	if err != nil {
+		return fmt.Errorf("link content object: %w", err)
+	}
+
+	err = f.storage.Rename(linkPathName, filePathName)
+	if err != nil {
+		f.storage.Delete(linkPathName)
+
+		return fmt.Errorf("replace file with link: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package files
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google-backup/internal/storage"
+)
+
+// Types of problems found by the verification
+const (
+	VerifyProblemMissing      = "missing"
+	VerifyProblemSizeMismatch = "size_mismatch"
+	VerifyProblemHashMismatch = "hash_mismatch"
+	VerifyProblemOrphan       = "orphan"
+	// The file is plain though it was encrypted or the other way around, e.g. it was replaced on the storage
+	VerifyProblemEncryptionMismatch = "encryption_mismatch"
+)
+
+type VerifyReport struct {
+	Email        string `json:"email"`
+	CheckedFiles int    `json:"checked_files"`
+	// Files downloaded before sizes and hashes were recorded, only their presence is checked
+	UnverifiedFiles int             `json:"unverified_files"`
+	Problems        []VerifyProblem `json:"problems"`
+}
+
+type VerifyProblem struct {
+	Type         string `json:"type"`
+	FilePathName string `json:"file_path_name"`
+	// Media item or drive file id, empty for orphans
+	Id       string `json:"id,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+type verifiedFile struct {
+	filePathName string
+	id           string
+	size         int64
+	sha256       string
+	md5          string
+	encryption   string
+}
+
+// Checks that every file of the account metadata is on disk with the recorded size and hash,
+// and lists files in the account folder which no metadata references.
+// Hashing reads every file, it can be skipped for a quick check
+func (f files) Verify(email string, checkHashes bool) (VerifyReport, error) {
+	report := VerifyReport{Email: email, Problems: []VerifyProblem{}}
+
+	expected, err := f.verifiedFiles(email)
+	if err != nil {
+		return report, fmt.Errorf("verified files: %w", err)
+	}
+
+	referenced := make(map[string]bool, len(expected))
+
+	for _, file := range expected {
+		referenced[file.filePathName] = true
+		report.CheckedFiles++
+
+		if file.size == 0 && file.sha256 == "" && file.md5 == "" {
+			report.UnverifiedFiles++
+		}
+
+		problem, err := f.verifyFile(file, checkHashes)
+		if err != nil {
+			return report, fmt.Errorf("verify file %s: %w", file.filePathName, err)
+		}
+
+		if problem != nil {
+			report.Problems = append(report.Problems, *problem)
+		}
+	}
+
+	orphans, err := f.orphanFiles(email, referenced)
+	if err != nil {
+		return report, fmt.Errorf("orphan files: %w", err)
+	}
+
+	for _, orphan := range orphans {
+		report.Problems = append(report.Problems, VerifyProblem{Type: VerifyProblemOrphan, FilePathName: orphan})
+	}
+
+	// metadata is read from maps, sorting keeps reports comparable between runs
+	slices.SortFunc(report.Problems, func(a, b VerifyProblem) int {
+		return strings.Compare(a.FilePathName, b.FilePathName)
+	})
+
+	return report, nil
+}
+
+// Photos with their motion videos and downloaded drive files
+func (f files) verifiedFiles(email string) ([]verifiedFile, error) {
+	var result []verifiedFile
+
+	fileMetas, err := f.repository.GetAllFileMeta(email)
+	if err != nil {
+		return nil, fmt.Errorf("get all file meta: %w", err)
+	}
+
+	for key, fileMetaJson := range fileMetas {
+		var fileMeta FileMeta
+		err = json.Unmarshal(fileMetaJson, &fileMeta)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal file meta %s: %w", key, err)
+		}
+
+		if fileMeta.FilePathName == "" {
+			continue
+		}
+
+		result = append(result, verifiedFile{
+			filePathName: fileMeta.FilePathName,
+			id:           fileMeta.MediaItem.ID,
+			size:         fileMeta.Size,
+			sha256:       fileMeta.ContentHash,
+			encryption:   fileMeta.Encryption,
+		})
+
+		if fileMeta.MotionVideoFilePathName != "" {
+			result = append(result, verifiedFile{
+				filePathName: fileMeta.MotionVideoFilePathName,
+				id:           fileMeta.MediaItem.ID,
+				size:         fileMeta.MotionVideoSize,
+				sha256:       fileMeta.MotionVideoContentHash,
+				encryption:   fileMeta.MotionVideoEncryption,
+			})
+		}
+	}
+
+	driveFileMetas, err := f.repository.GetAllDriveFileMeta(email)
+	if err != nil {
+		return nil, fmt.Errorf("get all drive file meta: %w", err)
+	}
+
+	for key, fileMetaJson := range driveFileMetas {
+		var fileMeta DriveFileMeta
+		err = json.Unmarshal(fileMetaJson, &fileMeta)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal drive file meta %s: %w", key, err)
+		}
+
+		if fileMeta.FilePathName == "" {
+			continue
+		}
+
+		// exported Google Docs have neither a size nor a checksum
+		size, _ := strconv.ParseInt(fileMeta.File.Size, 10, 64)
+
+		result = append(result, verifiedFile{
+			filePathName: fileMeta.FilePathName,
+			id:           fileMeta.File.ID,
+			size:         size,
+			md5:          fileMeta.File.Md5Checksum,
+			encryption:   fileMeta.Encryption,
+		})
+	}
+
+	return result, nil
+}
+
+// Returns nil if the file is intact
+func (f files) verifyFile(file verifiedFile, checkHashes bool) (*VerifyProblem, error) {
+	fileInfo, err := f.storage.Stat(file.filePathName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &VerifyProblem{Type: VerifyProblemMissing, FilePathName: file.filePathName, Id: file.id}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+
+	if file.size > 0 && fileInfo.Size != file.size {
+		return &VerifyProblem{
+			Type:         VerifyProblemSizeMismatch,
+			FilePathName: file.filePathName,
+			Id:           file.id,
+			Expected:     strconv.FormatInt(file.size, 10),
+			Actual:       strconv.FormatInt(fileInfo.Size, 10),
+		}, nil
+	}
+
+	encryptionScheme, err := storage.EncryptionScheme(f.storage, file.filePathName)
+	if err != nil {
+		return nil, fmt.Errorf("encryption scheme: %w", err)
+	}
+
+	if encryptionScheme != file.encryption {
+		return &VerifyProblem{
+			Type:         VerifyProblemEncryptionMismatch,
+			FilePathName: file.filePathName,
+			Id:           file.id,
+			Expected:     encryptionName(file.encryption),
+			Actual:       encryptionName(encryptionScheme),
+		}, nil
+	}
+
+	if !checkHashes {
+		return nil, nil
+	}
+
+	expectedHash, actualHash := file.sha256, ""
+
+	switch {
+	case file.sha256 != "":
+		actualHash, err = f.HashFile(file.filePathName)
+	case file.md5 != "":
+		expectedHash = file.md5
+		actualHash, err = f.md5File(file.filePathName)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("hash file: %w", err)
+	}
+
+	if actualHash != expectedHash {
+		return &VerifyProblem{
+			Type:         VerifyProblemHashMismatch,
+			FilePathName: file.filePathName,
+			Id:           file.id,
+			Expected:     expectedHash,
+			Actual:       actualHash,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// Album links, sidecars of referenced files and unfinished downloads are not orphans
+func (f files) orphanFiles(email string, referenced map[string]bool) ([]string, error) {
+	var orphans []string
+
+	albumsFolder := email + "/" + albumsFolderName + "/"
+
+	// nothing is listed if nothing was downloaded for the account yet
+	accountFiles, err := f.storage.List(email)
+	if err != nil {
+		return nil, fmt.Errorf("list account folder: %w", err)
+	}
+
+	for _, file := range accountFiles {
+		filePathName := file.PathName
+
+		if strings.HasPrefix(filePathName, albumsFolder) {
+			continue
+		}
+
+		if referenced[filePathName] || strings.HasSuffix(filePathName, ".partial") {
+			continue
+		}
+
+		if strings.HasSuffix(filePathName, xmpSidecarSuffix) && referenced[strings.TrimSuffix(filePathName, xmpSidecarSuffix)] {
+			continue
+		}
+
+		orphans = append(orphans, filePathName)
+	}
+
+	return orphans, nil
+}
+
+func (f files) md5File(filePathName string) (string, error) {
+	file, err := f.storage.Reader(filePathName)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+
+	defer file.Close()
+
+	hash := md5.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", fmt.Errorf("hash file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Plain files are reported as "none"
+func encryptionName(scheme string) string {
+	if scheme == "" {
+		return "none"
+	}
+
+	return scheme
+}
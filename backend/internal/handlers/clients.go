@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"google-backup/internal/account"
 	"google-backup/internal/google_client"
@@ -72,9 +73,18 @@ func (h *clientsApiHandler) handleGet(c *gin.Context) {
 			return
 		}
 
+		clientIds := make([]string, 0, len(clients))
+		for clientId := range clients {
+			clientIds = append(clientIds, clientId)
+		}
+
+		slices.Sort(clientIds)
+
 		clientsDataResponse := make([]clientDataResponse, 0, len(clients))
 
-		for _, client := range clients {
+		for _, clientId := range clientIds {
+			client := clients[clientId]
+
 			clientDataResponse, err := h.getClientData(client)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"google-backup/internal/account"
+	"google-backup/internal/files"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type dedupStatsHandler struct {
+	accountRepository account.Repository
+	filesManager      files.FilesManager
+}
+
+func NewDedupStatsHandler(
+	accountRepository account.Repository,
+	filesManager files.FilesManager,
+) *dedupStatsHandler {
+	return &dedupStatsHandler{accountRepository: accountRepository, filesManager: filesManager}
+}
+
+func (h *dedupStatsHandler) Handle(c *gin.Context) {
+	if c.Request.Method != http.MethodGet {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{})
+
+		return
+	}
+
+	var query struct {
+		Email string `form:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+
+		return
+	}
+
+	exist, err := h.accountRepository.AccountExist(query.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not check if account exists"})
+		log.Error(fmt.Errorf("dedup stats: account exists: %w", err))
+
+		return
+	}
+
+	if !exist {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Account not found"})
+
+		return
+	}
+
+	stats, err := h.filesManager.GetDedupStats(query.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("dedup stats: get dedup stats: %w", err))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
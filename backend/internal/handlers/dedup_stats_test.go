@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/files"
+	"google-backup/internal/files/filesfakes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupStatsHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("get dedup stats", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		handler := NewDedupStatsHandler(fakeAccountRepository, fakeFilesManager)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeFilesManager.GetDedupStatsReturns(files.DedupStats{
+			Files:       10,
+			Bytes:       1000,
+			SharedFiles: 2,
+			SharedBytes: 300,
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/dedup-stats?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"data":{"files":10,"bytes":1000,"shared_files":2,"shared_bytes":300}}`, w.Body.String())
+		assert.Equal(t, "test@gmail.com", fakeFilesManager.GetDedupStatsArgsForCall(0))
+	})
+
+	t.Run("get dedup stats account not found", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		handler := NewDedupStatsHandler(fakeAccountRepository, fakeFilesManager)
+
+		fakeAccountRepository.AccountExistReturns(false, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/dedup-stats?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, 0, fakeFilesManager.GetDedupStatsCallCount())
+	})
+
+	t.Run("get dedup stats error", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		handler := NewDedupStatsHandler(fakeAccountRepository, fakeFilesManager)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeFilesManager.GetDedupStatsReturns(files.DedupStats{}, errors.New("error"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/dedup-stats?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"google-backup/internal/account"
+	"google-backup/internal/files"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type deletedItemsHandler struct {
+	accountRepository account.Repository
+	filesManager      files.FilesManager
+}
+
+func NewDeletedItemsHandler(
+	accountRepository account.Repository,
+	filesManager files.FilesManager,
+) *deletedItemsHandler {
+	return &deletedItemsHandler{accountRepository: accountRepository, filesManager: filesManager}
+}
+
+func (h *deletedItemsHandler) Handle(c *gin.Context) {
+	if c.Request.Method != http.MethodGet {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{})
+
+		return
+	}
+
+	var query struct {
+		Email string `form:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+
+		return
+	}
+
+	exist, err := h.accountRepository.AccountExist(query.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not check if account exists"})
+		log.Error(fmt.Errorf("deleted items: account exists: %w", err))
+
+		return
+	}
+
+	if !exist {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Account not found"})
+
+		return
+	}
+
+	deletedItems, err := h.filesManager.GetRemotelyDeleted(query.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("deleted items: get remotely deleted: %w", err))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deletedItems})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/files"
+	"google-backup/internal/files/filesfakes"
+	"google-backup/internal/media"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletedItemsHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("get deleted items", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		handler := NewDeletedItemsHandler(fakeAccountRepository, fakeFilesManager)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeFilesManager.GetRemotelyDeletedReturns([]files.FileMeta{
+			{
+				FilePathName:      "test@gmail.com/_deleted/2023/1/IMG_0001.JPG",
+				MediaItem:         media.MediaItem{ID: "id1", Filename: "IMG_0001.JPG"},
+				RemoteDeletedTime: "2024-01-02T03:04:05Z",
+			},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/deleted-items?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"file_path_name":"test@gmail.com/_deleted/2023/1/IMG_0001.JPG"`)
+		assert.Contains(t, w.Body.String(), `"remote_deleted_time":"2024-01-02T03:04:05Z"`)
+		assert.Equal(t, "test@gmail.com", fakeFilesManager.GetRemotelyDeletedArgsForCall(0))
+	})
+
+	t.Run("get deleted items account not found", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		handler := NewDeletedItemsHandler(fakeAccountRepository, fakeFilesManager)
+
+		fakeAccountRepository.AccountExistReturns(false, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/deleted-items?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, 0, fakeFilesManager.GetRemotelyDeletedCallCount())
+	})
+
+	t.Run("get deleted items without email", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		handler := NewDeletedItemsHandler(fakeAccountRepository, fakeFilesManager)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/deleted-items", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, fakeAccountRepository.AccountExistCallCount())
+	})
+
+	t.Run("get deleted items error", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeFilesManager := new(filesfakes.FakeFilesManager)
+		handler := NewDeletedItemsHandler(fakeAccountRepository, fakeFilesManager)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeFilesManager.GetRemotelyDeletedReturns(nil, errors.New("error"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/deleted-items?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
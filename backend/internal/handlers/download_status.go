@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"google-backup/internal/downloader"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type downloadStatusHandler struct {
+	diskGuard downloader.DiskGuard
+}
+
+type downloadStatusResponse struct {
+	Paused bool              `json:"paused"`
+	Pause  *downloader.Pause `json:"pause"`
+}
+
+func NewDownloadStatusHandler(diskGuard downloader.DiskGuard) *downloadStatusHandler {
+	return &downloadStatusHandler{diskGuard: diskGuard}
+}
+
+func (h *downloadStatusHandler) Handle(c *gin.Context) {
+	if c.Request.Method != http.MethodGet {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{})
+
+		return
+	}
+
+	pause, err := h.diskGuard.GetPause()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("download status: get pause: %w", err))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": downloadStatusResponse{Paused: pause != nil, Pause: pause}})
+}
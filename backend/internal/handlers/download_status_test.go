@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google-backup/internal/downloader"
+	"google-backup/internal/downloader/downloaderfakes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadStatusHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("downloads running", func(t *testing.T) {
+		fakeDiskGuard := new(downloaderfakes.FakeDiskGuard)
+		handler := NewDownloadStatusHandler(fakeDiskGuard)
+
+		fakeDiskGuard.GetPauseReturns(nil, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/download-status", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"data":{"paused":false,"pause":null}}`, w.Body.String())
+	})
+
+	t.Run("downloads paused", func(t *testing.T) {
+		fakeDiskGuard := new(downloaderfakes.FakeDiskGuard)
+		handler := NewDownloadStatusHandler(fakeDiskGuard)
+
+		fakeDiskGuard.GetPauseReturns(&downloader.Pause{
+			Reason:       downloader.PauseReasonLowDiskSpace,
+			FreeSpace:    1024,
+			MinFreeSpace: 2048,
+			Since:        "2024-01-02T03:04:05Z",
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/download-status", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(
+			t,
+			`{"data":{"paused":true,"pause":{"reason":"free disk space is below the minimum","free_space":1024,"min_free_space":2048,"since":"2024-01-02T03:04:05Z"}}}`,
+			w.Body.String(),
+		)
+	})
+
+	t.Run("get pause error", func(t *testing.T) {
+		fakeDiskGuard := new(downloaderfakes.FakeDiskGuard)
+		handler := NewDownloadStatusHandler(fakeDiskGuard)
+
+		fakeDiskGuard.GetPauseReturns(nil, errors.New("error"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/download-status", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		fakeDiskGuard := new(downloaderfakes.FakeDiskGuard)
+		handler := NewDownloadStatusHandler(fakeDiskGuard)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/download-status", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, 0, fakeDiskGuard.GetPauseCallCount())
+	})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"google-backup/internal/account"
+	"google-backup/internal/downloader"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type failedDownloadsHandler struct {
+	accountRepository account.Repository
+	retryQueue        downloader.RetryQueue
+}
+
+type requeueRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Type  string `json:"type" binding:"required,oneof=photos drive"`
+	Id    string `json:"id" binding:"required"`
+}
+
+func NewFailedDownloadsHandler(
+	accountRepository account.Repository,
+	retryQueue downloader.RetryQueue,
+) *failedDownloadsHandler {
+	return &failedDownloadsHandler{accountRepository: accountRepository, retryQueue: retryQueue}
+}
+
+func (h *failedDownloadsHandler) Handle(c *gin.Context) {
+	switch c.Request.Method {
+	case http.MethodGet:
+		h.handleGet(c)
+	case http.MethodPost:
+		h.handlePost(c)
+	default:
+		c.JSON(http.StatusMethodNotAllowed, gin.H{})
+	}
+}
+
+func (h *failedDownloadsHandler) handleGet(c *gin.Context) {
+	var query struct {
+		Email string `form:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+
+		return
+	}
+
+	if !h.accountExist(c, query.Email) {
+		return
+	}
+
+	deadLetters, err := h.retryQueue.GetDeadLetters(query.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("failed downloads: get dead letters: %w", err))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deadLetters})
+}
+
+func (h *failedDownloadsHandler) handlePost(c *gin.Context) {
+	var requestData requeueRequest
+
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+
+		return
+	}
+
+	if !h.accountExist(c, requestData.Email) {
+		return
+	}
+
+	found, err := h.retryQueue.Requeue(requestData.Email, requestData.Type, requestData.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("failed downloads: requeue: %w", err))
+
+		return
+	}
+
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Failed download not found"})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// Writes the error response and returns false if the account does not exist
+func (h *failedDownloadsHandler) accountExist(c *gin.Context, email string) bool {
+	exist, err := h.accountRepository.AccountExist(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not check if account exists"})
+		log.Error(fmt.Errorf("failed downloads: account exists: %w", err))
+
+		return false
+	}
+
+	if !exist {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Account not found"})
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/downloader"
+	"google-backup/internal/downloader/downloaderfakes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailedDownloadsHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("get failed downloads", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeRetryQueue := new(downloaderfakes.FakeRetryQueue)
+		handler := NewFailedDownloadsHandler(fakeAccountRepository, fakeRetryQueue)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeRetryQueue.GetDeadLettersReturns([]downloader.FailedDownload{
+			{
+				Type:           "photos",
+				Id:             "id1",
+				Attempts:       8,
+				LastError:      "download file: connection reset",
+				DeadLetterTime: "2024-01-02T03:04:05Z",
+			},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/failed-downloads?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(
+			t,
+			`{"data":[{"type":"photos","id":"id1","attempts":8,"last_error":"download file: connection reset","dead_letter_time":"2024-01-02T03:04:05Z"}]}`,
+			w.Body.String(),
+		)
+		assert.Equal(t, "test@gmail.com", fakeRetryQueue.GetDeadLettersArgsForCall(0))
+	})
+
+	t.Run("get failed downloads account not found", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeRetryQueue := new(downloaderfakes.FakeRetryQueue)
+		handler := NewFailedDownloadsHandler(fakeAccountRepository, fakeRetryQueue)
+
+		fakeAccountRepository.AccountExistReturns(false, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/failed-downloads?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, 0, fakeRetryQueue.GetDeadLettersCallCount())
+	})
+
+	t.Run("get failed downloads error", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeRetryQueue := new(downloaderfakes.FakeRetryQueue)
+		handler := NewFailedDownloadsHandler(fakeAccountRepository, fakeRetryQueue)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeRetryQueue.GetDeadLettersReturns(nil, errors.New("error"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/failed-downloads?email=test@gmail.com", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("requeue failed download", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeRetryQueue := new(downloaderfakes.FakeRetryQueue)
+		handler := NewFailedDownloadsHandler(fakeAccountRepository, fakeRetryQueue)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeRetryQueue.RequeueReturns(true, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/failed-downloads", bytes.NewBuffer(
+			[]byte(`{"email":"test@gmail.com","type":"drive","id":"id1"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		email, downloadType, id := fakeRetryQueue.RequeueArgsForCall(0)
+		assert.Equal(t, "test@gmail.com", email)
+		assert.Equal(t, "drive", downloadType)
+		assert.Equal(t, "id1", id)
+	})
+
+	t.Run("requeue unknown failed download", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeRetryQueue := new(downloaderfakes.FakeRetryQueue)
+		handler := NewFailedDownloadsHandler(fakeAccountRepository, fakeRetryQueue)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+		fakeRetryQueue.RequeueReturns(false, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/failed-downloads", bytes.NewBuffer(
+			[]byte(`{"email":"test@gmail.com","type":"photos","id":"id1"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("requeue with invalid type", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeRetryQueue := new(downloaderfakes.FakeRetryQueue)
+		handler := NewFailedDownloadsHandler(fakeAccountRepository, fakeRetryQueue)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/failed-downloads", bytes.NewBuffer(
+			[]byte(`{"email":"test@gmail.com","type":"albums","id":"id1"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, fakeRetryQueue.RequeueCallCount())
+	})
+
+	t.Run("failed downloads method not allowed", func(t *testing.T) {
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		fakeRetryQueue := new(downloaderfakes.FakeRetryQueue)
+		handler := NewFailedDownloadsHandler(fakeAccountRepository, fakeRetryQueue)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "http://localhost:8080/api/v1/failed-downloads", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
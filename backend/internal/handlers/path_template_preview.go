@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"google-backup/internal/files"
+	"google-backup/internal/media"
+
+	"github.com/gin-gonic/gin"
+)
+
+type pathTemplatePreviewHandler struct{}
+
+type pathTemplatePreviewRequest struct {
+	PathTemplate string `json:"pathTemplate" binding:"required"`
+	Email        string `json:"email" binding:"omitempty,email"`
+}
+
+type pathTemplatePreview struct {
+	MediaItem    media.MediaItem `json:"mediaItem"`
+	Album        string          `json:"album,omitempty"`
+	FilePathName string          `json:"filePathName"`
+}
+
+const pathTemplatePreviewEmail = "user@gmail.com"
+
+// Sample items cover photos with and without camera info, a video and an item in an album
+var pathTemplatePreviewSamples = []pathTemplatePreview{
+	{
+		MediaItem: media.MediaItem{
+			ID:       "AF1QipNa6xJ1n0r9oQ3bPZb3k2vYp7TqXcW5d8eLmH2s",
+			Filename: "IMG_0001.JPG",
+			MimeType: "image/jpeg",
+			MediaMetadata: media.MediaMetadata{
+				CreationTime: "2023-07-14T09:30:00Z",
+				Photo:        &media.Photo{CameraMake: "Apple", CameraModel: "iPhone 14 Pro"},
+			},
+		},
+	},
+	{
+		MediaItem: media.MediaItem{
+			ID:       "AF1QipOq2Zr8vK4tYb1nW6cX9mJ3sD5fG7hL0pQeRuT1",
+			Filename: "PXL_20240102_181512345.mp4",
+			MimeType: "video/mp4",
+			MediaMetadata: media.MediaMetadata{
+				CreationTime: "2024-01-02T18:15:12Z",
+				Video:        &media.Video{CameraMake: "Google", CameraModel: "Pixel 8", Status: media.VideoStatusReady},
+			},
+		},
+	},
+	{
+		MediaItem: media.MediaItem{
+			ID:       "AF1QipM7bH3kR9wE2yU5iO8pA1sD4fG6jK0lZxCvBnQw",
+			Filename: "Screenshot 2022-11-30.png",
+			MimeType: "image/png",
+			MediaMetadata: media.MediaMetadata{
+				CreationTime: "2022-11-30T21:05:44Z",
+				Photo:        &media.Photo{},
+			},
+		},
+		Album: "Holidays",
+	},
+}
+
+func NewPathTemplatePreviewHandler() *pathTemplatePreviewHandler {
+	return &pathTemplatePreviewHandler{}
+}
+
+// Shows where the sample items would be saved with the template, nothing is changed
+func (h *pathTemplatePreviewHandler) Handle(c *gin.Context) {
+	if c.Request.Method != http.MethodPost {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{})
+
+		return
+	}
+
+	var request pathTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+
+		return
+	}
+
+	err := files.ValidatePathTemplate(request.PathTemplate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid path template: %s", err.Error())})
+
+		return
+	}
+
+	email := request.Email
+	if email == "" {
+		email = pathTemplatePreviewEmail
+	}
+
+	previews := make([]pathTemplatePreview, 0, len(pathTemplatePreviewSamples))
+
+	for _, sample := range pathTemplatePreviewSamples {
+		sample.FilePathName, err = files.RenderPathTemplate(request.PathTemplate, email, sample.MediaItem, sample.Album)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("render path template: %s", err.Error())})
+
+			return
+		}
+
+		previews = append(previews, sample)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": previews})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathTemplatePreviewHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("preview path template", func(t *testing.T) {
+		handler := NewPathTemplatePreviewHandler()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/path-template/preview", bytes.NewBuffer(
+			[]byte(`{"pathTemplate":"{account}/{year}/{year}-{month}-{day}/{camera_make} {camera_model}/{album}/{filename}","email":"test@gmail.com"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []struct {
+				FilePathName string `json:"filePathName"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 3)
+		assert.Equal(t, "test@gmail.com/2023/2023-07-14/Apple iPhone 14 Pro/no_album/IMG_0001.JPG", response.Data[0].FilePathName)
+		assert.Equal(t, "test@gmail.com/2024/2024-01-02/Google Pixel 8/no_album/PXL_20240102_181512345.mp4", response.Data[1].FilePathName)
+		assert.Equal(t, "test@gmail.com/2022/2022-11-30/unknown unknown/Holidays/Screenshot 2022-11-30.png", response.Data[2].FilePathName)
+	})
+
+	t.Run("preview path template with mime type and id", func(t *testing.T) {
+		handler := NewPathTemplatePreviewHandler()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/path-template/preview", bytes.NewBuffer(
+			[]byte(`{"pathTemplate":"{account}/{mime_type}/{id}_{filename}"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []struct {
+				FilePathName string `json:"filePathName"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "user@gmail.com/image_jpeg/AF1QipNa6xJ1n0r9oQ3bPZb3k2vYp7TqXcW5d8eLmH2s_IMG_0001.JPG", response.Data[0].FilePathName)
+	})
+
+	t.Run("preview invalid path templates", func(t *testing.T) {
+		for template, message := range map[string]string{
+			"{year}/{month}/{filename}":         `{"message":"invalid path template: path must start with the {account} folder"}`,
+			"{account}/{lens}/{filename}":       `{"message":"invalid path template: unknown placeholder {lens}"}`,
+			"{account}/{year/{filename}":        `{"message":"invalid path template: unbalanced braces"}`,
+			"{account}/../{filename}":           `{"message":"invalid path template: empty, . and .. folders are not allowed"}`,
+			"{account}//{filename}":             `{"message":"invalid path template: empty, . and .. folders are not allowed"}`,
+			"{account}/drive/{filename}":        `{"message":"invalid path template: drive folder is reserved"}`,
+			"{account}/_deleted/{filename}":     `{"message":"invalid path template: _deleted folder is reserved"}`,
+			"{account}/{year}/{id}":             `{"message":"invalid path template: file name must contain {filename}"}`,
+			"{account}/{filename}/{year}":       `{"message":"invalid path template: file name must contain {filename}"}`,
+			"{account}\\\\{year}\\\\{filename}": `{"message":"invalid path template: backslashes are not allowed, use / as a separator"}`,
+		} {
+			handler := NewPathTemplatePreviewHandler()
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/path-template/preview", bytes.NewBuffer(
+				[]byte(`{"pathTemplate":"`+template+`"}`),
+			))
+
+			handler.Handle(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, template)
+			assert.Equal(t, message, w.Body.String(), template)
+		}
+	})
+
+	t.Run("preview without path template", func(t *testing.T) {
+		handler := NewPathTemplatePreviewHandler()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/path-template/preview", bytes.NewBuffer(
+			[]byte(`{}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("preview method not allowed", func(t *testing.T) {
+		handler := NewPathTemplatePreviewHandler()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/path-template/preview", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
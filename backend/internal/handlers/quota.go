@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"google-backup/internal/quota"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type quotaHandler struct {
+	quotaLedger quota.Ledger
+}
+
+func NewQuotaHandler(quotaLedger quota.Ledger) *quotaHandler {
+	return &quotaHandler{quotaLedger: quotaLedger}
+}
+
+func (h *quotaHandler) Handle(c *gin.Context) {
+	if c.Request.Method != http.MethodGet {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{})
+
+		return
+	}
+
+	usage, err := h.quotaLedger.GetUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("quota: get usage: %w", err))
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
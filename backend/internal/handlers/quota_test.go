@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google-backup/internal/quota"
+	"google-backup/internal/quota/quotafakes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("get usage", func(t *testing.T) {
+		fakeQuotaLedger := new(quotafakes.FakeLedger)
+		handler := NewQuotaHandler(fakeQuotaLedger)
+
+		fakeQuotaLedger.GetUsageReturns([]quota.Usage{
+			{
+				ClientId:         "client1",
+				Day:              "2024-01-02",
+				ResetTime:        "2024-01-03T08:00:00Z",
+				ApiRequests:      120,
+				ApiRequestBudget: 9000,
+				Downloads:        100,
+				DownloadBudget:   70000,
+				Accounts: map[string]quota.AccountUsage{
+					"test@gmail.com": {ApiRequests: 120, Downloads: 100},
+				},
+			},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/quota", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(
+			t,
+			`{"data":[{"client_id":"client1","day":"2024-01-02","reset_time":"2024-01-03T08:00:00Z","api_requests":120,"api_request_budget":9000,"downloads":100,"download_budget":70000,"accounts":{"test@gmail.com":{"api_requests":120,"downloads":100}}}]}`,
+			w.Body.String(),
+		)
+	})
+
+	t.Run("get usage error", func(t *testing.T) {
+		fakeQuotaLedger := new(quotafakes.FakeLedger)
+		handler := NewQuotaHandler(fakeQuotaLedger)
+
+		fakeQuotaLedger.GetUsageReturns(nil, errors.New("error"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/quota", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		fakeQuotaLedger := new(quotafakes.FakeLedger)
+		handler := NewQuotaHandler(fakeQuotaLedger)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "http://localhost:8080/api/v1/quota", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, 0, fakeQuotaLedger.GetUsageCallCount())
+	})
+}
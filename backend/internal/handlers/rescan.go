@@ -17,8 +17,11 @@ type rescanHandler struct {
 }
 
 type requestData struct {
-	Type  string `json:"type" binding:"required,oneof=photos drive"`
-	Email string `json:"email" binding:"required,email"`
+	Type      string `json:"type" binding:"required,oneof=photos drive albums"`
+	Email     string `json:"email" binding:"required,email"`
+	StartDate string `json:"startDate" binding:"required_with=EndDate,omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"endDate" binding:"required_with=StartDate,omitempty,datetime=2006-01-02"`
+	MediaType string `json:"mediaType" binding:"omitempty,oneof=photos videos"`
 }
 
 func NewRescanHandler(
@@ -44,6 +47,25 @@ func (h *rescanHandler) Handle(c *gin.Context) {
 		return
 	}
 
+	filter := scanner.RescanFilter{
+		StartDate: requestData.StartDate,
+		EndDate:   requestData.EndDate,
+		MediaType: requestData.MediaType,
+	}
+
+	if !filter.IsEmpty() && requestData.Type != scanner.RescanTypePhotos {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Filters are supported only for photos rescan"})
+
+		return
+	}
+
+	// dates are in YYYY-MM-DD format, so they can be compared as strings
+	if filter.StartDate > filter.EndDate {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Start date must not be after end date"})
+
+		return
+	}
+
 	exist, err := h.accountRepository.AccountExist(requestData.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not check if account exists"})
@@ -53,7 +75,7 @@ func (h *rescanHandler) Handle(c *gin.Context) {
 	}
 
 	if exist {
-		err = h.scheduler.ScheduleRescan(requestData.Type, requestData.Email)
+		err = h.scheduler.ScheduleRescan(requestData.Type, requestData.Email, filter)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Could not schedule rescan"})
 			log.Error(fmt.Errorf("schedule rescan: %w", err))
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/scanner"
 	"google-backup/internal/scanner/scannerfakes"
 
 	"github.com/gin-gonic/gin"
@@ -34,9 +35,10 @@ func TestRescanHandle(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		requestType, email := fakeScheduler.ScheduleRescanArgsForCall(0)
+		requestType, email, filter := fakeScheduler.ScheduleRescanArgsForCall(0)
 		assert.Equal(t, "photos", requestType)
 		assert.Equal(t, "test@gmail.com", email)
+		assert.True(t, filter.IsEmpty())
 	})
 
 	t.Run("request drive rescan", func(t *testing.T) {
@@ -56,9 +58,85 @@ func TestRescanHandle(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		requestType, email := fakeScheduler.ScheduleRescanArgsForCall(0)
+		requestType, email, filter := fakeScheduler.ScheduleRescanArgsForCall(0)
 		assert.Equal(t, "drive", requestType)
 		assert.Equal(t, "test@gmail.com", email)
+		assert.True(t, filter.IsEmpty())
+	})
+
+	t.Run("request albums rescan", func(t *testing.T) {
+		fakeScheduler := new(scannerfakes.FakeScheduler)
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		handler := NewRescanHandler(fakeAccountRepository, fakeScheduler)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/rescan", bytes.NewBuffer(
+			[]byte(`{"type":"albums","email":"test@gmail.com"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		requestType, email, filter := fakeScheduler.ScheduleRescanArgsForCall(0)
+		assert.Equal(t, "albums", requestType)
+		assert.Equal(t, "test@gmail.com", email)
+		assert.True(t, filter.IsEmpty())
+	})
+
+	t.Run("request filtered photos rescan", func(t *testing.T) {
+		fakeScheduler := new(scannerfakes.FakeScheduler)
+		fakeAccountRepository := new(accountfakes.FakeRepository)
+		handler := NewRescanHandler(fakeAccountRepository, fakeScheduler)
+
+		fakeAccountRepository.AccountExistReturns(true, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/rescan", bytes.NewBuffer(
+			[]byte(`{"type":"photos","email":"test@gmail.com","startDate":"2023-01-01","endDate":"2023-12-31","mediaType":"videos"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		requestType, email, filter := fakeScheduler.ScheduleRescanArgsForCall(0)
+		assert.Equal(t, "photos", requestType)
+		assert.Equal(t, "test@gmail.com", email)
+		assert.Equal(t, scanner.RescanFilter{StartDate: "2023-01-01", EndDate: "2023-12-31", MediaType: "videos"}, filter)
+	})
+
+	t.Run("request filtered rescan validation", func(t *testing.T) {
+		requests := []string{
+			`{"type":"photos","email":"test@gmail.com","startDate":"2023-01-01"}`,
+			`{"type":"photos","email":"test@gmail.com","startDate":"01.01.2023","endDate":"2023-12-31"}`,
+			`{"type":"photos","email":"test@gmail.com","startDate":"2023-12-31","endDate":"2023-01-01"}`,
+			`{"type":"photos","email":"test@gmail.com","mediaType":"audio"}`,
+			`{"type":"drive","email":"test@gmail.com","mediaType":"photos"}`,
+		}
+
+		for _, request := range requests {
+			fakeScheduler := new(scannerfakes.FakeScheduler)
+			fakeAccountRepository := new(accountfakes.FakeRepository)
+			handler := NewRescanHandler(fakeAccountRepository, fakeScheduler)
+
+			fakeAccountRepository.AccountExistReturns(true, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/rescan", bytes.NewBuffer(
+				[]byte(request),
+			))
+
+			handler.Handle(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, request)
+			assert.Equal(t, 0, fakeScheduler.ScheduleRescanCallCount(), request)
+		}
 	})
 
 	t.Run("request photos rescan account not found", func(t *testing.T) {
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
+	"google-backup/internal/encryption"
+	"google-backup/internal/files"
+	"google-backup/internal/secrets"
 	"google-backup/internal/settings"
+	"google-backup/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -14,19 +19,88 @@ import (
 
 type settingsApiHandler struct {
 	settingsRepository settings.Repository
+	cipher             secrets.Cipher
 }
 
+// Optional settings the request doesn't contain keep their saved values, clients which don't know them don't reset them
 type settingsUpdateRequest struct {
-	RootPath                 string `json:"rootPath" binding:"required,ascii"`
-	PhotosScannerJobDelay    int64  `json:"photosScannerJobDelay" binding:"required,numeric"`
-	PhotosDownloaderJobDelay int64  `json:"photosDownloaderJobDelay" binding:"required,numeric"`
-	Host                     string `json:"host" binding:"required,ascii"`
-	PhotosBackupEnabled      bool   `json:"photosBackupEnabled" binding:"required,boolean"`
-	DriveBackupEnabled       bool   `json:"driveBackupEnabled" binding:"required,boolean"`
+	RootPath                      string                           `json:"rootPath" binding:"required,ascii"`
+	PhotosScannerJobDelay         int64                            `json:"photosScannerJobDelay" binding:"required,numeric"`
+	PhotosDownloaderJobDelay      int64                            `json:"photosDownloaderJobDelay" binding:"required,numeric"`
+	Host                          string                           `json:"host" binding:"required,ascii"`
+	PhotosBackupEnabled           bool                             `json:"photosBackupEnabled" binding:"required,boolean"`
+	DriveBackupEnabled            bool                             `json:"driveBackupEnabled" binding:"required,boolean"`
+	AlbumsLayout                  *string                          `json:"albumsLayout" binding:"omitempty,oneof='' symlink hardlink"`
+	DeletedItemsPolicy            *string                          `json:"deletedItemsPolicy" binding:"omitempty,oneof='' keep move prune"`
+	DeletedItemsPruneDays         *int                             `json:"deletedItemsPruneDays" binding:"omitempty,min=0"`
+	DownloadWorkersPerAccount     *int                             `json:"downloadWorkersPerAccount" binding:"omitempty,min=0,max=32"`
+	DownloadWorkersTotal          *int                             `json:"downloadWorkersTotal" binding:"omitempty,min=0"`
+	DownloadBatchSize             *int                             `json:"downloadBatchSize" binding:"omitempty,min=0"`
+	DownloadBandwidthLimit        *int64                           `json:"downloadBandwidthLimit" binding:"omitempty,min=0"`
+	DownloadAccountBandwidthLimit *int64                           `json:"downloadAccountBandwidthLimit" binding:"omitempty,min=0"`
+	DownloadBandwidthSchedules    []bandwidthScheduleRequest       `json:"downloadBandwidthSchedules" binding:"omitempty,dive"`
+	ContentStoreEnabled           *bool                            `json:"contentStoreEnabled" binding:"omitempty,boolean"`
+	PathTemplate                  *string                          `json:"pathTemplate"`
+	XmpSidecarsEnabled            *bool                            `json:"xmpSidecarsEnabled" binding:"omitempty,boolean"`
+	ExifEmbeddingEnabled          *bool                            `json:"exifEmbeddingEnabled" binding:"omitempty,boolean"`
+	MinFreeSpace                  *int64                           `json:"minFreeSpace" binding:"omitempty,min=0"`
+	DailyApiRequestBudget         *int64                           `json:"dailyApiRequestBudget" binding:"omitempty,min=0"`
+	DailyDownloadBudget           *int64                           `json:"dailyDownloadBudget" binding:"omitempty,min=0"`
+	AccountStorages               map[string]accountStorageRequest `json:"accountStorages" binding:"omitempty,dive,keys,email,endkeys,required"`
+	Encryption                    *encryptionRequest               `json:"encryption"`
 }
 
-func NewSettingsHandler(settingsRepository settings.Repository) *settingsApiHandler {
-	return &settingsApiHandler{settingsRepository: settingsRepository}
+type bandwidthScheduleRequest struct {
+	Start                 string `json:"start" binding:"required,datetime=15:04"`
+	End                   string `json:"end" binding:"required,datetime=15:04,nefield=Start"`
+	BandwidthLimit        int64  `json:"bandwidthLimit" binding:"omitempty,min=1"`
+	AccountBandwidthLimit int64  `json:"accountBandwidthLimit" binding:"omitempty,min=1"`
+}
+
+// Empty credentials keep the saved ones of the storage, credentials are never sent back
+type accountStorageRequest struct {
+	Type   string                `json:"type" binding:"required,oneof=local s3 sftp webdav"`
+	S3     *s3StorageRequest     `json:"s3" binding:"required_if=Type s3,omitempty"`
+	Sftp   *sftpStorageRequest   `json:"sftp" binding:"required_if=Type sftp,omitempty"`
+	Webdav *webdavStorageRequest `json:"webdav" binding:"required_if=Type webdav,omitempty"`
+}
+
+type s3StorageRequest struct {
+	Endpoint        string `json:"endpoint" binding:"required"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket" binding:"required"`
+	Prefix          string `json:"prefix"`
+	AccessKeyId     string `json:"accessKeyId" binding:"required"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	Insecure        bool   `json:"insecure" binding:"omitempty,boolean"`
+}
+
+type sftpStorageRequest struct {
+	Host       string `json:"host" binding:"required"`
+	User       string `json:"user" binding:"required"`
+	HostKey    string `json:"hostKey" binding:"required"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"privateKey"`
+	Path       string `json:"path"`
+}
+
+type webdavStorageRequest struct {
+	Url      string `json:"url" binding:"required,url"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// Settings without the encryption keep the saved one, an empty passphrase keeps the saved passphrase.
+// The passphrase is never sent back
+type encryptionRequest struct {
+	Enabled       bool   `json:"enabled" binding:"omitempty,boolean"`
+	Passphrase    string `json:"passphrase"`
+	KeyFile       string `json:"keyFile"`
+	HideFileNames bool   `json:"hideFileNames" binding:"omitempty,boolean"`
+}
+
+func NewSettingsHandler(settingsRepository settings.Repository, cipher secrets.Cipher) *settingsApiHandler {
+	return &settingsApiHandler{settingsRepository: settingsRepository, cipher: cipher}
 }
 
 func (h *settingsApiHandler) Handle(c *gin.Context) {
@@ -62,6 +136,7 @@ func (h *settingsApiHandler) handleGet(c *gin.Context) {
 	}
 
 	settingsData = h.convertDurationToMinutes(settingsData)
+	settingsData = h.hideSecrets(settingsData)
 
 	c.JSON(http.StatusOK, gin.H{"data": settingsData})
 }
@@ -75,13 +150,145 @@ func (h *settingsApiHandler) handlePost(c *gin.Context) {
 		return
 	}
 
+	if request.PathTemplate != nil && *request.PathTemplate != "" {
+		err = files.ValidatePathTemplate(*request.PathTemplate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid path template: %s", err.Error())})
+
+			return
+		}
+	}
+
+	savedSettings, err := h.savedSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("get saved settings: %w", err))
+
+		return
+	}
+
 	settingsData := settings.SettingsData{
-		RootPath:                 request.RootPath,
-		PhotosScannerJobDelay:    time.Duration(request.PhotosScannerJobDelay * int64(time.Minute)),
-		PhotosDownloaderJobDelay: time.Duration(request.PhotosDownloaderJobDelay * int64(time.Minute)),
-		Host:                     request.Host,
-		PhotosBackupEnabled:      true,
-		DriveBackupEnabled:       true,
+		RootPath:                      request.RootPath,
+		PhotosScannerJobDelay:         time.Duration(request.PhotosScannerJobDelay * int64(time.Minute)),
+		PhotosDownloaderJobDelay:      time.Duration(request.PhotosDownloaderJobDelay * int64(time.Minute)),
+		Host:                          request.Host,
+		PhotosBackupEnabled:           true,
+		DriveBackupEnabled:            true,
+		AlbumsLayout:                  valueOrSaved(request.AlbumsLayout, savedSettings.AlbumsLayout),
+		DeletedItemsPolicy:            valueOrSaved(request.DeletedItemsPolicy, savedSettings.DeletedItemsPolicy),
+		DeletedItemsPruneDays:         valueOrSaved(request.DeletedItemsPruneDays, savedSettings.DeletedItemsPruneDays),
+		DownloadWorkersPerAccount:     valueOrSaved(request.DownloadWorkersPerAccount, savedSettings.DownloadWorkersPerAccount),
+		DownloadWorkersTotal:          valueOrSaved(request.DownloadWorkersTotal, savedSettings.DownloadWorkersTotal),
+		DownloadBatchSize:             valueOrSaved(request.DownloadBatchSize, savedSettings.DownloadBatchSize),
+		DownloadBandwidthLimit:        valueOrSaved(request.DownloadBandwidthLimit, savedSettings.DownloadBandwidthLimit),
+		DownloadAccountBandwidthLimit: valueOrSaved(request.DownloadAccountBandwidthLimit, savedSettings.DownloadAccountBandwidthLimit),
+		DownloadBandwidthSchedules:    savedSettings.DownloadBandwidthSchedules,
+		ContentStoreEnabled:           valueOrSaved(request.ContentStoreEnabled, savedSettings.ContentStoreEnabled),
+		XmpSidecarsEnabled:            valueOrSaved(request.XmpSidecarsEnabled, savedSettings.XmpSidecarsEnabled),
+		ExifEmbeddingEnabled:          valueOrSaved(request.ExifEmbeddingEnabled, savedSettings.ExifEmbeddingEnabled),
+		MinFreeSpace:                  valueOrSaved(request.MinFreeSpace, savedSettings.MinFreeSpace),
+		DailyApiRequestBudget:         valueOrSaved(request.DailyApiRequestBudget, savedSettings.DailyApiRequestBudget),
+		DailyDownloadBudget:           valueOrSaved(request.DailyDownloadBudget, savedSettings.DailyDownloadBudget),
+	}
+
+	// the days are checked once merged, a request may change the policy and keep the saved days
+	if settingsData.DeletedItemsPolicy == settings.DeletedItemsPolicyPrune && settingsData.DeletedItemsPruneDays < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "days of the prune policy are required"})
+
+		return
+	}
+
+	// clients which don't know the schedules keep them, an empty list removes them
+	if request.DownloadBandwidthSchedules != nil {
+		settingsData.DownloadBandwidthSchedules = nil
+
+		for _, schedule := range request.DownloadBandwidthSchedules {
+			settingsData.DownloadBandwidthSchedules = append(settingsData.DownloadBandwidthSchedules, settings.BandwidthSchedule{
+				Start:                 schedule.Start,
+				End:                   schedule.End,
+				BandwidthLimit:        schedule.BandwidthLimit,
+				AccountBandwidthLimit: schedule.AccountBandwidthLimit,
+			})
+		}
+	}
+
+	// clients which don't know the path template keep it, an empty template restores the default layout
+	settingsData.PathTemplate = savedSettings.PathTemplate
+
+	if request.PathTemplate != nil {
+		settingsData.PathTemplate = *request.PathTemplate
+	}
+
+	// clients which don't know the storages keep them, an empty object removes them
+	settingsData.AccountStorages = savedSettings.AccountStorages
+
+	if request.AccountStorages != nil {
+		savedAccountStorages := savedSettings.AccountStorages
+
+		settingsData.AccountStorages = make(map[string]settings.AccountStorage, len(request.AccountStorages))
+
+		for email, accountStorageRequest := range request.AccountStorages {
+			accountStorage := accountStorageRequest.toAccountStorage()
+
+			if accountStorage.Sftp != nil {
+				err = storage.ValidateSftp(*accountStorage.Sftp)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("invalid sftp storage of %s: %s", email, err.Error())})
+
+					return
+				}
+			}
+
+			savedAccountStorage := savedAccountStorages[email]
+			if savedAccountStorage.Type == accountStorage.Type {
+				savedSecrets := savedAccountStorage.Secrets()
+
+				for i, secret := range accountStorage.Secrets() {
+					if *secret == "" && i < len(savedSecrets) {
+						*secret = *savedSecrets[i]
+					}
+				}
+			}
+
+			message := h.missingCredentials(email, accountStorage)
+			if message != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"message": message})
+
+				return
+			}
+
+			settingsData.AccountStorages[email] = accountStorage
+		}
+	}
+
+	// clients which don't know the encryption keep it, its salt and key check are needed to read the files
+	settingsData.Encryption = savedSettings.Encryption
+
+	if request.Encryption != nil {
+		encryptionSettings, message, err := h.encryptionSettings(*request.Encryption, savedSettings.Encryption)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			log.Error(fmt.Errorf("encryption settings: %w", err))
+
+			return
+		}
+
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": message})
+
+			return
+		}
+
+		settingsData.Encryption = encryptionSettings
+	}
+
+	// credentials kept from the saved settings are encrypted already
+	settingsData, err = settings.EncryptSecrets(h.cipher, settingsData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		log.Error(fmt.Errorf("encrypt secrets: %w", err))
+
+		return
 	}
 
 	settingsJson, err := json.Marshal(settingsData)
@@ -101,13 +308,166 @@ func (h *settingsApiHandler) handlePost(c *gin.Context) {
 	}
 
 	settingsData = h.convertDurationToMinutes(settingsData)
+	settingsData = h.hideSecrets(settingsData)
 
 	c.JSON(http.StatusOK, gin.H{"data": settingsData})
 }
 
+// Value of the request field, the saved value if the request doesn't contain it
+func valueOrSaved[T any](value *T, saved T) T {
+	if value == nil {
+		return saved
+	}
+
+	return *value
+}
+
+// Settings as they are saved, with encrypted credentials
+func (h *settingsApiHandler) savedSettings() (settings.SettingsData, error) {
+	settingsJson, err := h.settingsRepository.Find()
+	if err != nil {
+		return settings.SettingsData{}, fmt.Errorf("find settings: %w", err)
+	}
+
+	if settingsJson == nil {
+		return settings.SettingsData{}, nil
+	}
+
+	var settingsData settings.SettingsData
+	err = json.Unmarshal(settingsJson, &settingsData)
+	if err != nil {
+		return settings.SettingsData{}, fmt.Errorf("unmarshall settings json: %w", err)
+	}
+
+	return settingsData, nil
+}
+
+// Returns the encryption settings of the request and the message of invalid settings, empty if they are valid.
+// The salt is created with the first key, later keys have to match the key check of the saved settings,
+// files encrypted before couldn't be read otherwise
+func (h *settingsApiHandler) encryptionSettings(request encryptionRequest, saved *settings.Encryption) (*settings.Encryption, string, error) {
+	result := &settings.Encryption{
+		Enabled:       request.Enabled,
+		Passphrase:    request.Passphrase,
+		KeyFile:       request.KeyFile,
+		HideFileNames: request.HideFileNames,
+	}
+
+	if saved != nil {
+		result.Salt = saved.Salt
+		result.KeyCheck = saved.KeyCheck
+
+		if result.Passphrase == "" {
+			passphrase, err := h.cipher.Decrypt(saved.Passphrase)
+			if err != nil {
+				return nil, "", fmt.Errorf("decrypt saved passphrase: %w", err)
+			}
+
+			result.Passphrase = passphrase
+		}
+	}
+
+	if result.Passphrase == "" && result.KeyFile == "" {
+		if result.Enabled {
+			return nil, "passphrase or key file of the encryption is required", nil
+		}
+
+		return result, "", nil
+	}
+
+	if result.Salt == "" {
+		salt, err := encryption.NewSalt()
+		if err != nil {
+			return nil, "", err
+		}
+
+		result.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+
+	keyring, err := encryption.NewKeyring(result.Passphrase, result.KeyFile)
+	if err != nil {
+		return nil, fmt.Sprintf("invalid encryption key: %s", err.Error()), nil
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(result.Salt)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode salt: %w", err)
+	}
+
+	key, err := keyring.Key(keyring.Kdf(), salt)
+	if err != nil {
+		return nil, "", fmt.Errorf("derive key: %w", err)
+	}
+
+	if result.KeyCheck != "" && result.KeyCheck != key.Check() {
+		return nil, "encryption key doesn't match the key files were encrypted with", nil
+	}
+
+	result.KeyCheck = key.Check()
+
+	return result, "", nil
+}
+
+// Returns the message of missing credentials of the storage, empty if it has them
+func (h *settingsApiHandler) missingCredentials(email string, accountStorage settings.AccountStorage) string {
+	switch {
+	case accountStorage.S3 != nil && accountStorage.S3.SecretAccessKey == "":
+		return fmt.Sprintf("secret access key of %s is required", email)
+	case accountStorage.Sftp != nil && accountStorage.Sftp.Password == "" && accountStorage.Sftp.PrivateKey == "":
+		return fmt.Sprintf("password or private key of %s is required", email)
+	case accountStorage.Webdav != nil && accountStorage.Webdav.User != "" && accountStorage.Webdav.Password == "":
+		return fmt.Sprintf("password of %s is required", email)
+	}
+
+	return ""
+}
+
+// Removes the storage credentials and the encryption passphrase from the settings sent to the client
+func (h *settingsApiHandler) hideSecrets(settingsData settings.SettingsData) settings.SettingsData {
+	settingsData, _ = settingsData.MapSecrets(func(secret string) (string, error) {
+		return "", nil
+	})
+
+	return settingsData
+}
+
 func (h *settingsApiHandler) convertDurationToMinutes(settingsData settings.SettingsData) settings.SettingsData {
 	settingsData.PhotosScannerJobDelay = settingsData.PhotosScannerJobDelay / time.Minute
 	settingsData.PhotosDownloaderJobDelay = settingsData.PhotosDownloaderJobDelay / time.Minute
 
 	return settingsData
 }
+
+func (r accountStorageRequest) toAccountStorage() settings.AccountStorage {
+	accountStorage := settings.AccountStorage{Type: r.Type}
+
+	switch r.Type {
+	case settings.StorageTypeS3:
+		accountStorage.S3 = &settings.S3Storage{
+			Endpoint:        r.S3.Endpoint,
+			Region:          r.S3.Region,
+			Bucket:          r.S3.Bucket,
+			Prefix:          r.S3.Prefix,
+			AccessKeyId:     r.S3.AccessKeyId,
+			SecretAccessKey: r.S3.SecretAccessKey,
+			Insecure:        r.S3.Insecure,
+		}
+	case settings.StorageTypeSftp:
+		accountStorage.Sftp = &settings.SftpStorage{
+			Host:       r.Sftp.Host,
+			User:       r.Sftp.User,
+			HostKey:    r.Sftp.HostKey,
+			Password:   r.Sftp.Password,
+			PrivateKey: r.Sftp.PrivateKey,
+			Path:       r.Sftp.Path,
+		}
+	case settings.StorageTypeWebdav:
+		accountStorage.Webdav = &settings.WebdavStorage{
+			Url:      r.Webdav.Url,
+			User:     r.Webdav.User,
+			Password: r.Webdav.Password,
+		}
+	}
+
+	return accountStorage
+}
@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"google-backup/internal/secrets/secretsfakes"
+	"google-backup/internal/settings"
 	"google-backup/internal/settings/settingsfakes"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +20,8 @@ func TestSettingsHandle(t *testing.T) {
 
 	t.Run("get settings", func(t *testing.T) {
 		fakeSettingsRepository := new(settingsfakes.FakeRepository)
-		handler := NewSettingsHandler(fakeSettingsRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
 
 		fakeSettingsRepository.FindReturns([]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 60000000000, "photosDownloaderJobDelay": 120000000000, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true}`), nil)
 
@@ -33,7 +37,8 @@ func TestSettingsHandle(t *testing.T) {
 
 	t.Run("update settings", func(t *testing.T) {
 		fakeSettingsRepository := new(settingsfakes.FakeRepository)
-		handler := NewSettingsHandler(fakeSettingsRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -50,9 +55,577 @@ func TestSettingsHandle(t *testing.T) {
 		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true}`, string(settingsJson))
 	})
 
+	t.Run("update settings with albums layout", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "albumsLayout": "symlink"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"albumsLayout":"symlink"}`, string(settingsJson))
+	})
+
+	t.Run("update settings with unknown albums layout", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "albumsLayout": "copy"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings with download workers", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "downloadWorkersPerAccount": 4, "downloadWorkersTotal": 8, "downloadBatchSize": 200}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"downloadWorkersPerAccount":4,"downloadWorkersTotal":8,"downloadBatchSize":200}`, string(settingsJson))
+	})
+
+	t.Run("update settings with invalid download workers", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "downloadWorkersPerAccount": -1}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings with bandwidth schedules", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "downloadBandwidthLimit": 2000000, "downloadBandwidthSchedules": [{"start": "23:00", "end": "07:00"}]}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"downloadBandwidthLimit":2000000,"downloadBandwidthSchedules":[{"start":"23:00","end":"07:00"}]}`, string(settingsJson))
+	})
+
+	t.Run("update settings with invalid bandwidth schedule", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "downloadBandwidthSchedules": [{"start": "25:00", "end": "07:00"}]}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings with path template", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "pathTemplate": "{account}/{year}/{year}-{month}-{day}/{filename}"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"pathTemplate":"{account}/{year}/{year}-{month}-{day}/{filename}"}`, string(settingsJson))
+	})
+
+	t.Run("update settings with invalid path template", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "pathTemplate": "{account}/{lens}/{filename}"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"message":"invalid path template: unknown placeholder {lens}"}`, w.Body.String())
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings without path template keeps saved template", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"pathTemplate": "{account}/{year}/{filename}"}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"pathTemplate":"{account}/{year}/{filename}"}`, string(settingsJson))
+	})
+
+	t.Run("update settings with empty path template removes template", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"pathTemplate": "{account}/{year}/{filename}"}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "pathTemplate": ""}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true}`, string(settingsJson))
+	})
+
+	t.Run("update settings prune policy without days", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "deletedItemsPolicy": "prune"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings prune policy with saved days", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"deletedItemsPolicy": "keep", "deletedItemsPruneDays": 30}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "deletedItemsPolicy": "prune"}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"deletedItemsPolicy":"prune","deletedItemsPruneDays":30}`, string(settingsJson))
+	})
+
+	t.Run("update settings without optional settings keeps saved settings", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"albumsLayout":"symlink","deletedItemsPolicy":"prune","deletedItemsPruneDays":30,"downloadWorkersPerAccount":4,"downloadWorkersTotal":8,"downloadBatchSize":200,"downloadBandwidthLimit":2000000,"downloadAccountBandwidthLimit":1000000,"downloadBandwidthSchedules":[{"start":"23:00","end":"07:00"}],"contentStoreEnabled":true,"xmpSidecarsEnabled":true,"exifEmbeddingEnabled":true,"minFreeSpace":1000000000,"dailyApiRequestBudget":5000,"dailyDownloadBudget":50000}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"albumsLayout":"symlink","deletedItemsPolicy":"prune","deletedItemsPruneDays":30,"downloadWorkersPerAccount":4,"downloadWorkersTotal":8,"downloadBatchSize":200,"downloadBandwidthLimit":2000000,"downloadAccountBandwidthLimit":1000000,"downloadBandwidthSchedules":[{"start":"23:00","end":"07:00"}],"contentStoreEnabled":true,"xmpSidecarsEnabled":true,"exifEmbeddingEnabled":true,"minFreeSpace":1000000000,"dailyApiRequestBudget":5000,"dailyDownloadBudget":50000}`, string(settingsJson))
+	})
+
+	t.Run("update settings with empty optional settings removes saved settings", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"albumsLayout":"symlink","deletedItemsPolicy":"prune","deletedItemsPruneDays":30,"downloadWorkersPerAccount":4,"downloadWorkersTotal":8,"downloadBatchSize":200,"downloadBandwidthLimit":2000000,"downloadAccountBandwidthLimit":1000000,"downloadBandwidthSchedules":[{"start":"23:00","end":"07:00"}],"contentStoreEnabled":true,"xmpSidecarsEnabled":true,"exifEmbeddingEnabled":true,"minFreeSpace":1000000000,"dailyApiRequestBudget":5000,"dailyDownloadBudget":50000}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "albumsLayout": "", "deletedItemsPolicy": "", "deletedItemsPruneDays": 0, "downloadWorkersPerAccount": 0, "downloadWorkersTotal": 0, "downloadBatchSize": 0, "downloadBandwidthLimit": 0, "downloadAccountBandwidthLimit": 0, "downloadBandwidthSchedules": [], "contentStoreEnabled": false, "xmpSidecarsEnabled": false, "exifEmbeddingEnabled": false, "minFreeSpace": 0, "dailyApiRequestBudget": 0, "dailyDownloadBudget": 0}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true}`, string(settingsJson))
+	})
+
+	t.Run("get settings hides storage secrets", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 60000000000, "photosDownloaderJobDelay": 120000000000, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "bucket": "photos", "accessKeyId": "minio", "secretAccessKey": "enc:v1:saved"}}}}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/settings", nil)
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"data":{"rootPath":"/root/path","photosScannerJobDelay":1,"photosDownloaderJobDelay":2,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"accountStorages":{"user@gmail.com":{"type":"s3","s3":{"endpoint":"localhost:9000","bucket":"photos","accessKeyId":"minio"}}}}}`, w.Body.String())
+	})
+
+	t.Run("update settings with account storages", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeCipher.EncryptReturns("enc:v1:encrypted", nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "bucket": "photos", "prefix": "backups/", "accessKeyId": "minio", "secretAccessKey": "secret", "insecure": true}}, "other@gmail.com": {"type": "local"}}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"data":{"rootPath":"/root/path","photosScannerJobDelay":1,"photosDownloaderJobDelay":5,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"accountStorages":{"other@gmail.com":{"type":"local"},"user@gmail.com":{"type":"s3","s3":{"endpoint":"localhost:9000","bucket":"photos","prefix":"backups/","accessKeyId":"minio","insecure":true}}}}}`, w.Body.String())
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"accountStorages":{"other@gmail.com":{"type":"local"},"user@gmail.com":{"type":"s3","s3":{"endpoint":"localhost:9000","bucket":"photos","prefix":"backups/","accessKeyId":"minio","secretAccessKey":"enc:v1:encrypted","insecure":true}}}}`, string(settingsJson))
+		assert.Equal(t, "secret", fakeCipher.EncryptArgsForCall(0))
+	})
+
+	t.Run("update settings keeps saved storage secret", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"accountStorages": {"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "bucket": "photos", "accessKeyId": "minio", "secretAccessKey": "enc:v1:saved"}}}}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "bucket": "other", "accessKeyId": "minio"}}}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"accountStorages":{"user@gmail.com":{"type":"s3","s3":{"endpoint":"localhost:9000","bucket":"other","accessKeyId":"minio","secretAccessKey":"enc:v1:saved"}}}}`, string(settingsJson))
+		assert.Equal(t, 0, fakeCipher.EncryptCallCount())
+	})
+
+	t.Run("update settings without account storages keeps saved storages", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"accountStorages": {"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "bucket": "photos", "accessKeyId": "minio", "secretAccessKey": "enc:v1:saved"}}}}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"accountStorages":{"user@gmail.com":{"type":"s3","s3":{"endpoint":"localhost:9000","bucket":"photos","accessKeyId":"minio","secretAccessKey":"enc:v1:saved"}}}}`, string(settingsJson))
+		assert.Equal(t, 0, fakeCipher.EncryptCallCount())
+	})
+
+	t.Run("update settings with empty account storages removes storages", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"accountStorages": {"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "bucket": "photos", "accessKeyId": "minio", "secretAccessKey": "enc:v1:saved"}}}}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true}`, string(settingsJson))
+	})
+
+	t.Run("update settings with sftp storage", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeCipher.EncryptReturns("enc:v1:encrypted", nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {"user@gmail.com": {"type": "sftp", "sftp": {"host": "backup.local:2222", "user": "backup", "hostKey": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBb8d7cgG/yWcN5z5oQdWZH1eGtxLWRHvIOE5jjDPUit", "password": "secret", "path": "/srv/photos"}}}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"data":{"rootPath":"/root/path","photosScannerJobDelay":1,"photosDownloaderJobDelay":5,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"accountStorages":{"user@gmail.com":{"type":"sftp","sftp":{"host":"backup.local:2222","user":"backup","hostKey":"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBb8d7cgG/yWcN5z5oQdWZH1eGtxLWRHvIOE5jjDPUit","path":"/srv/photos"}}}}}`, w.Body.String())
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"accountStorages":{"user@gmail.com":{"type":"sftp","sftp":{"host":"backup.local:2222","user":"backup","hostKey":"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBb8d7cgG/yWcN5z5oQdWZH1eGtxLWRHvIOE5jjDPUit","password":"enc:v1:encrypted","path":"/srv/photos"}}}}`, string(settingsJson))
+		assert.Equal(t, 1, fakeCipher.EncryptCallCount())
+	})
+
+	t.Run("update settings with invalid sftp host key", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {"user@gmail.com": {"type": "sftp", "sftp": {"host": "backup.local", "user": "backup", "hostKey": "fingerprint", "password": "secret"}}}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"message":"invalid sftp storage of user@gmail.com: parse host key: ssh: no key found"}`, w.Body.String())
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings with webdav storage without password", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {"user@gmail.com": {"type": "webdav", "webdav": {"url": "https://cloud.example.com/remote.php/dav/files/user/Photos", "user": "user"}}}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"message":"password of user@gmail.com is required"}`, w.Body.String())
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings with new storage without secret", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": {"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "bucket": "photos", "accessKeyId": "minio"}}}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"message":"secret access key of user@gmail.com is required"}`, w.Body.String())
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings with invalid account storages", func(t *testing.T) {
+		for _, accountStorages := range []string{
+			`{"user@gmail.com": {"type": "s3"}}`,
+			`{"user@gmail.com": {"type": "ftp"}}`,
+			`{"user@gmail.com": {"type": "s3", "s3": {"endpoint": "localhost:9000", "accessKeyId": "minio", "secretAccessKey": "secret"}}}`,
+			`{"user": {"type": "local"}}`,
+			`{"user@gmail.com": {"type": "sftp", "sftp": {"host": "backup.local", "user": "backup", "password": "secret"}}}`,
+			`{"user@gmail.com": {"type": "webdav", "webdav": {"url": "cloud.example.com", "user": "user", "password": "secret"}}}`,
+		} {
+			fakeSettingsRepository := new(settingsfakes.FakeRepository)
+			fakeCipher := new(secretsfakes.FakeCipher)
+			handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+				[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "accountStorages": `+accountStorages+`}`),
+			))
+
+			handler.Handle(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, accountStorages)
+			assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+		}
+	})
+
+	t.Run("update settings with encryption", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeCipher.EncryptReturns("enc:v1:encrypted", nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "encryption": {"enabled": true, "passphrase": "secret", "hideFileNames": true}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "passphrase")
+
+		var savedSettings settings.SettingsData
+		err := json.Unmarshal(fakeSettingsRepository.SaveArgsForCall(0), &savedSettings)
+		assert.NoError(t, err)
+		assert.True(t, savedSettings.Encryption.Enabled)
+		assert.True(t, savedSettings.Encryption.HideFileNames)
+		assert.Equal(t, "enc:v1:encrypted", savedSettings.Encryption.Passphrase)
+		assert.NotEmpty(t, savedSettings.Encryption.Salt)
+		assert.NotEmpty(t, savedSettings.Encryption.KeyCheck)
+		assert.Equal(t, "secret", fakeCipher.EncryptArgsForCall(0))
+	})
+
+	t.Run("update settings with encryption without key", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "encryption": {"enabled": true}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"message":"passphrase or key file of the encryption is required"}`, w.Body.String())
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+	})
+
+	t.Run("update settings with changed encryption key", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"encryption": {"enabled": true, "passphrase": "enc:v1:saved", "salt": "AAAAAAAAAAAAAAAAAAAAAA==", "keyCheck": "0000000000000000"}}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true, "encryption": {"enabled": true, "passphrase": "other"}}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"message":"encryption key doesn't match the key files were encrypted with"}`, w.Body.String())
+		assert.Equal(t, 0, fakeSettingsRepository.SaveCallCount())
+		assert.Equal(t, 0, fakeCipher.DecryptCallCount())
+	})
+
+	t.Run("update settings without encryption keeps saved encryption", func(t *testing.T) {
+		fakeSettingsRepository := new(settingsfakes.FakeRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
+
+		fakeSettingsRepository.FindReturns([]byte(`{"encryption": {"enabled": true, "passphrase": "enc:v1:saved", "hideFileNames": true, "salt": "AAAAAAAAAAAAAAAAAAAAAA==", "keyCheck": "0000000000000000"}}`), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/settings", bytes.NewBuffer(
+			[]byte(`{"rootPath": "/root/path", "photosScannerJobDelay": 1, "photosDownloaderJobDelay": 5, "host": "http://localhost:8080", "photosBackupEnabled": true, "driveBackupEnabled": true}`),
+		))
+
+		handler.Handle(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"data":{"rootPath":"/root/path","photosScannerJobDelay":1,"photosDownloaderJobDelay":5,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"encryption":{"enabled":true,"hideFileNames":true,"salt":"AAAAAAAAAAAAAAAAAAAAAA==","keyCheck":"0000000000000000"}}}`, w.Body.String())
+
+		settingsJson := fakeSettingsRepository.SaveArgsForCall(0)
+		assert.Equal(t, `{"rootPath":"/root/path","photosScannerJobDelay":60000000000,"photosDownloaderJobDelay":300000000000,"host":"http://localhost:8080","photosBackupEnabled":true,"driveBackupEnabled":true,"encryption":{"enabled":true,"passphrase":"enc:v1:saved","hideFileNames":true,"salt":"AAAAAAAAAAAAAAAAAAAAAA==","keyCheck":"0000000000000000"}}`, string(settingsJson))
+		assert.Equal(t, 0, fakeCipher.EncryptCallCount())
+		assert.Equal(t, 0, fakeCipher.DecryptCallCount())
+	})
+
 	t.Run("update settings validation", func(t *testing.T) {
 		fakeSettingsRepository := new(settingsfakes.FakeRepository)
-		handler := NewSettingsHandler(fakeSettingsRepository)
+		fakeCipher := new(secretsfakes.FakeCipher)
+		handler := NewSettingsHandler(fakeSettingsRepository, fakeCipher)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -0,0 +1,33 @@
+package media
+
+type albumsListResponseBody struct {
+	Albums        []Album `json:"albums"`
+	NextPageToken string  `json:"nextPageToken"`
+}
+
+type sharedAlbumsListResponseBody struct {
+	SharedAlbums  []Album `json:"sharedAlbums"`
+	NextPageToken string  `json:"nextPageToken"`
+}
+
+type Album struct {
+	ID                    string     `json:"id"`
+	Title                 string     `json:"title"`
+	ProductUrl            string     `json:"productUrl"`
+	IsWriteable           bool       `json:"isWriteable"`
+	MediaItemsCount       string     `json:"mediaItemsCount"`
+	CoverPhotoBaseUrl     string     `json:"coverPhotoBaseUrl"`
+	CoverPhotoMediaItemId string     `json:"coverPhotoMediaItemId"`
+	ShareInfo             *ShareInfo `json:"shareInfo,omitempty"`
+}
+
+type ShareInfo struct {
+	ShareableUrl string `json:"shareableUrl"`
+	IsJoined     bool   `json:"isJoined"`
+	IsOwned      bool   `json:"isOwned"`
+}
+
+type Albums struct {
+	Items         []Album
+	NextPageToken string
+}
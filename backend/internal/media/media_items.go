@@ -1,10 +1,33 @@
 package media
 
+import (
+	"strings"
+	"time"
+)
+
+const (
+	MediaTypeAll   = "ALL_MEDIA"
+	MediaTypePhoto = "PHOTO"
+	MediaTypeVideo = "VIDEO"
+
+	VideoStatusReady = "READY"
+
+	BatchGetMaxMediaItems = 50
+)
+
 type mediaItemsListResponseBody struct {
 	MediaItems    []MediaItem `json:"mediaItems"`
 	NextPageToken string      `json:"nextPageToken"`
 }
 
+type mediaItemsBatchGetResponseBody struct {
+	MediaItemResults []mediaItemResult `json:"mediaItemResults"`
+}
+
+type mediaItemResult struct {
+	MediaItem MediaItem `json:"mediaItem"`
+}
+
 type MediaItem struct {
 	ID              string          `json:"id"`
 	Description     string          `json:"description"`
@@ -20,8 +43,9 @@ type MediaMetadata struct {
 	CreationTime string `json:"creationTime"`
 	Width        string `json:"width"`
 	Height       string `json:"height"`
-	Photo        Photo  `json:"photo"`
-	Video        Video  `json:"video"`
+	// Only one of photo and video is set depending on the media type
+	Photo *Photo `json:"photo,omitempty"`
+	Video *Video `json:"video,omitempty"`
 }
 
 type Photo struct {
@@ -40,6 +64,43 @@ type Video struct {
 	Status      string  `json:"status"`
 }
 
+func (m MediaItem) IsVideo() bool {
+	return m.MediaMetadata.Video != nil || strings.HasPrefix(m.MimeType, "video/")
+}
+
+// Videos are downloaded only after processing, before that the base url returns a thumbnail
+func (m MediaItem) IsReadyToDownload() bool {
+	if m.MediaMetadata.Video == nil {
+		return true
+	}
+
+	return m.MediaMetadata.Video.Status == VideoStatusReady
+}
+
+// Motion photos are stored as jpeg or heic images with a video component
+func (m MediaItem) MayBeMotionPhoto() bool {
+	switch strings.ToLower(m.MimeType) {
+	case "image/jpeg", "image/heic", "image/heif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Original bytes of the photo or the video
+func (m MediaItem) DownloadUrl() string {
+	if m.IsVideo() {
+		return m.BaseUrl + "=dv"
+	}
+
+	return m.BaseUrl + "=d"
+}
+
+// Video component of a motion photo
+func (m MediaItem) MotionVideoUrl() string {
+	return m.BaseUrl + "=dv"
+}
+
 type ContributorInfo struct {
 	ProfilePictureBaseUrl string `json:"profilePictureBaseUrl"`
 	DisplayName           string `json:"displayName"`
@@ -50,14 +111,27 @@ type MediaItems struct {
 	NextPageToken string
 }
 
+type SearchFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	MediaType string
+}
+
 type requestBody struct {
-	Filters   filters `json:"filters"`
-	PageToken string  `json:"pageToken"`
-	OrderBy   string  `json:"orderBy"`
+	AlbumId   string   `json:"albumId,omitempty"`
+	Filters   *filters `json:"filters,omitempty"`
+	PageSize  int      `json:"pageSize"`
+	PageToken string   `json:"pageToken,omitempty"`
+	OrderBy   string   `json:"orderBy,omitempty"`
 }
 
 type filters struct {
-	DateFilter dateFilter `json:"dateFilter"`
+	DateFilter      *dateFilter      `json:"dateFilter,omitempty"`
+	MediaTypeFilter *mediaTypeFilter `json:"mediaTypeFilter,omitempty"`
+}
+
+type mediaTypeFilter struct {
+	MediaTypes []string `json:"mediaTypes"`
 }
 
 type dateFilter struct {
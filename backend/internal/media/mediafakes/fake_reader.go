@@ -0,0 +1,600 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mediafakes
+
+import (
+	"google-backup/internal/media"
+	"sync"
+)
+
+type FakeReader struct {
+	BatchGetMediaItemsStub        func([]string) (map[string]media.MediaItem, error)
+	batchGetMediaItemsMutex       sync.RWMutex
+	batchGetMediaItemsArgsForCall []struct {
+		arg1 []string
+	}
+	batchGetMediaItemsReturns struct {
+		result1 map[string]media.MediaItem
+		result2 error
+	}
+	batchGetMediaItemsReturnsOnCall map[int]struct {
+		result1 map[string]media.MediaItem
+		result2 error
+	}
+	GetAlbumMediaItemsStub        func(string, string) (media.MediaItems, error)
+	getAlbumMediaItemsMutex       sync.RWMutex
+	getAlbumMediaItemsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getAlbumMediaItemsReturns struct {
+		result1 media.MediaItems
+		result2 error
+	}
+	getAlbumMediaItemsReturnsOnCall map[int]struct {
+		result1 media.MediaItems
+		result2 error
+	}
+	GetAlbumsStub        func(string) (media.Albums, error)
+	getAlbumsMutex       sync.RWMutex
+	getAlbumsArgsForCall []struct {
+		arg1 string
+	}
+	getAlbumsReturns struct {
+		result1 media.Albums
+		result2 error
+	}
+	getAlbumsReturnsOnCall map[int]struct {
+		result1 media.Albums
+		result2 error
+	}
+	GetMediaItemStub        func(string) (media.MediaItem, error)
+	getMediaItemMutex       sync.RWMutex
+	getMediaItemArgsForCall []struct {
+		arg1 string
+	}
+	getMediaItemReturns struct {
+		result1 media.MediaItem
+		result2 error
+	}
+	getMediaItemReturnsOnCall map[int]struct {
+		result1 media.MediaItem
+		result2 error
+	}
+	GetMediaItemsStub        func(string, string) (media.MediaItems, error)
+	getMediaItemsMutex       sync.RWMutex
+	getMediaItemsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getMediaItemsReturns struct {
+		result1 media.MediaItems
+		result2 error
+	}
+	getMediaItemsReturnsOnCall map[int]struct {
+		result1 media.MediaItems
+		result2 error
+	}
+	GetSharedAlbumsStub        func(string) (media.Albums, error)
+	getSharedAlbumsMutex       sync.RWMutex
+	getSharedAlbumsArgsForCall []struct {
+		arg1 string
+	}
+	getSharedAlbumsReturns struct {
+		result1 media.Albums
+		result2 error
+	}
+	getSharedAlbumsReturnsOnCall map[int]struct {
+		result1 media.Albums
+		result2 error
+	}
+	SearchMediaItemsStub        func(media.SearchFilter, string) (media.MediaItems, error)
+	searchMediaItemsMutex       sync.RWMutex
+	searchMediaItemsArgsForCall []struct {
+		arg1 media.SearchFilter
+		arg2 string
+	}
+	searchMediaItemsReturns struct {
+		result1 media.MediaItems
+		result2 error
+	}
+	searchMediaItemsReturnsOnCall map[int]struct {
+		result1 media.MediaItems
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReader) BatchGetMediaItems(arg1 []string) (map[string]media.MediaItem, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.batchGetMediaItemsMutex.Lock()
+	ret, specificReturn := fake.batchGetMediaItemsReturnsOnCall[len(fake.batchGetMediaItemsArgsForCall)]
+	fake.batchGetMediaItemsArgsForCall = append(fake.batchGetMediaItemsArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.BatchGetMediaItemsStub
+	fakeReturns := fake.batchGetMediaItemsReturns
+	fake.recordInvocation("BatchGetMediaItems", []interface{}{arg1Copy})
+	fake.batchGetMediaItemsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) BatchGetMediaItemsCallCount() int {
+	fake.batchGetMediaItemsMutex.RLock()
+	defer fake.batchGetMediaItemsMutex.RUnlock()
+	return len(fake.batchGetMediaItemsArgsForCall)
+}
+
+func (fake *FakeReader) BatchGetMediaItemsCalls(stub func([]string) (map[string]media.MediaItem, error)) {
+	fake.batchGetMediaItemsMutex.Lock()
+	defer fake.batchGetMediaItemsMutex.Unlock()
+	fake.BatchGetMediaItemsStub = stub
+}
+
+func (fake *FakeReader) BatchGetMediaItemsArgsForCall(i int) []string {
+	fake.batchGetMediaItemsMutex.RLock()
+	defer fake.batchGetMediaItemsMutex.RUnlock()
+	argsForCall := fake.batchGetMediaItemsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) BatchGetMediaItemsReturns(result1 map[string]media.MediaItem, result2 error) {
+	fake.batchGetMediaItemsMutex.Lock()
+	defer fake.batchGetMediaItemsMutex.Unlock()
+	fake.BatchGetMediaItemsStub = nil
+	fake.batchGetMediaItemsReturns = struct {
+		result1 map[string]media.MediaItem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) BatchGetMediaItemsReturnsOnCall(i int, result1 map[string]media.MediaItem, result2 error) {
+	fake.batchGetMediaItemsMutex.Lock()
+	defer fake.batchGetMediaItemsMutex.Unlock()
+	fake.BatchGetMediaItemsStub = nil
+	if fake.batchGetMediaItemsReturnsOnCall == nil {
+		fake.batchGetMediaItemsReturnsOnCall = make(map[int]struct {
+			result1 map[string]media.MediaItem
+			result2 error
+		})
+	}
+	fake.batchGetMediaItemsReturnsOnCall[i] = struct {
+		result1 map[string]media.MediaItem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetAlbumMediaItems(arg1 string, arg2 string) (media.MediaItems, error) {
+	fake.getAlbumMediaItemsMutex.Lock()
+	ret, specificReturn := fake.getAlbumMediaItemsReturnsOnCall[len(fake.getAlbumMediaItemsArgsForCall)]
+	fake.getAlbumMediaItemsArgsForCall = append(fake.getAlbumMediaItemsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetAlbumMediaItemsStub
+	fakeReturns := fake.getAlbumMediaItemsReturns
+	fake.recordInvocation("GetAlbumMediaItems", []interface{}{arg1, arg2})
+	fake.getAlbumMediaItemsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetAlbumMediaItemsCallCount() int {
+	fake.getAlbumMediaItemsMutex.RLock()
+	defer fake.getAlbumMediaItemsMutex.RUnlock()
+	return len(fake.getAlbumMediaItemsArgsForCall)
+}
+
+func (fake *FakeReader) GetAlbumMediaItemsCalls(stub func(string, string) (media.MediaItems, error)) {
+	fake.getAlbumMediaItemsMutex.Lock()
+	defer fake.getAlbumMediaItemsMutex.Unlock()
+	fake.GetAlbumMediaItemsStub = stub
+}
+
+func (fake *FakeReader) GetAlbumMediaItemsArgsForCall(i int) (string, string) {
+	fake.getAlbumMediaItemsMutex.RLock()
+	defer fake.getAlbumMediaItemsMutex.RUnlock()
+	argsForCall := fake.getAlbumMediaItemsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeReader) GetAlbumMediaItemsReturns(result1 media.MediaItems, result2 error) {
+	fake.getAlbumMediaItemsMutex.Lock()
+	defer fake.getAlbumMediaItemsMutex.Unlock()
+	fake.GetAlbumMediaItemsStub = nil
+	fake.getAlbumMediaItemsReturns = struct {
+		result1 media.MediaItems
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetAlbumMediaItemsReturnsOnCall(i int, result1 media.MediaItems, result2 error) {
+	fake.getAlbumMediaItemsMutex.Lock()
+	defer fake.getAlbumMediaItemsMutex.Unlock()
+	fake.GetAlbumMediaItemsStub = nil
+	if fake.getAlbumMediaItemsReturnsOnCall == nil {
+		fake.getAlbumMediaItemsReturnsOnCall = make(map[int]struct {
+			result1 media.MediaItems
+			result2 error
+		})
+	}
+	fake.getAlbumMediaItemsReturnsOnCall[i] = struct {
+		result1 media.MediaItems
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetAlbums(arg1 string) (media.Albums, error) {
+	fake.getAlbumsMutex.Lock()
+	ret, specificReturn := fake.getAlbumsReturnsOnCall[len(fake.getAlbumsArgsForCall)]
+	fake.getAlbumsArgsForCall = append(fake.getAlbumsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetAlbumsStub
+	fakeReturns := fake.getAlbumsReturns
+	fake.recordInvocation("GetAlbums", []interface{}{arg1})
+	fake.getAlbumsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetAlbumsCallCount() int {
+	fake.getAlbumsMutex.RLock()
+	defer fake.getAlbumsMutex.RUnlock()
+	return len(fake.getAlbumsArgsForCall)
+}
+
+func (fake *FakeReader) GetAlbumsCalls(stub func(string) (media.Albums, error)) {
+	fake.getAlbumsMutex.Lock()
+	defer fake.getAlbumsMutex.Unlock()
+	fake.GetAlbumsStub = stub
+}
+
+func (fake *FakeReader) GetAlbumsArgsForCall(i int) string {
+	fake.getAlbumsMutex.RLock()
+	defer fake.getAlbumsMutex.RUnlock()
+	argsForCall := fake.getAlbumsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) GetAlbumsReturns(result1 media.Albums, result2 error) {
+	fake.getAlbumsMutex.Lock()
+	defer fake.getAlbumsMutex.Unlock()
+	fake.GetAlbumsStub = nil
+	fake.getAlbumsReturns = struct {
+		result1 media.Albums
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetAlbumsReturnsOnCall(i int, result1 media.Albums, result2 error) {
+	fake.getAlbumsMutex.Lock()
+	defer fake.getAlbumsMutex.Unlock()
+	fake.GetAlbumsStub = nil
+	if fake.getAlbumsReturnsOnCall == nil {
+		fake.getAlbumsReturnsOnCall = make(map[int]struct {
+			result1 media.Albums
+			result2 error
+		})
+	}
+	fake.getAlbumsReturnsOnCall[i] = struct {
+		result1 media.Albums
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetMediaItem(arg1 string) (media.MediaItem, error) {
+	fake.getMediaItemMutex.Lock()
+	ret, specificReturn := fake.getMediaItemReturnsOnCall[len(fake.getMediaItemArgsForCall)]
+	fake.getMediaItemArgsForCall = append(fake.getMediaItemArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetMediaItemStub
+	fakeReturns := fake.getMediaItemReturns
+	fake.recordInvocation("GetMediaItem", []interface{}{arg1})
+	fake.getMediaItemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetMediaItemCallCount() int {
+	fake.getMediaItemMutex.RLock()
+	defer fake.getMediaItemMutex.RUnlock()
+	return len(fake.getMediaItemArgsForCall)
+}
+
+func (fake *FakeReader) GetMediaItemCalls(stub func(string) (media.MediaItem, error)) {
+	fake.getMediaItemMutex.Lock()
+	defer fake.getMediaItemMutex.Unlock()
+	fake.GetMediaItemStub = stub
+}
+
+func (fake *FakeReader) GetMediaItemArgsForCall(i int) string {
+	fake.getMediaItemMutex.RLock()
+	defer fake.getMediaItemMutex.RUnlock()
+	argsForCall := fake.getMediaItemArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) GetMediaItemReturns(result1 media.MediaItem, result2 error) {
+	fake.getMediaItemMutex.Lock()
+	defer fake.getMediaItemMutex.Unlock()
+	fake.GetMediaItemStub = nil
+	fake.getMediaItemReturns = struct {
+		result1 media.MediaItem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetMediaItemReturnsOnCall(i int, result1 media.MediaItem, result2 error) {
+	fake.getMediaItemMutex.Lock()
+	defer fake.getMediaItemMutex.Unlock()
+	fake.GetMediaItemStub = nil
+	if fake.getMediaItemReturnsOnCall == nil {
+		fake.getMediaItemReturnsOnCall = make(map[int]struct {
+			result1 media.MediaItem
+			result2 error
+		})
+	}
+	fake.getMediaItemReturnsOnCall[i] = struct {
+		result1 media.MediaItem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetMediaItems(arg1 string, arg2 string) (media.MediaItems, error) {
+	fake.getMediaItemsMutex.Lock()
+	ret, specificReturn := fake.getMediaItemsReturnsOnCall[len(fake.getMediaItemsArgsForCall)]
+	fake.getMediaItemsArgsForCall = append(fake.getMediaItemsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetMediaItemsStub
+	fakeReturns := fake.getMediaItemsReturns
+	fake.recordInvocation("GetMediaItems", []interface{}{arg1, arg2})
+	fake.getMediaItemsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetMediaItemsCallCount() int {
+	fake.getMediaItemsMutex.RLock()
+	defer fake.getMediaItemsMutex.RUnlock()
+	return len(fake.getMediaItemsArgsForCall)
+}
+
+func (fake *FakeReader) GetMediaItemsCalls(stub func(string, string) (media.MediaItems, error)) {
+	fake.getMediaItemsMutex.Lock()
+	defer fake.getMediaItemsMutex.Unlock()
+	fake.GetMediaItemsStub = stub
+}
+
+func (fake *FakeReader) GetMediaItemsArgsForCall(i int) (string, string) {
+	fake.getMediaItemsMutex.RLock()
+	defer fake.getMediaItemsMutex.RUnlock()
+	argsForCall := fake.getMediaItemsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeReader) GetMediaItemsReturns(result1 media.MediaItems, result2 error) {
+	fake.getMediaItemsMutex.Lock()
+	defer fake.getMediaItemsMutex.Unlock()
+	fake.GetMediaItemsStub = nil
+	fake.getMediaItemsReturns = struct {
+		result1 media.MediaItems
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetMediaItemsReturnsOnCall(i int, result1 media.MediaItems, result2 error) {
+	fake.getMediaItemsMutex.Lock()
+	defer fake.getMediaItemsMutex.Unlock()
+	fake.GetMediaItemsStub = nil
+	if fake.getMediaItemsReturnsOnCall == nil {
+		fake.getMediaItemsReturnsOnCall = make(map[int]struct {
+			result1 media.MediaItems
+			result2 error
+		})
+	}
+	fake.getMediaItemsReturnsOnCall[i] = struct {
+		result1 media.MediaItems
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetSharedAlbums(arg1 string) (media.Albums, error) {
+	fake.getSharedAlbumsMutex.Lock()
+	ret, specificReturn := fake.getSharedAlbumsReturnsOnCall[len(fake.getSharedAlbumsArgsForCall)]
+	fake.getSharedAlbumsArgsForCall = append(fake.getSharedAlbumsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetSharedAlbumsStub
+	fakeReturns := fake.getSharedAlbumsReturns
+	fake.recordInvocation("GetSharedAlbums", []interface{}{arg1})
+	fake.getSharedAlbumsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) GetSharedAlbumsCallCount() int {
+	fake.getSharedAlbumsMutex.RLock()
+	defer fake.getSharedAlbumsMutex.RUnlock()
+	return len(fake.getSharedAlbumsArgsForCall)
+}
+
+func (fake *FakeReader) GetSharedAlbumsCalls(stub func(string) (media.Albums, error)) {
+	fake.getSharedAlbumsMutex.Lock()
+	defer fake.getSharedAlbumsMutex.Unlock()
+	fake.GetSharedAlbumsStub = stub
+}
+
+func (fake *FakeReader) GetSharedAlbumsArgsForCall(i int) string {
+	fake.getSharedAlbumsMutex.RLock()
+	defer fake.getSharedAlbumsMutex.RUnlock()
+	argsForCall := fake.getSharedAlbumsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) GetSharedAlbumsReturns(result1 media.Albums, result2 error) {
+	fake.getSharedAlbumsMutex.Lock()
+	defer fake.getSharedAlbumsMutex.Unlock()
+	fake.GetSharedAlbumsStub = nil
+	fake.getSharedAlbumsReturns = struct {
+		result1 media.Albums
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) GetSharedAlbumsReturnsOnCall(i int, result1 media.Albums, result2 error) {
+	fake.getSharedAlbumsMutex.Lock()
+	defer fake.getSharedAlbumsMutex.Unlock()
+	fake.GetSharedAlbumsStub = nil
+	if fake.getSharedAlbumsReturnsOnCall == nil {
+		fake.getSharedAlbumsReturnsOnCall = make(map[int]struct {
+			result1 media.Albums
+			result2 error
+		})
+	}
+	fake.getSharedAlbumsReturnsOnCall[i] = struct {
+		result1 media.Albums
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) SearchMediaItems(arg1 media.SearchFilter, arg2 string) (media.MediaItems, error) {
+	fake.searchMediaItemsMutex.Lock()
+	ret, specificReturn := fake.searchMediaItemsReturnsOnCall[len(fake.searchMediaItemsArgsForCall)]
+	fake.searchMediaItemsArgsForCall = append(fake.searchMediaItemsArgsForCall, struct {
+		arg1 media.SearchFilter
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.SearchMediaItemsStub
+	fakeReturns := fake.searchMediaItemsReturns
+	fake.recordInvocation("SearchMediaItems", []interface{}{arg1, arg2})
+	fake.searchMediaItemsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) SearchMediaItemsCallCount() int {
+	fake.searchMediaItemsMutex.RLock()
+	defer fake.searchMediaItemsMutex.RUnlock()
+	return len(fake.searchMediaItemsArgsForCall)
+}
+
+func (fake *FakeReader) SearchMediaItemsCalls(stub func(media.SearchFilter, string) (media.MediaItems, error)) {
+	fake.searchMediaItemsMutex.Lock()
+	defer fake.searchMediaItemsMutex.Unlock()
+	fake.SearchMediaItemsStub = stub
+}
+
+func (fake *FakeReader) SearchMediaItemsArgsForCall(i int) (media.SearchFilter, string) {
+	fake.searchMediaItemsMutex.RLock()
+	defer fake.searchMediaItemsMutex.RUnlock()
+	argsForCall := fake.searchMediaItemsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeReader) SearchMediaItemsReturns(result1 media.MediaItems, result2 error) {
+	fake.searchMediaItemsMutex.Lock()
+	defer fake.searchMediaItemsMutex.Unlock()
+	fake.SearchMediaItemsStub = nil
+	fake.searchMediaItemsReturns = struct {
+		result1 media.MediaItems
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) SearchMediaItemsReturnsOnCall(i int, result1 media.MediaItems, result2 error) {
+	fake.searchMediaItemsMutex.Lock()
+	defer fake.searchMediaItemsMutex.Unlock()
+	fake.SearchMediaItemsStub = nil
+	if fake.searchMediaItemsReturnsOnCall == nil {
+		fake.searchMediaItemsReturnsOnCall = make(map[int]struct {
+			result1 media.MediaItems
+			result2 error
+		})
+	}
+	fake.searchMediaItemsReturnsOnCall[i] = struct {
+		result1 media.MediaItems
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.batchGetMediaItemsMutex.RLock()
+	defer fake.batchGetMediaItemsMutex.RUnlock()
+	fake.getAlbumMediaItemsMutex.RLock()
+	defer fake.getAlbumMediaItemsMutex.RUnlock()
+	fake.getAlbumsMutex.RLock()
+	defer fake.getAlbumsMutex.RUnlock()
+	fake.getMediaItemMutex.RLock()
+	defer fake.getMediaItemMutex.RUnlock()
+	fake.getMediaItemsMutex.RLock()
+	defer fake.getMediaItemsMutex.RUnlock()
+	fake.getSharedAlbumsMutex.RLock()
+	defer fake.getSharedAlbumsMutex.RUnlock()
+	fake.searchMediaItemsMutex.RLock()
+	defer fake.searchMediaItemsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ media.Reader = new(FakeReader)
@@ -1,15 +1,25 @@
 package media
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
+const apiUrl = "https://photoslibrary.googleapis.com/v1"
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Reader
 type Reader interface {
 	GetMediaItems(email string, nextPageToken string) (MediaItems, error)
+	SearchMediaItems(filter SearchFilter, nextPageToken string) (MediaItems, error)
 	GetMediaItem(mediaItemId string) (MediaItem, error)
+	BatchGetMediaItems(mediaItemIds []string) (map[string]MediaItem, error)
+	GetAlbums(nextPageToken string) (Albums, error)
+	GetSharedAlbums(nextPageToken string) (Albums, error)
+	GetAlbumMediaItems(albumId string, nextPageToken string) (MediaItems, error)
 }
 
 type TooManyRequestsError struct {
@@ -18,6 +28,7 @@ type TooManyRequestsError struct {
 
 type NotOkRequestError struct {
 	error
+	StatusCode int
 }
 
 type reader struct {
@@ -31,32 +42,116 @@ func NewReader(httpClient *http.Client) (*reader, error) {
 }
 
 func (m *reader) GetMediaItems(email string, nextPageToken string) (MediaItems, error) {
-	resp, err := m.httpClient.Get(
-		"https://photoslibrary.googleapis.com/v1/mediaItems?pageSize=100&pageToken=" + nextPageToken,
-	)
+	var responseBody mediaItemsListResponseBody
+
+	err := m.get(apiUrl+"/mediaItems?pageSize=100&pageToken="+url.QueryEscape(nextPageToken), &responseBody)
+	if err != nil {
+		return MediaItems{}, fmt.Errorf("media items list request: %w", err)
+	}
+
+	return MediaItems{
+		Items:         responseBody.MediaItems,
+		NextPageToken: responseBody.NextPageToken,
+	}, nil
+}
+
+func (m *reader) SearchMediaItems(filter SearchFilter, nextPageToken string) (MediaItems, error) {
+	filters := m.getFilters(filter)
+
+	var responseBody mediaItemsListResponseBody
+
+	err := m.post(apiUrl+"/mediaItems:search", requestBody{
+		Filters:   &filters,
+		PageSize:  100,
+		PageToken: nextPageToken,
+	}, &responseBody)
 	if err != nil {
 		return MediaItems{}, fmt.Errorf("media items search request: %w", err)
 	}
 
-	defer resp.Body.Close()
+	return MediaItems{
+		Items:         responseBody.MediaItems,
+		NextPageToken: responseBody.NextPageToken,
+	}, nil
+}
 
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return MediaItems{}, TooManyRequestsError{}
+func (m *reader) GetMediaItem(mediaItemId string) (MediaItem, error) {
+	var mediaItem MediaItem
+
+	err := m.get(apiUrl+"/mediaItems/"+mediaItemId, &mediaItem)
+	if err != nil {
+		return MediaItem{}, fmt.Errorf("media item get request: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		responseBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return MediaItems{}, fmt.Errorf("read response body: %w", err)
+	return mediaItem, nil
+}
+
+// Resolves up to BatchGetMaxMediaItems items with one request.
+// Items which couldn't be resolved are missing in the result
+func (m *reader) BatchGetMediaItems(mediaItemIds []string) (map[string]MediaItem, error) {
+	if len(mediaItemIds) > BatchGetMaxMediaItems {
+		return nil, fmt.Errorf("too many media item ids: %d", len(mediaItemIds))
+	}
+
+	var responseBody mediaItemsBatchGetResponseBody
+
+	err := m.get(apiUrl+"/mediaItems:batchGet?"+url.Values{"mediaItemIds": mediaItemIds}.Encode(), &responseBody)
+	if err != nil {
+		return nil, fmt.Errorf("media items batch get request: %w", err)
+	}
+
+	mediaItems := make(map[string]MediaItem, len(responseBody.MediaItemResults))
+
+	for _, result := range responseBody.MediaItemResults {
+		if result.MediaItem.ID == "" {
+			continue
 		}
 
-		return MediaItems{}, NotOkRequestError{fmt.Errorf(string(responseBody))}
+		mediaItems[result.MediaItem.ID] = result.MediaItem
 	}
 
+	return mediaItems, nil
+}
+
+func (m *reader) GetAlbums(nextPageToken string) (Albums, error) {
+	var responseBody albumsListResponseBody
+
+	err := m.get(apiUrl+"/albums?pageSize=50&pageToken="+url.QueryEscape(nextPageToken), &responseBody)
+	if err != nil {
+		return Albums{}, fmt.Errorf("albums list request: %w", err)
+	}
+
+	return Albums{
+		Items:         responseBody.Albums,
+		NextPageToken: responseBody.NextPageToken,
+	}, nil
+}
+
+func (m *reader) GetSharedAlbums(nextPageToken string) (Albums, error) {
+	var responseBody sharedAlbumsListResponseBody
+
+	err := m.get(apiUrl+"/sharedAlbums?pageSize=50&pageToken="+url.QueryEscape(nextPageToken), &responseBody)
+	if err != nil {
+		return Albums{}, fmt.Errorf("shared albums list request: %w", err)
+	}
+
+	return Albums{
+		Items:         responseBody.SharedAlbums,
+		NextPageToken: responseBody.NextPageToken,
+	}, nil
+}
+
+func (m *reader) GetAlbumMediaItems(albumId string, nextPageToken string) (MediaItems, error) {
 	var responseBody mediaItemsListResponseBody
-	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+
+	// album id can't be combined with filters
+	err := m.post(apiUrl+"/mediaItems:search", requestBody{
+		AlbumId:   albumId,
+		PageSize:  100,
+		PageToken: nextPageToken,
+	}, &responseBody)
 	if err != nil {
-		return MediaItems{}, fmt.Errorf("decode response body: %w", err)
+		return MediaItems{}, fmt.Errorf("album media items search request: %w", err)
 	}
 
 	return MediaItems{
@@ -65,55 +160,82 @@ func (m *reader) GetMediaItems(email string, nextPageToken string) (MediaItems,
 	}, nil
 }
 
-func (m *reader) GetMediaItem(mediaItemId string) (MediaItem, error) {
-	resp, err := m.httpClient.Get(
-		"https://photoslibrary.googleapis.com/v1/mediaItems/" + mediaItemId,
-	)
+func (m *reader) get(url string, responseBody any) error {
+	resp, err := m.httpClient.Get(url)
 	if err != nil {
-		return MediaItem{}, fmt.Errorf("media item get request: %w", err)
+		return fmt.Errorf("get: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	return m.decodeResponse(resp, responseBody)
+}
+
+func (m *reader) post(url string, requestBody any, responseBody any) error {
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("marshal request body: %w", err)
+	}
+
+	resp, err := m.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
 	}
 
 	defer resp.Body.Close()
 
+	return m.decodeResponse(resp, responseBody)
+}
+
+func (m *reader) decodeResponse(resp *http.Response, responseBody any) error {
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return MediaItem{}, TooManyRequestsError{}
+		return TooManyRequestsError{}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		responseBody, err := io.ReadAll(resp.Body)
+		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return MediaItem{}, fmt.Errorf("read response body: %w", err)
+			return fmt.Errorf("read response body: %w", err)
 		}
 
-		return MediaItem{}, NotOkRequestError{fmt.Errorf(string(responseBody))}
+		return NotOkRequestError{error: fmt.Errorf(string(body)), StatusCode: resp.StatusCode}
 	}
 
-	var mediaItem MediaItem
-	err = json.NewDecoder(resp.Body).Decode(&mediaItem)
+	err := json.NewDecoder(resp.Body).Decode(responseBody)
 	if err != nil {
-		return MediaItem{}, fmt.Errorf("decode response body: %w", err)
+		return fmt.Errorf("decode response body: %w", err)
 	}
 
-	return mediaItem, nil
+	return nil
 }
 
-// func (m *reader) getFilters(startDate, endDate time.Time) filters {
-// 	return filters{
-// 		DateFilter: dateFilter{
-// 			Ranges: []ranges{
-// 				{
-// 					StartDate: date{
-// 						Year:  startDate.Year(),
-// 						Month: int(startDate.Month()),
-// 						Day:   startDate.Day(),
-// 					},
-// 					EndDate: date{
-// 						Year:  endDate.Year(),
-// 						Month: int(endDate.Month()),
-// 						Day:   endDate.Day(),
-// 					},
-// 				},
-// 			},
-// 		},
-// 	}
-// }
+func (m *reader) getFilters(filter SearchFilter) filters {
+	result := filters{}
+
+	if !filter.StartDate.IsZero() || !filter.EndDate.IsZero() {
+		result.DateFilter = &dateFilter{
+			Ranges: []ranges{
+				{
+					StartDate: date{
+						Year:  filter.StartDate.Year(),
+						Month: int(filter.StartDate.Month()),
+						Day:   filter.StartDate.Day(),
+					},
+					EndDate: date{
+						Year:  filter.EndDate.Year(),
+						Month: int(filter.EndDate.Month()),
+						Day:   filter.EndDate.Day(),
+					},
+				},
+			},
+		}
+	}
+
+	if filter.MediaType != "" {
+		result.MediaTypeFilter = &mediaTypeFilter{
+			MediaTypes: []string{filter.MediaType},
+		}
+	}
+
+	return result
+}
@@ -3,41 +3,94 @@ package media_reader
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
 
 	"google-backup/internal/account"
 	"google-backup/internal/auth"
+	"google-backup/internal/drive"
 	"google-backup/internal/media"
+	"google-backup/internal/quota"
+
+	log "github.com/sirupsen/logrus"
 )
 
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Reader
 type Reader interface {
 	CreateMediaReaders(ctx context.Context) (map[string]media.Reader, error)
+	CreateDriveReaders(ctx context.Context) (map[string]drive.Reader, error)
 }
 
 type reader struct {
 	account        account.Account
 	googleAuth     auth.Auth
 	accountLimiter account.Limiter
+	quotaLedger    quota.Ledger
 }
 
 func NewMediaReader(
 	account account.Account,
 	googleAuth auth.Auth,
 	accountLimiter account.Limiter,
+	quotaLedger quota.Ledger,
 ) reader {
 	return reader{
 		account:        account,
 		googleAuth:     googleAuth,
 		accountLimiter: accountLimiter,
+		quotaLedger:    quotaLedger,
 	}
 }
 
 func (r reader) CreateMediaReaders(ctx context.Context) (map[string]media.Reader, error) {
+	clients, err := r.createHttpClients(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create http clients: %w", err)
+	}
+
+	readers := make(map[string]media.Reader, len(clients))
+
+	for email, gClient := range clients {
+		mediaReader, err := media.NewReader(gClient)
+		if err != nil {
+			return nil, fmt.Errorf("new media reader: %w", err)
+		}
+
+		readers[email] = mediaReader
+	}
+
+	return readers, nil
+}
+
+func (r reader) CreateDriveReaders(ctx context.Context) (map[string]drive.Reader, error) {
+	clients, err := r.createHttpClients(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create http clients: %w", err)
+	}
+
+	readers := make(map[string]drive.Reader, len(clients))
+
+	for email, gClient := range clients {
+		driveReader, err := drive.NewReader(gClient)
+		if err != nil {
+			return nil, fmt.Errorf("new drive reader: %w", err)
+		}
+
+		readers[email] = driveReader
+	}
+
+	return readers, nil
+}
+
+// Creates authorized http clients of accounts which didn't reach the API requests limit
+// and didn't spend their daily API requests budget. Library API requests of the clients are counted by the quota ledger
+func (r reader) createHttpClients(ctx context.Context) (map[string]*http.Client, error) {
 	accounts, err := r.account.GetAccounts()
 	if err != nil {
 		return nil, fmt.Errorf("get accounts: %w", err)
 	}
 
-	readers := make(map[string]media.Reader, len(accounts))
+	clients := make(map[string]*http.Client, len(accounts))
 
 	for _, email := range accounts {
 		limitReached, err := r.accountLimiter.LimitReached(string(email), account.ApiRequestLimitType)
@@ -49,6 +102,20 @@ func (r reader) CreateMediaReaders(ctx context.Context) (map[string]media.Reader
 			continue
 		}
 
+		available, err := r.quotaLedger.Available(string(email), quota.ApiRequestQuotaType)
+		if err != nil {
+			return nil, fmt.Errorf("quota available check: %w", err)
+		}
+
+		if !available {
+			log.WithFields(log.Fields{
+				"email":      string(email),
+				"reset_time": quota.ResetTime(time.Now()).Format(time.RFC3339),
+			}).Info("daily api request budget spent")
+
+			continue
+		}
+
 		authToken, err := r.account.GetTokenByEmail(string(email))
 		if err != nil {
 			return nil, fmt.Errorf("get token by email: %w", err)
@@ -64,13 +131,10 @@ func (r reader) CreateMediaReaders(ctx context.Context) (map[string]media.Reader
 			return nil, fmt.Errorf("get google client: %w", err)
 		}
 
-		mediaReader, err := media.NewReader(gClient)
-		if err != nil {
-			return nil, fmt.Errorf("new media reader: %w", err)
-		}
+		gClient.Transport = quota.NewTransport(r.quotaLedger, string(email), gClient.Transport)
 
-		readers[string(email)] = mediaReader
+		clients[string(email)] = gClient
 	}
 
-	return readers, nil
+	return clients, nil
 }
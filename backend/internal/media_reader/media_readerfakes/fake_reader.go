@@ -0,0 +1,197 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package media_readerfakes
+
+import (
+	"context"
+	"google-backup/internal/drive"
+	"google-backup/internal/media"
+	"google-backup/internal/media_reader"
+	"sync"
+)
+
+type FakeReader struct {
+	CreateDriveReadersStub        func(context.Context) (map[string]drive.Reader, error)
+	createDriveReadersMutex       sync.RWMutex
+	createDriveReadersArgsForCall []struct {
+		arg1 context.Context
+	}
+	createDriveReadersReturns struct {
+		result1 map[string]drive.Reader
+		result2 error
+	}
+	createDriveReadersReturnsOnCall map[int]struct {
+		result1 map[string]drive.Reader
+		result2 error
+	}
+	CreateMediaReadersStub        func(context.Context) (map[string]media.Reader, error)
+	createMediaReadersMutex       sync.RWMutex
+	createMediaReadersArgsForCall []struct {
+		arg1 context.Context
+	}
+	createMediaReadersReturns struct {
+		result1 map[string]media.Reader
+		result2 error
+	}
+	createMediaReadersReturnsOnCall map[int]struct {
+		result1 map[string]media.Reader
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReader) CreateDriveReaders(arg1 context.Context) (map[string]drive.Reader, error) {
+	fake.createDriveReadersMutex.Lock()
+	ret, specificReturn := fake.createDriveReadersReturnsOnCall[len(fake.createDriveReadersArgsForCall)]
+	fake.createDriveReadersArgsForCall = append(fake.createDriveReadersArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.CreateDriveReadersStub
+	fakeReturns := fake.createDriveReadersReturns
+	fake.recordInvocation("CreateDriveReaders", []interface{}{arg1})
+	fake.createDriveReadersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) CreateDriveReadersCallCount() int {
+	fake.createDriveReadersMutex.RLock()
+	defer fake.createDriveReadersMutex.RUnlock()
+	return len(fake.createDriveReadersArgsForCall)
+}
+
+func (fake *FakeReader) CreateDriveReadersCalls(stub func(context.Context) (map[string]drive.Reader, error)) {
+	fake.createDriveReadersMutex.Lock()
+	defer fake.createDriveReadersMutex.Unlock()
+	fake.CreateDriveReadersStub = stub
+}
+
+func (fake *FakeReader) CreateDriveReadersArgsForCall(i int) context.Context {
+	fake.createDriveReadersMutex.RLock()
+	defer fake.createDriveReadersMutex.RUnlock()
+	argsForCall := fake.createDriveReadersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) CreateDriveReadersReturns(result1 map[string]drive.Reader, result2 error) {
+	fake.createDriveReadersMutex.Lock()
+	defer fake.createDriveReadersMutex.Unlock()
+	fake.CreateDriveReadersStub = nil
+	fake.createDriveReadersReturns = struct {
+		result1 map[string]drive.Reader
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) CreateDriveReadersReturnsOnCall(i int, result1 map[string]drive.Reader, result2 error) {
+	fake.createDriveReadersMutex.Lock()
+	defer fake.createDriveReadersMutex.Unlock()
+	fake.CreateDriveReadersStub = nil
+	if fake.createDriveReadersReturnsOnCall == nil {
+		fake.createDriveReadersReturnsOnCall = make(map[int]struct {
+			result1 map[string]drive.Reader
+			result2 error
+		})
+	}
+	fake.createDriveReadersReturnsOnCall[i] = struct {
+		result1 map[string]drive.Reader
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) CreateMediaReaders(arg1 context.Context) (map[string]media.Reader, error) {
+	fake.createMediaReadersMutex.Lock()
+	ret, specificReturn := fake.createMediaReadersReturnsOnCall[len(fake.createMediaReadersArgsForCall)]
+	fake.createMediaReadersArgsForCall = append(fake.createMediaReadersArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.CreateMediaReadersStub
+	fakeReturns := fake.createMediaReadersReturns
+	fake.recordInvocation("CreateMediaReaders", []interface{}{arg1})
+	fake.createMediaReadersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeReader) CreateMediaReadersCallCount() int {
+	fake.createMediaReadersMutex.RLock()
+	defer fake.createMediaReadersMutex.RUnlock()
+	return len(fake.createMediaReadersArgsForCall)
+}
+
+func (fake *FakeReader) CreateMediaReadersCalls(stub func(context.Context) (map[string]media.Reader, error)) {
+	fake.createMediaReadersMutex.Lock()
+	defer fake.createMediaReadersMutex.Unlock()
+	fake.CreateMediaReadersStub = stub
+}
+
+func (fake *FakeReader) CreateMediaReadersArgsForCall(i int) context.Context {
+	fake.createMediaReadersMutex.RLock()
+	defer fake.createMediaReadersMutex.RUnlock()
+	argsForCall := fake.createMediaReadersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReader) CreateMediaReadersReturns(result1 map[string]media.Reader, result2 error) {
+	fake.createMediaReadersMutex.Lock()
+	defer fake.createMediaReadersMutex.Unlock()
+	fake.CreateMediaReadersStub = nil
+	fake.createMediaReadersReturns = struct {
+		result1 map[string]media.Reader
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) CreateMediaReadersReturnsOnCall(i int, result1 map[string]media.Reader, result2 error) {
+	fake.createMediaReadersMutex.Lock()
+	defer fake.createMediaReadersMutex.Unlock()
+	fake.CreateMediaReadersStub = nil
+	if fake.createMediaReadersReturnsOnCall == nil {
+		fake.createMediaReadersReturnsOnCall = make(map[int]struct {
+			result1 map[string]media.Reader
+			result2 error
+		})
+	}
+	fake.createMediaReadersReturnsOnCall[i] = struct {
+		result1 map[string]media.Reader
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createDriveReadersMutex.RLock()
+	defer fake.createDriveReadersMutex.RUnlock()
+	fake.createMediaReadersMutex.RLock()
+	defer fake.createMediaReadersMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ media_reader.Reader = new(FakeReader)
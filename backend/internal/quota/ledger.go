@@ -0,0 +1,336 @@
+package quota
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"google-backup/internal/account"
+	"google-backup/internal/google_client"
+	"google-backup/internal/settings"
+)
+
+const (
+	ApiRequestQuotaType = "request"
+	DownloadQuotaType   = "download"
+
+	// Kept below the Google Photos defaults of 10 000 requests and 75 000 media downloads per project and day,
+	// the margin covers requests the ledger doesn't see, e.g. made by other tools with the same client
+	DefaultApiRequestBudget = 9_000
+	DefaultDownloadBudget   = 70_000
+
+	dayLayout = "2006-01-02"
+)
+
+// Returned instead of sending a request when the account used its part of the daily budget
+var ErrBudgetSpent = errors.New("daily quota budget is spent")
+
+// Google resets the quotas at midnight Pacific time
+var pacificTime = loadPacificTime()
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Ledger
+type Ledger interface {
+	Available(email, quotaType string) (bool, error)
+	Reserve(email, quotaType string) (bool, error)
+	GetUsage() ([]Usage, error)
+}
+
+// Usage of an OAuth client during the current Pacific day
+type Usage struct {
+	ClientId         string                  `json:"client_id"`
+	Day              string                  `json:"day"`
+	ResetTime        string                  `json:"reset_time"`
+	ApiRequests      int64                   `json:"api_requests"`
+	ApiRequestBudget int64                   `json:"api_request_budget"`
+	Downloads        int64                   `json:"downloads"`
+	DownloadBudget   int64                   `json:"download_budget"`
+	Accounts         map[string]AccountUsage `json:"accounts"`
+}
+
+type AccountUsage struct {
+	ApiRequests int64 `json:"api_requests"`
+	Downloads   int64 `json:"downloads"`
+}
+
+type dailyUsage struct {
+	Day      string                  `json:"day"`
+	Accounts map[string]AccountUsage `json:"accounts"`
+}
+
+// Counts the Library API requests and media downloads of every OAuth client per Pacific day.
+// The budget of a client is shared by its accounts: an account may use more than its equal share
+// only while the unused shares of the other accounts stay available, so one busy account
+// can't starve the others
+type ledger struct {
+	repository             Repository
+	account                account.Account
+	googleClientRepository google_client.Repository
+	settingsReader         settings.SettingsReader
+	mutex                  *sync.Mutex
+}
+
+func NewLedger(
+	repository Repository,
+	account account.Account,
+	googleClientRepository google_client.Repository,
+	settingsReader settings.SettingsReader,
+) ledger {
+	return ledger{
+		repository:             repository,
+		account:                account,
+		googleClientRepository: googleClientRepository,
+		settingsReader:         settingsReader,
+		mutex:                  &sync.Mutex{},
+	}
+}
+
+// Returns the time the quotas of the day of now are reset
+func ResetTime(now time.Time) time.Time {
+	year, month, day := now.In(pacificTime).Date()
+
+	return time.Date(year, month, day+1, 0, 0, 0, 0, pacificTime)
+}
+
+// Returns false if the account can't send another call of the type today
+func (l ledger) Available(email, quotaType string) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.reserve(email, quotaType, false)
+}
+
+// Counts a call of the account, returns false without counting if the account can't send it today
+func (l ledger) Reserve(email, quotaType string) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.reserve(email, quotaType, true)
+}
+
+func (l ledger) GetUsage() ([]Usage, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	apiRequestBudget, err := l.budget(ApiRequestQuotaType)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadBudget, err := l.budget(DownloadQuotaType)
+	if err != nil {
+		return nil, err
+	}
+
+	usagesJson, err := l.repository.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("get all usages: %w", err)
+	}
+
+	now := time.Now()
+	day := now.In(pacificTime).Format(dayLayout)
+	result := make([]Usage, 0, len(usagesJson))
+
+	for clientId, usageJson := range usagesJson {
+		var usage dailyUsage
+		err = json.Unmarshal(usageJson, &usage)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal usage %s: %w", clientId, err)
+		}
+
+		// counted on a previous day
+		if usage.Day != day {
+			usage.Accounts = nil
+		}
+
+		clientUsage := Usage{
+			ClientId:         clientId,
+			Day:              day,
+			ResetTime:        ResetTime(now).UTC().Format(time.RFC3339),
+			ApiRequests:      usage.total(ApiRequestQuotaType),
+			ApiRequestBudget: apiRequestBudget,
+			Downloads:        usage.total(DownloadQuotaType),
+			DownloadBudget:   downloadBudget,
+			Accounts:         map[string]AccountUsage{},
+		}
+
+		for email, accountUsage := range usage.Accounts {
+			clientUsage.Accounts[email] = accountUsage
+		}
+
+		result = append(result, clientUsage)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ClientId < result[j].ClientId })
+
+	return result, nil
+}
+
+func (l ledger) reserve(email, quotaType string, count bool) (bool, error) {
+	budget, err := l.budget(quotaType)
+	if err != nil {
+		return false, err
+	}
+
+	clientId, err := l.account.GetAccountOauthClientName(email)
+	if err != nil {
+		return false, fmt.Errorf("get account oauth client name: %w", err)
+	}
+
+	usage, err := l.dailyUsage(clientId, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	accounts, err := l.clientAccounts(clientId, email)
+	if err != nil {
+		return false, err
+	}
+
+	share := budget / int64(len(accounts))
+
+	var reservedForOthers int64
+	for _, accountEmail := range accounts {
+		used := usage.Accounts[accountEmail].count(quotaType)
+		if accountEmail != email && used < share {
+			reservedForOthers += share - used
+		}
+	}
+
+	if usage.total(quotaType)+reservedForOthers >= budget {
+		return false, nil
+	}
+
+	if !count {
+		return true, nil
+	}
+
+	accountUsage := usage.Accounts[email]
+
+	switch quotaType {
+	case ApiRequestQuotaType:
+		accountUsage.ApiRequests++
+	case DownloadQuotaType:
+		accountUsage.Downloads++
+	}
+
+	usage.Accounts[email] = accountUsage
+
+	usageJson, err := json.Marshal(usage)
+	if err != nil {
+		return false, fmt.Errorf("marshal usage: %w", err)
+	}
+
+	err = l.repository.Save(clientId, usageJson)
+	if err != nil {
+		return false, fmt.Errorf("save usage: %w", err)
+	}
+
+	return true, nil
+}
+
+// Returns the usage of the Pacific day of now, usage of previous days is dropped
+func (l ledger) dailyUsage(clientId string, now time.Time) (dailyUsage, error) {
+	day := now.In(pacificTime).Format(dayLayout)
+
+	usageJson, err := l.repository.Get(clientId)
+	if err != nil {
+		return dailyUsage{}, fmt.Errorf("get usage: %w", err)
+	}
+
+	usage := dailyUsage{}
+
+	if usageJson != nil {
+		err = json.Unmarshal(usageJson, &usage)
+		if err != nil {
+			return dailyUsage{}, fmt.Errorf("unmarshal usage: %w", err)
+		}
+	}
+
+	if usage.Day != day || usage.Accounts == nil {
+		usage = dailyUsage{Day: day, Accounts: map[string]AccountUsage{}}
+	}
+
+	return usage, nil
+}
+
+// Accounts sharing the budget of the client, the given account included
+func (l ledger) clientAccounts(clientId string, email string) ([]string, error) {
+	assignedAccountsJson, err := l.googleClientRepository.FindAssignedAccounts(clientId)
+	if err != nil {
+		return nil, fmt.Errorf("find assigned accounts: %w", err)
+	}
+
+	var accounts []string
+
+	if assignedAccountsJson != nil {
+		err = json.Unmarshal(assignedAccountsJson, &accounts)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal assigned accounts: %w", err)
+		}
+	}
+
+	if !slices.Contains(accounts, email) {
+		accounts = append(accounts, email)
+	}
+
+	return accounts, nil
+}
+
+func (l ledger) budget(quotaType string) (int64, error) {
+	settingsData, err := l.settingsReader.Get()
+	if err != nil {
+		return 0, fmt.Errorf("get settings: %w", err)
+	}
+
+	switch quotaType {
+	case ApiRequestQuotaType:
+		if settingsData.DailyApiRequestBudget > 0 {
+			return settingsData.DailyApiRequestBudget, nil
+		}
+
+		return DefaultApiRequestBudget, nil
+	case DownloadQuotaType:
+		if settingsData.DailyDownloadBudget > 0 {
+			return settingsData.DailyDownloadBudget, nil
+		}
+
+		return DefaultDownloadBudget, nil
+	default:
+		return 0, fmt.Errorf("unknown quota type: %s", quotaType)
+	}
+}
+
+func (u dailyUsage) total(quotaType string) int64 {
+	var total int64
+	for _, accountUsage := range u.Accounts {
+		total += accountUsage.count(quotaType)
+	}
+
+	return total
+}
+
+func (a AccountUsage) count(quotaType string) int64 {
+	switch quotaType {
+	case ApiRequestQuotaType:
+		return a.ApiRequests
+	case DownloadQuotaType:
+		return a.Downloads
+	default:
+		return 0
+	}
+}
+
+func loadPacificTime() *time.Location {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		// the zone database is embedded, standard time is a safe fallback
+		return time.FixedZone("PST", -8*60*60)
+	}
+
+	return location
+}
@@ -0,0 +1,224 @@
+package quota
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google-backup/internal/account"
+	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/google_client/google_clientfakes"
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+const testClientId = "client-1"
+
+func newTestLedger(t *testing.T, settingsData settings.SettingsData, assignedAccounts ...string) ledger {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	fakeAccountRepository := new(accountfakes.FakeRepository)
+	fakeAccountRepository.GetAccountOauthClientNameReturns([]byte(testClientId), nil)
+
+	assignedAccountsJson, err := json.Marshal(assignedAccounts)
+	require.NoError(t, err)
+
+	fakeGoogleClientRepository := new(google_clientfakes.FakeRepository)
+	fakeGoogleClientRepository.FindAssignedAccountsReturns(assignedAccountsJson, nil)
+
+	fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+	fakeSettingsReader.GetReturns(settingsData, nil)
+
+	return NewLedger(NewRepository(db), account.NewAccount(fakeAccountRepository), fakeGoogleClientRepository, fakeSettingsReader)
+}
+
+// Reserves calls until the ledger refuses one, returns the number of reserved calls
+func reserveAll(t *testing.T, l ledger, email, quotaType string) int {
+	reserved := 0
+	for {
+		ok, err := l.Reserve(email, quotaType)
+		require.NoError(t, err)
+
+		if !ok {
+			return reserved
+		}
+
+		reserved++
+	}
+}
+
+func TestLedgerReserve(t *testing.T) {
+	t.Run("budget is exhausted", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{DailyApiRequestBudget: 3}, "user1@gmail.com")
+
+		assert.Equal(t, 3, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+
+		available, err := l.Available("user1@gmail.com", ApiRequestQuotaType)
+		assert.NoError(t, err)
+		assert.False(t, available)
+
+		// refused calls aren't counted
+		usages, err := l.GetUsage()
+		assert.NoError(t, err)
+		require.Len(t, usages, 1)
+		assert.Equal(t, int64(3), usages[0].ApiRequests)
+		assert.Equal(t, int64(3), usages[0].ApiRequestBudget)
+	})
+
+	t.Run("quota types are counted separately", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{DailyApiRequestBudget: 2, DailyDownloadBudget: 5}, "user1@gmail.com")
+
+		assert.Equal(t, 2, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 5, reserveAll(t, l, "user1@gmail.com", DownloadQuotaType))
+	})
+
+	t.Run("available doesn't count", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{DailyApiRequestBudget: 1}, "user1@gmail.com")
+
+		for i := 0; i < 3; i++ {
+			available, err := l.Available("user1@gmail.com", ApiRequestQuotaType)
+			assert.NoError(t, err)
+			assert.True(t, available)
+		}
+
+		assert.Equal(t, 1, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+	})
+
+	t.Run("default budgets", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{}, "user1@gmail.com")
+
+		apiRequestBudget, err := l.budget(ApiRequestQuotaType)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(DefaultApiRequestBudget), apiRequestBudget)
+
+		downloadBudget, err := l.budget(DownloadQuotaType)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(DefaultDownloadBudget), downloadBudget)
+
+		_, err = l.Reserve("user1@gmail.com", "unknown")
+		assert.EqualError(t, err, "unknown quota type: unknown")
+	})
+
+	t.Run("busy account keeps the shares of the others", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{DailyApiRequestBudget: 10}, "user1@gmail.com", "user2@gmail.com")
+
+		assert.Equal(t, 5, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 5, reserveAll(t, l, "user2@gmail.com", ApiRequestQuotaType))
+	})
+
+	t.Run("remainder of the shares goes to the first account asking", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{DailyApiRequestBudget: 10}, "user1@gmail.com", "user2@gmail.com", "user3@gmail.com")
+
+		// share is 3, 6 are kept for the others
+		assert.Equal(t, 4, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 3, reserveAll(t, l, "user2@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 3, reserveAll(t, l, "user3@gmail.com", ApiRequestQuotaType))
+	})
+
+	t.Run("unused part of a share is kept while the account is below it", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{DailyApiRequestBudget: 9}, "user1@gmail.com", "user2@gmail.com", "user3@gmail.com")
+
+		ok, err := l.Reserve("user2@gmail.com", ApiRequestQuotaType)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// 2 are kept for the second account, 3 for the third one
+		assert.Equal(t, 3, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 2, reserveAll(t, l, "user2@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 3, reserveAll(t, l, "user3@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 0, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+	})
+
+	t.Run("account which isn't assigned yet shares the budget", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{DailyApiRequestBudget: 10}, "user1@gmail.com")
+
+		assert.Equal(t, 5, reserveAll(t, l, "user2@gmail.com", ApiRequestQuotaType))
+		assert.Equal(t, 5, reserveAll(t, l, "user1@gmail.com", ApiRequestQuotaType))
+	})
+}
+
+func TestLedgerDailyUsage(t *testing.T) {
+	saveUsage := func(t *testing.T, l ledger, day string) {
+		usageJson, err := json.Marshal(dailyUsage{
+			Day:      day,
+			Accounts: map[string]AccountUsage{"user1@gmail.com": {ApiRequests: 7, Downloads: 3}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, l.repository.Save(testClientId, usageJson))
+	}
+
+	tests := []struct {
+		name     string
+		saved    string
+		now      time.Time
+		expected string
+		dropped  bool
+	}{
+		// daylight saving time starts on 2024-03-10 at 2:00 PST
+		{"last minute before the spring forward day", "2024-03-09", time.Date(2024, 3, 10, 7, 59, 0, 0, time.UTC), "2024-03-09", false},
+		{"midnight of the spring forward day", "2024-03-09", time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC), "2024-03-10", true},
+		{"end of the spring forward day", "2024-03-10", time.Date(2024, 3, 11, 6, 59, 0, 0, time.UTC), "2024-03-10", false},
+		{"midnight after the spring forward day", "2024-03-10", time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC), "2024-03-11", true},
+		// daylight saving time ends on 2024-11-03 at 2:00 PDT
+		{"midnight of the fall back day", "2024-11-02", time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC), "2024-11-03", true},
+		{"second 1:30 of the fall back day", "2024-11-03", time.Date(2024, 11, 3, 9, 30, 0, 0, time.UTC), "2024-11-03", false},
+		{"end of the fall back day", "2024-11-03", time.Date(2024, 11, 4, 7, 59, 0, 0, time.UTC), "2024-11-03", false},
+		{"midnight after the fall back day", "2024-11-03", time.Date(2024, 11, 4, 8, 0, 0, 0, time.UTC), "2024-11-04", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newTestLedger(t, settings.SettingsData{}, "user1@gmail.com")
+			saveUsage(t, l, test.saved)
+
+			usage, err := l.dailyUsage(testClientId, test.now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, usage.Day)
+
+			if test.dropped {
+				assert.Empty(t, usage.Accounts)
+			} else {
+				assert.Equal(t, int64(7), usage.total(ApiRequestQuotaType))
+			}
+		})
+	}
+
+	t.Run("nothing counted for the client", func(t *testing.T) {
+		l := newTestLedger(t, settings.SettingsData{}, "user1@gmail.com")
+
+		usage, err := l.dailyUsage(testClientId, time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-03-10", usage.Day)
+		assert.NotNil(t, usage.Accounts)
+	})
+}
+
+func TestResetTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{"standard time", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC)},
+		{"before the spring forward", time.Date(2024, 3, 10, 9, 59, 0, 0, time.UTC), time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC)},
+		{"after the spring forward", time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC)},
+		{"daylight saving time", time.Date(2024, 7, 1, 6, 59, 0, 0, time.UTC), time.Date(2024, 7, 1, 7, 0, 0, 0, time.UTC)},
+		{"fall back", time.Date(2024, 11, 3, 9, 30, 0, 0, time.UTC), time.Date(2024, 11, 4, 8, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.True(t, test.expected.Equal(ResetTime(test.now)), ResetTime(test.now).UTC())
+		})
+	}
+}
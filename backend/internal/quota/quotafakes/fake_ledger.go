@@ -0,0 +1,268 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package quotafakes
+
+import (
+	"google-backup/internal/quota"
+	"sync"
+)
+
+type FakeLedger struct {
+	AvailableStub        func(string, string) (bool, error)
+	availableMutex       sync.RWMutex
+	availableArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	availableReturns struct {
+		result1 bool
+		result2 error
+	}
+	availableReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	GetUsageStub        func() ([]quota.Usage, error)
+	getUsageMutex       sync.RWMutex
+	getUsageArgsForCall []struct {
+	}
+	getUsageReturns struct {
+		result1 []quota.Usage
+		result2 error
+	}
+	getUsageReturnsOnCall map[int]struct {
+		result1 []quota.Usage
+		result2 error
+	}
+	ReserveStub        func(string, string) (bool, error)
+	reserveMutex       sync.RWMutex
+	reserveArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	reserveReturns struct {
+		result1 bool
+		result2 error
+	}
+	reserveReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLedger) Available(arg1 string, arg2 string) (bool, error) {
+	fake.availableMutex.Lock()
+	ret, specificReturn := fake.availableReturnsOnCall[len(fake.availableArgsForCall)]
+	fake.availableArgsForCall = append(fake.availableArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.AvailableStub
+	fakeReturns := fake.availableReturns
+	fake.recordInvocation("Available", []interface{}{arg1, arg2})
+	fake.availableMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLedger) AvailableCallCount() int {
+	fake.availableMutex.RLock()
+	defer fake.availableMutex.RUnlock()
+	return len(fake.availableArgsForCall)
+}
+
+func (fake *FakeLedger) AvailableCalls(stub func(string, string) (bool, error)) {
+	fake.availableMutex.Lock()
+	defer fake.availableMutex.Unlock()
+	fake.AvailableStub = stub
+}
+
+func (fake *FakeLedger) AvailableArgsForCall(i int) (string, string) {
+	fake.availableMutex.RLock()
+	defer fake.availableMutex.RUnlock()
+	argsForCall := fake.availableArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLedger) AvailableReturns(result1 bool, result2 error) {
+	fake.availableMutex.Lock()
+	defer fake.availableMutex.Unlock()
+	fake.AvailableStub = nil
+	fake.availableReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLedger) AvailableReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.availableMutex.Lock()
+	defer fake.availableMutex.Unlock()
+	fake.AvailableStub = nil
+	if fake.availableReturnsOnCall == nil {
+		fake.availableReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.availableReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLedger) GetUsage() ([]quota.Usage, error) {
+	fake.getUsageMutex.Lock()
+	ret, specificReturn := fake.getUsageReturnsOnCall[len(fake.getUsageArgsForCall)]
+	fake.getUsageArgsForCall = append(fake.getUsageArgsForCall, struct {
+	}{})
+	stub := fake.GetUsageStub
+	fakeReturns := fake.getUsageReturns
+	fake.recordInvocation("GetUsage", []interface{}{})
+	fake.getUsageMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLedger) GetUsageCallCount() int {
+	fake.getUsageMutex.RLock()
+	defer fake.getUsageMutex.RUnlock()
+	return len(fake.getUsageArgsForCall)
+}
+
+func (fake *FakeLedger) GetUsageCalls(stub func() ([]quota.Usage, error)) {
+	fake.getUsageMutex.Lock()
+	defer fake.getUsageMutex.Unlock()
+	fake.GetUsageStub = stub
+}
+
+func (fake *FakeLedger) GetUsageReturns(result1 []quota.Usage, result2 error) {
+	fake.getUsageMutex.Lock()
+	defer fake.getUsageMutex.Unlock()
+	fake.GetUsageStub = nil
+	fake.getUsageReturns = struct {
+		result1 []quota.Usage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLedger) GetUsageReturnsOnCall(i int, result1 []quota.Usage, result2 error) {
+	fake.getUsageMutex.Lock()
+	defer fake.getUsageMutex.Unlock()
+	fake.GetUsageStub = nil
+	if fake.getUsageReturnsOnCall == nil {
+		fake.getUsageReturnsOnCall = make(map[int]struct {
+			result1 []quota.Usage
+			result2 error
+		})
+	}
+	fake.getUsageReturnsOnCall[i] = struct {
+		result1 []quota.Usage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLedger) Reserve(arg1 string, arg2 string) (bool, error) {
+	fake.reserveMutex.Lock()
+	ret, specificReturn := fake.reserveReturnsOnCall[len(fake.reserveArgsForCall)]
+	fake.reserveArgsForCall = append(fake.reserveArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ReserveStub
+	fakeReturns := fake.reserveReturns
+	fake.recordInvocation("Reserve", []interface{}{arg1, arg2})
+	fake.reserveMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLedger) ReserveCallCount() int {
+	fake.reserveMutex.RLock()
+	defer fake.reserveMutex.RUnlock()
+	return len(fake.reserveArgsForCall)
+}
+
+func (fake *FakeLedger) ReserveCalls(stub func(string, string) (bool, error)) {
+	fake.reserveMutex.Lock()
+	defer fake.reserveMutex.Unlock()
+	fake.ReserveStub = stub
+}
+
+func (fake *FakeLedger) ReserveArgsForCall(i int) (string, string) {
+	fake.reserveMutex.RLock()
+	defer fake.reserveMutex.RUnlock()
+	argsForCall := fake.reserveArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLedger) ReserveReturns(result1 bool, result2 error) {
+	fake.reserveMutex.Lock()
+	defer fake.reserveMutex.Unlock()
+	fake.ReserveStub = nil
+	fake.reserveReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLedger) ReserveReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.reserveMutex.Lock()
+	defer fake.reserveMutex.Unlock()
+	fake.ReserveStub = nil
+	if fake.reserveReturnsOnCall == nil {
+		fake.reserveReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.reserveReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLedger) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.availableMutex.RLock()
+	defer fake.availableMutex.RUnlock()
+	fake.getUsageMutex.RLock()
+	defer fake.getUsageMutex.RUnlock()
+	fake.reserveMutex.RLock()
+	defer fake.reserveMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLedger) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ quota.Ledger = new(FakeLedger)
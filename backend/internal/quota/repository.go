@@ -0,0 +1,76 @@
+package quota
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+const ledgerBucketName = "quota_ledger"
+
+type Repository interface {
+	Save(clientId string, value []byte) error
+	Get(clientId string) ([]byte, error)
+	GetAll() (map[string][]byte, error)
+}
+
+type repo struct {
+	DB *bbolt.DB
+}
+
+func NewRepository(db *bbolt.DB) repo {
+	return repo{DB: db}
+}
+
+func (r repo) Save(clientId string, value []byte) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(ledgerBucketName))
+		if err != nil {
+			return fmt.Errorf("create %s bucket: %w", ledgerBucketName, err)
+		}
+
+		return bucket.Put([]byte(clientId), value)
+	})
+}
+
+// Returns nil if nothing was counted for the client
+func (r repo) Get(clientId string) ([]byte, error) {
+	var value []byte
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(ledgerBucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		v := bucket.Get([]byte(clientId))
+		if v != nil {
+			value = make([]byte, len(v))
+			copy(value, v)
+		}
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (r repo) GetAll() (map[string][]byte, error) {
+	values := make(map[string][]byte)
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(ledgerBucketName))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			// values are valid only during the transaction
+			values[string(k)] = append([]byte{}, v...)
+
+			return nil
+		})
+	})
+
+	return values, err
+}
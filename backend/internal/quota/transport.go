@@ -0,0 +1,46 @@
+package quota
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const libraryApiHost = "photoslibrary.googleapis.com"
+
+// Counts the Library API requests of the account against the daily budget of its client.
+// Requests to other hosts, e.g. the Drive API, are passed through
+type transport struct {
+	ledger Ledger
+	email  string
+	base   http.RoundTripper
+}
+
+func NewTransport(ledger Ledger, email string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return transport{ledger: ledger, email: email, base: base}
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != libraryApiHost {
+		return t.base.RoundTrip(req)
+	}
+
+	reserved, err := t.ledger.Reserve(t.email, ApiRequestQuotaType)
+	if err == nil && !reserved {
+		err = ErrBudgetSpent
+	}
+
+	if err != nil {
+		// a round tripper has to close the body even if the request is not sent
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, fmt.Errorf("reserve api request: %w", err)
+	}
+
+	return t.base.RoundTrip(req)
+}
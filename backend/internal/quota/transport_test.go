@@ -0,0 +1,111 @@
+package quota_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google-backup/internal/quota"
+	"google-backup/internal/quota/quotafakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+
+	return nil
+}
+
+func TestTransport(t *testing.T) {
+	newBase := func(sent *int) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			*sent++
+
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(http.StatusOK)
+
+			return recorder.Result(), nil
+		})
+	}
+
+	t.Run("library api request is counted", func(t *testing.T) {
+		fakeLedger := new(quotafakes.FakeLedger)
+		fakeLedger.ReserveReturns(true, nil)
+
+		sent := 0
+		transport := quota.NewTransport(fakeLedger, "user1@gmail.com", newBase(&sent))
+
+		req := httptest.NewRequest(http.MethodGet, "https://photoslibrary.googleapis.com/v1/mediaItems", nil)
+		resp, err := transport.RoundTrip(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 1, sent)
+
+		require.Equal(t, 1, fakeLedger.ReserveCallCount())
+		email, quotaType := fakeLedger.ReserveArgsForCall(0)
+		assert.Equal(t, "user1@gmail.com", email)
+		assert.Equal(t, quota.ApiRequestQuotaType, quotaType)
+	})
+
+	t.Run("other hosts are passed through", func(t *testing.T) {
+		fakeLedger := new(quotafakes.FakeLedger)
+
+		sent := 0
+		transport := quota.NewTransport(fakeLedger, "user1@gmail.com", newBase(&sent))
+
+		req := httptest.NewRequest(http.MethodGet, "https://www.googleapis.com/drive/v3/files", nil)
+		_, err := transport.RoundTrip(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Equal(t, 0, fakeLedger.ReserveCallCount())
+	})
+
+	t.Run("spent budget", func(t *testing.T) {
+		fakeLedger := new(quotafakes.FakeLedger)
+		fakeLedger.ReserveReturns(false, nil)
+
+		sent := 0
+		transport := quota.NewTransport(fakeLedger, "user1@gmail.com", newBase(&sent))
+
+		body := &trackedBody{Reader: strings.NewReader(`{"mediaItemIds":[]}`)}
+		req := httptest.NewRequest(http.MethodPost, "https://photoslibrary.googleapis.com/v1/mediaItems:batchGet", body)
+		resp, err := transport.RoundTrip(req)
+
+		assert.ErrorIs(t, err, quota.ErrBudgetSpent)
+		assert.Nil(t, resp)
+		assert.Equal(t, 0, sent)
+		assert.True(t, body.closed)
+	})
+
+	t.Run("failed reservation", func(t *testing.T) {
+		fakeLedger := new(quotafakes.FakeLedger)
+		fakeLedger.ReserveReturns(false, errors.New("database is closed"))
+
+		sent := 0
+		transport := quota.NewTransport(fakeLedger, "user1@gmail.com", newBase(&sent))
+
+		req := httptest.NewRequest(http.MethodGet, "https://photoslibrary.googleapis.com/v1/albums", nil)
+		_, err := transport.RoundTrip(req)
+
+		assert.EqualError(t, err, "reserve api request: database is closed")
+		assert.Equal(t, 0, sent)
+	})
+}
@@ -7,14 +7,21 @@ import (
 )
 
 const (
-	rescanRequestKey = "rescan"
+	rescanRequestKey         = "rescan"
+	changesPageTokenKey      = "changes"
+	seenMediaItemsBucketName = "seen_media_items"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Repository
 type Repository interface {
 	UpdateRescanRequest(rescanType, email string, value []byte) error
 	GetRescanRequests(email string) (map[string][]byte, error)
-	DeleteRescanRequest(email string) error
+	DeleteRescanRequest(rescanType, email string) error
+	UpdateChangesPageToken(rescanType, email string, value []byte) error
+	GetChangesPageToken(rescanType, email string) ([]byte, error)
+	SaveSeenMediaItems(email string, mediaItemIds []string) error
+	GetSeenMediaItems(email string) (map[string]bool, error)
+	DeleteSeenMediaItems(email string) error
 }
 
 type repo struct {
@@ -42,16 +49,18 @@ func (r *repo) UpdateRescanRequest(rescanType, email string, value []byte) error
 }
 
 func (r *repo) GetRescanRequests(email string) (map[string][]byte, error) {
-	var values map[string][]byte
+	values := make(map[string][]byte)
 
 	err := r.DB.View(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(email))
 		if bucket == nil {
-			return errors.New("account not found")
+			return nil
 		}
 
 		values[RescanTypePhotos] = bucket.Get([]byte(rescanRequestKey + "-" + RescanTypePhotos))
+		values[rescanTypeFilteredPhotos] = bucket.Get([]byte(rescanRequestKey + "-" + rescanTypeFilteredPhotos))
 		values[RescanTypeDrive] = bucket.Get([]byte(rescanRequestKey + "-" + RescanTypeDrive))
+		values[RescanTypeAlbums] = bucket.Get([]byte(rescanRequestKey + "-" + RescanTypeAlbums))
 
 		return nil
 	})
@@ -59,13 +68,104 @@ func (r *repo) GetRescanRequests(email string) (map[string][]byte, error) {
 	return values, err
 }
 
-func (r *repo) DeleteRescanRequest(email string) error {
+func (r *repo) DeleteRescanRequest(rescanType, email string) error {
 	return r.DB.Update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket([]byte(email))
 		if bucket == nil {
 			return errors.New("account not found")
 		}
 
-		return bucket.Delete([]byte(rescanRequestKey))
+		return bucket.Delete([]byte(rescanRequestKey + "-" + rescanType))
+	})
+}
+
+func (r *repo) UpdateChangesPageToken(rescanType, email string, value []byte) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(changesPageTokenKey+"-"+rescanType), value)
+	})
+}
+
+func (r *repo) GetChangesPageToken(rescanType, email string) ([]byte, error) {
+	var value []byte
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		value = bucket.Get([]byte(changesPageTokenKey + "-" + rescanType))
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (r *repo) SaveSeenMediaItems(email string, mediaItemIds []string) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(email))
+		if err != nil {
+			return err
+		}
+
+		seenBucket, err := bucket.CreateBucketIfNotExists([]byte(seenMediaItemsBucketName))
+		if err != nil {
+			return err
+		}
+
+		for _, mediaItemId := range mediaItemIds {
+			err = seenBucket.Put([]byte(mediaItemId), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *repo) GetSeenMediaItems(email string) (map[string]bool, error) {
+	values := make(map[string]bool)
+
+	err := r.DB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		seenBucket := bucket.Bucket([]byte(seenMediaItemsBucketName))
+		if seenBucket == nil {
+			return nil
+		}
+
+		return seenBucket.ForEach(func(k, v []byte) error {
+			values[string(k)] = true
+
+			return nil
+		})
+	})
+
+	return values, err
+}
+
+func (r *repo) DeleteSeenMediaItems(email string) error {
+	return r.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(email))
+		if bucket == nil {
+			return nil
+		}
+
+		err := bucket.DeleteBucket([]byte(seenMediaItemsBucketName))
+		if err != nil && err != bbolt.ErrBucketNotFound {
+			return err
+		}
+
+		return nil
 	})
 }
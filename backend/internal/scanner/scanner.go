@@ -3,28 +3,50 @@ package scanner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"time"
 
 	"google-backup/internal/account"
+	"google-backup/internal/album"
 	"google-backup/internal/downloader"
+	"google-backup/internal/drive"
+	"google-backup/internal/files"
 	"google-backup/internal/media"
 	"google-backup/internal/media_reader"
+	"google-backup/internal/quota"
+	"google-backup/internal/settings"
 
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
 const (
 	RescanTypePhotos = "photos"
 	RescanTypeDrive  = "drive"
+	RescanTypeAlbums = "albums"
+
+	// Filtered photos rescans have their own request, so they don't replace a pending full scan
+	rescanTypeFilteredPhotos = "filtered-photos"
+
+	MediaTypePhotos = "photos"
+	MediaTypeVideos = "videos"
+
+	rescanFilterDateLayout = "2006-01-02"
 )
 
 type UpdatesScanner interface {
 	ScanAll(ctx context.Context) error
 }
 
+// Nil means there is no pending rescan of the type
 type RescanRequests struct {
-	Photos RescanRequest `json:"photos"`
-	Drive  RescanRequest `json:"drive"`
+	Photos         *RescanRequest `json:"photos"`
+	FilteredPhotos *RescanRequest `json:"filteredPhotos"`
+	Drive          *RescanRequest `json:"drive"`
+	Albums         *RescanRequest `json:"albums"`
 }
 
 type updatesScanner struct {
@@ -32,6 +54,10 @@ type updatesScanner struct {
 	downloadScheduler downloader.Scheduler
 	accountLimiter    account.Limiter
 	mediaReader       media_reader.Reader
+	albums            album.Albums
+	driveTree         drive.Tree
+	settingsReader    settings.SettingsReader
+	filesManager      files.FilesManager
 }
 
 func NewUpdatesScanner(
@@ -39,30 +65,60 @@ func NewUpdatesScanner(
 	downloadScheduler downloader.Scheduler,
 	accountLimiter account.Limiter,
 	mediaReader media_reader.Reader,
+	albums album.Albums,
+	driveTree drive.Tree,
+	settingsReader settings.SettingsReader,
+	filesManager files.FilesManager,
 ) updatesScanner {
 	return updatesScanner{
 		repository:        repository,
 		downloadScheduler: downloadScheduler,
 		accountLimiter:    accountLimiter,
 		mediaReader:       mediaReader,
+		albums:            albums,
+		driveTree:         driveTree,
+		settingsReader:    settingsReader,
+		filesManager:      filesManager,
 	}
 }
 
 func (u updatesScanner) ScanAll(ctx context.Context) error {
+	settingsData, err := u.settingsReader.Get()
+	if err != nil {
+		return fmt.Errorf("get settings: %w", err)
+	}
+
 	readers, err := u.mediaReader.CreateMediaReaders(ctx)
 	if err != nil {
 		return fmt.Errorf("create media readers: %w", err)
 	}
 
-	errs, ctx := errgroup.WithContext(ctx)
+	driveReaders, err := u.mediaReader.CreateDriveReaders(ctx)
+	if err != nil {
+		return fmt.Errorf("create drive readers: %w", err)
+	}
+
+	// accounts don't cancel each other, a failing account would interrupt the scans of the others
+	var errs errgroup.Group
 
 	for email, reader := range readers {
 		r := reader
+		dr := driveReaders[email]
 		e := email
 
 		errs.Go(
 			func() error {
-				err := u.scan(ctx, r, e)
+				err := u.scan(ctx, settingsData, r, dr, e)
+				// the saved page tokens continue the scan after the quota reset
+				if errors.Is(err, quota.ErrBudgetSpent) {
+					log.WithFields(log.Fields{
+						"email":      e,
+						"reset_time": quota.ResetTime(time.Now()).Format(time.RFC3339),
+					}).Info("scan stopped, daily api request budget spent")
+
+					return nil
+				}
+
 				if err != nil {
 					return fmt.Errorf("scan updates: %w", err)
 				}
@@ -75,52 +131,516 @@ func (u updatesScanner) ScanAll(ctx context.Context) error {
 	return errs.Wait()
 }
 
-func (u updatesScanner) scan(ctx context.Context, mediaReader media.Reader, email string) error {
-	// rescanRequests, err := u.getRescanRequests(email)
-	// if err != nil {
-	// 	return fmt.Errorf("get rescan requests: %w", err)
-	// }
+func (u updatesScanner) scan(
+	ctx context.Context,
+	settingsData settings.SettingsData,
+	mediaReader media.Reader,
+	driveReader drive.Reader,
+	email string,
+) error {
+	rescanRequests, err := u.getRescanRequests(email)
+	if err != nil {
+		return fmt.Errorf("get rescan requests: %w", err)
+	}
 
-	// mediaItems, err := mediaReader.GetMediaItems(string(email), rescanRequest.NextPageToken)
-	// if err != nil {
-	// 	if errors.As(err, &media.TooManyRequestsError{}) {
-	// 		u.accountLimiter.SetLimitReached(string(email), account.ApiRequestLimitType, true)
-	// 	}
+	if rescanRequests.Photos != nil && settingsData.PhotosBackupEnabled {
+		err = u.scanPhotos(ctx, mediaReader, email, RescanTypePhotos, *rescanRequests.Photos)
+		if err != nil {
+			return fmt.Errorf("scan photos: %w", err)
+		}
+	}
 
-	// 	return fmt.Errorf("get media items: %w", err)
-	// }
+	if rescanRequests.FilteredPhotos != nil && settingsData.PhotosBackupEnabled {
+		err = u.scanPhotos(ctx, mediaReader, email, rescanTypeFilteredPhotos, *rescanRequests.FilteredPhotos)
+		if err != nil {
+			return fmt.Errorf("scan filtered photos: %w", err)
+		}
+	}
 
-	// u.accountLimiter.SetLimitReached(string(email), account.ApiRequestLimitType, false)
+	if rescanRequests.Albums != nil && settingsData.PhotosBackupEnabled {
+		err = u.scanAlbums(ctx, mediaReader, email, *rescanRequests.Albums)
+		if err != nil {
+			return fmt.Errorf("scan albums: %w", err)
+		}
+	}
 
-	// if len(mediaItems.Items) == 0 {
-	// 	return u.repository.DeleteRescanRequest(email)
-	// }
+	if !settingsData.DriveBackupEnabled || driveReader == nil {
+		return nil
+	}
 
-	// for _, item := range mediaItems.Items {
-	// 	err := u.downloadScheduler.ScheduleDownload(email, item.ID)
-	// 	if err != nil {
-	// 		return fmt.Errorf("schedule download: %w", err)
-	// 	}
-	// }
+	if rescanRequests.Drive != nil {
+		err = u.scanDrive(ctx, driveReader, email, *rescanRequests.Drive)
+		if err != nil {
+			return fmt.Errorf("scan drive: %w", err)
+		}
 
-	// rescanRequest.NextPageToken = mediaItems.NextPageToken
-	// if rescanRequest.NextPageToken == "" {
-	// 	return u.repository.DeleteRescanRequest(email)
+		return nil
+	}
 
-	// }
+	err = u.scanDriveChanges(ctx, driveReader, email)
+	if err != nil {
+		return fmt.Errorf("scan drive changes: %w", err)
+	}
 
-	// rescanRequestJson, err := json.Marshal(rescanRequest)
-	// if err != nil {
-	// 	return fmt.Errorf("marshal next page token: %w", err)
-	// }
+	return nil
+}
 
-	// return u.repository.UpdateRescanRequest(email, rescanRequestJson)
+// Pages through the media items and saves the next page token after every page,
+// so an interrupted scan continues from the last processed page.
+// Ids seen during a full (not filtered) scan are saved to find items deleted from Google Photos
+func (u updatesScanner) scanPhotos(
+	ctx context.Context,
+	mediaReader media.Reader,
+	email string,
+	rescanType string,
+	rescanRequest RescanRequest,
+) error {
+	fullScan := rescanRequest.Filter.IsEmpty()
 
-	return nil
+	if fullScan && rescanRequest.NextPageToken == "" {
+		err := u.repository.DeleteSeenMediaItems(email)
+		if err != nil {
+			return fmt.Errorf("delete seen media items: %w", err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		mediaItems, err := u.getMediaItems(mediaReader, email, rescanRequest)
+		if err != nil {
+			u.setLimitReachedIfTooManyRequests(email, err)
+
+			return fmt.Errorf("get media items: %w", err)
+		}
+
+		mediaItemIds := make([]string, 0, len(mediaItems.Items))
+
+		for _, item := range mediaItems.Items {
+			err := u.downloadScheduler.ScheduleDownload(email, item.ID)
+			if err != nil {
+				return fmt.Errorf("schedule download: %w", err)
+			}
+
+			mediaItemIds = append(mediaItemIds, item.ID)
+		}
+
+		if fullScan {
+			err = u.repository.SaveSeenMediaItems(email, mediaItemIds)
+			if err != nil {
+				return fmt.Errorf("save seen media items: %w", err)
+			}
+		}
+
+		rescanRequest.NextPageToken = mediaItems.NextPageToken
+		if rescanRequest.NextPageToken == "" {
+			break
+		}
+
+		err = u.updateRescanRequest(rescanType, email, rescanRequest)
+		if err != nil {
+			return fmt.Errorf("update rescan request: %w", err)
+		}
+	}
+
+	err := u.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, false)
+	if err != nil {
+		return fmt.Errorf("reset limit: %w", err)
+	}
+
+	if fullScan {
+		err = u.reconcileDeleted(email)
+		if err != nil {
+			return fmt.Errorf("reconcile deleted: %w", err)
+		}
+	}
+
+	return u.repository.DeleteRescanRequest(rescanType, email)
+}
+
+func (u updatesScanner) reconcileDeleted(email string) error {
+	seenMediaItemIds, err := u.repository.GetSeenMediaItems(email)
+	if err != nil {
+		return fmt.Errorf("get seen media items: %w", err)
+	}
+
+	err = u.filesManager.ReconcileDeleted(email, seenMediaItemIds)
+	if err != nil {
+		return fmt.Errorf("reconcile deleted files: %w", err)
+	}
+
+	return u.repository.DeleteSeenMediaItems(email)
+}
+
+// Saves owned and shared albums, then pages through the media items of every album
+// in album id order. The album id and the next page token are saved after every page,
+// so an interrupted scan continues from the last processed page of the last album
+func (u updatesScanner) scanAlbums(
+	ctx context.Context,
+	mediaReader media.Reader,
+	email string,
+	rescanRequest RescanRequest,
+) error {
+	albums, renamedAlbumIds, err := u.saveAlbums(mediaReader, email)
+	if err != nil {
+		u.setLimitReachedIfTooManyRequests(email, err)
+
+		return fmt.Errorf("save albums: %w", err)
+	}
+
+	// kept in the request, an interrupted scan has saved the new titles already
+	if len(renamedAlbumIds) > 0 {
+		for _, albumId := range renamedAlbumIds {
+			if !slices.Contains(rescanRequest.RenamedAlbumIds, albumId) {
+				rescanRequest.RenamedAlbumIds = append(rescanRequest.RenamedAlbumIds, albumId)
+			}
+		}
+
+		err = u.updateRescanRequest(RescanTypeAlbums, email, rescanRequest)
+		if err != nil {
+			return fmt.Errorf("update rescan request: %w", err)
+		}
+	}
+
+	for _, album := range albums {
+		if album.ID < rescanRequest.AlbumId {
+			continue
+		}
+
+		if album.ID != rescanRequest.AlbumId {
+			rescanRequest.AlbumId = album.ID
+			rescanRequest.NextPageToken = ""
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+			}
+
+			mediaItems, err := mediaReader.GetAlbumMediaItems(album.ID, rescanRequest.NextPageToken)
+			if err != nil {
+				u.setLimitReachedIfTooManyRequests(email, err)
+
+				return fmt.Errorf("get album media items: %w", err)
+			}
+
+			for _, item := range mediaItems.Items {
+				added, err := u.albums.AddMediaItem(email, album.ID, item.ID)
+				if err != nil {
+					return fmt.Errorf("add album media item: %w", err)
+				}
+
+				// sidecars list the album titles of the item
+				if added || slices.Contains(rescanRequest.RenamedAlbumIds, album.ID) {
+					err = u.filesManager.UpdateXmpSidecar(email, item.ID)
+					if err != nil {
+						return fmt.Errorf("update xmp sidecar: %w", err)
+					}
+				}
+
+				err = u.downloadScheduler.ScheduleDownload(email, item.ID)
+				if err != nil {
+					return fmt.Errorf("schedule download: %w", err)
+				}
+			}
+
+			rescanRequest.NextPageToken = mediaItems.NextPageToken
+			if rescanRequest.NextPageToken == "" {
+				break
+			}
+
+			err = u.updateRescanRequest(RescanTypeAlbums, email, rescanRequest)
+			if err != nil {
+				return fmt.Errorf("update rescan request: %w", err)
+			}
+		}
+	}
+
+	err = u.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, false)
+	if err != nil {
+		return fmt.Errorf("reset limit: %w", err)
+	}
+
+	return u.repository.DeleteRescanRequest(RescanTypeAlbums, email)
+}
+
+// Returns owned and shared albums sorted by id and the ids of saved albums which title changed
+func (u updatesScanner) saveAlbums(mediaReader media.Reader, email string) ([]media.Album, []string, error) {
+	savedAlbums, err := u.albums.GetAlbums(email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get saved albums: %w", err)
+	}
+
+	savedTitles := make(map[string]string, len(savedAlbums))
+	for _, album := range savedAlbums {
+		savedTitles[album.ID] = album.Title
+	}
+
+	albumsById := make(map[string]media.Album)
+
+	for _, getAlbums := range []func(nextPageToken string) (media.Albums, error){
+		mediaReader.GetAlbums,
+		mediaReader.GetSharedAlbums,
+	} {
+		nextPageToken := ""
+
+		for {
+			albums, err := getAlbums(nextPageToken)
+			if err != nil {
+				return nil, nil, fmt.Errorf("get albums: %w", err)
+			}
+
+			for _, album := range albums.Items {
+				albumsById[album.ID] = album
+			}
+
+			nextPageToken = albums.NextPageToken
+			if nextPageToken == "" {
+				break
+			}
+		}
+	}
+
+	albums := make([]media.Album, 0, len(albumsById))
+	var renamedAlbumIds []string
+
+	for _, album := range albumsById {
+		err := u.albums.SaveAlbum(email, album)
+		if err != nil {
+			return nil, nil, fmt.Errorf("save album: %w", err)
+		}
+
+		if title, found := savedTitles[album.ID]; found && title != album.Title {
+			renamedAlbumIds = append(renamedAlbumIds, album.ID)
+		}
+
+		albums = append(albums, album)
+	}
+
+	sort.Slice(albums, func(i, j int) bool {
+		return albums[i].ID < albums[j].ID
+	})
+
+	return albums, renamedAlbumIds, nil
+}
+
+func (u updatesScanner) setLimitReachedIfTooManyRequests(email string, err error) {
+	if errors.As(err, &media.TooManyRequestsError{}) {
+		u.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, true)
+	}
+}
+
+// Pages through the drive files and saves the next page token after every page.
+// Folders are saved to build file paths, other files are scheduled for download
+func (u updatesScanner) scanDrive(
+	ctx context.Context,
+	driveReader drive.Reader,
+	email string,
+	rescanRequest RescanRequest,
+) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		// changes made during the full scan are consumed from the changes feed afterwards
+		if rescanRequest.NextPageToken == "" {
+			err := u.saveDriveStartPageToken(driveReader, email)
+			if err != nil {
+				return fmt.Errorf("save start page token: %w", err)
+			}
+		}
+
+		driveFiles, err := driveReader.GetFiles(rescanRequest.NextPageToken)
+		if err != nil {
+			if errors.As(err, &drive.TooManyRequestsError{}) {
+				u.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, true)
+			}
+
+			return fmt.Errorf("get files: %w", err)
+		}
+
+		for _, file := range driveFiles.Items {
+			if file.IsFolder() {
+				err = u.driveTree.SaveFolder(email, file)
+				if err != nil {
+					return fmt.Errorf("save folder: %w", err)
+				}
+
+				continue
+			}
+
+			if !file.IsDownloadable() {
+				continue
+			}
+
+			err = u.downloadScheduler.ScheduleDriveDownload(email, file.ID)
+			if err != nil {
+				return fmt.Errorf("schedule drive download: %w", err)
+			}
+		}
+
+		rescanRequest.NextPageToken = driveFiles.NextPageToken
+		if rescanRequest.NextPageToken == "" {
+			break
+		}
+
+		err = u.updateRescanRequest(RescanTypeDrive, email, rescanRequest)
+		if err != nil {
+			return fmt.Errorf("update rescan request: %w", err)
+		}
+	}
+
+	err := u.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, false)
+	if err != nil {
+		return fmt.Errorf("reset limit: %w", err)
+	}
+
+	return u.repository.DeleteRescanRequest(RescanTypeDrive, email)
+}
+
+// Schedules downloads of the files added, modified, trashed or removed since the last full scan
+// or the last processed changes page. The changes page token is saved after every page
+func (u updatesScanner) scanDriveChanges(ctx context.Context, driveReader drive.Reader, email string) error {
+	pageToken, err := u.repository.GetChangesPageToken(RescanTypeDrive, email)
+	if err != nil {
+		return fmt.Errorf("get changes page token: %w", err)
+	}
+
+	// full scan wasn't done yet
+	if pageToken == nil {
+		return nil
+	}
+
+	nextPageToken := string(pageToken)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		changes, err := driveReader.GetChanges(nextPageToken)
+		if err != nil {
+			if errors.As(err, &drive.TooManyRequestsError{}) {
+				u.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, true)
+			}
+
+			return fmt.Errorf("get changes: %w", err)
+		}
+
+		for _, change := range changes.Items {
+			if change.File != nil && !change.File.IsFolder() && !change.File.IsDownloadable() {
+				continue
+			}
+
+			err = u.downloadScheduler.ScheduleDriveDownload(email, change.FileId)
+			if err != nil {
+				return fmt.Errorf("schedule drive download: %w", err)
+			}
+		}
+
+		nextPageToken = changes.NextPageToken
+		if nextPageToken == "" {
+			nextPageToken = changes.NewStartPageToken
+		}
+
+		if nextPageToken == "" {
+			return fmt.Errorf("changes response without page token")
+		}
+
+		err = u.repository.UpdateChangesPageToken(RescanTypeDrive, email, []byte(nextPageToken))
+		if err != nil {
+			return fmt.Errorf("update changes page token: %w", err)
+		}
+
+		if changes.NextPageToken == "" {
+			return nil
+		}
+	}
+}
+
+func (u updatesScanner) saveDriveStartPageToken(driveReader drive.Reader, email string) error {
+	startPageToken, err := driveReader.GetStartPageToken()
+	if err != nil {
+		if errors.As(err, &drive.TooManyRequestsError{}) {
+			u.accountLimiter.SetLimitReached(email, account.ApiRequestLimitType, true)
+		}
+
+		return fmt.Errorf("get start page token: %w", err)
+	}
+
+	return u.repository.UpdateChangesPageToken(RescanTypeDrive, email, []byte(startPageToken))
+}
+
+func (u updatesScanner) getMediaItems(
+	mediaReader media.Reader,
+	email string,
+	rescanRequest RescanRequest,
+) (media.MediaItems, error) {
+	if rescanRequest.Filter.IsEmpty() {
+		return mediaReader.GetMediaItems(email, rescanRequest.NextPageToken)
+	}
+
+	searchFilter, err := u.createSearchFilter(rescanRequest.Filter)
+	if err != nil {
+		return media.MediaItems{}, fmt.Errorf("create search filter: %w", err)
+	}
+
+	return mediaReader.SearchMediaItems(searchFilter, rescanRequest.NextPageToken)
+}
+
+func (u updatesScanner) createSearchFilter(filter RescanFilter) (media.SearchFilter, error) {
+	searchFilter := media.SearchFilter{}
+
+	if filter.StartDate != "" {
+		startDate, err := time.Parse(rescanFilterDateLayout, filter.StartDate)
+		if err != nil {
+			return media.SearchFilter{}, fmt.Errorf("parse start date: %w", err)
+		}
+
+		searchFilter.StartDate = startDate
+	}
+
+	if filter.EndDate != "" {
+		endDate, err := time.Parse(rescanFilterDateLayout, filter.EndDate)
+		if err != nil {
+			return media.SearchFilter{}, fmt.Errorf("parse end date: %w", err)
+		}
+
+		searchFilter.EndDate = endDate
+	}
+
+	switch filter.MediaType {
+	case MediaTypePhotos:
+		searchFilter.MediaType = media.MediaTypePhoto
+	case MediaTypeVideos:
+		searchFilter.MediaType = media.MediaTypeVideo
+	}
+
+	return searchFilter, nil
+}
+
+func (u updatesScanner) updateRescanRequest(rescanType, email string, rescanRequest RescanRequest) error {
+	rescanRequestJson, err := json.Marshal(rescanRequest)
+	if err != nil {
+		return fmt.Errorf("marshal rescan request: %w", err)
+	}
+
+	return u.repository.UpdateRescanRequest(rescanType, email, rescanRequestJson)
 }
 
 func (u updatesScanner) getRescanRequests(email string) (RescanRequests, error) {
-	rescanRequestsMap, err := u.repository.GetRescanRequests(string(email))
+	rescanRequestsMap, err := u.repository.GetRescanRequests(email)
 	if err != nil {
 		return RescanRequests{}, fmt.Errorf("get rescan request: %w", err)
 	}
@@ -140,9 +660,13 @@ func (u updatesScanner) getRescanRequests(email string) (RescanRequests, error)
 
 		switch rescanType {
 		case RescanTypePhotos:
-			rescanRequests.Photos = rescanRequest
+			rescanRequests.Photos = &rescanRequest
+		case rescanTypeFilteredPhotos:
+			rescanRequests.FilteredPhotos = &rescanRequest
 		case RescanTypeDrive:
-			rescanRequests.Drive = rescanRequest
+			rescanRequests.Drive = &rescanRequest
+		case RescanTypeAlbums:
+			rescanRequests.Albums = &rescanRequest
 		}
 	}
 
@@ -0,0 +1,419 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google-backup/internal/account/accountfakes"
+	"google-backup/internal/downloader/downloaderfakes"
+	"google-backup/internal/drive"
+	"google-backup/internal/drive/drivefakes"
+	"google-backup/internal/files/filesfakes"
+	"google-backup/internal/media"
+	"google-backup/internal/media/mediafakes"
+	"google-backup/internal/media_reader/media_readerfakes"
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+const testEmail = "user@gmail.com"
+
+type testScanner struct {
+	updatesScanner
+	repository            *repo
+	fakeDownloadScheduler *downloaderfakes.FakeScheduler
+	fakeMediaReader       *media_readerfakes.FakeReader
+	fakeFilesManager      *filesfakes.FakeFilesManager
+}
+
+// Scanner on a database of the test, the rescan requests are saved like the scheduler saves them
+func newTestScanner(t *testing.T) testScanner {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	repository := NewRepository(db)
+	fakeDownloadScheduler := new(downloaderfakes.FakeScheduler)
+	fakeMediaReader := new(media_readerfakes.FakeReader)
+	fakeFilesManager := new(filesfakes.FakeFilesManager)
+
+	fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+	fakeSettingsReader.GetReturns(settings.SettingsData{PhotosBackupEnabled: true, DriveBackupEnabled: true}, nil)
+
+	return testScanner{
+		updatesScanner: NewUpdatesScanner(
+			repository,
+			fakeDownloadScheduler,
+			new(accountfakes.FakeLimiter),
+			fakeMediaReader,
+			nil,
+			nil,
+			fakeSettingsReader,
+			fakeFilesManager,
+		),
+		repository:            repository,
+		fakeDownloadScheduler: fakeDownloadScheduler,
+		fakeMediaReader:       fakeMediaReader,
+		fakeFilesManager:      fakeFilesManager,
+	}
+}
+
+func testMediaItems(nextPageToken string, ids ...string) media.MediaItems {
+	mediaItems := media.MediaItems{NextPageToken: nextPageToken}
+	for _, id := range ids {
+		mediaItems.Items = append(mediaItems.Items, media.MediaItem{ID: id})
+	}
+
+	return mediaItems
+}
+
+func (s testScanner) scheduleRescan(t *testing.T, rescanType string, email string, rescanRequest RescanRequest) {
+	rescanRequestJson, err := json.Marshal(rescanRequest)
+	require.NoError(t, err)
+
+	require.NoError(t, s.repository.UpdateRescanRequest(rescanType, email, rescanRequestJson))
+}
+
+// Nil if the rescan request was deleted
+func (s testScanner) rescanRequest(t *testing.T, rescanType string, email string) *RescanRequest {
+	rescanRequests, err := s.getRescanRequests(email)
+	require.NoError(t, err)
+
+	switch rescanType {
+	case RescanTypePhotos:
+		return rescanRequests.Photos
+	case rescanTypeFilteredPhotos:
+		return rescanRequests.FilteredPhotos
+	case RescanTypeDrive:
+		return rescanRequests.Drive
+	}
+
+	return rescanRequests.Albums
+}
+
+func scheduledDownloads(fakeDownloadScheduler *downloaderfakes.FakeScheduler) []string {
+	var ids []string
+
+	for i := 0; i < fakeDownloadScheduler.ScheduleDownloadCallCount(); i++ {
+		_, id := fakeDownloadScheduler.ScheduleDownloadArgsForCall(i)
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func TestScanPhotos(t *testing.T) {
+	t.Run("page token saved after every page", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(mediafakes.FakeReader)
+		pages := []media.MediaItems{
+			testMediaItems("page-2", "item-1", "item-2"),
+			testMediaItems("page-3", "item-3"),
+			testMediaItems("", "item-4"),
+		}
+
+		s.scheduleRescan(t, RescanTypePhotos, testEmail, RescanRequest{})
+
+		var savedPageTokens []string
+
+		fakeReader.GetMediaItemsStub = func(_ string, pageToken string) (media.MediaItems, error) {
+			savedPageTokens = append(savedPageTokens, s.rescanRequest(t, RescanTypePhotos, testEmail).NextPageToken)
+
+			return pages[fakeReader.GetMediaItemsCallCount()-1], nil
+		}
+
+		err := s.scanPhotos(context.Background(), fakeReader, testEmail, RescanTypePhotos, RescanRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"item-1", "item-2", "item-3", "item-4"}, scheduledDownloads(s.fakeDownloadScheduler))
+
+		// every page is requested with the token saved before
+		assert.Equal(t, []string{"", "page-2", "page-3"}, savedPageTokens)
+
+		for i, pageToken := range savedPageTokens {
+			_, requestedPageToken := fakeReader.GetMediaItemsArgsForCall(i)
+			assert.Equal(t, pageToken, requestedPageToken)
+		}
+
+		assert.Nil(t, s.rescanRequest(t, RescanTypePhotos, testEmail))
+	})
+
+	t.Run("failed page keeps the last saved page token", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(mediafakes.FakeReader)
+
+		s.scheduleRescan(t, RescanTypePhotos, testEmail, RescanRequest{})
+		fakeReader.GetMediaItemsReturnsOnCall(0, testMediaItems("page-2", "item-1"), nil)
+		fakeReader.GetMediaItemsReturnsOnCall(1, media.MediaItems{}, errors.New("server error"))
+
+		err := s.scanPhotos(context.Background(), fakeReader, testEmail, RescanTypePhotos, RescanRequest{})
+
+		assert.ErrorContains(t, err, "server error")
+		assert.Equal(t, &RescanRequest{NextPageToken: "page-2"}, s.rescanRequest(t, RescanTypePhotos, testEmail))
+		assert.Equal(t, 0, s.fakeFilesManager.ReconcileDeletedCallCount())
+	})
+
+	t.Run("resumed scan reconciles the items seen before the failure", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(mediafakes.FakeReader)
+
+		s.scheduleRescan(t, RescanTypePhotos, testEmail, RescanRequest{})
+		fakeReader.GetMediaItemsReturnsOnCall(0, testMediaItems("page-2", "item-1"), nil)
+		fakeReader.GetMediaItemsReturnsOnCall(1, media.MediaItems{}, errors.New("server error"))
+		fakeReader.GetMediaItemsReturnsOnCall(2, testMediaItems("", "item-2"), nil)
+
+		err := s.scanPhotos(context.Background(), fakeReader, testEmail, RescanTypePhotos, RescanRequest{})
+		require.Error(t, err)
+
+		err = s.scanPhotos(context.Background(), fakeReader, testEmail, RescanTypePhotos, *s.rescanRequest(t, RescanTypePhotos, testEmail))
+
+		assert.NoError(t, err)
+
+		_, pageToken := fakeReader.GetMediaItemsArgsForCall(2)
+		assert.Equal(t, "page-2", pageToken)
+
+		require.Equal(t, 1, s.fakeFilesManager.ReconcileDeletedCallCount())
+		email, seenMediaItemIds := s.fakeFilesManager.ReconcileDeletedArgsForCall(0)
+		assert.Equal(t, testEmail, email)
+		assert.Equal(t, map[string]bool{"item-1": true, "item-2": true}, seenMediaItemIds)
+
+		seenMediaItemIds, err = s.repository.GetSeenMediaItems(testEmail)
+		assert.NoError(t, err)
+		assert.Empty(t, seenMediaItemIds)
+		assert.Nil(t, s.rescanRequest(t, RescanTypePhotos, testEmail))
+	})
+
+	t.Run("new full scan forgets the items seen before", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(mediafakes.FakeReader)
+
+		s.scheduleRescan(t, RescanTypePhotos, testEmail, RescanRequest{})
+		require.NoError(t, s.repository.SaveSeenMediaItems(testEmail, []string{"item-1"}))
+		fakeReader.GetMediaItemsReturns(testMediaItems("", "item-2"), nil)
+
+		err := s.scanPhotos(context.Background(), fakeReader, testEmail, RescanTypePhotos, RescanRequest{})
+
+		assert.NoError(t, err)
+
+		_, seenMediaItemIds := s.fakeFilesManager.ReconcileDeletedArgsForCall(0)
+		assert.Equal(t, map[string]bool{"item-2": true}, seenMediaItemIds)
+	})
+
+	t.Run("filtered scan searches and doesn't reconcile", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(mediafakes.FakeReader)
+		filter := RescanFilter{StartDate: "2023-01-01", EndDate: "2023-12-31", MediaType: MediaTypeVideos}
+
+		s.scheduleRescan(t, RescanTypePhotos, testEmail, RescanRequest{NextPageToken: "full-scan-page"})
+		s.scheduleRescan(t, rescanTypeFilteredPhotos, testEmail, RescanRequest{Filter: filter})
+		fakeReader.SearchMediaItemsReturnsOnCall(0, testMediaItems("page-2", "item-1"), nil)
+		fakeReader.SearchMediaItemsReturnsOnCall(1, media.MediaItems{}, errors.New("server error"))
+		fakeReader.SearchMediaItemsReturnsOnCall(2, testMediaItems("", "item-2"), nil)
+
+		err := s.scanPhotos(context.Background(), fakeReader, testEmail, rescanTypeFilteredPhotos, RescanRequest{Filter: filter})
+		require.Error(t, err)
+
+		// the filter is kept with the page token of the search
+		assert.Equal(t, &RescanRequest{NextPageToken: "page-2", Filter: filter}, s.rescanRequest(t, rescanTypeFilteredPhotos, testEmail))
+
+		err = s.scanPhotos(context.Background(), fakeReader, testEmail, rescanTypeFilteredPhotos, *s.rescanRequest(t, rescanTypeFilteredPhotos, testEmail))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, fakeReader.GetMediaItemsCallCount())
+		assert.Equal(t, []string{"item-1", "item-2"}, scheduledDownloads(s.fakeDownloadScheduler))
+
+		searchFilter, pageToken := fakeReader.SearchMediaItemsArgsForCall(2)
+		assert.Equal(t, media.SearchFilter{
+			StartDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+			MediaType: media.MediaTypeVideo,
+		}, searchFilter)
+		assert.Equal(t, "page-2", pageToken)
+
+		assert.Nil(t, s.rescanRequest(t, rescanTypeFilteredPhotos, testEmail))
+		assert.Equal(t, &RescanRequest{NextPageToken: "full-scan-page"}, s.rescanRequest(t, RescanTypePhotos, testEmail))
+
+		seenMediaItemIds, err := s.repository.GetSeenMediaItems(testEmail)
+		assert.NoError(t, err)
+		assert.Empty(t, seenMediaItemIds)
+		assert.Equal(t, 0, s.fakeFilesManager.ReconcileDeletedCallCount())
+	})
+
+	t.Run("invalid filter date", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(mediafakes.FakeReader)
+
+		err := s.scanPhotos(context.Background(), fakeReader, testEmail, rescanTypeFilteredPhotos, RescanRequest{Filter: RescanFilter{StartDate: "2023-13-01"}})
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, fakeReader.SearchMediaItemsCallCount())
+	})
+}
+
+func TestScanDrive(t *testing.T) {
+	t.Run("start page token saved before the full scan", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(drivefakes.FakeReader)
+
+		s.scheduleRescan(t, RescanTypeDrive, testEmail, RescanRequest{})
+		fakeReader.GetStartPageTokenReturns("start-token", nil)
+		fakeReader.GetFilesReturns(drive.Files{Items: []drive.File{
+			{ID: "file-1", MimeType: "image/jpeg"},
+			{ID: "file-2", MimeType: "application/vnd.google-apps.form"},
+		}}, nil)
+
+		err := s.scanDrive(context.Background(), fakeReader, testEmail, RescanRequest{})
+
+		assert.NoError(t, err)
+
+		pageToken, err := s.repository.GetChangesPageToken(RescanTypeDrive, testEmail)
+		assert.NoError(t, err)
+		assert.Equal(t, "start-token", string(pageToken))
+
+		require.Equal(t, 1, s.fakeDownloadScheduler.ScheduleDriveDownloadCallCount())
+		_, fileId := s.fakeDownloadScheduler.ScheduleDriveDownloadArgsForCall(0)
+		assert.Equal(t, "file-1", fileId)
+
+		assert.Nil(t, s.rescanRequest(t, RescanTypeDrive, testEmail))
+	})
+
+	t.Run("resumed scan keeps the start page token", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(drivefakes.FakeReader)
+
+		s.scheduleRescan(t, RescanTypeDrive, testEmail, RescanRequest{NextPageToken: "page-2"})
+		require.NoError(t, s.repository.UpdateChangesPageToken(RescanTypeDrive, testEmail, []byte("start-token")))
+		fakeReader.GetFilesReturns(drive.Files{}, nil)
+
+		err := s.scanDrive(context.Background(), fakeReader, testEmail, RescanRequest{NextPageToken: "page-2"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, fakeReader.GetStartPageTokenCallCount())
+		assert.Equal(t, "page-2", fakeReader.GetFilesArgsForCall(0))
+
+		pageToken, err := s.repository.GetChangesPageToken(RescanTypeDrive, testEmail)
+		assert.NoError(t, err)
+		assert.Equal(t, "start-token", string(pageToken))
+	})
+}
+
+func TestScanDriveChanges(t *testing.T) {
+	t.Run("no full scan yet", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(drivefakes.FakeReader)
+
+		err := s.scanDriveChanges(context.Background(), fakeReader, testEmail)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, fakeReader.GetChangesCallCount())
+	})
+
+	t.Run("pages saved until the new start page token", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(drivefakes.FakeReader)
+
+		require.NoError(t, s.repository.UpdateChangesPageToken(RescanTypeDrive, testEmail, []byte("token-1")))
+		fakeReader.GetChangesReturnsOnCall(0, drive.Changes{
+			Items:         []drive.Change{{FileId: "file-1", File: &drive.File{ID: "file-1", MimeType: "image/jpeg"}}},
+			NextPageToken: "token-2",
+		}, nil)
+		fakeReader.GetChangesReturnsOnCall(1, drive.Changes{
+			Items: []drive.Change{
+				{FileId: "file-2", Removed: true},
+				{FileId: "file-3", File: &drive.File{ID: "file-3", MimeType: "application/vnd.google-apps.form"}},
+			},
+			NewStartPageToken: "token-3",
+		}, nil)
+
+		err := s.scanDriveChanges(context.Background(), fakeReader, testEmail)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, fakeReader.GetChangesCallCount())
+		assert.Equal(t, "token-1", fakeReader.GetChangesArgsForCall(0))
+		assert.Equal(t, "token-2", fakeReader.GetChangesArgsForCall(1))
+
+		pageToken, err := s.repository.GetChangesPageToken(RescanTypeDrive, testEmail)
+		assert.NoError(t, err)
+		assert.Equal(t, "token-3", string(pageToken))
+
+		// removed files are scheduled to be marked as removed, files which can't be downloaded are skipped
+		require.Equal(t, 2, s.fakeDownloadScheduler.ScheduleDriveDownloadCallCount())
+		_, fileId := s.fakeDownloadScheduler.ScheduleDriveDownloadArgsForCall(0)
+		assert.Equal(t, "file-1", fileId)
+		_, fileId = s.fakeDownloadScheduler.ScheduleDriveDownloadArgsForCall(1)
+		assert.Equal(t, "file-2", fileId)
+	})
+
+	t.Run("failed page keeps the saved page token", func(t *testing.T) {
+		s := newTestScanner(t)
+		fakeReader := new(drivefakes.FakeReader)
+
+		require.NoError(t, s.repository.UpdateChangesPageToken(RescanTypeDrive, testEmail, []byte("token-1")))
+		fakeReader.GetChangesReturnsOnCall(0, drive.Changes{NextPageToken: "token-2"}, nil)
+		fakeReader.GetChangesReturnsOnCall(1, drive.Changes{}, errors.New("server error"))
+
+		err := s.scanDriveChanges(context.Background(), fakeReader, testEmail)
+
+		assert.ErrorContains(t, err, "server error")
+
+		pageToken, err := s.repository.GetChangesPageToken(RescanTypeDrive, testEmail)
+		assert.NoError(t, err)
+		assert.Equal(t, "token-2", string(pageToken))
+	})
+}
+
+func TestScanAll(t *testing.T) {
+	t.Run("failing account doesn't stop the others", func(t *testing.T) {
+		s := newTestScanner(t)
+		failingReader := new(mediafakes.FakeReader)
+		reader := new(mediafakes.FakeReader)
+		failed := make(chan struct{})
+
+		s.fakeMediaReader.CreateMediaReadersReturns(map[string]media.Reader{
+			"failing@gmail.com": failingReader,
+			testEmail:           reader,
+		}, nil)
+
+		s.scheduleRescan(t, RescanTypePhotos, "failing@gmail.com", RescanRequest{})
+		s.scheduleRescan(t, RescanTypePhotos, testEmail, RescanRequest{})
+
+		failingReader.GetMediaItemsStub = func(string, string) (media.MediaItems, error) {
+			close(failed)
+
+			return media.MediaItems{}, errors.New("invalid grant")
+		}
+
+		var once sync.Once
+
+		reader.GetMediaItemsStub = func(string, string) (media.MediaItems, error) {
+			nextPageToken := ""
+
+			once.Do(func() {
+				// the next page is requested after the other account failed
+				<-failed
+				time.Sleep(50 * time.Millisecond)
+
+				nextPageToken = "page-2"
+			})
+
+			return testMediaItems(nextPageToken, "item-1"), nil
+		}
+
+		err := s.ScanAll(context.Background())
+
+		assert.ErrorContains(t, err, "invalid grant")
+		assert.Equal(t, 2, reader.GetMediaItemsCallCount())
+		assert.Nil(t, s.rescanRequest(t, RescanTypePhotos, testEmail))
+		assert.NotNil(t, s.rescanRequest(t, RescanTypePhotos, "failing@gmail.com"))
+	})
+}
@@ -7,10 +7,11 @@ import (
 )
 
 type FakeRepository struct {
-	DeleteRescanRequestStub        func(string) error
+	DeleteRescanRequestStub        func(string, string) error
 	deleteRescanRequestMutex       sync.RWMutex
 	deleteRescanRequestArgsForCall []struct {
 		arg1 string
+		arg2 string
 	}
 	deleteRescanRequestReturns struct {
 		result1 error
@@ -18,6 +19,31 @@ type FakeRepository struct {
 	deleteRescanRequestReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteSeenMediaItemsStub        func(string) error
+	deleteSeenMediaItemsMutex       sync.RWMutex
+	deleteSeenMediaItemsArgsForCall []struct {
+		arg1 string
+	}
+	deleteSeenMediaItemsReturns struct {
+		result1 error
+	}
+	deleteSeenMediaItemsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetChangesPageTokenStub        func(string, string) ([]byte, error)
+	getChangesPageTokenMutex       sync.RWMutex
+	getChangesPageTokenArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getChangesPageTokenReturns struct {
+		result1 []byte
+		result2 error
+	}
+	getChangesPageTokenReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	GetRescanRequestsStub        func(string) (map[string][]byte, error)
 	getRescanRequestsMutex       sync.RWMutex
 	getRescanRequestsArgsForCall []struct {
@@ -31,6 +57,44 @@ type FakeRepository struct {
 		result1 map[string][]byte
 		result2 error
 	}
+	GetSeenMediaItemsStub        func(string) (map[string]bool, error)
+	getSeenMediaItemsMutex       sync.RWMutex
+	getSeenMediaItemsArgsForCall []struct {
+		arg1 string
+	}
+	getSeenMediaItemsReturns struct {
+		result1 map[string]bool
+		result2 error
+	}
+	getSeenMediaItemsReturnsOnCall map[int]struct {
+		result1 map[string]bool
+		result2 error
+	}
+	SaveSeenMediaItemsStub        func(string, []string) error
+	saveSeenMediaItemsMutex       sync.RWMutex
+	saveSeenMediaItemsArgsForCall []struct {
+		arg1 string
+		arg2 []string
+	}
+	saveSeenMediaItemsReturns struct {
+		result1 error
+	}
+	saveSeenMediaItemsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateChangesPageTokenStub        func(string, string, []byte) error
+	updateChangesPageTokenMutex       sync.RWMutex
+	updateChangesPageTokenArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}
+	updateChangesPageTokenReturns struct {
+		result1 error
+	}
+	updateChangesPageTokenReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UpdateRescanRequestStub        func(string, string, []byte) error
 	updateRescanRequestMutex       sync.RWMutex
 	updateRescanRequestArgsForCall []struct {
@@ -48,18 +112,19 @@ type FakeRepository struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeRepository) DeleteRescanRequest(arg1 string) error {
+func (fake *FakeRepository) DeleteRescanRequest(arg1 string, arg2 string) error {
 	fake.deleteRescanRequestMutex.Lock()
 	ret, specificReturn := fake.deleteRescanRequestReturnsOnCall[len(fake.deleteRescanRequestArgsForCall)]
 	fake.deleteRescanRequestArgsForCall = append(fake.deleteRescanRequestArgsForCall, struct {
 		arg1 string
-	}{arg1})
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.DeleteRescanRequestStub
 	fakeReturns := fake.deleteRescanRequestReturns
-	fake.recordInvocation("DeleteRescanRequest", []interface{}{arg1})
+	fake.recordInvocation("DeleteRescanRequest", []interface{}{arg1, arg2})
 	fake.deleteRescanRequestMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -73,17 +138,17 @@ func (fake *FakeRepository) DeleteRescanRequestCallCount() int {
 	return len(fake.deleteRescanRequestArgsForCall)
 }
 
-func (fake *FakeRepository) DeleteRescanRequestCalls(stub func(string) error) {
+func (fake *FakeRepository) DeleteRescanRequestCalls(stub func(string, string) error) {
 	fake.deleteRescanRequestMutex.Lock()
 	defer fake.deleteRescanRequestMutex.Unlock()
 	fake.DeleteRescanRequestStub = stub
 }
 
-func (fake *FakeRepository) DeleteRescanRequestArgsForCall(i int) string {
+func (fake *FakeRepository) DeleteRescanRequestArgsForCall(i int) (string, string) {
 	fake.deleteRescanRequestMutex.RLock()
 	defer fake.deleteRescanRequestMutex.RUnlock()
 	argsForCall := fake.deleteRescanRequestArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeRepository) DeleteRescanRequestReturns(result1 error) {
@@ -109,6 +174,132 @@ func (fake *FakeRepository) DeleteRescanRequestReturnsOnCall(i int, result1 erro
 	}{result1}
 }
 
+func (fake *FakeRepository) DeleteSeenMediaItems(arg1 string) error {
+	fake.deleteSeenMediaItemsMutex.Lock()
+	ret, specificReturn := fake.deleteSeenMediaItemsReturnsOnCall[len(fake.deleteSeenMediaItemsArgsForCall)]
+	fake.deleteSeenMediaItemsArgsForCall = append(fake.deleteSeenMediaItemsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteSeenMediaItemsStub
+	fakeReturns := fake.deleteSeenMediaItemsReturns
+	fake.recordInvocation("DeleteSeenMediaItems", []interface{}{arg1})
+	fake.deleteSeenMediaItemsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepository) DeleteSeenMediaItemsCallCount() int {
+	fake.deleteSeenMediaItemsMutex.RLock()
+	defer fake.deleteSeenMediaItemsMutex.RUnlock()
+	return len(fake.deleteSeenMediaItemsArgsForCall)
+}
+
+func (fake *FakeRepository) DeleteSeenMediaItemsCalls(stub func(string) error) {
+	fake.deleteSeenMediaItemsMutex.Lock()
+	defer fake.deleteSeenMediaItemsMutex.Unlock()
+	fake.DeleteSeenMediaItemsStub = stub
+}
+
+func (fake *FakeRepository) DeleteSeenMediaItemsArgsForCall(i int) string {
+	fake.deleteSeenMediaItemsMutex.RLock()
+	defer fake.deleteSeenMediaItemsMutex.RUnlock()
+	argsForCall := fake.deleteSeenMediaItemsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepository) DeleteSeenMediaItemsReturns(result1 error) {
+	fake.deleteSeenMediaItemsMutex.Lock()
+	defer fake.deleteSeenMediaItemsMutex.Unlock()
+	fake.DeleteSeenMediaItemsStub = nil
+	fake.deleteSeenMediaItemsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) DeleteSeenMediaItemsReturnsOnCall(i int, result1 error) {
+	fake.deleteSeenMediaItemsMutex.Lock()
+	defer fake.deleteSeenMediaItemsMutex.Unlock()
+	fake.DeleteSeenMediaItemsStub = nil
+	if fake.deleteSeenMediaItemsReturnsOnCall == nil {
+		fake.deleteSeenMediaItemsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteSeenMediaItemsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) GetChangesPageToken(arg1 string, arg2 string) ([]byte, error) {
+	fake.getChangesPageTokenMutex.Lock()
+	ret, specificReturn := fake.getChangesPageTokenReturnsOnCall[len(fake.getChangesPageTokenArgsForCall)]
+	fake.getChangesPageTokenArgsForCall = append(fake.getChangesPageTokenArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetChangesPageTokenStub
+	fakeReturns := fake.getChangesPageTokenReturns
+	fake.recordInvocation("GetChangesPageToken", []interface{}{arg1, arg2})
+	fake.getChangesPageTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepository) GetChangesPageTokenCallCount() int {
+	fake.getChangesPageTokenMutex.RLock()
+	defer fake.getChangesPageTokenMutex.RUnlock()
+	return len(fake.getChangesPageTokenArgsForCall)
+}
+
+func (fake *FakeRepository) GetChangesPageTokenCalls(stub func(string, string) ([]byte, error)) {
+	fake.getChangesPageTokenMutex.Lock()
+	defer fake.getChangesPageTokenMutex.Unlock()
+	fake.GetChangesPageTokenStub = stub
+}
+
+func (fake *FakeRepository) GetChangesPageTokenArgsForCall(i int) (string, string) {
+	fake.getChangesPageTokenMutex.RLock()
+	defer fake.getChangesPageTokenMutex.RUnlock()
+	argsForCall := fake.getChangesPageTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRepository) GetChangesPageTokenReturns(result1 []byte, result2 error) {
+	fake.getChangesPageTokenMutex.Lock()
+	defer fake.getChangesPageTokenMutex.Unlock()
+	fake.GetChangesPageTokenStub = nil
+	fake.getChangesPageTokenReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetChangesPageTokenReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.getChangesPageTokenMutex.Lock()
+	defer fake.getChangesPageTokenMutex.Unlock()
+	fake.GetChangesPageTokenStub = nil
+	if fake.getChangesPageTokenReturnsOnCall == nil {
+		fake.getChangesPageTokenReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.getChangesPageTokenReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRepository) GetRescanRequests(arg1 string) (map[string][]byte, error) {
 	fake.getRescanRequestsMutex.Lock()
 	ret, specificReturn := fake.getRescanRequestsReturnsOnCall[len(fake.getRescanRequestsArgsForCall)]
@@ -173,6 +364,205 @@ func (fake *FakeRepository) GetRescanRequestsReturnsOnCall(i int, result1 map[st
 	}{result1, result2}
 }
 
+func (fake *FakeRepository) GetSeenMediaItems(arg1 string) (map[string]bool, error) {
+	fake.getSeenMediaItemsMutex.Lock()
+	ret, specificReturn := fake.getSeenMediaItemsReturnsOnCall[len(fake.getSeenMediaItemsArgsForCall)]
+	fake.getSeenMediaItemsArgsForCall = append(fake.getSeenMediaItemsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetSeenMediaItemsStub
+	fakeReturns := fake.getSeenMediaItemsReturns
+	fake.recordInvocation("GetSeenMediaItems", []interface{}{arg1})
+	fake.getSeenMediaItemsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepository) GetSeenMediaItemsCallCount() int {
+	fake.getSeenMediaItemsMutex.RLock()
+	defer fake.getSeenMediaItemsMutex.RUnlock()
+	return len(fake.getSeenMediaItemsArgsForCall)
+}
+
+func (fake *FakeRepository) GetSeenMediaItemsCalls(stub func(string) (map[string]bool, error)) {
+	fake.getSeenMediaItemsMutex.Lock()
+	defer fake.getSeenMediaItemsMutex.Unlock()
+	fake.GetSeenMediaItemsStub = stub
+}
+
+func (fake *FakeRepository) GetSeenMediaItemsArgsForCall(i int) string {
+	fake.getSeenMediaItemsMutex.RLock()
+	defer fake.getSeenMediaItemsMutex.RUnlock()
+	argsForCall := fake.getSeenMediaItemsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepository) GetSeenMediaItemsReturns(result1 map[string]bool, result2 error) {
+	fake.getSeenMediaItemsMutex.Lock()
+	defer fake.getSeenMediaItemsMutex.Unlock()
+	fake.GetSeenMediaItemsStub = nil
+	fake.getSeenMediaItemsReturns = struct {
+		result1 map[string]bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) GetSeenMediaItemsReturnsOnCall(i int, result1 map[string]bool, result2 error) {
+	fake.getSeenMediaItemsMutex.Lock()
+	defer fake.getSeenMediaItemsMutex.Unlock()
+	fake.GetSeenMediaItemsStub = nil
+	if fake.getSeenMediaItemsReturnsOnCall == nil {
+		fake.getSeenMediaItemsReturnsOnCall = make(map[int]struct {
+			result1 map[string]bool
+			result2 error
+		})
+	}
+	fake.getSeenMediaItemsReturnsOnCall[i] = struct {
+		result1 map[string]bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepository) SaveSeenMediaItems(arg1 string, arg2 []string) error {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.saveSeenMediaItemsMutex.Lock()
+	ret, specificReturn := fake.saveSeenMediaItemsReturnsOnCall[len(fake.saveSeenMediaItemsArgsForCall)]
+	fake.saveSeenMediaItemsArgsForCall = append(fake.saveSeenMediaItemsArgsForCall, struct {
+		arg1 string
+		arg2 []string
+	}{arg1, arg2Copy})
+	stub := fake.SaveSeenMediaItemsStub
+	fakeReturns := fake.saveSeenMediaItemsReturns
+	fake.recordInvocation("SaveSeenMediaItems", []interface{}{arg1, arg2Copy})
+	fake.saveSeenMediaItemsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepository) SaveSeenMediaItemsCallCount() int {
+	fake.saveSeenMediaItemsMutex.RLock()
+	defer fake.saveSeenMediaItemsMutex.RUnlock()
+	return len(fake.saveSeenMediaItemsArgsForCall)
+}
+
+func (fake *FakeRepository) SaveSeenMediaItemsCalls(stub func(string, []string) error) {
+	fake.saveSeenMediaItemsMutex.Lock()
+	defer fake.saveSeenMediaItemsMutex.Unlock()
+	fake.SaveSeenMediaItemsStub = stub
+}
+
+func (fake *FakeRepository) SaveSeenMediaItemsArgsForCall(i int) (string, []string) {
+	fake.saveSeenMediaItemsMutex.RLock()
+	defer fake.saveSeenMediaItemsMutex.RUnlock()
+	argsForCall := fake.saveSeenMediaItemsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRepository) SaveSeenMediaItemsReturns(result1 error) {
+	fake.saveSeenMediaItemsMutex.Lock()
+	defer fake.saveSeenMediaItemsMutex.Unlock()
+	fake.SaveSeenMediaItemsStub = nil
+	fake.saveSeenMediaItemsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) SaveSeenMediaItemsReturnsOnCall(i int, result1 error) {
+	fake.saveSeenMediaItemsMutex.Lock()
+	defer fake.saveSeenMediaItemsMutex.Unlock()
+	fake.SaveSeenMediaItemsStub = nil
+	if fake.saveSeenMediaItemsReturnsOnCall == nil {
+		fake.saveSeenMediaItemsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveSeenMediaItemsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) UpdateChangesPageToken(arg1 string, arg2 string, arg3 []byte) error {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.updateChangesPageTokenMutex.Lock()
+	ret, specificReturn := fake.updateChangesPageTokenReturnsOnCall[len(fake.updateChangesPageTokenArgsForCall)]
+	fake.updateChangesPageTokenArgsForCall = append(fake.updateChangesPageTokenArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.UpdateChangesPageTokenStub
+	fakeReturns := fake.updateChangesPageTokenReturns
+	fake.recordInvocation("UpdateChangesPageToken", []interface{}{arg1, arg2, arg3Copy})
+	fake.updateChangesPageTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepository) UpdateChangesPageTokenCallCount() int {
+	fake.updateChangesPageTokenMutex.RLock()
+	defer fake.updateChangesPageTokenMutex.RUnlock()
+	return len(fake.updateChangesPageTokenArgsForCall)
+}
+
+func (fake *FakeRepository) UpdateChangesPageTokenCalls(stub func(string, string, []byte) error) {
+	fake.updateChangesPageTokenMutex.Lock()
+	defer fake.updateChangesPageTokenMutex.Unlock()
+	fake.UpdateChangesPageTokenStub = stub
+}
+
+func (fake *FakeRepository) UpdateChangesPageTokenArgsForCall(i int) (string, string, []byte) {
+	fake.updateChangesPageTokenMutex.RLock()
+	defer fake.updateChangesPageTokenMutex.RUnlock()
+	argsForCall := fake.updateChangesPageTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRepository) UpdateChangesPageTokenReturns(result1 error) {
+	fake.updateChangesPageTokenMutex.Lock()
+	defer fake.updateChangesPageTokenMutex.Unlock()
+	fake.UpdateChangesPageTokenStub = nil
+	fake.updateChangesPageTokenReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepository) UpdateChangesPageTokenReturnsOnCall(i int, result1 error) {
+	fake.updateChangesPageTokenMutex.Lock()
+	defer fake.updateChangesPageTokenMutex.Unlock()
+	fake.UpdateChangesPageTokenStub = nil
+	if fake.updateChangesPageTokenReturnsOnCall == nil {
+		fake.updateChangesPageTokenReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateChangesPageTokenReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRepository) UpdateRescanRequest(arg1 string, arg2 string, arg3 []byte) error {
 	var arg3Copy []byte
 	if arg3 != nil {
@@ -246,8 +636,18 @@ func (fake *FakeRepository) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.deleteRescanRequestMutex.RLock()
 	defer fake.deleteRescanRequestMutex.RUnlock()
+	fake.deleteSeenMediaItemsMutex.RLock()
+	defer fake.deleteSeenMediaItemsMutex.RUnlock()
+	fake.getChangesPageTokenMutex.RLock()
+	defer fake.getChangesPageTokenMutex.RUnlock()
 	fake.getRescanRequestsMutex.RLock()
 	defer fake.getRescanRequestsMutex.RUnlock()
+	fake.getSeenMediaItemsMutex.RLock()
+	defer fake.getSeenMediaItemsMutex.RUnlock()
+	fake.saveSeenMediaItemsMutex.RLock()
+	defer fake.saveSeenMediaItemsMutex.RUnlock()
+	fake.updateChangesPageTokenMutex.RLock()
+	defer fake.updateChangesPageTokenMutex.RUnlock()
 	fake.updateRescanRequestMutex.RLock()
 	defer fake.updateRescanRequestMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
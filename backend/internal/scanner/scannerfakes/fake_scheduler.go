@@ -7,11 +7,12 @@ import (
 )
 
 type FakeScheduler struct {
-	ScheduleRescanStub        func(string, string) error
+	ScheduleRescanStub        func(string, string, scanner.RescanFilter) error
 	scheduleRescanMutex       sync.RWMutex
 	scheduleRescanArgsForCall []struct {
 		arg1 string
 		arg2 string
+		arg3 scanner.RescanFilter
 	}
 	scheduleRescanReturns struct {
 		result1 error
@@ -23,19 +24,20 @@ type FakeScheduler struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeScheduler) ScheduleRescan(arg1 string, arg2 string) error {
+func (fake *FakeScheduler) ScheduleRescan(arg1 string, arg2 string, arg3 scanner.RescanFilter) error {
 	fake.scheduleRescanMutex.Lock()
 	ret, specificReturn := fake.scheduleRescanReturnsOnCall[len(fake.scheduleRescanArgsForCall)]
 	fake.scheduleRescanArgsForCall = append(fake.scheduleRescanArgsForCall, struct {
 		arg1 string
 		arg2 string
-	}{arg1, arg2})
+		arg3 scanner.RescanFilter
+	}{arg1, arg2, arg3})
 	stub := fake.ScheduleRescanStub
 	fakeReturns := fake.scheduleRescanReturns
-	fake.recordInvocation("ScheduleRescan", []interface{}{arg1, arg2})
+	fake.recordInvocation("ScheduleRescan", []interface{}{arg1, arg2, arg3})
 	fake.scheduleRescanMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -49,17 +51,17 @@ func (fake *FakeScheduler) ScheduleRescanCallCount() int {
 	return len(fake.scheduleRescanArgsForCall)
 }
 
-func (fake *FakeScheduler) ScheduleRescanCalls(stub func(string, string) error) {
+func (fake *FakeScheduler) ScheduleRescanCalls(stub func(string, string, scanner.RescanFilter) error) {
 	fake.scheduleRescanMutex.Lock()
 	defer fake.scheduleRescanMutex.Unlock()
 	fake.ScheduleRescanStub = stub
 }
 
-func (fake *FakeScheduler) ScheduleRescanArgsForCall(i int) (string, string) {
+func (fake *FakeScheduler) ScheduleRescanArgsForCall(i int) (string, string, scanner.RescanFilter) {
 	fake.scheduleRescanMutex.RLock()
 	defer fake.scheduleRescanMutex.RUnlock()
 	argsForCall := fake.scheduleRescanArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeScheduler) ScheduleRescanReturns(result1 error) {
@@ -7,7 +7,7 @@ import (
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Scheduler
 type Scheduler interface {
-	ScheduleRescan(rescanType, email string) error
+	ScheduleRescan(rescanType, email string, filter RescanFilter) error
 }
 
 type scheduler struct {
@@ -15,16 +15,34 @@ type scheduler struct {
 }
 
 type RescanRequest struct {
-	NextPageToken string `json:"next_page_token"`
+	NextPageToken string       `json:"next_page_token"`
+	Filter        RescanFilter `json:"filter"`
+	// Album which media items are being scanned during an albums rescan
+	AlbumId string `json:"album_id,omitempty"`
+	// Albums with a changed title, sidecars of all their media items are rewritten during an albums rescan
+	RenamedAlbumIds []string `json:"renamed_album_ids,omitempty"`
+}
+
+// Limits a photos rescan to a date range (inclusive, YYYY-MM-DD) and/or a media type
+type RescanFilter struct {
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
 }
 
 func NewScheduler(repository Repository) *scheduler {
 	return &scheduler{repository: repository}
 }
 
-func (s *scheduler) ScheduleRescan(rescanType, email string) error {
+// A filtered photos rescan is saved next to a full one, a new request replaces a pending one of the same type
+func (s *scheduler) ScheduleRescan(rescanType, email string, filter RescanFilter) error {
+	if rescanType == RescanTypePhotos && !filter.IsEmpty() {
+		rescanType = rescanTypeFilteredPhotos
+	}
+
 	rescanRequest := RescanRequest{
 		NextPageToken: "",
+		Filter:        filter,
 	}
 
 	rescanRequestJson, err := json.Marshal(rescanRequest)
@@ -34,3 +52,7 @@ func (s *scheduler) ScheduleRescan(rescanType, email string) error {
 
 	return s.repository.UpdateRescanRequest(rescanType, email, rescanRequestJson)
 }
+
+func (f RescanFilter) IsEmpty() bool {
+	return f.StartDate == "" && f.EndDate == "" && f.MediaType == ""
+}
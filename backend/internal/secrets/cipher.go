@@ -0,0 +1,151 @@
+package secrets
+
+import (
+	"crypto/aes"
+	gocipher "crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+const (
+	keySize = 32
+
+	// Prefix of encrypted values, values without it are plain text saved before the encryption was added
+	encryptedPrefix = "enc:v1:"
+)
+
+// Encrypts credentials saved in the settings bucket
+//
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Cipher
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	// Plain text values are returned as they are
+	Decrypt(value string) (string, error)
+}
+
+// AES-256-GCM with a random nonce per value
+type cipher struct {
+	aead gocipher.AEAD
+}
+
+func NewCipher(key []byte) (cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return cipher{}, fmt.Errorf("new aes cipher: %w", err)
+	}
+
+	aead, err := gocipher.NewGCM(block)
+	if err != nil {
+		return cipher{}, fmt.Errorf("new gcm: %w", err)
+	}
+
+	return cipher{aead: aead}, nil
+}
+
+// Reads the key from the file, a random key is saved if the file doesn't exist.
+// The key is kept outside of the database, a copy of the database alone doesn't reveal the credentials
+func LoadKey(keyPath string) ([]byte, error) {
+	encodedKey, err := os.ReadFile(keyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return createKey(keyPath)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedKey)))
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key has %d bytes, expected %d", len(key), keySize)
+	}
+
+	return key, nil
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+func (c cipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("read nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("decode value: %w", err)
+	}
+
+	if len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("value is too short")
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("open value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func createKey(keyPath string) ([]byte, error) {
+	key := make([]byte, keySize)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+
+	// fails if another process created the key in the meantime, its key is used then
+	file, err := os.OpenFile(keyPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return LoadKey(keyPath)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("create key file: %w", err)
+	}
+
+	_, err = file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("write key file: %w", err)
+	}
+
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("sync key file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("close key file: %w", err)
+	}
+
+	return key, nil
+}
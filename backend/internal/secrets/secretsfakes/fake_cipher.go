@@ -0,0 +1,194 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package secretsfakes
+
+import (
+	"google-backup/internal/secrets"
+	"sync"
+)
+
+type FakeCipher struct {
+	DecryptStub        func(string) (string, error)
+	decryptMutex       sync.RWMutex
+	decryptArgsForCall []struct {
+		arg1 string
+	}
+	decryptReturns struct {
+		result1 string
+		result2 error
+	}
+	decryptReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	EncryptStub        func(string) (string, error)
+	encryptMutex       sync.RWMutex
+	encryptArgsForCall []struct {
+		arg1 string
+	}
+	encryptReturns struct {
+		result1 string
+		result2 error
+	}
+	encryptReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeCipher) Decrypt(arg1 string) (string, error) {
+	fake.decryptMutex.Lock()
+	ret, specificReturn := fake.decryptReturnsOnCall[len(fake.decryptArgsForCall)]
+	fake.decryptArgsForCall = append(fake.decryptArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DecryptStub
+	fakeReturns := fake.decryptReturns
+	fake.recordInvocation("Decrypt", []interface{}{arg1})
+	fake.decryptMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCipher) DecryptCallCount() int {
+	fake.decryptMutex.RLock()
+	defer fake.decryptMutex.RUnlock()
+	return len(fake.decryptArgsForCall)
+}
+
+func (fake *FakeCipher) DecryptCalls(stub func(string) (string, error)) {
+	fake.decryptMutex.Lock()
+	defer fake.decryptMutex.Unlock()
+	fake.DecryptStub = stub
+}
+
+func (fake *FakeCipher) DecryptArgsForCall(i int) string {
+	fake.decryptMutex.RLock()
+	defer fake.decryptMutex.RUnlock()
+	argsForCall := fake.decryptArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCipher) DecryptReturns(result1 string, result2 error) {
+	fake.decryptMutex.Lock()
+	defer fake.decryptMutex.Unlock()
+	fake.DecryptStub = nil
+	fake.decryptReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCipher) DecryptReturnsOnCall(i int, result1 string, result2 error) {
+	fake.decryptMutex.Lock()
+	defer fake.decryptMutex.Unlock()
+	fake.DecryptStub = nil
+	if fake.decryptReturnsOnCall == nil {
+		fake.decryptReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.decryptReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCipher) Encrypt(arg1 string) (string, error) {
+	fake.encryptMutex.Lock()
+	ret, specificReturn := fake.encryptReturnsOnCall[len(fake.encryptArgsForCall)]
+	fake.encryptArgsForCall = append(fake.encryptArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.EncryptStub
+	fakeReturns := fake.encryptReturns
+	fake.recordInvocation("Encrypt", []interface{}{arg1})
+	fake.encryptMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCipher) EncryptCallCount() int {
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	return len(fake.encryptArgsForCall)
+}
+
+func (fake *FakeCipher) EncryptCalls(stub func(string) (string, error)) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = stub
+}
+
+func (fake *FakeCipher) EncryptArgsForCall(i int) string {
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	argsForCall := fake.encryptArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCipher) EncryptReturns(result1 string, result2 error) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = nil
+	fake.encryptReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCipher) EncryptReturnsOnCall(i int, result1 string, result2 error) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = nil
+	if fake.encryptReturnsOnCall == nil {
+		fake.encryptReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.encryptReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCipher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.decryptMutex.RLock()
+	defer fake.decryptMutex.RUnlock()
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeCipher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ secrets.Cipher = new(FakeCipher)
@@ -4,12 +4,42 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"google-backup/internal/secrets"
+)
+
+// Album folders layouts, album folders are not created if the layout is empty
+const (
+	AlbumsLayoutSymlink  = "symlink"
+	AlbumsLayoutHardlink = "hardlink"
+)
+
+// Policies of local copies of items deleted from Google Photos
+const (
+	DeletedItemsPolicyKeep  = "keep"
+	DeletedItemsPolicyMove  = "move"
+	DeletedItemsPolicyPrune = "prune"
+)
+
+const BandwidthScheduleTimeLayout = "15:04"
+
+// Types of account storages
+const (
+	StorageTypeLocal  = "local"
+	StorageTypeS3     = "s3"
+	StorageTypeSftp   = "sftp"
+	StorageTypeWebdav = "webdav"
 )
 
 type SettingsInitializer interface {
 	Init() error
 }
 
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . SettingsReader
+type SettingsReader interface {
+	Get() (SettingsData, error)
+}
+
 type SettingsData struct {
 	RootPath                 string        `json:"rootPath"`
 	PhotosScannerJobDelay    time.Duration `json:"photosScannerJobDelay"`
@@ -17,20 +47,264 @@ type SettingsData struct {
 	Host                     string        `json:"host"`
 	PhotosBackupEnabled      bool          `json:"photosBackupEnabled"`
 	DriveBackupEnabled       bool          `json:"driveBackupEnabled"`
+	AlbumsLayout             string        `json:"albumsLayout,omitempty"`
+	DeletedItemsPolicy       string        `json:"deletedItemsPolicy,omitempty"`
+	DeletedItemsPruneDays    int           `json:"deletedItemsPruneDays,omitempty"`
+	// Zero values fall back to the downloader defaults, zero total workers means no global cap
+	DownloadWorkersPerAccount int `json:"downloadWorkersPerAccount,omitempty"`
+	DownloadWorkersTotal      int `json:"downloadWorkersTotal,omitempty"`
+	DownloadBatchSize         int `json:"downloadBatchSize,omitempty"`
+	// Bytes per second, zero means unlimited
+	DownloadBandwidthLimit        int64               `json:"downloadBandwidthLimit,omitempty"`
+	DownloadAccountBandwidthLimit int64               `json:"downloadAccountBandwidthLimit,omitempty"`
+	DownloadBandwidthSchedules    []BandwidthSchedule `json:"downloadBandwidthSchedules,omitempty"`
+	// Stores photos once under "_store" by content hash, the folders tree is made of hardlinks
+	ContentStoreEnabled bool `json:"contentStoreEnabled,omitempty"`
+	// Layout of downloaded photos, e.g. "{account}/{year}/{year}-{month}-{day}/{filename}".
+	// Empty keeps the "email/year/month/filename" layout
+	PathTemplate string `json:"pathTemplate,omitempty"`
+	// Writes Google Photos metadata into "file.ext.xmp" sidecars next to the downloaded files
+	XmpSidecarsEnabled bool `json:"xmpSidecarsEnabled,omitempty"`
+	// Writes the creation time, the description and missing camera details into the EXIF of downloaded jpeg files
+	ExifEmbeddingEnabled bool `json:"exifEmbeddingEnabled,omitempty"`
+	// Bytes which have to stay free on the volume of the root path, downloads are paused below it
+	MinFreeSpace int64 `json:"minFreeSpace,omitempty"`
+	// Calls per OAuth client and Pacific day, zero values fall back to the defaults of the quota ledger
+	DailyApiRequestBudget int64 `json:"dailyApiRequestBudget,omitempty"`
+	DailyDownloadBudget   int64 `json:"dailyDownloadBudget,omitempty"`
+	// Storages of accounts by email, accounts without one are backed up to the local disk
+	AccountStorages map[string]AccountStorage `json:"accountStorages,omitempty"`
+	// Client-side encryption of the files written to the storages
+	Encryption *Encryption `json:"encryption,omitempty"`
+}
+
+// Credentials of the storages are saved encrypted
+type AccountStorage struct {
+	Type   string         `json:"type"`
+	S3     *S3Storage     `json:"s3,omitempty"`
+	Sftp   *SftpStorage   `json:"sftp,omitempty"`
+	Webdav *WebdavStorage `json:"webdav,omitempty"`
+}
+
+// S3-compatible bucket, e.g. MinIO, Garage or Backblaze B2
+type S3Storage struct {
+	// Host and optional port without a scheme, e.g. "s3.eu-central-003.backblazeb2.com"
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region,omitempty"`
+	Bucket   string `json:"bucket"`
+	// Prepended to object names, e.g. "backups/"
+	Prefix          string `json:"prefix,omitempty"`
+	AccessKeyId     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+	// Plain http, e.g. for a MinIO on the local network
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// Folder on a remote machine, either the password or the private key is used to log in
+type SftpStorage struct {
+	// Host and optional port, e.g. "backup.local:2222", the port defaults to 22
+	Host string `json:"host"`
+	User string `json:"user"`
+	// Pinned public key of the host in the authorized_keys format, e.g. "ssh-ed25519 AAAAC3Nza..."
+	HostKey  string `json:"hostKey"`
+	Password string `json:"password,omitempty"`
+	// PEM encoded key without a passphrase
+	PrivateKey string `json:"privateKey,omitempty"`
+	// Folder the backup is written to, relative paths start in the home folder of the user
+	Path string `json:"path,omitempty"`
+}
+
+// WebDAV share, e.g. a Nextcloud folder
+type WebdavStorage struct {
+	// Url of the folder the backup is written to, e.g. "https://cloud.example.com/remote.php/dav/files/user/Photos"
+	Url      string `json:"url"`
+	User     string `json:"user"`
+	Password string `json:"password,omitempty"`
+}
+
+// Files written while it is enabled are encrypted, files written before stay readable as long as the key is set.
+// The key is derived from the passphrase or from the content of the key file, the key file takes precedence
+type Encryption struct {
+	Enabled    bool   `json:"enabled"`
+	Passphrase string `json:"passphrase,omitempty"`
+	// Path of the key file on the server
+	KeyFile string `json:"keyFile,omitempty"`
+	// Names files by a keyed hash of their path, the paths are kept in an encrypted index.
+	// Album folders and the content store need links, so they are not created then
+	HideFileNames bool `json:"hideFileNames,omitempty"`
+	// Base64 salt of the key derivation, created once the key is set the first time
+	Salt string `json:"salt,omitempty"`
+	// Detects a changed passphrase or key file, files encrypted before can't be read with another key
+	KeyCheck string `json:"keyCheck,omitempty"`
+}
+
+// Overrides the bandwidth limits between start and end local time ("15:04").
+// The window wraps midnight if end is before start
+type BandwidthSchedule struct {
+	Start                 string `json:"start"`
+	End                   string `json:"end"`
+	BandwidthLimit        int64  `json:"bandwidthLimit,omitempty"`
+	AccountBandwidthLimit int64  `json:"accountBandwidthLimit,omitempty"`
+}
+
+// Returns the global and the per account bandwidth limits in effect at the given time
+func (s SettingsData) BandwidthLimitsAt(now time.Time) (int64, int64) {
+	minutes := now.Hour()*60 + now.Minute()
+
+	for _, schedule := range s.DownloadBandwidthSchedules {
+		start, err := time.Parse(BandwidthScheduleTimeLayout, schedule.Start)
+		if err != nil {
+			continue
+		}
+
+		end, err := time.Parse(BandwidthScheduleTimeLayout, schedule.End)
+		if err != nil {
+			continue
+		}
+
+		startMinutes := start.Hour()*60 + start.Minute()
+		endMinutes := end.Hour()*60 + end.Minute()
+
+		active := minutes >= startMinutes && minutes < endMinutes
+		if endMinutes <= startMinutes {
+			active = minutes >= startMinutes || minutes < endMinutes
+		}
+
+		if active {
+			return schedule.BandwidthLimit, schedule.AccountBandwidthLimit
+		}
+	}
+
+	return s.DownloadBandwidthLimit, s.DownloadAccountBandwidthLimit
+}
+
+// Returns true if the files of the account are backed up to the local disk under the root path
+func (s SettingsData) IsLocalStorage(email string) bool {
+	accountStorage, ok := s.AccountStorages[email]
+
+	return !ok || accountStorage.Type == StorageTypeLocal
+}
+
+// Copy which doesn't share the storage configs with the original
+func (s AccountStorage) Clone() AccountStorage {
+	if s.S3 != nil {
+		s3Storage := *s.S3
+		s.S3 = &s3Storage
+	}
+
+	if s.Sftp != nil {
+		sftpStorage := *s.Sftp
+		s.Sftp = &sftpStorage
+	}
+
+	if s.Webdav != nil {
+		webdavStorage := *s.Webdav
+		s.Webdav = &webdavStorage
+	}
+
+	return s
+}
+
+// Credentials of the storage, the order is the same for storages of the same type
+func (s *AccountStorage) Secrets() []*string {
+	var result []*string
+
+	if s.S3 != nil {
+		result = append(result, &s.S3.SecretAccessKey)
+	}
+
+	if s.Sftp != nil {
+		result = append(result, &s.Sftp.Password, &s.Sftp.PrivateKey)
+	}
+
+	if s.Webdav != nil {
+		result = append(result, &s.Webdav.Password)
+	}
+
+	return result
+}
+
+// Returns the settings with every non empty credential replaced by the result of the function.
+// The account storages and the encryption of the original settings are not changed
+func (s SettingsData) MapSecrets(fn func(secret string) (string, error)) (SettingsData, error) {
+	if s.Encryption != nil {
+		encryption := *s.Encryption
+		s.Encryption = &encryption
+
+		if encryption.Passphrase != "" {
+			passphrase, err := fn(encryption.Passphrase)
+			if err != nil {
+				return SettingsData{}, fmt.Errorf("encryption passphrase: %w", err)
+			}
+
+			s.Encryption.Passphrase = passphrase
+		}
+	}
+
+	if s.AccountStorages == nil {
+		return s, nil
+	}
+
+	accountStorages := make(map[string]AccountStorage, len(s.AccountStorages))
+
+	for email, accountStorage := range s.AccountStorages {
+		accountStorage = accountStorage.Clone()
+
+		for _, secret := range accountStorage.Secrets() {
+			if *secret == "" {
+				continue
+			}
+
+			value, err := fn(*secret)
+			if err != nil {
+				return SettingsData{}, fmt.Errorf("storage secret of %s: %w", email, err)
+			}
+
+			*secret = value
+		}
+
+		accountStorages[email] = accountStorage
+	}
+
+	s.AccountStorages = accountStorages
+
+	return s, nil
+}
+
+// Encrypts the credentials before the settings are saved, encrypted credentials are kept as they are
+func EncryptSecrets(cipher secrets.Cipher, settingsData SettingsData) (SettingsData, error) {
+	return settingsData.MapSecrets(func(secret string) (string, error) {
+		if secrets.IsEncrypted(secret) {
+			return secret, nil
+		}
+
+		return cipher.Encrypt(secret)
+	})
 }
 
 type settings struct {
 	repository Repository
+	cipher     secrets.Cipher
 }
 
-func NewSettings(repository Repository) settings {
+func NewSettings(repository Repository, cipher secrets.Cipher) settings {
 	return settings{
 		repository: repository,
+		cipher:     cipher,
 	}
 }
 
 // Saves default settings if doesn't exist
 func (c settings) Init() error {
+	existingSettings, err := c.repository.Find()
+	if err != nil {
+		return fmt.Errorf("find settings: %w", err)
+	}
+
+	if existingSettings != nil {
+		return nil
+	}
+
 	defaultSettingsData := SettingsData{
 		RootPath:                 "/data",
 		PhotosScannerJobDelay:    time.Minute,
@@ -52,3 +326,27 @@ func (c settings) Init() error {
 
 	return nil
 }
+
+func (c settings) Get() (SettingsData, error) {
+	settingsJson, err := c.repository.Find()
+	if err != nil {
+		return SettingsData{}, fmt.Errorf("find settings: %w", err)
+	}
+
+	if settingsJson == nil {
+		return SettingsData{}, fmt.Errorf("settings not found")
+	}
+
+	var settingsData SettingsData
+	err = json.Unmarshal(settingsJson, &settingsData)
+	if err != nil {
+		return SettingsData{}, fmt.Errorf("unmarshal settings: %w", err)
+	}
+
+	settingsData, err = settingsData.MapSecrets(c.cipher.Decrypt)
+	if err != nil {
+		return SettingsData{}, fmt.Errorf("decrypt secrets: %w", err)
+	}
+
+	return settingsData, nil
+}
@@ -0,0 +1,39 @@
+package settings_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google-backup/internal/secrets/secretsfakes"
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	t.Run("default settings saved on first start", func(t *testing.T) {
+		fakeRepository := new(settingsfakes.FakeRepository)
+
+		err := settings.NewSettings(fakeRepository, new(secretsfakes.FakeCipher)).Init()
+
+		assert.NoError(t, err)
+		require.Equal(t, 1, fakeRepository.SaveCallCount())
+
+		var settingsData settings.SettingsData
+		require.NoError(t, json.Unmarshal(fakeRepository.SaveArgsForCall(0), &settingsData))
+		assert.Equal(t, "/data", settingsData.RootPath)
+		assert.True(t, settingsData.PhotosBackupEnabled)
+	})
+
+	t.Run("saved settings kept on start", func(t *testing.T) {
+		fakeRepository := new(settingsfakes.FakeRepository)
+		fakeRepository.FindReturns([]byte(`{"rootPath":"/backup","albumsLayout":"hardlink"}`), nil)
+
+		err := settings.NewSettings(fakeRepository, new(secretsfakes.FakeCipher)).Init()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, fakeRepository.SaveCallCount())
+	})
+}
@@ -0,0 +1,106 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package settingsfakes
+
+import (
+	"google-backup/internal/settings"
+	"sync"
+)
+
+type FakeSettingsReader struct {
+	GetStub        func() (settings.SettingsData, error)
+	getMutex       sync.RWMutex
+	getArgsForCall []struct {
+	}
+	getReturns struct {
+		result1 settings.SettingsData
+		result2 error
+	}
+	getReturnsOnCall map[int]struct {
+		result1 settings.SettingsData
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSettingsReader) Get() (settings.SettingsData, error) {
+	fake.getMutex.Lock()
+	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
+	fake.getArgsForCall = append(fake.getArgsForCall, struct {
+	}{})
+	stub := fake.GetStub
+	fakeReturns := fake.getReturns
+	fake.recordInvocation("Get", []interface{}{})
+	fake.getMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSettingsReader) GetCallCount() int {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return len(fake.getArgsForCall)
+}
+
+func (fake *FakeSettingsReader) GetCalls(stub func() (settings.SettingsData, error)) {
+	fake.getMutex.Lock()
+	defer fake.getMutex.Unlock()
+	fake.GetStub = stub
+}
+
+func (fake *FakeSettingsReader) GetReturns(result1 settings.SettingsData, result2 error) {
+	fake.getMutex.Lock()
+	defer fake.getMutex.Unlock()
+	fake.GetStub = nil
+	fake.getReturns = struct {
+		result1 settings.SettingsData
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSettingsReader) GetReturnsOnCall(i int, result1 settings.SettingsData, result2 error) {
+	fake.getMutex.Lock()
+	defer fake.getMutex.Unlock()
+	fake.GetStub = nil
+	if fake.getReturnsOnCall == nil {
+		fake.getReturnsOnCall = make(map[int]struct {
+			result1 settings.SettingsData
+			result2 error
+		})
+	}
+	fake.getReturnsOnCall[i] = struct {
+		result1 settings.SettingsData
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSettingsReader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSettingsReader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ settings.SettingsReader = new(FakeSettingsReader)
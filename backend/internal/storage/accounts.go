@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google-backup/internal/settings"
+)
+
+// Sends every path to the storage of the account in its first folder, e.g. "user@gmail.com/2024/1/IMG_0001.JPG".
+// Paths of accounts without a storage in the settings and other paths, e.g. of the content store, are on the local disk
+// under the root path of the settings
+type accounts struct {
+	settingsReader settings.SettingsReader
+	mutex          *sync.Mutex
+	remotes        map[string]*cachedRemote
+}
+
+// Backend of the account, it is created again once the settings of the account change.
+// The previous backend is closed once the operations and the open files which use it are done
+type cachedRemote struct {
+	// Json of the account storage settings
+	config  string
+	backend Backend
+	users   int
+	retired bool
+}
+
+// Releases the backend once the file is closed
+type accountsWriter struct {
+	io.WriteCloser
+	release func()
+}
+
+type accountsReader struct {
+	io.ReadCloser
+	release func()
+}
+
+func NewAccounts(settingsReader settings.SettingsReader) accounts {
+	return accounts{
+		settingsReader: settingsReader,
+		mutex:          &sync.Mutex{},
+		remotes:        map[string]*cachedRemote{},
+	}
+}
+
+func (a accounts) Writer(pathName string, appendData bool) (io.WriteCloser, error) {
+	backend, _, release, err := a.backend(pathName)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := backend.Writer(pathName, appendData)
+	if err != nil {
+		release()
+
+		return nil, err
+	}
+
+	return accountsWriter{WriteCloser: writer, release: release}, nil
+}
+
+func (a accounts) Reader(pathName string) (io.ReadCloser, error) {
+	backend, _, release, err := a.backend(pathName)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := backend.Reader(pathName)
+	if err != nil {
+		release()
+
+		return nil, err
+	}
+
+	return accountsReader{ReadCloser: reader, release: release}, nil
+}
+
+func (a accounts) Stat(pathName string) (FileInfo, error) {
+	backend, _, release, err := a.backend(pathName)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	defer release()
+
+	return backend.Stat(pathName)
+}
+
+func (a accounts) Rename(oldPathName string, newPathName string) error {
+	return a.RenameWithMetadata(oldPathName, newPathName, nil)
+}
+
+// Files can't be moved between storages
+func (a accounts) RenameWithMetadata(oldPathName string, newPathName string, metadata map[string]string) error {
+	backend, name, release, err := a.backend(oldPathName)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	newName, err := a.storageName(newPathName)
+	if err != nil {
+		return err
+	}
+
+	if name != newName {
+		return fmt.Errorf("rename %s to %s: paths are on different storages", oldPathName, newPathName)
+	}
+
+	return RenameWithMetadata(backend, oldPathName, newPathName, metadata)
+}
+
+func (a accounts) Delete(pathName string) error {
+	backend, _, release, err := a.backend(pathName)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return backend.Delete(pathName)
+}
+
+func (a accounts) SetTimes(pathName string, modTime time.Time) error {
+	backend, _, release, err := a.backend(pathName)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return backend.SetTimes(pathName, modTime)
+}
+
+func (a accounts) List(folderPathName string) ([]FileInfo, error) {
+	backend, _, release, err := a.backend(folderPathName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return backend.List(folderPathName)
+}
+
+func (a accounts) CanLink(pathName string, otherPathName string) bool {
+	backend, name, release, err := a.backend(pathName)
+	if err != nil {
+		return false
+	}
+
+	defer release()
+
+	otherName, err := a.storageName(otherPathName)
+	if err != nil || name != otherName {
+		return false
+	}
+
+	return CanLink(backend, pathName, otherPathName)
+}
+
+func (a accounts) Link(targetPathName string, linkPathName string) error {
+	linker, release, err := a.linker(targetPathName, linkPathName)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return linker.Link(targetPathName, linkPathName)
+}
+
+func (a accounts) Symlink(targetPathName string, linkPathName string) error {
+	linker, release, err := a.linker(targetPathName, linkPathName)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	return linker.Symlink(targetPathName, linkPathName)
+}
+
+func (a accounts) SameFile(pathName string, otherPathName string) (bool, error) {
+	if !a.CanLink(pathName, otherPathName) {
+		return false, nil
+	}
+
+	linker, release, err := a.linker(pathName, otherPathName)
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	return linker.SameFile(pathName, otherPathName)
+}
+
+func (a accounts) linker(pathName string, otherPathName string) (Linker, func(), error) {
+	if !a.CanLink(pathName, otherPathName) {
+		return nil, nil, ErrLinksUnsupported
+	}
+
+	backend, _, release, err := a.backend(pathName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return backend.(Linker), release, nil
+}
+
+// Name of the storage of the path, the local backend has an empty name
+func (a accounts) storageName(pathName string) (string, error) {
+	email, _, _ := strings.Cut(pathName, "/")
+
+	settingsData, err := a.settingsReader.Get()
+	if err != nil {
+		return "", fmt.Errorf("get settings: %w", err)
+	}
+
+	if settingsData.IsLocalStorage(email) {
+		return "", nil
+	}
+
+	return email, nil
+}
+
+// Returns the backend of the path and the name of its storage, the local backend has an empty name.
+// The backend is in use until the returned function is called
+func (a accounts) backend(pathName string) (Backend, string, func(), error) {
+	email, _, _ := strings.Cut(pathName, "/")
+
+	settingsData, err := a.settingsReader.Get()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("get settings: %w", err)
+	}
+
+	if settingsData.IsLocalStorage(email) {
+		// a relative root would put the files into the working directory
+		if settingsData.RootPath == "" {
+			return nil, "", nil, errors.New("root path of the local storage is not set")
+		}
+
+		return NewLocal(settingsData.RootPath), "", func() {}, nil
+	}
+
+	accountStorage := settingsData.AccountStorages[email]
+
+	config, err := json.Marshal(accountStorage)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("marshal account storage: %w", err)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	cached, ok := a.remotes[email]
+	if !ok || cached.config != string(config) {
+		backend, err := newRemote(accountStorage)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("new %s storage of %s: %w", accountStorage.Type, email, err)
+		}
+
+		// connections of the previous settings are closed once they aren't used anymore
+		if ok {
+			cached.retired = true
+			if cached.users == 0 {
+				closeRemote(cached)
+			}
+		}
+
+		cached = &cachedRemote{config: string(config), backend: backend}
+		a.remotes[email] = cached
+	}
+
+	cached.users++
+
+	once := &sync.Once{}
+
+	return cached.backend, email, func() { once.Do(func() { a.release(cached) }) }, nil
+}
+
+func (a accounts) release(remote *cachedRemote) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	remote.users--
+
+	if remote.retired && remote.users == 0 {
+		closeRemote(remote)
+	}
+}
+
+func (w accountsWriter) Close() error {
+	defer w.release()
+
+	return w.WriteCloser.Close()
+}
+
+func (r accountsReader) Close() error {
+	defer r.release()
+
+	return r.ReadCloser.Close()
+}
+
+func closeRemote(remote *cachedRemote) {
+	closer, ok := remote.backend.(io.Closer)
+	if !ok {
+		return
+	}
+
+	// the mutex is held, closing connections waits on the network
+	go closer.Close()
+}
+
+func newRemote(accountStorage settings.AccountStorage) (Backend, error) {
+	switch {
+	case accountStorage.Type == settings.StorageTypeS3 && accountStorage.S3 != nil:
+		return NewS3(*accountStorage.S3)
+	case accountStorage.Type == settings.StorageTypeSftp && accountStorage.Sftp != nil:
+		return NewSftp(*accountStorage.Sftp)
+	case accountStorage.Type == settings.StorageTypeWebdav && accountStorage.Webdav != nil:
+		return NewWebdav(*accountStorage.Webdav)
+	}
+
+	return nil, fmt.Errorf("missing %s settings", accountStorage.Type)
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccounts(t *testing.T) {
+	sftpSettings := func(host string) settings.SettingsData {
+		return settings.SettingsData{AccountStorages: map[string]settings.AccountStorage{
+			"user@gmail.com": {Type: settings.StorageTypeSftp, Sftp: &settings.SftpStorage{
+				Host:     host,
+				User:     "backup",
+				HostKey:  "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBb8d7cgG/yWcN5z5oQdWZH1eGtxLWRHvIOE5jjDPUit",
+				Password: "secret",
+			}},
+		}}
+	}
+
+	t.Run("local paths", func(t *testing.T) {
+		root := t.TempDir()
+
+		settingsData := sftpSettings("backup.local")
+		settingsData.RootPath = root
+
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(settingsData, nil)
+
+		accounts := NewAccounts(fakeSettingsReader)
+
+		backend, name, release, err := accounts.backend("other@gmail.com/photos/a.jpg")
+		require.NoError(t, err)
+		release()
+
+		assert.Equal(t, NewLocal(root), backend)
+		assert.Equal(t, "", name)
+	})
+
+	t.Run("local paths follow the root path of the settings", func(t *testing.T) {
+		root := t.TempDir()
+
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(settings.SettingsData{RootPath: root}, nil)
+
+		accounts := NewAccounts(fakeSettingsReader)
+
+		writer, err := accounts.Writer("user@gmail.com/photos/a.jpg", false)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		_, err = os.Stat(filepath.Join(root, "user@gmail.com/photos/a.jpg"))
+		assert.NoError(t, err)
+
+		otherRoot := t.TempDir()
+		fakeSettingsReader.GetReturns(settings.SettingsData{RootPath: otherRoot}, nil)
+
+		_, err = accounts.Stat("user@gmail.com/photos/a.jpg")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("local paths without root path", func(t *testing.T) {
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(settings.SettingsData{}, nil)
+
+		accounts := NewAccounts(fakeSettingsReader)
+
+		_, err := accounts.Writer("user@gmail.com/photos/a.jpg", false)
+
+		assert.EqualError(t, err, "root path of the local storage is not set")
+	})
+
+	t.Run("close previous storage once it is released", func(t *testing.T) {
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(sftpSettings("backup.local"), nil)
+
+		accounts := NewAccounts(fakeSettingsReader)
+
+		previous, name, releasePrevious, err := accounts.backend("user@gmail.com/photos/a.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, "user@gmail.com", name)
+
+		fakeSettingsReader.GetReturns(sftpSettings("other.local"), nil)
+
+		current, _, releaseCurrent, err := accounts.backend("user@gmail.com/photos/a.jpg")
+		require.NoError(t, err)
+		releaseCurrent()
+
+		assert.NotEqual(t, previous, current)
+		assert.False(t, sftpClosed(previous.(sftpStorage)), "closed while in use")
+
+		releasePrevious()
+		releasePrevious()
+
+		assert.Eventually(t, func() bool { return sftpClosed(previous.(sftpStorage)) }, time.Second, 10*time.Millisecond)
+		assert.False(t, sftpClosed(current.(sftpStorage)))
+	})
+
+	t.Run("close previous storage which isn't used", func(t *testing.T) {
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(sftpSettings("backup.local"), nil)
+
+		accounts := NewAccounts(fakeSettingsReader)
+
+		previous, _, release, err := accounts.backend("user@gmail.com/photos/a.jpg")
+		require.NoError(t, err)
+		release()
+
+		assert.False(t, sftpClosed(previous.(sftpStorage)))
+
+		fakeSettingsReader.GetReturns(sftpSettings("other.local"), nil)
+
+		_, _, release, err = accounts.backend("user@gmail.com/photos/a.jpg")
+		require.NoError(t, err)
+		release()
+
+		assert.Eventually(t, func() bool { return sftpClosed(previous.(sftpStorage)) }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func sftpClosed(storage sftpStorage) bool {
+	storage.pool.mutex.Lock()
+	defer storage.pool.mutex.Unlock()
+
+	return storage.pool.closed
+}
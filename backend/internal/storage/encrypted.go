@@ -0,0 +1,835 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"google-backup/internal/encryption"
+	"google-backup/internal/settings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	encryptedSuffix = ".enc"
+	// Index of the hidden names in the first folder, e.g. "user@gmail.com/.index.enc"
+	encryptedIndexName = ".index" + encryptedSuffix
+	// Files are downloaded into partial files which are renamed into place
+	partialSuffix = ".partial"
+	// Temporary file of an encrypted file which is written again, e.g. to append to it
+	rewriteSuffix = ".rewrite"
+)
+
+// Encrypts the files written to the inner backend and decrypts them when they are read.
+// Encrypted files are named by their path followed by ".enc", or by a keyed hash of their path in the first folder
+// if names are hidden, e.g. "user@gmail.com/3f/9a...c1.enc". Files written before encryption was enabled stay plain,
+// so a path is looked up under all its names, the names of the current settings first
+type encrypted struct {
+	inner          Backend
+	settingsReader settings.SettingsReader
+	mutex          *sync.Mutex
+	keyring        *cachedKeyring
+	// Serializes updates of the indexes of hidden names
+	indexMutex *sync.Mutex
+}
+
+// Keyring of the passphrase or key file of the settings, it is created again once they change
+type cachedKeyring struct {
+	config  string
+	keyring *encryption.Keyring
+}
+
+// Encryption in effect, files can't be encrypted or decrypted without a keyring
+type encryptionConfig struct {
+	enabled   bool
+	hideNames bool
+	keyring   *encryption.Keyring
+	// Key of new files and of hidden names
+	key encryption.Key
+}
+
+// Name of a path on the inner backend
+type storedName struct {
+	name      string
+	encrypted bool
+}
+
+// Replaces the existing file with the rewritten one on close
+type rewriteWriter struct {
+	io.WriteCloser
+	commit func() error
+}
+
+type encryptedReader struct {
+	*encryption.Reader
+	io.Closer
+}
+
+func NewEncrypted(inner Backend, settingsReader settings.SettingsReader) encrypted {
+	return encrypted{
+		inner:          inner,
+		settingsReader: settingsReader,
+		mutex:          &sync.Mutex{},
+		keyring:        &cachedKeyring{},
+		indexMutex:     &sync.Mutex{},
+	}
+}
+
+// Encrypted files can't be appended, they are written again with the new data to a temporary file which replaces them
+func (e encrypted) Writer(pathName string, appendData bool) (io.WriteCloser, error) {
+	config, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+
+	name := e.names(pathName, config)[0]
+
+	if appendData {
+		existing, _, err := e.locate(pathName, config)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		if err == nil && (existing != name || name.encrypted) {
+			return e.rewriteWriter(pathName, existing, name, config)
+		}
+	}
+
+	out, err := e.inner.Writer(name.name, appendData && !name.encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.encryptWriter(out, pathName, name, config)
+}
+
+func (e encrypted) Reader(pathName string) (io.ReadCloser, error) {
+	config, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+
+	name, _, err := e.locate(pathName, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.open(name, config)
+}
+
+// Sizes of encrypted files are the sizes of their content. Folders of files with hidden names don't exist
+func (e encrypted) Stat(pathName string) (FileInfo, error) {
+	config, err := e.config()
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	name, info, err := e.locate(pathName, config)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	info.PathName = pathName
+	if name.encrypted && !info.IsDir {
+		info.Size = encryption.PlainSize(info.Size)
+	}
+
+	return info, nil
+}
+
+func (e encrypted) Rename(oldPathName string, newPathName string) error {
+	return e.RenameWithMetadata(oldPathName, newPathName, nil)
+}
+
+// Files keep the kind of their name. Files with hidden names carry their path,
+// so they are written again unless a partial file is renamed into place. Folders are renamed with the files with hidden names in them
+func (e encrypted) RenameWithMetadata(oldPathName string, newPathName string, metadata map[string]string) error {
+	config, err := e.config()
+	if err != nil {
+		return err
+	}
+
+	old, info, err := e.locate(oldPathName, config)
+	if errors.Is(err, fs.ErrNotExist) && config.keyring != nil {
+		return e.renameHiddenFolder(oldPathName, newPathName, config, true)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir {
+		// plain and encrypted files with visible names are moved with the folder
+		err = e.inner.Rename(old.name, newPathName)
+		if err != nil || config.keyring == nil {
+			return err
+		}
+
+		return e.renameHiddenFolder(oldPathName, newPathName, config, false)
+	}
+
+	name := e.sameKindName(newPathName, old, config)
+
+	if isHiddenName(old.name) && sealedPathName(oldPathName) != sealedPathName(newPathName) {
+		err = e.rewrite(old, name, newPathName, metadata, config)
+	} else {
+		err = RenameWithMetadata(e.inner, old.name, name.name, metadata)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// other versions of the new path, e.g. a plain file replaced by an encrypted one
+	for _, other := range e.names(newPathName, config) {
+		if other == name {
+			continue
+		}
+
+		err = e.inner.Delete(other.name)
+		if err != nil {
+			return fmt.Errorf("delete previous version: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Deletes the file under all its names
+func (e encrypted) Delete(pathName string) error {
+	config, err := e.config()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range e.names(pathName, config) {
+		err = e.inner.Delete(name.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e encrypted) SetTimes(pathName string, modTime time.Time) error {
+	config, err := e.config()
+	if err != nil {
+		return err
+	}
+
+	name, _, err := e.locate(pathName, config)
+	if err != nil {
+		return err
+	}
+
+	return e.inner.SetTimes(name.name, modTime)
+}
+
+// The first folder of the path is listed, hidden names are resolved by the index
+func (e encrypted) List(folderPathName string) ([]FileInfo, error) {
+	config, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.keyring == nil {
+		return e.inner.List(folderPathName)
+	}
+
+	firstFolder, _, _ := strings.Cut(folderPathName, "/")
+
+	files, err := e.inner.List(firstFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	// indexes by the first folder, a listing of the root has the files of all first folders
+	indexes := map[string]map[string]string{}
+
+	var result []FileInfo
+
+	for _, file := range files {
+		name := file.PathName
+
+		switch {
+		case strings.HasSuffix(name, "/"+encryptedIndexName) || strings.HasSuffix(name, rewriteSuffix):
+			continue
+		case isHiddenName(name):
+			nameFolder, _, _ := strings.Cut(name, "/")
+
+			index, ok := indexes[nameFolder]
+			if !ok {
+				index, err = e.index(nameFolder, files, config)
+				if err != nil {
+					return nil, err
+				}
+
+				indexes[nameFolder] = index
+			}
+
+			pathName, ok := index[name]
+			if !ok {
+				continue
+			}
+
+			file.PathName = pathName
+			file.Size = encryption.PlainSize(file.Size)
+		case strings.HasSuffix(name, encryptedSuffix):
+			file.PathName = strings.TrimSuffix(name, encryptedSuffix)
+			file.Size = encryption.PlainSize(file.Size)
+		}
+
+		if inFolder(file.PathName, folderPathName) {
+			result = append(result, file)
+		}
+	}
+
+	return result, nil
+}
+
+// Files with hidden names are not linked, links would share the path encrypted into the file
+func (e encrypted) CanLink(pathName string, otherPathName string) bool {
+	config, err := e.config()
+	if err != nil || config.enabled && config.hideNames {
+		return false
+	}
+
+	return CanLink(e.inner, pathName, otherPathName)
+}
+
+func (e encrypted) Link(targetPathName string, linkPathName string) error {
+	return e.link(targetPathName, linkPathName, Linker.Link)
+}
+
+func (e encrypted) Symlink(targetPathName string, linkPathName string) error {
+	return e.link(targetPathName, linkPathName, Linker.Symlink)
+}
+
+func (e encrypted) SameFile(pathName string, otherPathName string) (bool, error) {
+	linker, ok := e.inner.(Linker)
+	if !ok {
+		return false, nil
+	}
+
+	config, err := e.config()
+	if err != nil {
+		return false, err
+	}
+
+	name, _, err := e.locate(pathName, config)
+	if err != nil {
+		return false, err
+	}
+
+	otherName, _, err := e.locate(otherPathName, config)
+	if err != nil {
+		return false, err
+	}
+
+	return linker.SameFile(name.name, otherName.name)
+}
+
+func (e encrypted) EncryptionScheme(pathName string) (string, error) {
+	config, err := e.config()
+	if err != nil {
+		return "", err
+	}
+
+	name, _, err := e.locate(pathName, config)
+	if err != nil {
+		return "", err
+	}
+
+	if !name.encrypted {
+		return "", nil
+	}
+
+	return encryption.Scheme, nil
+}
+
+func (e encrypted) config() (encryptionConfig, error) {
+	settingsData, err := e.settingsReader.Get()
+	if err != nil {
+		return encryptionConfig{}, fmt.Errorf("get settings: %w", err)
+	}
+
+	encryptionSettings := settingsData.Encryption
+	if encryptionSettings == nil || encryptionSettings.Passphrase == "" && encryptionSettings.KeyFile == "" {
+		return encryptionConfig{}, nil
+	}
+
+	keyring, err := e.cachedKeyring(*encryptionSettings)
+	if err != nil {
+		return encryptionConfig{}, err
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(encryptionSettings.Salt)
+	if err != nil {
+		return encryptionConfig{}, fmt.Errorf("decode encryption salt: %w", err)
+	}
+
+	key, err := keyring.Key(keyring.Kdf(), salt)
+	if err != nil {
+		return encryptionConfig{}, err
+	}
+
+	if encryptionSettings.KeyCheck != "" && key.Check() != encryptionSettings.KeyCheck {
+		return encryptionConfig{}, fmt.Errorf("%w: the passphrase or the key file changed", encryption.ErrWrongKey)
+	}
+
+	return encryptionConfig{
+		enabled:   encryptionSettings.Enabled,
+		hideNames: encryptionSettings.HideFileNames,
+		keyring:   keyring,
+		key:       key,
+	}, nil
+}
+
+func (e encrypted) cachedKeyring(encryptionSettings settings.Encryption) (*encryption.Keyring, error) {
+	config := encryptionSettings.Passphrase + "\x00" + encryptionSettings.KeyFile
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.keyring.keyring != nil && e.keyring.config == config {
+		return e.keyring.keyring, nil
+	}
+
+	keyring, err := encryption.NewKeyring(encryptionSettings.Passphrase, encryptionSettings.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("new keyring: %w", err)
+	}
+
+	*e.keyring = cachedKeyring{config: config, keyring: keyring}
+
+	return keyring, nil
+}
+
+// Names the path can have on the inner backend, new files get the first one
+func (e encrypted) names(pathName string, config encryptionConfig) []storedName {
+	plain := storedName{name: pathName}
+
+	if config.keyring == nil {
+		return []storedName{plain}
+	}
+
+	visible := storedName{name: pathName + encryptedSuffix, encrypted: true}
+	hidden := storedName{name: hiddenName(pathName, config.key), encrypted: true}
+
+	switch {
+	case !config.enabled:
+		return []storedName{plain, visible, hidden}
+	case config.hideNames:
+		return []storedName{hidden, visible, plain}
+	}
+
+	return []storedName{visible, hidden, plain}
+}
+
+// Name of the path of the same kind as the other name
+func (e encrypted) sameKindName(pathName string, other storedName, config encryptionConfig) storedName {
+	switch {
+	case !other.encrypted:
+		return storedName{name: pathName}
+	case isHiddenName(other.name):
+		return storedName{name: hiddenName(pathName, config.key), encrypted: true}
+	}
+
+	return storedName{name: pathName + encryptedSuffix, encrypted: true}
+}
+
+// Returns the first name of the path which exists on the inner backend
+func (e encrypted) locate(pathName string, config encryptionConfig) (storedName, FileInfo, error) {
+	for _, name := range e.names(pathName, config) {
+		info, err := e.inner.Stat(name.name)
+		if err == nil {
+			return name, info, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return storedName{}, FileInfo{}, err
+		}
+	}
+
+	return storedName{}, FileInfo{}, &fs.PathError{Op: "stat", Path: pathName, Err: fs.ErrNotExist}
+}
+
+func (e encrypted) open(name storedName, config encryptionConfig) (io.ReadCloser, error) {
+	file, err := e.inner.Reader(name.name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !name.encrypted {
+		return file, nil
+	}
+
+	reader, err := encryption.NewReader(file, config.keyring.Key)
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("decrypt %s: %w", name.name, err)
+	}
+
+	return encryptedReader{Reader: reader, Closer: file}, nil
+}
+
+func (e encrypted) encryptWriter(out io.WriteCloser, pathName string, name storedName, config encryptionConfig) (io.WriteCloser, error) {
+	if !name.encrypted {
+		return out, nil
+	}
+
+	writer, err := encryption.NewWriter(out, config.key, sealedPathName(pathName))
+	if err != nil {
+		out.Close()
+
+		return nil, fmt.Errorf("encrypt %s: %w", pathName, err)
+	}
+
+	return writer, nil
+}
+
+// Writes the content of the existing file to a temporary file, the caller appends to it
+func (e encrypted) rewriteWriter(pathName string, existing storedName, name storedName, config encryptionConfig) (io.WriteCloser, error) {
+	reader, err := e.open(existing, config)
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	tempName := name.name + rewriteSuffix
+
+	out, err := e.inner.Writer(tempName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := e.encryptWriter(out, pathName, name, config)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(writer, reader)
+	if err != nil {
+		writer.Close()
+		e.inner.Delete(tempName)
+
+		return nil, fmt.Errorf("copy existing file: %w", err)
+	}
+
+	return rewriteWriter{WriteCloser: writer, commit: func() error {
+		err := e.inner.Rename(tempName, name.name)
+		if err != nil {
+			return err
+		}
+
+		if existing.name != name.name {
+			return e.inner.Delete(existing.name)
+		}
+
+		return nil
+	}}, nil
+}
+
+// Writes the file again under the new name with the new path, the old file is deleted
+func (e encrypted) rewrite(old storedName, name storedName, newPathName string, metadata map[string]string, config encryptionConfig) error {
+	reader, err := e.open(old, config)
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	tempName := name.name + rewriteSuffix
+
+	out, err := e.inner.Writer(tempName, false)
+	if err != nil {
+		return err
+	}
+
+	writer, err := e.encryptWriter(out, newPathName, name, config)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, reader)
+	if err != nil {
+		writer.Close()
+		e.inner.Delete(tempName)
+
+		return fmt.Errorf("copy file: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		e.inner.Delete(tempName)
+
+		return fmt.Errorf("close file: %w", err)
+	}
+
+	err = RenameWithMetadata(e.inner, tempName, name.name, metadata)
+	if err != nil {
+		return err
+	}
+
+	return e.inner.Delete(old.name)
+}
+
+// Renames the files with hidden names in the folder one by one
+func (e encrypted) renameHiddenFolder(oldFolderPathName string, newFolderPathName string, config encryptionConfig, mustExist bool) error {
+	files, err := e.hiddenFiles(oldFolderPathName, config)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 && mustExist {
+		return &fs.PathError{Op: "rename", Path: oldFolderPathName, Err: fs.ErrNotExist}
+	}
+
+	for _, pathName := range files {
+		err = e.RenameWithMetadata(pathName, newFolderPathName+strings.TrimPrefix(pathName, oldFolderPathName), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e encrypted) link(targetPathName string, linkPathName string, create func(linker Linker, targetName string, linkName string) error) error {
+	linker, ok := e.inner.(Linker)
+	if !ok {
+		return ErrLinksUnsupported
+	}
+
+	config, err := e.config()
+	if err != nil {
+		return err
+	}
+
+	target, _, err := e.locate(targetPathName, config)
+	if err != nil {
+		return err
+	}
+
+	if isHiddenName(target.name) {
+		return ErrLinksUnsupported
+	}
+
+	return create(linker, target.name, e.sameKindName(linkPathName, target, config).name)
+}
+
+// Paths of the files with hidden names in the folder by their names
+func (e encrypted) hiddenFiles(folderPathName string, config encryptionConfig) (map[string]string, error) {
+	firstFolder, _, _ := strings.Cut(folderPathName, "/")
+
+	files, err := e.inner.List(firstFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := e.index(firstFolder, files, config)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+
+	for name, pathName := range index {
+		if inFolder(pathName, folderPathName) {
+			result[name] = pathName
+		}
+	}
+
+	return result, nil
+}
+
+// Paths of the files with hidden names in the first folder by their names. The index is saved encrypted in the folder,
+// paths of names which are not in it yet are read from the files and names of deleted files are removed
+func (e encrypted) index(firstFolder string, files []FileInfo, config encryptionConfig) (map[string]string, error) {
+	e.indexMutex.Lock()
+	defer e.indexMutex.Unlock()
+
+	indexName := storedName{name: firstFolder + "/" + encryptedIndexName, encrypted: true}
+
+	index, err := e.readIndex(indexName, config)
+	if err != nil {
+		log.WithFields(log.Fields{"index": indexName.name, "error": err}).Warn("index of hidden names can't be read, creating it again")
+
+		index = map[string]string{}
+	}
+
+	changed := false
+	present := map[string]bool{}
+
+	for _, file := range files {
+		if !isHiddenName(file.PathName) || !strings.HasPrefix(file.PathName, firstFolder+"/") {
+			continue
+		}
+
+		present[file.PathName] = true
+
+		if _, ok := index[file.PathName]; ok {
+			continue
+		}
+
+		pathName, err := e.hiddenPathName(file.PathName, config)
+		if err != nil {
+			// damaged files are reported by the verification of their path
+			log.WithFields(log.Fields{"name": file.PathName, "error": err}).Warn("path of hidden name can't be read")
+
+			continue
+		}
+
+		index[file.PathName] = pathName
+		changed = true
+	}
+
+	for name := range index {
+		if !present[name] {
+			delete(index, name)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return index, nil
+	}
+
+	return index, e.writeIndex(indexName, firstFolder, index, config)
+}
+
+// A missing index is empty
+func (e encrypted) readIndex(indexName storedName, config encryptionConfig) (map[string]string, error) {
+	file, err := e.open(indexName, config)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	// read to the end, so the last chunk is authenticated
+	indexJson, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	index := map[string]string{}
+
+	err = json.Unmarshal(indexJson, &index)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal index: %w", err)
+	}
+
+	return index, nil
+}
+
+func (e encrypted) writeIndex(indexName storedName, firstFolder string, index map[string]string, config encryptionConfig) error {
+	indexJson, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("marshal index: %w", err)
+	}
+
+	out, err := e.inner.Writer(indexName.name, false)
+	if err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+
+	writer, err := e.encryptWriter(out, firstFolder+"/.index", indexName, config)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(indexJson)
+	if err != nil {
+		writer.Close()
+
+		return fmt.Errorf("write index: %w", err)
+	}
+
+	return writer.Close()
+}
+
+// Reads the path encrypted into the file with the hidden name
+func (e encrypted) hiddenPathName(name string, config encryptionConfig) (string, error) {
+	file, err := e.inner.Reader(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	reader, err := encryption.NewReader(file, config.keyring.Key)
+	if err != nil {
+		return "", err
+	}
+
+	return restoredPathName(name, reader.PathName(), config.key), nil
+}
+
+func (w rewriteWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if err != nil {
+		return err
+	}
+
+	return w.commit()
+}
+
+// Partial files are renamed into place, so they carry the path of the final file
+func sealedPathName(pathName string) string {
+	return strings.TrimSuffix(pathName, partialSuffix)
+}
+
+// Path of the file with the hidden name and the sealed path, partial files carry the path of the final file
+func restoredPathName(name string, sealedPathName string, key encryption.Key) string {
+	if hiddenName(sealedPathName+partialSuffix, key) == name {
+		return sealedPathName + partialSuffix
+	}
+
+	return sealedPathName
+}
+
+// Keyed hash of the path in the first folder of the path, e.g. "user@gmail.com/3f/9a...c1.enc"
+func hiddenName(pathName string, key encryption.Key) string {
+	firstFolder, _, found := strings.Cut(pathName, "/")
+	if !found {
+		return pathName + encryptedSuffix
+	}
+
+	hash := key.NameHash(pathName)
+
+	return firstFolder + "/" + hash[:2] + "/" + hash[2:] + encryptedSuffix
+}
+
+func isHiddenName(name string) bool {
+	segments := strings.Split(name, "/")
+	if len(segments) != 3 || len(segments[1]) != 2 || !strings.HasSuffix(segments[2], encryptedSuffix) {
+		return false
+	}
+
+	hash, err := hex.DecodeString(segments[1] + strings.TrimSuffix(segments[2], encryptedSuffix))
+
+	return err == nil && len(hash) == 32
+}
+
+// An empty folder is the root
+func inFolder(pathName string, folderPathName string) bool {
+	folderPathName = strings.TrimSuffix(folderPathName, "/")
+
+	return folderPathName == "" || pathName == folderPathName || strings.HasPrefix(pathName, folderPathName+"/")
+}
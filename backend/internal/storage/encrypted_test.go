@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google-backup/internal/encryption"
+	"google-backup/internal/settings"
+	"google-backup/internal/settings/settingsfakes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncrypted(t *testing.T) {
+	salt := make([]byte, encryption.SaltSize)
+
+	keyring, err := encryption.NewKeyring("secret", "")
+	require.NoError(t, err)
+
+	key, err := keyring.Key(encryption.KdfScrypt, salt)
+	require.NoError(t, err)
+
+	newEncrypted := func(t *testing.T, enabled bool, hideNames bool) (encrypted, local, string) {
+		root := t.TempDir()
+		inner := NewLocal(root)
+
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(settings.SettingsData{Encryption: &settings.Encryption{
+			Enabled:       enabled,
+			Passphrase:    "secret",
+			HideFileNames: hideNames,
+			Salt:          base64.StdEncoding.EncodeToString(salt),
+			KeyCheck:      key.Check(),
+		}}, nil)
+
+		return NewEncrypted(inner, fakeSettingsReader), inner, root
+	}
+
+	write := func(t *testing.T, backend Backend, pathName string, content []byte, appendData bool) {
+		writer, err := backend.Writer(pathName, appendData)
+		require.NoError(t, err)
+
+		_, err = writer.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+	}
+
+	read := func(backend Backend, pathName string) ([]byte, error) {
+		reader, err := backend.Reader(pathName)
+		if err != nil {
+			return nil, err
+		}
+
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	}
+
+	listNames := func(t *testing.T, backend Backend, folderPathName string) []string {
+		files, err := backend.List(folderPathName)
+		require.NoError(t, err)
+
+		var result []string
+		for _, file := range files {
+			result = append(result, file.PathName)
+		}
+
+		return result
+	}
+
+	content := bytes.Repeat([]byte("0123456789"), 20000)
+
+	t.Run("write and read", func(t *testing.T) {
+		backend, inner, _ := newEncrypted(t, true, false)
+
+		write(t, backend, "user@gmail.com/photos/a.jpg", content, false)
+
+		stored, err := read(inner, "user@gmail.com/photos/a.jpg.enc")
+		assert.NoError(t, err)
+		assert.NotContains(t, string(stored), "0123456789")
+
+		decrypted, err := read(backend, "user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted)
+
+		info, err := backend.Stat("user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), info.Size)
+		assert.Equal(t, "user@gmail.com/photos/a.jpg", info.PathName)
+
+		scheme, err := EncryptionScheme(backend, "user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, encryption.Scheme, scheme)
+
+		assert.Equal(t, []string{"user@gmail.com/photos/a.jpg"}, listNames(t, backend, "user@gmail.com/photos"))
+	})
+
+	t.Run("resume partial file", func(t *testing.T) {
+		backend, _, _ := newEncrypted(t, true, true)
+
+		write(t, backend, "user@gmail.com/photos/a.jpg.partial", content[:70000], false)
+		write(t, backend, "user@gmail.com/photos/a.jpg.partial", content[70000:], true)
+
+		info, err := backend.Stat("user@gmail.com/photos/a.jpg.partial")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), info.Size)
+
+		err = backend.Rename("user@gmail.com/photos/a.jpg.partial", "user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+
+		decrypted, err := read(backend, "user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted)
+
+		_, err = backend.Stat("user@gmail.com/photos/a.jpg.partial")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("read truncated partial file", func(t *testing.T) {
+		backend, _, root := newEncrypted(t, true, false)
+
+		write(t, backend, "user@gmail.com/photos/a.jpg.partial", content, false)
+
+		storedPath := filepath.Join(root, "user@gmail.com/photos/a.jpg.partial.enc")
+		stored, err := os.ReadFile(storedPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(storedPath, stored[:len(stored)-100], 0644))
+
+		_, err = read(backend, "user@gmail.com/photos/a.jpg.partial")
+		assert.ErrorIs(t, err, encryption.ErrTruncated)
+
+		_, err = backend.Writer("user@gmail.com/photos/a.jpg.partial", true)
+		assert.ErrorIs(t, err, encryption.ErrTruncated)
+	})
+
+	t.Run("append to plain file", func(t *testing.T) {
+		backend, inner, _ := newEncrypted(t, true, false)
+
+		write(t, inner, "user@gmail.com/photos/a.jpg.partial", content[:1000], false)
+		write(t, backend, "user@gmail.com/photos/a.jpg.partial", content[1000:], true)
+
+		decrypted, err := read(backend, "user@gmail.com/photos/a.jpg.partial")
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted)
+
+		_, err = inner.Stat("user@gmail.com/photos/a.jpg.partial")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("read plain file", func(t *testing.T) {
+		backend, inner, _ := newEncrypted(t, true, true)
+
+		write(t, inner, "user@gmail.com/photos/a.jpg", content, false)
+
+		decrypted, err := read(backend, "user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, content, decrypted)
+
+		scheme, err := EncryptionScheme(backend, "user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, "", scheme)
+	})
+
+	t.Run("hide names", func(t *testing.T) {
+		backend, inner, _ := newEncrypted(t, true, true)
+
+		write(t, backend, "user@gmail.com/photos/a.jpg", content, false)
+		write(t, backend, "user@gmail.com/drive/folder/b.txt", []byte("content"), false)
+
+		hiddenA := hiddenName("user@gmail.com/photos/a.jpg", key)
+		hiddenB := hiddenName("user@gmail.com/drive/folder/b.txt", key)
+
+		assert.ElementsMatch(t, []string{hiddenA, hiddenB}, listNames(t, inner, "user@gmail.com"))
+		assert.ElementsMatch(t, []string{"user@gmail.com/photos/a.jpg", "user@gmail.com/drive/folder/b.txt"}, listNames(t, backend, "user@gmail.com"))
+		assert.Equal(t, []string{"user@gmail.com/drive/folder/b.txt"}, listNames(t, backend, "user@gmail.com/drive"))
+	})
+
+	t.Run("rename folder with hidden names", func(t *testing.T) {
+		backend, _, _ := newEncrypted(t, true, true)
+
+		write(t, backend, "user@gmail.com/drive/folder/b.txt", []byte("content"), false)
+
+		err := backend.Rename("user@gmail.com/drive/folder", "user@gmail.com/drive/other")
+		assert.NoError(t, err)
+
+		decrypted, err := read(backend, "user@gmail.com/drive/other/b.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("content"), decrypted)
+
+		assert.Equal(t, []string{"user@gmail.com/drive/other/b.txt"}, listNames(t, backend, "user@gmail.com"))
+
+		err = backend.Rename("user@gmail.com/drive/missing", "user@gmail.com/drive/other")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("index of hidden names", func(t *testing.T) {
+		backend, inner, _ := newEncrypted(t, true, true)
+
+		write(t, backend, "user@gmail.com/photos/a.jpg", content, false)
+		write(t, backend, "user@gmail.com/photos/b.jpg", content, false)
+
+		listNames(t, backend, "user@gmail.com")
+
+		config, err := backend.config()
+		require.NoError(t, err)
+
+		indexName := storedName{name: "user@gmail.com/" + encryptedIndexName, encrypted: true}
+
+		index, err := backend.readIndex(indexName, config)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			hiddenName("user@gmail.com/photos/a.jpg", key): "user@gmail.com/photos/a.jpg",
+			hiddenName("user@gmail.com/photos/b.jpg", key): "user@gmail.com/photos/b.jpg",
+		}, index)
+
+		err = backend.Delete("user@gmail.com/photos/b.jpg")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"user@gmail.com/photos/a.jpg"}, listNames(t, backend, "user@gmail.com"))
+
+		index, err = backend.readIndex(indexName, config)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{hiddenName("user@gmail.com/photos/a.jpg", key): "user@gmail.com/photos/a.jpg"}, index)
+
+		// a damaged index is created again from the files
+		write(t, inner, indexName.name, []byte("damaged"), false)
+
+		assert.Equal(t, []string{"user@gmail.com/photos/a.jpg"}, listNames(t, backend, "user@gmail.com"))
+
+		index, err = backend.readIndex(indexName, config)
+		assert.NoError(t, err)
+		assert.Len(t, index, 1)
+	})
+
+	t.Run("changed key", func(t *testing.T) {
+		fakeSettingsReader := new(settingsfakes.FakeSettingsReader)
+		fakeSettingsReader.GetReturns(settings.SettingsData{Encryption: &settings.Encryption{
+			Enabled:    true,
+			Passphrase: "other",
+			Salt:       base64.StdEncoding.EncodeToString(salt),
+			KeyCheck:   key.Check(),
+		}}, nil)
+
+		backend := NewEncrypted(NewLocal(t.TempDir()), fakeSettingsReader)
+
+		_, err := backend.Writer("user@gmail.com/photos/a.jpg", false)
+		assert.ErrorIs(t, err, encryption.ErrWrongKey)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		backend, inner, _ := newEncrypted(t, true, true)
+
+		write(t, backend, "user@gmail.com/photos/a.jpg", content, false)
+		write(t, backend, "user@gmail.com/photos/b.jpg.partial", content, false)
+		write(t, inner, "user@gmail.com/photos/plain.jpg", []byte("plain"), false)
+		listNames(t, backend, "user@gmail.com")
+
+		target := NewLocal(t.TempDir())
+
+		report, err := Restore(inner, keyring, "user@gmail.com", target)
+		assert.NoError(t, err)
+		assert.Equal(t, RestoreReport{Restored: 2, Decrypted: 1, Skipped: 2, Failed: []RestoreFailure{}}, report)
+
+		restored, err := read(target, "user@gmail.com/photos/a.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, content, restored)
+
+		restored, err = read(target, "user@gmail.com/photos/plain.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("plain"), restored)
+	})
+}
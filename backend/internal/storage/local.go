@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Local disk under the root folder
+type local struct {
+	root string
+}
+
+// Syncs the file before closing it
+type localWriter struct {
+	*os.File
+}
+
+func NewLocal(root string) local {
+	return local{root: root}
+}
+
+func (l local) Writer(pathName string, appendData bool) (io.WriteCloser, error) {
+	fullPath := l.fullPath(pathName)
+
+	err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("create folder: %w", err)
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendData {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(fullPath, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return localWriter{File: file}, nil
+}
+
+func (l local) Reader(pathName string) (io.ReadCloser, error) {
+	return os.Open(l.fullPath(pathName))
+}
+
+func (l local) Stat(pathName string) (FileInfo, error) {
+	info, err := os.Stat(l.fullPath(pathName))
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	return l.fileInfo(pathName, info), nil
+}
+
+func (l local) Rename(oldPathName string, newPathName string) error {
+	newFullPath := l.fullPath(newPathName)
+
+	err := os.MkdirAll(filepath.Dir(newFullPath), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("create folder: %w", err)
+	}
+
+	err = os.Rename(l.fullPath(oldPathName), newFullPath)
+	if err != nil {
+		return err
+	}
+
+	// persist the rename itself
+	dir, err := os.Open(filepath.Dir(newFullPath))
+	if err != nil {
+		return fmt.Errorf("open folder: %w", err)
+	}
+
+	defer dir.Close()
+
+	err = dir.Sync()
+	if err != nil {
+		return fmt.Errorf("sync folder: %w", err)
+	}
+
+	return nil
+}
+
+func (l local) Delete(pathName string) error {
+	err := os.Remove(l.fullPath(pathName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (l local) SetTimes(pathName string, modTime time.Time) error {
+	return os.Chtimes(l.fullPath(pathName), modTime, modTime)
+}
+
+// Links are listed as files, they are not followed
+func (l local) List(folderPathName string) ([]FileInfo, error) {
+	var result []FileInfo
+
+	folder := l.fullPath(folderPathName)
+
+	err := filepath.WalkDir(folder, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if fullPath == folder && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("file info: %w", err)
+		}
+
+		relativePath, err := filepath.Rel(folder, fullPath)
+		if err != nil {
+			return fmt.Errorf("relative path: %w", err)
+		}
+
+		result = append(result, l.fileInfo(path.Join(folderPathName, filepath.ToSlash(relativePath)), info))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk folder: %w", err)
+	}
+
+	return result, nil
+}
+
+func (l local) CanLink(pathName string, otherPathName string) bool {
+	return true
+}
+
+func (l local) Link(targetPathName string, linkPathName string) error {
+	linkFullPath := l.fullPath(linkPathName)
+
+	err := os.MkdirAll(filepath.Dir(linkFullPath), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("create folder: %w", err)
+	}
+
+	return os.Link(l.fullPath(targetPathName), linkFullPath)
+}
+
+func (l local) Symlink(targetPathName string, linkPathName string) error {
+	linkFullPath := l.fullPath(linkPathName)
+
+	err := os.MkdirAll(filepath.Dir(linkFullPath), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("create folder: %w", err)
+	}
+
+	// relative target keeps links valid when the root folder is moved
+	relativeTarget, err := filepath.Rel(filepath.Dir(linkFullPath), l.fullPath(targetPathName))
+	if err != nil {
+		return fmt.Errorf("relative path: %w", err)
+	}
+
+	return os.Symlink(relativeTarget, linkFullPath)
+}
+
+func (l local) SameFile(pathName string, otherPathName string) (bool, error) {
+	info, err := os.Stat(l.fullPath(pathName))
+	if err != nil {
+		return false, err
+	}
+
+	otherInfo, err := os.Stat(l.fullPath(otherPathName))
+	if err != nil {
+		return false, err
+	}
+
+	return os.SameFile(info, otherInfo), nil
+}
+
+func (l local) fullPath(pathName string) string {
+	return filepath.Join(l.root, filepath.FromSlash(pathName))
+}
+
+func (l local) fileInfo(pathName string, info fs.FileInfo) FileInfo {
+	return FileInfo{PathName: pathName, Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}
+}
+
+func (w localWriter) Close() error {
+	err := w.File.Sync()
+	if err != nil {
+		w.File.Close()
+
+		return fmt.Errorf("sync file: %w", err)
+	}
+
+	return w.File.Close()
+}
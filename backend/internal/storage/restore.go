@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google-backup/internal/encryption"
+)
+
+type RestoreReport struct {
+	Restored int `json:"restored"`
+	// Restored files which were encrypted
+	Decrypted int `json:"decrypted"`
+	// Unfinished downloads, temporary files and indexes
+	Skipped int              `json:"skipped"`
+	Failed  []RestoreFailure `json:"failed"`
+}
+
+type RestoreFailure struct {
+	// Name of the file on the source
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// Copies the files of the folder as they are stored on the source to their paths on the target, encrypted files are decrypted.
+// Paths of files with hidden names are read from the files, so the key is all that is needed, not the settings or the index.
+// Links are copied as files. The keyring can be nil if the backup isn't encrypted
+func Restore(source Backend, keyring *encryption.Keyring, folderPathName string, target Backend) (RestoreReport, error) {
+	report := RestoreReport{Failed: []RestoreFailure{}}
+
+	files, err := source.List(folderPathName)
+	if err != nil {
+		return report, fmt.Errorf("list files: %w", err)
+	}
+
+	for _, file := range files {
+		pathName, decrypted, err := restoreFile(source, keyring, file, target)
+		if err != nil {
+			report.Failed = append(report.Failed, RestoreFailure{Name: file.PathName, Error: err.Error()})
+
+			continue
+		}
+
+		if pathName == "" {
+			report.Skipped++
+
+			continue
+		}
+
+		report.Restored++
+		if decrypted {
+			report.Decrypted++
+		}
+	}
+
+	return report, nil
+}
+
+// Returns the path the file was restored to, it is empty if the file was skipped
+func restoreFile(source Backend, keyring *encryption.Keyring, file FileInfo, target Backend) (string, bool, error) {
+	name := file.PathName
+
+	if strings.HasSuffix(name, "/"+encryptedIndexName) || strings.HasSuffix(name, rewriteSuffix) || strings.HasSuffix(name, partialSuffix) {
+		return "", false, nil
+	}
+
+	in, err := source.Reader(name)
+	if err != nil {
+		return "", false, fmt.Errorf("open file: %w", err)
+	}
+
+	defer in.Close()
+
+	pathName := name
+	decrypted := false
+
+	var reader io.Reader = in
+
+	if strings.HasSuffix(name, encryptedSuffix) {
+		if keyring == nil {
+			return "", false, errors.New("file is encrypted, the passphrase or the key file is needed")
+		}
+
+		var key encryption.Key
+
+		encryptedReader, err := encryption.NewReader(in, func(kdf byte, salt []byte) (encryption.Key, error) {
+			fileKey, err := keyring.Key(kdf, salt)
+			key = fileKey
+
+			return fileKey, err
+		})
+		if err != nil {
+			return "", false, fmt.Errorf("decrypt file: %w", err)
+		}
+
+		pathName = strings.TrimSuffix(name, encryptedSuffix)
+		if isHiddenName(name) {
+			pathName = restoredPathName(name, encryptedReader.PathName(), key)
+		}
+
+		if strings.HasSuffix(pathName, partialSuffix) {
+			return "", false, nil
+		}
+
+		reader = encryptedReader
+		decrypted = true
+	}
+
+	out, err := target.Writer(pathName, false)
+	if err != nil {
+		return "", false, fmt.Errorf("create file: %w", err)
+	}
+
+	_, err = io.Copy(out, reader)
+	if err != nil {
+		out.Close()
+		target.Delete(pathName)
+
+		return "", false, fmt.Errorf("copy file: %w", err)
+	}
+
+	err = out.Close()
+	if err != nil {
+		target.Delete(pathName)
+
+		return "", false, fmt.Errorf("close file: %w", err)
+	}
+
+	if !file.ModTime.IsZero() {
+		err = target.SetTimes(pathName, file.ModTime)
+		if err != nil {
+			return "", false, fmt.Errorf("set times: %w", err)
+		}
+	}
+
+	return pathName, decrypted, nil
+}
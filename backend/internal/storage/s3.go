@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"google-backup/internal/settings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Uploads are streamed in parts of this size, it bounds the memory used by every upload
+const s3PartSize = 16 << 20
+
+// S3-compatible bucket, objects are named by the prefix followed by the path
+type s3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// Streams the written data into a multipart upload
+type s3Writer struct {
+	pipe   *io.PipeWriter
+	result chan error
+}
+
+// Calculates the ETag S3 gives an object uploaded in parts of the part size
+type etagHash struct {
+	partSize   int64
+	whole      hash.Hash
+	part       hash.Hash
+	partLength int64
+	partSums   []byte
+	parts      int
+}
+
+func NewS3(config settings.S3Storage) (s3, error) {
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKeyId, config.SecretAccessKey, ""),
+		Secure: !config.Insecure,
+		Region: config.Region,
+	})
+	if err != nil {
+		return s3{}, fmt.Errorf("new client: %w", err)
+	}
+
+	prefix := strings.Trim(config.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return s3{client: client, bucket: config.Bucket, prefix: prefix}, nil
+}
+
+// Objects can't be appended, the existing object is uploaded again in front of the new data
+func (s s3) Writer(pathName string, appendData bool) (io.WriteCloser, error) {
+	var existing io.ReadCloser
+
+	if appendData {
+		object, err := s.Reader(pathName)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		existing = object
+	}
+
+	reader, writer := io.Pipe()
+	result := make(chan error, 1)
+
+	go func() {
+		var source io.Reader = reader
+
+		if existing != nil {
+			defer existing.Close()
+
+			source = io.MultiReader(existing, reader)
+		}
+
+		etag := newEtagHash(s3PartSize)
+
+		info, err := s.client.PutObject(context.Background(), s.bucket, s.key(pathName), io.TeeReader(source, etag), -1, minio.PutObjectOptions{
+			PartSize:    s3PartSize,
+			ContentType: "application/octet-stream",
+		})
+		if err == nil && !etag.matches(info.ETag) {
+			s.client.RemoveObject(context.Background(), s.bucket, s.key(pathName), minio.RemoveObjectOptions{})
+
+			err = fmt.Errorf("uploaded object etag %s does not match the written data", info.ETag)
+		}
+
+		// unblocks the writer if the upload failed before the data was read
+		reader.CloseWithError(err)
+
+		result <- err
+	}()
+
+	return s3Writer{pipe: writer, result: result}, nil
+}
+
+func (s s3) Reader(pathName string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(context.Background(), s.bucket, s.key(pathName), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.pathError("open", pathName, err)
+	}
+
+	// the request is sent by the first call, so a missing object is reported here and not by the first read
+	_, err = object.Stat()
+	if err != nil {
+		object.Close()
+
+		return nil, s.pathError("open", pathName, err)
+	}
+
+	return object, nil
+}
+
+// Folders are prefixes of object names, a folder exists if there is an object in it
+func (s s3) Stat(pathName string) (FileInfo, error) {
+	info, err := s.client.StatObject(context.Background(), s.bucket, s.key(pathName), minio.StatObjectOptions{})
+	if err == nil {
+		return FileInfo{PathName: pathName, Size: info.Size, ModTime: info.LastModified}, nil
+	}
+
+	if !s.notFound(err) {
+		return FileInfo{}, s.pathError("stat", pathName, err)
+	}
+
+	files, err := s.list(pathName, 1)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	if len(files) == 0 {
+		return FileInfo{}, &fs.PathError{Op: "stat", Path: pathName, Err: fs.ErrNotExist}
+	}
+
+	return FileInfo{PathName: pathName, IsDir: true}, nil
+}
+
+func (s s3) Rename(oldPathName string, newPathName string) error {
+	return s.RenameWithMetadata(oldPathName, newPathName, nil)
+}
+
+// Objects are copied and deleted, nil metadata keeps the metadata of the object.
+// Folders are renamed object by object
+func (s s3) RenameWithMetadata(oldPathName string, newPathName string, metadata map[string]string) error {
+	_, err := s.client.StatObject(context.Background(), s.bucket, s.key(oldPathName), minio.StatObjectOptions{})
+	if err == nil {
+		return s.renameObject(oldPathName, newPathName, metadata)
+	}
+
+	if !s.notFound(err) {
+		return s.pathError("rename", oldPathName, err)
+	}
+
+	files, err := s.List(oldPathName)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return &fs.PathError{Op: "rename", Path: oldPathName, Err: fs.ErrNotExist}
+	}
+
+	for _, file := range files {
+		err = s.renameObject(file.PathName, newPathName+strings.TrimPrefix(file.PathName, oldPathName), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deleting a missing object succeeds in S3
+func (s s3) Delete(pathName string) error {
+	err := s.client.RemoveObject(context.Background(), s.bucket, s.key(pathName), minio.RemoveObjectOptions{})
+	if err != nil {
+		return s.pathError("delete", pathName, err)
+	}
+
+	return nil
+}
+
+// Objects keep the upload time, the creation time of items is recorded in the file meta
+func (s s3) SetTimes(pathName string, modTime time.Time) error {
+	return nil
+}
+
+func (s s3) List(folderPathName string) ([]FileInfo, error) {
+	return s.list(folderPathName, 0)
+}
+
+// Zero limit lists all objects
+func (s s3) list(folderPathName string, limit int) ([]FileInfo, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var result []FileInfo
+
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    s.key(strings.TrimSuffix(folderPathName, "/") + "/"),
+		Recursive: true,
+	})
+
+	for object := range objects {
+		if object.Err != nil {
+			return nil, fmt.Errorf("list objects: %w", object.Err)
+		}
+
+		result = append(result, FileInfo{
+			PathName: strings.TrimPrefix(object.Key, s.prefix),
+			Size:     object.Size,
+			ModTime:  object.LastModified,
+		})
+
+		// cancelling stops the listing
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func (s s3) renameObject(oldPathName string, newPathName string, metadata map[string]string) error {
+	destination := minio.CopyDestOptions{Bucket: s.bucket, Object: s.key(newPathName)}
+	if metadata != nil {
+		destination.UserMetadata = metadata
+		destination.ReplaceMetadata = true
+	}
+
+	// composing copies objects bigger than the 5 GiB limit of a single copy in parts
+	_, err := s.client.ComposeObject(context.Background(), destination, minio.CopySrcOptions{Bucket: s.bucket, Object: s.key(oldPathName)})
+	if err != nil {
+		return s.pathError("rename", oldPathName, err)
+	}
+
+	return s.Delete(oldPathName)
+}
+
+func (s s3) key(pathName string) string {
+	return s.prefix + pathName
+}
+
+func (s s3) notFound(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+
+	return code == "NoSuchKey" || code == "NotFound"
+}
+
+// Missing objects match fs.ErrNotExist like missing local files
+func (s s3) pathError(op string, pathName string, err error) error {
+	if s.notFound(err) {
+		err = fs.ErrNotExist
+	}
+
+	return &fs.PathError{Op: op, Path: pathName, Err: err}
+}
+
+func (w s3Writer) Write(p []byte) (int, error) {
+	return w.pipe.Write(p)
+}
+
+// Returns once the object is uploaded and its ETag is checked
+func (w s3Writer) Close() error {
+	w.pipe.Close()
+
+	err := <-w.result
+	if err != nil {
+		return fmt.Errorf("upload object: %w", err)
+	}
+
+	return nil
+}
+
+func newEtagHash(partSize int64) *etagHash {
+	return &etagHash{partSize: partSize, whole: md5.New(), part: md5.New()}
+}
+
+func (e *etagHash) Write(p []byte) (int, error) {
+	e.whole.Write(p)
+
+	written := len(p)
+
+	for len(p) > 0 {
+		n := min(int64(len(p)), e.partSize-e.partLength)
+
+		e.part.Write(p[:n])
+		e.partLength += n
+		p = p[n:]
+
+		if e.partLength == e.partSize {
+			e.finishPart()
+		}
+	}
+
+	return written, nil
+}
+
+func (e *etagHash) finishPart() {
+	e.partSums = e.part.Sum(e.partSums)
+	e.parts++
+	e.part.Reset()
+	e.partLength = 0
+}
+
+// Single part ETags are the md5 of the content, multipart ETags are the md5 of the part md5s
+// followed by the number of parts, e.g. "<md5>-3". ETags in other formats, e.g. of encrypted objects, are not checked
+func (e *etagHash) matches(etag string) bool {
+	etag = strings.Trim(etag, `"`)
+	sum, parts, multipart := strings.Cut(etag, "-")
+
+	if len(sum) != 32 {
+		return true
+	}
+
+	if !multipart {
+		return hex.EncodeToString(e.whole.Sum(nil)) == sum
+	}
+
+	// the last part, an empty object is uploaded as one empty part
+	if e.partLength > 0 || e.parts == 0 {
+		e.finishPart()
+	}
+
+	partsSum := md5.Sum(e.partSums)
+
+	return hex.EncodeToString(partsSum[:]) == sum && strconv.Itoa(e.parts) == parts
+}
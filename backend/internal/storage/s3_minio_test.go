@@ -0,0 +1,168 @@
+//go:build minio
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"google-backup/internal/settings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs against a local MinIO, e.g. "docker run -p 9000:9000 minio/minio server /data",
+// with "go test -tags minio ./internal/storage"
+func TestS3Minio(t *testing.T) {
+	config := settings.S3Storage{
+		Endpoint:        envOr("MINIO_ENDPOINT", "localhost:9000"),
+		Bucket:          envOr("MINIO_BUCKET", "google-backup-test"),
+		Prefix:          fmt.Sprintf("test-%d/", time.Now().UnixNano()),
+		AccessKeyId:     envOr("MINIO_ACCESS_KEY", "minioadmin"),
+		SecretAccessKey: envOr("MINIO_SECRET_KEY", "minioadmin"),
+		Insecure:        true,
+	}
+
+	backend, err := NewS3(config)
+	require.NoError(t, err)
+
+	exists, err := backend.client.BucketExists(context.Background(), config.Bucket)
+	require.NoError(t, err)
+
+	if !exists {
+		require.NoError(t, backend.client.MakeBucket(context.Background(), config.Bucket, minio.MakeBucketOptions{}))
+	}
+
+	t.Cleanup(func() {
+		files, _ := backend.List("")
+		for _, file := range files {
+			backend.Delete(file.PathName)
+		}
+	})
+
+	write := func(t *testing.T, pathName string, content []byte, appendData bool) {
+		writer, err := backend.Writer(pathName, appendData)
+		require.NoError(t, err)
+
+		_, err = writer.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+	}
+
+	read := func(t *testing.T, pathName string) []byte {
+		reader, err := backend.Reader(pathName)
+		require.NoError(t, err)
+
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		return content
+	}
+
+	t.Run("write and read", func(t *testing.T) {
+		write(t, "user@gmail.com/photos/a.jpg", []byte("content"), false)
+
+		assert.Equal(t, []byte("content"), read(t, "user@gmail.com/photos/a.jpg"))
+	})
+
+	t.Run("write in parts", func(t *testing.T) {
+		content := bytes.Repeat([]byte{7}, s3PartSize+1000)
+
+		write(t, "user@gmail.com/photos/big.mp4", content, false)
+
+		info, err := backend.Stat("user@gmail.com/photos/big.mp4")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), info.Size)
+	})
+
+	t.Run("append", func(t *testing.T) {
+		write(t, "user@gmail.com/photos/b.jpg.partial", []byte("first "), true)
+		write(t, "user@gmail.com/photos/b.jpg.partial", []byte("second"), true)
+
+		assert.Equal(t, []byte("first second"), read(t, "user@gmail.com/photos/b.jpg.partial"))
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		write(t, "user@gmail.com/photos/c.jpg", []byte("content"), false)
+
+		info, err := backend.Stat("user@gmail.com/photos/c.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, FileInfo{PathName: "user@gmail.com/photos/c.jpg", Size: 7, ModTime: info.ModTime}, info)
+		assert.False(t, info.ModTime.IsZero())
+
+		info, err = backend.Stat("user@gmail.com/photos")
+		assert.NoError(t, err)
+		assert.True(t, info.IsDir)
+
+		_, err = backend.Stat("user@gmail.com/photos/missing.jpg")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		_, err = backend.Reader("user@gmail.com/photos/missing.jpg")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		write(t, "user@gmail.com/photos/d.jpg.partial", []byte("content"), false)
+
+		err := backend.RenameWithMetadata("user@gmail.com/photos/d.jpg.partial", "user@gmail.com/photos/d.jpg", map[string]string{"Sha256": "abc"})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []byte("content"), read(t, "user@gmail.com/photos/d.jpg"))
+
+		info, err := backend.client.StatObject(context.Background(), config.Bucket, backend.key("user@gmail.com/photos/d.jpg"), minio.StatObjectOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", info.UserMetadata["Sha256"])
+
+		_, err = backend.Stat("user@gmail.com/photos/d.jpg.partial")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("rename folder", func(t *testing.T) {
+		write(t, "user@gmail.com/drive/folder/e.txt", []byte("e"), false)
+		write(t, "user@gmail.com/drive/folder/sub/f.txt", []byte("f"), false)
+
+		err := backend.Rename("user@gmail.com/drive/folder", "user@gmail.com/drive/other")
+		assert.NoError(t, err)
+
+		files, err := backend.List("user@gmail.com/drive")
+		assert.NoError(t, err)
+
+		var names []string
+		for _, file := range files {
+			names = append(names, file.PathName)
+		}
+
+		assert.ElementsMatch(t, []string{"user@gmail.com/drive/other/e.txt", "user@gmail.com/drive/other/sub/f.txt"}, names)
+
+		err = backend.Rename("user@gmail.com/drive/missing", "user@gmail.com/drive/other")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		write(t, "user@gmail.com/photos/g.jpg", []byte("content"), false)
+
+		assert.NoError(t, backend.Delete("user@gmail.com/photos/g.jpg"))
+		assert.NoError(t, backend.Delete("user@gmail.com/photos/g.jpg"))
+
+		_, err := backend.Stat("user@gmail.com/photos/g.jpg")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func envOr(name string, fallback string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
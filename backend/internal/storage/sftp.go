@@ -0,0 +1,482 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"google-backup/internal/settings"
+
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// Open connections per account, downloads of an account share them
+	sftpPoolSize = 4
+
+	sftpDialTimeout = 30 * time.Second
+)
+
+// Folder on a remote machine. Operations run on pooled connections,
+// an operation which fails because the connection was lost is run once more on a new connection.
+// Open files share the connection they were opened on, so they don't keep other operations waiting
+type sftpStorage struct {
+	pool *sftpPool
+	root string
+}
+
+type sftpPool struct {
+	address   string
+	sshConfig *ssh.ClientConfig
+	// Holds a value for every running operation, it blocks once all connections are in use
+	slots  chan struct{}
+	mutex  *sync.Mutex
+	idle   []*sftpConnection
+	closed bool
+}
+
+type sftpConnection struct {
+	sshClient  *ssh.Client
+	sftpClient *sftp.Client
+	// Closed once the connection is lost
+	lost chan struct{}
+	// Files open on the connection, guarded by the mutex of the pool
+	openFiles int
+	closeOnce *sync.Once
+}
+
+// Syncs the file before closing it, the connection is kept open until the file is closed
+type sftpWriter struct {
+	*sftp.File
+	pool       *sftpPool
+	connection *sftpConnection
+}
+
+type sftpReader struct {
+	*sftp.File
+	pool       *sftpPool
+	connection *sftpConnection
+}
+
+func NewSftp(config settings.SftpStorage) (sftpStorage, error) {
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.HostKey))
+	if err != nil {
+		return sftpStorage{}, fmt.Errorf("parse host key: %w", err)
+	}
+
+	var authMethods []ssh.AuthMethod
+
+	if config.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(config.PrivateKey))
+		if err != nil {
+			return sftpStorage{}, fmt.Errorf("parse private key: %w", err)
+		}
+
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+
+	if config.Password != "" {
+		authMethods = append(authMethods, ssh.Password(config.Password))
+	}
+
+	address := config.Host
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+
+	// relative paths start in the home folder
+	root := path.Clean(config.Path)
+
+	pool := &sftpPool{
+		address: address,
+		sshConfig: &ssh.ClientConfig{
+			User:            config.User,
+			Auth:            authMethods,
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+			Timeout:         sftpDialTimeout,
+		},
+		slots: make(chan struct{}, sftpPoolSize),
+		mutex: &sync.Mutex{},
+	}
+
+	return sftpStorage{pool: pool, root: root}, nil
+}
+
+// Checks the keys of the settings without connecting
+func ValidateSftp(config settings.SftpStorage) error {
+	_, err := NewSftp(config)
+
+	return err
+}
+
+func (s sftpStorage) Writer(pathName string, appendData bool) (io.WriteCloser, error) {
+	fullPath := s.fullPath(pathName)
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendData {
+		flag = os.O_CREATE | os.O_WRONLY
+	}
+
+	var file *sftp.File
+
+	connection, err := s.pool.open(func(client *sftp.Client) error {
+		err := client.MkdirAll(path.Dir(fullPath))
+		if err != nil {
+			return fmt.Errorf("create folder: %w", err)
+		}
+
+		file, err = client.OpenFile(fullPath, flag)
+		if err != nil || !appendData {
+			return err
+		}
+
+		// writes carry their offset, servers ignore the append flag
+		_, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			file.Close()
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: pathName, Err: err}
+	}
+
+	return sftpWriter{File: file, pool: s.pool, connection: connection}, nil
+}
+
+func (s sftpStorage) Reader(pathName string) (io.ReadCloser, error) {
+	var file *sftp.File
+
+	connection, err := s.pool.open(func(client *sftp.Client) error {
+		var err error
+		file, err = client.Open(s.fullPath(pathName))
+
+		return err
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: pathName, Err: err}
+	}
+
+	return sftpReader{File: file, pool: s.pool, connection: connection}, nil
+}
+
+func (s sftpStorage) Stat(pathName string) (FileInfo, error) {
+	var info fs.FileInfo
+
+	err := s.pool.run(func(client *sftp.Client) error {
+		var err error
+		info, err = client.Stat(s.fullPath(pathName))
+
+		return err
+	})
+	if err != nil {
+		return FileInfo{}, &fs.PathError{Op: "stat", Path: pathName, Err: err}
+	}
+
+	return FileInfo{PathName: pathName, Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}, nil
+}
+
+// Servers without the posix-rename extension can't replace files, the existing file is removed first there
+func (s sftpStorage) Rename(oldPathName string, newPathName string) error {
+	newFullPath := s.fullPath(newPathName)
+
+	err := s.pool.run(func(client *sftp.Client) error {
+		err := client.MkdirAll(path.Dir(newFullPath))
+		if err != nil {
+			return fmt.Errorf("create folder: %w", err)
+		}
+
+		err = client.PosixRename(s.fullPath(oldPathName), newFullPath)
+		if !isSftpUnsupported(err) {
+			return err
+		}
+
+		err = client.Remove(newFullPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		return client.Rename(s.fullPath(oldPathName), newFullPath)
+	})
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldPathName, Err: err}
+	}
+
+	return nil
+}
+
+func (s sftpStorage) Delete(pathName string) error {
+	err := s.pool.run(func(client *sftp.Client) error {
+		return client.Remove(s.fullPath(pathName))
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &fs.PathError{Op: "delete", Path: pathName, Err: err}
+	}
+
+	return nil
+}
+
+func (s sftpStorage) SetTimes(pathName string, modTime time.Time) error {
+	err := s.pool.run(func(client *sftp.Client) error {
+		return client.Chtimes(s.fullPath(pathName), modTime, modTime)
+	})
+	if err != nil {
+		return &fs.PathError{Op: "chtimes", Path: pathName, Err: err}
+	}
+
+	return nil
+}
+
+func (s sftpStorage) List(folderPathName string) ([]FileInfo, error) {
+	var result []FileInfo
+
+	err := s.pool.run(func(client *sftp.Client) error {
+		result = nil
+
+		walker := client.Walk(s.fullPath(folderPathName))
+
+		for walker.Step() {
+			err := walker.Err()
+			if errors.Is(err, fs.ErrNotExist) && walker.Path() == s.fullPath(folderPathName) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if walker.Stat().IsDir() {
+				continue
+			}
+
+			result = append(result, FileInfo{
+				PathName: s.pathName(walker.Path()),
+				Size:     walker.Stat().Size(),
+				ModTime:  walker.Stat().ModTime(),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk folder: %w", err)
+	}
+
+	return result, nil
+}
+
+// Closes the idle connections, connections in use are closed once they are returned and their files are closed
+func (s sftpStorage) Close() error {
+	s.pool.mutex.Lock()
+	defer s.pool.mutex.Unlock()
+
+	s.pool.closed = true
+
+	for _, connection := range s.pool.idle {
+		if connection.openFiles == 0 {
+			connection.close()
+		}
+	}
+
+	s.pool.idle = nil
+
+	return nil
+}
+
+func (s sftpStorage) fullPath(pathName string) string {
+	return path.Join(s.root, pathName)
+}
+
+func (s sftpStorage) pathName(fullPath string) string {
+	if s.root == "." {
+		return fullPath
+	}
+
+	return strings.TrimPrefix(strings.TrimPrefix(fullPath, s.root), "/")
+}
+
+// Runs the operation on a pooled connection.
+// The operation runs again on a new connection if the connection was lost
+func (p *sftpPool) run(operation func(client *sftp.Client) error) error {
+	_, err := p.runOn(operation, false)
+
+	return err
+}
+
+// Runs the operation which opens a file and returns the connection of the file, it has to be passed to closeFile
+// once the file is closed. The connection goes back to the pool right away, other operations share it meanwhile
+func (p *sftpPool) open(operation func(client *sftp.Client) error) (*sftpConnection, error) {
+	return p.runOn(operation, true)
+}
+
+func (p *sftpPool) runOn(operation func(client *sftp.Client) error, opensFile bool) (*sftpConnection, error) {
+	for attempt := 1; ; attempt++ {
+		connection, err := p.acquire()
+		if err != nil {
+			return nil, err
+		}
+
+		err = operation(connection.sftpClient)
+		if err == nil {
+			if opensFile {
+				p.mutex.Lock()
+				connection.openFiles++
+				p.mutex.Unlock()
+			}
+
+			p.release(connection)
+
+			return connection, nil
+		}
+
+		lost := connection.lostBy(err)
+
+		p.release(connection)
+
+		if !lost || attempt == 2 {
+			return nil, err
+		}
+
+		log.WithFields(log.Fields{"address": p.address, "error": err}).Warn("sftp connection lost, reconnecting")
+	}
+}
+
+func (p *sftpPool) acquire() (*sftpConnection, error) {
+	p.slots <- struct{}{}
+
+	p.mutex.Lock()
+
+	for len(p.idle) > 0 {
+		connection := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+
+		if !connection.isLost() {
+			p.mutex.Unlock()
+
+			return connection, nil
+		}
+
+		// lost connections with open files are closed with their last file
+		if connection.openFiles == 0 {
+			connection.close()
+		}
+	}
+
+	p.mutex.Unlock()
+
+	connection, err := p.dial()
+	if err != nil {
+		<-p.slots
+
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+func (p *sftpPool) release(connection *sftpConnection) {
+	p.mutex.Lock()
+
+	if !p.closed && !connection.isLost() {
+		p.idle = append(p.idle, connection)
+	} else if connection.openFiles == 0 {
+		connection.close()
+	}
+
+	p.mutex.Unlock()
+
+	<-p.slots
+}
+
+// Closes the connection with its last file if it isn't pooled anymore
+func (p *sftpPool) closeFile(connection *sftpConnection) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	connection.openFiles--
+
+	if connection.openFiles == 0 && (p.closed || connection.isLost()) {
+		connection.close()
+	}
+}
+
+func (p *sftpPool) dial() (*sftpConnection, error) {
+	sshClient, err := ssh.Dial("tcp", p.address, p.sshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("ssh dial %s: %w", p.address, err)
+	}
+
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+
+		return nil, fmt.Errorf("new sftp client: %w", err)
+	}
+
+	connection := &sftpConnection{sshClient: sshClient, sftpClient: sftpClient, lost: make(chan struct{}), closeOnce: &sync.Once{}}
+
+	go func() {
+		sftpClient.Wait()
+		close(connection.lost)
+	}()
+
+	return connection, nil
+}
+
+func (c *sftpConnection) isLost() bool {
+	select {
+	case <-c.lost:
+		return true
+	default:
+		return false
+	}
+}
+
+// Errors of requests in flight when the connection breaks are reported before the connection is marked as lost
+func (c *sftpConnection) lostBy(err error) bool {
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) || c.isLost()
+}
+
+// A lost connection may be dropped from the pool and closed with its last file later, it is closed once
+func (c *sftpConnection) close() {
+	c.closeOnce.Do(func() {
+		c.sftpClient.Close()
+		c.sshClient.Close()
+	})
+}
+
+func (w sftpWriter) Close() error {
+	defer w.pool.closeFile(w.connection)
+
+	// the fsync extension is optional, the data is written once the file is closed without it
+	err := w.File.Sync()
+	if err != nil && !isSftpUnsupported(err) {
+		w.File.Close()
+
+		return fmt.Errorf("sync file: %w", err)
+	}
+
+	return w.File.Close()
+}
+
+func (r sftpReader) Close() error {
+	defer r.pool.closeFile(r.connection)
+
+	return r.File.Close()
+}
+
+func isSftpUnsupported(err error) bool {
+	var statusErr *sftp.StatusError
+
+	return errors.As(err, &statusErr) && statusErr.FxCode() == sftp.ErrSSHFxOpUnsupported
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// Metadata keys, backends which keep metadata store them with the files
+const (
+	MetadataMediaItemId = "media-item-id"
+	MetadataDriveFileId = "drive-file-id"
+	MetadataSha256      = "sha256"
+)
+
+// Returned when a feature needs links but the backend has none
+var ErrLinksUnsupported = errors.New("storage backend does not support links")
+
+// Target the backups are written to. Paths are relative to the root of the backend and use "/" as a separator,
+// e.g. "user@gmail.com/2024/1/IMG_0001.JPG". Errors of missing files match fs.ErrNotExist
+//
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Backend
+type Backend interface {
+	// Stream which writes the file, missing parent folders are created.
+	// The file is truncated unless appendData is set, the data is durable once Close returns without an error
+	Writer(pathName string, appendData bool) (io.WriteCloser, error)
+	Reader(pathName string) (io.ReadCloser, error)
+	Stat(pathName string) (FileInfo, error)
+	// Replaces an existing file, missing parent folders of the new path are created. Folders can be renamed too
+	Rename(oldPathName string, newPathName string) error
+	// Deleting a missing file is not an error
+	Delete(pathName string) error
+	SetTimes(pathName string, modTime time.Time) error
+	// Files under the folder at any depth, a missing folder has no files
+	List(folderPathName string) ([]FileInfo, error)
+}
+
+// Implemented by backends which can link files, album folders and the content store need it
+type Linker interface {
+	// Returns false if the paths can't be linked, e.g. they are on different storages
+	CanLink(pathName string, otherPathName string) bool
+	// Hardlink, missing parent folders of the link are created
+	Link(targetPathName string, linkPathName string) error
+	// Relative symlink, missing parent folders of the link are created
+	Symlink(targetPathName string, linkPathName string) error
+	SameFile(pathName string, otherPathName string) (bool, error)
+}
+
+// Implemented by backends which keep metadata with the files, e.g. object storages
+type MetadataRenamer interface {
+	// Renames like Rename and replaces the metadata of the file
+	RenameWithMetadata(oldPathName string, newPathName string, metadata map[string]string) error
+}
+
+// Implemented by backends which encrypt files
+type Encrypter interface {
+	// Scheme the file is encrypted with, empty if the file is plain
+	EncryptionScheme(pathName string) (string, error)
+}
+
+type FileInfo struct {
+	PathName string
+	Size     int64
+	ModTime  time.Time
+	IsDir    bool
+}
+
+// Returns false if the backend has no links or can't link the paths
+func CanLink(backend Backend, pathName string, otherPathName string) bool {
+	linker, ok := backend.(Linker)
+
+	return ok && linker.CanLink(pathName, otherPathName)
+}
+
+// Renames the file and records the metadata if the backend keeps metadata
+func RenameWithMetadata(backend Backend, oldPathName string, newPathName string, metadata map[string]string) error {
+	renamer, ok := backend.(MetadataRenamer)
+	if !ok {
+		return backend.Rename(oldPathName, newPathName)
+	}
+
+	return renamer.RenameWithMetadata(oldPathName, newPathName, metadata)
+}
+
+// Returns the encryption scheme of the file, files of backends without encryption are plain
+func EncryptionScheme(backend Backend, pathName string) (string, error) {
+	encrypter, ok := backend.(Encrypter)
+	if !ok {
+		return "", nil
+	}
+
+	return encrypter.EncryptionScheme(pathName)
+}
@@ -0,0 +1,582 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package storagefakes
+
+import (
+	"google-backup/internal/storage"
+	"io"
+	"sync"
+	"time"
+)
+
+type FakeBackend struct {
+	DeleteStub        func(string) error
+	deleteMutex       sync.RWMutex
+	deleteArgsForCall []struct {
+		arg1 string
+	}
+	deleteReturns struct {
+		result1 error
+	}
+	deleteReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ListStub        func(string) ([]storage.FileInfo, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		arg1 string
+	}
+	listReturns struct {
+		result1 []storage.FileInfo
+		result2 error
+	}
+	listReturnsOnCall map[int]struct {
+		result1 []storage.FileInfo
+		result2 error
+	}
+	ReaderStub        func(string) (io.ReadCloser, error)
+	readerMutex       sync.RWMutex
+	readerArgsForCall []struct {
+		arg1 string
+	}
+	readerReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	readerReturnsOnCall map[int]struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	RenameStub        func(string, string) error
+	renameMutex       sync.RWMutex
+	renameArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	renameReturns struct {
+		result1 error
+	}
+	renameReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetTimesStub        func(string, time.Time) error
+	setTimesMutex       sync.RWMutex
+	setTimesArgsForCall []struct {
+		arg1 string
+		arg2 time.Time
+	}
+	setTimesReturns struct {
+		result1 error
+	}
+	setTimesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StatStub        func(string) (storage.FileInfo, error)
+	statMutex       sync.RWMutex
+	statArgsForCall []struct {
+		arg1 string
+	}
+	statReturns struct {
+		result1 storage.FileInfo
+		result2 error
+	}
+	statReturnsOnCall map[int]struct {
+		result1 storage.FileInfo
+		result2 error
+	}
+	WriterStub        func(string, bool) (io.WriteCloser, error)
+	writerMutex       sync.RWMutex
+	writerArgsForCall []struct {
+		arg1 string
+		arg2 bool
+	}
+	writerReturns struct {
+		result1 io.WriteCloser
+		result2 error
+	}
+	writerReturnsOnCall map[int]struct {
+		result1 io.WriteCloser
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeBackend) Delete(arg1 string) error {
+	fake.deleteMutex.Lock()
+	ret, specificReturn := fake.deleteReturnsOnCall[len(fake.deleteArgsForCall)]
+	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteStub
+	fakeReturns := fake.deleteReturns
+	fake.recordInvocation("Delete", []interface{}{arg1})
+	fake.deleteMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackend) DeleteCallCount() int {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return len(fake.deleteArgsForCall)
+}
+
+func (fake *FakeBackend) DeleteCalls(stub func(string) error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = stub
+}
+
+func (fake *FakeBackend) DeleteArgsForCall(i int) string {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	argsForCall := fake.deleteArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackend) DeleteReturns(result1 error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = nil
+	fake.deleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) DeleteReturnsOnCall(i int, result1 error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = nil
+	if fake.deleteReturnsOnCall == nil {
+		fake.deleteReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) List(arg1 string) ([]storage.FileInfo, error) {
+	fake.listMutex.Lock()
+	ret, specificReturn := fake.listReturnsOnCall[len(fake.listArgsForCall)]
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ListStub
+	fakeReturns := fake.listReturns
+	fake.recordInvocation("List", []interface{}{arg1})
+	fake.listMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeBackend) ListCalls(stub func(string) ([]storage.FileInfo, error)) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = stub
+}
+
+func (fake *FakeBackend) ListArgsForCall(i int) string {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	argsForCall := fake.listArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackend) ListReturns(result1 []storage.FileInfo, result2 error) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 []storage.FileInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) ListReturnsOnCall(i int, result1 []storage.FileInfo, result2 error) {
+	fake.listMutex.Lock()
+	defer fake.listMutex.Unlock()
+	fake.ListStub = nil
+	if fake.listReturnsOnCall == nil {
+		fake.listReturnsOnCall = make(map[int]struct {
+			result1 []storage.FileInfo
+			result2 error
+		})
+	}
+	fake.listReturnsOnCall[i] = struct {
+		result1 []storage.FileInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) Reader(arg1 string) (io.ReadCloser, error) {
+	fake.readerMutex.Lock()
+	ret, specificReturn := fake.readerReturnsOnCall[len(fake.readerArgsForCall)]
+	fake.readerArgsForCall = append(fake.readerArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReaderStub
+	fakeReturns := fake.readerReturns
+	fake.recordInvocation("Reader", []interface{}{arg1})
+	fake.readerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) ReaderCallCount() int {
+	fake.readerMutex.RLock()
+	defer fake.readerMutex.RUnlock()
+	return len(fake.readerArgsForCall)
+}
+
+func (fake *FakeBackend) ReaderCalls(stub func(string) (io.ReadCloser, error)) {
+	fake.readerMutex.Lock()
+	defer fake.readerMutex.Unlock()
+	fake.ReaderStub = stub
+}
+
+func (fake *FakeBackend) ReaderArgsForCall(i int) string {
+	fake.readerMutex.RLock()
+	defer fake.readerMutex.RUnlock()
+	argsForCall := fake.readerArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackend) ReaderReturns(result1 io.ReadCloser, result2 error) {
+	fake.readerMutex.Lock()
+	defer fake.readerMutex.Unlock()
+	fake.ReaderStub = nil
+	fake.readerReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) ReaderReturnsOnCall(i int, result1 io.ReadCloser, result2 error) {
+	fake.readerMutex.Lock()
+	defer fake.readerMutex.Unlock()
+	fake.ReaderStub = nil
+	if fake.readerReturnsOnCall == nil {
+		fake.readerReturnsOnCall = make(map[int]struct {
+			result1 io.ReadCloser
+			result2 error
+		})
+	}
+	fake.readerReturnsOnCall[i] = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) Rename(arg1 string, arg2 string) error {
+	fake.renameMutex.Lock()
+	ret, specificReturn := fake.renameReturnsOnCall[len(fake.renameArgsForCall)]
+	fake.renameArgsForCall = append(fake.renameArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RenameStub
+	fakeReturns := fake.renameReturns
+	fake.recordInvocation("Rename", []interface{}{arg1, arg2})
+	fake.renameMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackend) RenameCallCount() int {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	return len(fake.renameArgsForCall)
+}
+
+func (fake *FakeBackend) RenameCalls(stub func(string, string) error) {
+	fake.renameMutex.Lock()
+	defer fake.renameMutex.Unlock()
+	fake.RenameStub = stub
+}
+
+func (fake *FakeBackend) RenameArgsForCall(i int) (string, string) {
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	argsForCall := fake.renameArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBackend) RenameReturns(result1 error) {
+	fake.renameMutex.Lock()
+	defer fake.renameMutex.Unlock()
+	fake.RenameStub = nil
+	fake.renameReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) RenameReturnsOnCall(i int, result1 error) {
+	fake.renameMutex.Lock()
+	defer fake.renameMutex.Unlock()
+	fake.RenameStub = nil
+	if fake.renameReturnsOnCall == nil {
+		fake.renameReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.renameReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) SetTimes(arg1 string, arg2 time.Time) error {
+	fake.setTimesMutex.Lock()
+	ret, specificReturn := fake.setTimesReturnsOnCall[len(fake.setTimesArgsForCall)]
+	fake.setTimesArgsForCall = append(fake.setTimesArgsForCall, struct {
+		arg1 string
+		arg2 time.Time
+	}{arg1, arg2})
+	stub := fake.SetTimesStub
+	fakeReturns := fake.setTimesReturns
+	fake.recordInvocation("SetTimes", []interface{}{arg1, arg2})
+	fake.setTimesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackend) SetTimesCallCount() int {
+	fake.setTimesMutex.RLock()
+	defer fake.setTimesMutex.RUnlock()
+	return len(fake.setTimesArgsForCall)
+}
+
+func (fake *FakeBackend) SetTimesCalls(stub func(string, time.Time) error) {
+	fake.setTimesMutex.Lock()
+	defer fake.setTimesMutex.Unlock()
+	fake.SetTimesStub = stub
+}
+
+func (fake *FakeBackend) SetTimesArgsForCall(i int) (string, time.Time) {
+	fake.setTimesMutex.RLock()
+	defer fake.setTimesMutex.RUnlock()
+	argsForCall := fake.setTimesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBackend) SetTimesReturns(result1 error) {
+	fake.setTimesMutex.Lock()
+	defer fake.setTimesMutex.Unlock()
+	fake.SetTimesStub = nil
+	fake.setTimesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) SetTimesReturnsOnCall(i int, result1 error) {
+	fake.setTimesMutex.Lock()
+	defer fake.setTimesMutex.Unlock()
+	fake.SetTimesStub = nil
+	if fake.setTimesReturnsOnCall == nil {
+		fake.setTimesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setTimesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) Stat(arg1 string) (storage.FileInfo, error) {
+	fake.statMutex.Lock()
+	ret, specificReturn := fake.statReturnsOnCall[len(fake.statArgsForCall)]
+	fake.statArgsForCall = append(fake.statArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.StatStub
+	fakeReturns := fake.statReturns
+	fake.recordInvocation("Stat", []interface{}{arg1})
+	fake.statMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) StatCallCount() int {
+	fake.statMutex.RLock()
+	defer fake.statMutex.RUnlock()
+	return len(fake.statArgsForCall)
+}
+
+func (fake *FakeBackend) StatCalls(stub func(string) (storage.FileInfo, error)) {
+	fake.statMutex.Lock()
+	defer fake.statMutex.Unlock()
+	fake.StatStub = stub
+}
+
+func (fake *FakeBackend) StatArgsForCall(i int) string {
+	fake.statMutex.RLock()
+	defer fake.statMutex.RUnlock()
+	argsForCall := fake.statArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackend) StatReturns(result1 storage.FileInfo, result2 error) {
+	fake.statMutex.Lock()
+	defer fake.statMutex.Unlock()
+	fake.StatStub = nil
+	fake.statReturns = struct {
+		result1 storage.FileInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) StatReturnsOnCall(i int, result1 storage.FileInfo, result2 error) {
+	fake.statMutex.Lock()
+	defer fake.statMutex.Unlock()
+	fake.StatStub = nil
+	if fake.statReturnsOnCall == nil {
+		fake.statReturnsOnCall = make(map[int]struct {
+			result1 storage.FileInfo
+			result2 error
+		})
+	}
+	fake.statReturnsOnCall[i] = struct {
+		result1 storage.FileInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) Writer(arg1 string, arg2 bool) (io.WriteCloser, error) {
+	fake.writerMutex.Lock()
+	ret, specificReturn := fake.writerReturnsOnCall[len(fake.writerArgsForCall)]
+	fake.writerArgsForCall = append(fake.writerArgsForCall, struct {
+		arg1 string
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.WriterStub
+	fakeReturns := fake.writerReturns
+	fake.recordInvocation("Writer", []interface{}{arg1, arg2})
+	fake.writerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackend) WriterCallCount() int {
+	fake.writerMutex.RLock()
+	defer fake.writerMutex.RUnlock()
+	return len(fake.writerArgsForCall)
+}
+
+func (fake *FakeBackend) WriterCalls(stub func(string, bool) (io.WriteCloser, error)) {
+	fake.writerMutex.Lock()
+	defer fake.writerMutex.Unlock()
+	fake.WriterStub = stub
+}
+
+func (fake *FakeBackend) WriterArgsForCall(i int) (string, bool) {
+	fake.writerMutex.RLock()
+	defer fake.writerMutex.RUnlock()
+	argsForCall := fake.writerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBackend) WriterReturns(result1 io.WriteCloser, result2 error) {
+	fake.writerMutex.Lock()
+	defer fake.writerMutex.Unlock()
+	fake.WriterStub = nil
+	fake.writerReturns = struct {
+		result1 io.WriteCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) WriterReturnsOnCall(i int, result1 io.WriteCloser, result2 error) {
+	fake.writerMutex.Lock()
+	defer fake.writerMutex.Unlock()
+	fake.WriterStub = nil
+	if fake.writerReturnsOnCall == nil {
+		fake.writerReturnsOnCall = make(map[int]struct {
+			result1 io.WriteCloser
+			result2 error
+		})
+	}
+	fake.writerReturnsOnCall[i] = struct {
+		result1 io.WriteCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	fake.readerMutex.RLock()
+	defer fake.readerMutex.RUnlock()
+	fake.renameMutex.RLock()
+	defer fake.renameMutex.RUnlock()
+	fake.setTimesMutex.RLock()
+	defer fake.setTimesMutex.RUnlock()
+	fake.statMutex.RLock()
+	defer fake.statMutex.RUnlock()
+	fake.writerMutex.RLock()
+	defer fake.writerMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeBackend) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ storage.Backend = new(FakeBackend)
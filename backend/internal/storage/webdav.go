@@ -0,0 +1,475 @@
+package storage
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"google-backup/internal/settings"
+)
+
+const (
+	// Idle connections kept open per account, downloads of an account share them
+	webdavIdleConnections = 4
+
+	webdavPropfindBody = `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop>` +
+		`<d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+	webdavProppatchBody = `<?xml version="1.0" encoding="utf-8"?><d:propertyupdate xmlns:d="DAV:"><d:set><d:prop>` +
+		`<d:lastmodified>%s</d:lastmodified></d:prop></d:set></d:propertyupdate>`
+)
+
+// WebDAV share, e.g. a Nextcloud folder. Idle connections are reused,
+// requests but uploads are sent once more if the connection fails, moves and deletes only if they weren't applied
+type webdav struct {
+	client   *http.Client
+	baseUrl  *url.URL
+	user     string
+	password string
+}
+
+// Streams the written data into a PUT request
+type webdavWriter struct {
+	pipe   *io.PipeWriter
+	result chan error
+}
+
+type webdavMultistatus struct {
+	Responses []webdavResponse `xml:"DAV: response"`
+}
+
+type webdavResponse struct {
+	Href      string           `xml:"DAV: href"`
+	Propstats []webdavPropstat `xml:"DAV: propstat"`
+}
+
+type webdavPropstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   struct {
+		ContentLength int64  `xml:"DAV: getcontentlength"`
+		LastModified  string `xml:"DAV: getlastmodified"`
+		ResourceType  struct {
+			Collection *struct{} `xml:"DAV: collection"`
+		} `xml:"DAV: resourcetype"`
+	} `xml:"DAV: prop"`
+}
+
+func NewWebdav(config settings.WebdavStorage) (webdav, error) {
+	baseUrl, err := url.Parse(strings.TrimSuffix(config.Url, "/"))
+	if err != nil {
+		return webdav{}, fmt.Errorf("parse url: %w", err)
+	}
+
+	if baseUrl.Scheme != "http" && baseUrl.Scheme != "https" {
+		return webdav{}, fmt.Errorf("unsupported url scheme: %s", baseUrl.Scheme)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = webdavIdleConnections
+
+	return webdav{
+		client:   &http.Client{Transport: transport},
+		baseUrl:  baseUrl,
+		user:     config.User,
+		password: config.Password,
+	}, nil
+}
+
+// Shares can't append to files, the existing file is uploaded again in front of the new data
+func (w webdav) Writer(pathName string, appendData bool) (io.WriteCloser, error) {
+	var existing io.ReadCloser
+
+	if appendData {
+		file, err := w.Reader(pathName)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		existing = file
+	}
+
+	err := w.createFolders(path.Dir(pathName))
+	if err != nil {
+		if existing != nil {
+			existing.Close()
+		}
+
+		return nil, err
+	}
+
+	reader, writer := io.Pipe()
+	result := make(chan error, 1)
+
+	go func() {
+		var body io.Reader = reader
+
+		if existing != nil {
+			defer existing.Close()
+
+			body = io.MultiReader(existing, reader)
+		}
+
+		resp, err := w.send(http.MethodPut, pathName, body, nil)
+		err = w.expect(resp, err, "put", pathName, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+
+		// unblocks the writer if the upload failed before the data was read
+		reader.CloseWithError(err)
+
+		result <- err
+	}()
+
+	return webdavWriter{pipe: writer, result: result}, nil
+}
+
+func (w webdav) Reader(pathName string) (io.ReadCloser, error) {
+	resp, err := w.do(http.MethodGet, pathName, "", nil)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: pathName, Err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+
+		return nil, w.statusError("open", pathName, resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+func (w webdav) Stat(pathName string) (FileInfo, error) {
+	files, err := w.propfind(pathName, "0")
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	if len(files) == 0 {
+		return FileInfo{}, &fs.PathError{Op: "stat", Path: pathName, Err: errors.New("empty propfind response")}
+	}
+
+	files[0].PathName = pathName
+
+	return files[0], nil
+}
+
+// Moves replace existing files, folders are moved with their content
+func (w webdav) Rename(oldPathName string, newPathName string) error {
+	err := w.createFolders(path.Dir(newPathName))
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{
+		"Destination": w.url(newPathName),
+		"Overwrite":   "T",
+	}
+
+	resp, applied, err := w.doUnlessApplied("MOVE", oldPathName, headers, func() (bool, error) {
+		return w.moved(oldPathName, newPathName)
+	})
+	if applied {
+		return nil
+	}
+
+	return w.expect(resp, err, "rename", oldPathName, http.StatusCreated, http.StatusNoContent)
+}
+
+func (w webdav) Delete(pathName string) error {
+	resp, applied, err := w.doUnlessApplied(http.MethodDelete, pathName, nil, func() (bool, error) {
+		return w.missing(pathName)
+	})
+	if applied {
+		return nil
+	}
+
+	if err == nil && resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+
+		return nil
+	}
+
+	return w.expect(resp, err, "delete", pathName, http.StatusOK, http.StatusNoContent)
+}
+
+// Servers which don't allow to change the modification time, e.g. most servers but Nextcloud, keep the upload time
+func (w webdav) SetTimes(pathName string, modTime time.Time) error {
+	resp, err := w.do("PROPPATCH", pathName, fmt.Sprintf(webdavProppatchBody, modTime.UTC().Format(http.TimeFormat)), nil)
+	if err != nil {
+		return &fs.PathError{Op: "chtimes", Path: pathName, Err: err}
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusMultiStatus, http.StatusMethodNotAllowed, http.StatusNotImplemented, http.StatusForbidden:
+		return nil
+	}
+
+	return w.statusError("chtimes", pathName, resp.StatusCode)
+}
+
+// Folders are listed level by level, servers often don't allow infinite depth
+func (w webdav) List(folderPathName string) ([]FileInfo, error) {
+	var result []FileInfo
+
+	folders := []string{strings.TrimSuffix(folderPathName, "/")}
+
+	for len(folders) > 0 {
+		folder := folders[0]
+		folders = folders[1:]
+
+		files, err := w.propfind(folder, "1")
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			// the folder itself
+			if file.PathName == folder {
+				continue
+			}
+
+			if file.IsDir {
+				folders = append(folders, file.PathName)
+
+				continue
+			}
+
+			result = append(result, file)
+		}
+	}
+
+	return result, nil
+}
+
+// Missing folders of the path are created one by one, the folder of the share url has to exist
+func (w webdav) createFolders(folderPathName string) error {
+	if folderPathName == "." || folderPathName == "" {
+		return nil
+	}
+
+	info, err := w.Stat(folderPathName)
+	if err == nil && info.IsDir {
+		return nil
+	}
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	err = w.createFolders(path.Dir(folderPathName))
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.do("MKCOL", folderPathName, "", nil)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		// created by another worker in the meantime
+		resp.Body.Close()
+
+		return nil
+	}
+
+	return w.expect(resp, err, "mkdir", folderPathName, http.StatusCreated)
+}
+
+func (w webdav) propfind(pathName string, depth string) ([]FileInfo, error) {
+	resp, err := w.do("PROPFIND", pathName, webdavPropfindBody, map[string]string{
+		"Depth":        depth,
+		"Content-Type": "application/xml",
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: pathName, Err: err}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, w.statusError("stat", pathName, resp.StatusCode)
+	}
+
+	var multistatus webdavMultistatus
+	err = xml.NewDecoder(resp.Body).Decode(&multistatus)
+	if err != nil {
+		return nil, fmt.Errorf("decode propfind response: %w", err)
+	}
+
+	var result []FileInfo
+
+	for _, response := range multistatus.Responses {
+		pathName, err := w.pathName(response.Href)
+		if err != nil {
+			return nil, err
+		}
+
+		file := FileInfo{PathName: pathName}
+
+		for _, propstat := range response.Propstats {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+
+			file.IsDir = propstat.Prop.ResourceType.Collection != nil
+			file.Size = propstat.Prop.ContentLength
+			file.ModTime, _ = http.ParseTime(propstat.Prop.LastModified)
+		}
+
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+// Sends a request with a buffered body, it is sent once more if the connection fails
+func (w webdav) do(method string, pathName string, body string, headers map[string]string) (*http.Response, error) {
+	resp, err := w.send(method, pathName, strings.NewReader(body), headers)
+
+	if w.connectionFailed(err) {
+		resp, err = w.send(method, pathName, strings.NewReader(body), headers)
+	}
+
+	return resp, err
+}
+
+// Sends a request which changes the share. If the connection fails the change may have been applied anyway,
+// the request is sent once more only if the check tells it wasn't. Returns true if the check found the change applied
+func (w webdav) doUnlessApplied(method string, pathName string, headers map[string]string, applied func() (bool, error)) (*http.Response, bool, error) {
+	resp, err := w.send(method, pathName, strings.NewReader(""), headers)
+	if !w.connectionFailed(err) {
+		return resp, false, err
+	}
+
+	done, checkErr := applied()
+	if checkErr != nil {
+		return nil, false, err
+	}
+
+	if done {
+		return nil, true, nil
+	}
+
+	resp, err = w.send(method, pathName, strings.NewReader(""), headers)
+
+	return resp, false, err
+}
+
+func (w webdav) connectionFailed(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// The source of a move is gone and the destination exists
+func (w webdav) moved(oldPathName string, newPathName string) (bool, error) {
+	oldMissing, err := w.missing(oldPathName)
+	if err != nil || !oldMissing {
+		return false, err
+	}
+
+	newMissing, err := w.missing(newPathName)
+
+	return !newMissing, err
+}
+
+func (w webdav) missing(pathName string) (bool, error) {
+	_, err := w.Stat(pathName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+
+	return false, err
+}
+
+func (w webdav) send(method string, pathName string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, w.url(pathName), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	if w.user != "" {
+		req.SetBasicAuth(w.user, w.password)
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	return w.client.Do(req)
+}
+
+func (w webdav) url(pathName string) string {
+	result := *w.baseUrl
+
+	for _, segment := range strings.Split(pathName, "/") {
+		if segment != "" {
+			result.Path += "/" + segment
+		}
+	}
+
+	return result.String()
+}
+
+// Path relative to the share of a href, hrefs are absolute paths or urls
+func (w webdav) pathName(href string) (string, error) {
+	hrefUrl, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("parse href %s: %w", href, err)
+	}
+
+	pathName := strings.TrimPrefix(hrefUrl.Path, w.baseUrl.Path)
+
+	return strings.Trim(pathName, "/"), nil
+}
+
+// Closes the response and returns nil if it has one of the statuses
+func (w webdav) expect(resp *http.Response, err error, op string, pathName string, statuses ...int) error {
+	if err != nil {
+		return &fs.PathError{Op: op, Path: pathName, Err: err}
+	}
+
+	defer resp.Body.Close()
+
+	for _, status := range statuses {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+
+	return w.statusError(op, pathName, resp.StatusCode)
+}
+
+// Missing files match fs.ErrNotExist like missing local files
+func (w webdav) statusError(op string, pathName string, statusCode int) error {
+	err := fmt.Errorf("unexpected status: %d %s", statusCode, http.StatusText(statusCode))
+	if statusCode == http.StatusNotFound {
+		err = fs.ErrNotExist
+	}
+
+	return &fs.PathError{Op: op, Path: pathName, Err: err}
+}
+
+func (w webdavWriter) Write(p []byte) (int, error) {
+	return w.pipe.Write(p)
+}
+
+// Returns once the server stored the file
+func (w webdavWriter) Close() error {
+	w.pipe.Close()
+
+	err := <-w.result
+	if err != nil {
+		return fmt.Errorf("upload file: %w", err)
+	}
+
+	return nil
+}
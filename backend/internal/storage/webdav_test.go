@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"google-backup/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Share of flat files which drops the connection of the first change request,
+// before or after the change is applied
+type testWebdavServer struct {
+	mutex        *sync.Mutex
+	files        map[string]bool
+	requests     map[string]int
+	dropApplied  bool
+	dropRejected bool
+}
+
+func (s *testWebdavServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests[r.Method]++
+
+	switch r.Method {
+	case "PROPFIND":
+		if !s.files[r.URL.Path] {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:"><d:response><d:href>%s</d:href>`+
+			`<d:propstat><d:status>HTTP/1.1 200 OK</d:status><d:prop><d:resourcetype/></d:prop></d:propstat>`+
+			`</d:response></d:multistatus>`, r.URL.Path)
+	case "MOVE", http.MethodDelete:
+		first := s.requests[r.Method] == 1
+
+		if first && s.dropRejected {
+			s.drop(w)
+
+			return
+		}
+
+		if !s.files[r.URL.Path] {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		delete(s.files, r.URL.Path)
+
+		if r.Method == "MOVE" {
+			destination, _ := url.Parse(r.Header.Get("Destination"))
+			s.files[destination.Path] = true
+		}
+
+		if first && s.dropApplied {
+			s.drop(w)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *testWebdavServer) drop(w http.ResponseWriter) {
+	connection, _, _ := w.(http.Hijacker).Hijack()
+	connection.Close()
+}
+
+func TestWebdav(t *testing.T) {
+	newWebdav := func(t *testing.T, server *testWebdavServer) webdav {
+		httpServer := httptest.NewServer(server)
+		t.Cleanup(httpServer.Close)
+
+		storage, err := NewWebdav(settings.WebdavStorage{Url: httpServer.URL})
+		require.NoError(t, err)
+
+		return storage
+	}
+
+	newServer := func() *testWebdavServer {
+		return &testWebdavServer{mutex: &sync.Mutex{}, files: map[string]bool{"/old.jpg": true}, requests: map[string]int{}}
+	}
+
+	t.Run("move applied before the connection failed", func(t *testing.T) {
+		server := newServer()
+		server.dropApplied = true
+
+		err := newWebdav(t, server).Rename("old.jpg", "new.jpg")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, server.requests["MOVE"])
+		assert.Equal(t, map[string]bool{"/new.jpg": true}, server.files)
+	})
+
+	t.Run("move not applied before the connection failed", func(t *testing.T) {
+		server := newServer()
+		server.dropRejected = true
+
+		err := newWebdav(t, server).Rename("old.jpg", "new.jpg")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, server.requests["MOVE"])
+		assert.Equal(t, map[string]bool{"/new.jpg": true}, server.files)
+	})
+
+	t.Run("delete applied before the connection failed", func(t *testing.T) {
+		server := newServer()
+		server.dropApplied = true
+
+		err := newWebdav(t, server).Delete("old.jpg")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, server.requests[http.MethodDelete])
+		assert.Empty(t, server.files)
+	})
+
+	t.Run("delete not applied before the connection failed", func(t *testing.T) {
+		server := newServer()
+		server.dropRejected = true
+
+		err := newWebdav(t, server).Delete("old.jpg")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, server.requests[http.MethodDelete])
+		assert.Empty(t, server.files)
+	})
+}